@@ -10,11 +10,10 @@ import (
 )
 
 func HasAction(c *fiber.Ctx, resource string, actions ...string) *model.ErrorST {
-	if allowedActions, ok := middleware.GetPermissionsMap(c)[resource]; ok {
-		for _, action := range allowedActions {
-			if !slices.Contains(allowedActions, action) {
-				return model.NewError(http.StatusForbidden).AddError("authorization", "invalid")
-			}
+	allowedActions := middleware.GetPermissionsMap(c)[resource]
+	for _, action := range actions {
+		if !slices.Contains(allowedActions, action) {
+			return model.NewError(http.StatusForbidden).AddError("authorization", "invalid")
 		}
 	}
 	return nil
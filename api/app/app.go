@@ -10,6 +10,7 @@ import (
 	"github.com/aicacia/auth/api/app/config"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
 	"github.com/aicacia/auth/api/docs"
 	"github.com/gofiber/fiber/v2"
 	fiberLogger "github.com/gofiber/fiber/v2/middleware/logger"
@@ -43,6 +44,10 @@ func InitApp(appConfig AppConfigST) *fiber.App {
 		slog.Error("error initializing config", "error", err)
 		return nil
 	}
+	if err := service.CheckBreachedPasswords(config.Get().Password.BreachedPath); err != nil {
+		slog.Error("error reading breached passwords", "error", err)
+		return nil
+	}
 
 	loggerWriter := os.Stdout
 	logger := slog.New(slog.NewTextHandler(loggerWriter, &slog.HandlerOptions{
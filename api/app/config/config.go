@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
 	atomic_value "github.com/aicacia/go-atomic-value"
+	"github.com/alexedwards/argon2id"
 	"github.com/lib/pq"
 	"github.com/mitchellh/mapstructure"
 )
@@ -27,6 +29,54 @@ type ConfigST struct {
 	OpenAPI struct {
 		Enabled bool `json:"enabled"`
 	} `json:"openapi"`
+	MFA struct {
+		CodeExpiresInSeconds int64 `json:"code_expires_in_seconds"`
+		CodeMaxAttempts      int32 `json:"code_max_attempts"`
+		CodeResendSeconds    int64 `json:"code_resend_seconds"`
+		CodeMaxSendsPerHour  int   `json:"code_max_sends_per_hour"`
+	} `json:"mfa"`
+	Passwordless struct {
+		CodeExpiresInSeconds int64 `json:"code_expires_in_seconds"`
+		CodeMaxAttempts      int32 `json:"code_max_attempts"`
+		CodeResendSeconds    int64 `json:"code_resend_seconds"`
+		CodeMaxSendsPerHour  int   `json:"code_max_sends_per_hour"`
+	} `json:"passwordless"`
+	Confirmation struct {
+		CodeExpiresInSeconds int64 `json:"code_expires_in_seconds"`
+		CodeMaxAttempts      int32 `json:"code_max_attempts"`
+		CodeResendSeconds    int64 `json:"code_resend_seconds"`
+		CodeMaxSendsPerHour  int   `json:"code_max_sends_per_hour"`
+	} `json:"confirmation"`
+	IdentityProvider struct {
+		LoginExpiresInSeconds int64 `json:"login_expires_in_seconds"`
+	} `json:"identity_provider"`
+	User struct {
+		ReauthenticationMaxAgeSeconds int64 `json:"reauthentication_max_age_seconds"`
+		ImportMaxRows                 int   `json:"import_max_rows"`
+	} `json:"user"`
+	Impersonation struct {
+		ExpiresInSeconds int64 `json:"expires_in_seconds"`
+	} `json:"impersonation"`
+	Invitation struct {
+		ExpiresInSeconds int64 `json:"expires_in_seconds"`
+	} `json:"invitation"`
+	Pagination struct {
+		DefaultLimit int `json:"default_limit"`
+		MaxLimit     int `json:"max_limit"`
+	} `json:"pagination"`
+	SAML struct {
+		RequestExpiresInSeconds int64 `json:"request_expires_in_seconds"`
+	} `json:"saml"`
+	Password struct {
+		BreachedPath string `json:"breached_path"`
+	} `json:"password"`
+	Argon2 struct {
+		Memory      uint32 `json:"memory"`
+		Iterations  uint32 `json:"iterations"`
+		Parallelism uint8  `json:"parallelism"`
+		SaltLength  uint32 `json:"salt_length"`
+		KeyLength   uint32 `json:"key_length"`
+	} `json:"argon2"`
 }
 
 func InitConfig() error {
@@ -58,6 +108,7 @@ func InitConfig() error {
 		return err
 	}
 	config.Store(&c)
+	applyArgon2Params(&c)
 
 	listener, err := repository.CreateListener("configs_channel")
 	if err != nil {
@@ -128,10 +179,27 @@ func updateConfig(key string, value interface{}) error {
 		return err
 	}
 	config.Store(&c)
+	applyArgon2Params(&c)
 
 	return nil
 }
 
+func applyArgon2Params(c *ConfigST) {
+	if c.Argon2.Memory == 0 {
+		return
+	}
+	err := util.SetArgon2Params(argon2id.Params{
+		Memory:      c.Argon2.Memory,
+		Iterations:  c.Argon2.Iterations,
+		Parallelism: c.Argon2.Parallelism,
+		SaltLength:  c.Argon2.SaltLength,
+		KeyLength:   c.Argon2.KeyLength,
+	})
+	if err != nil {
+		slog.Error("invalid argon2 config", "error", err)
+	}
+}
+
 func setKeyValue(parent map[string]interface{}, key string, value interface{}) {
 	entry := parent
 	path := strings.Split(key, ".")
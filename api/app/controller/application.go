@@ -19,7 +19,7 @@ import (
 //	@Tags			application
 //	@Accept			json
 //	@Produce		json
-//	@Param			query	query		model.OffsetAndLimitQueryST	false	"query"
+//	@Param			query	query		model.PaginationQueryST	false	"query"
 //	@Success		200	{object}   	model.PaginationST[model.ApplicationST]
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
@@ -32,24 +32,20 @@ func GetApplications(c *fiber.Ctx) error {
 	if err := access.HasAction(c, "applications", "read"); err != nil {
 		return err
 	}
-	var offsetAndLimit model.OffsetAndLimitQueryST
-	if err := c.QueryParser(&offsetAndLimit); err != nil {
+	var query model.PaginationQueryST
+	if err := c.QueryParser(&query); err != nil {
 		slog.Error("failed to parse query", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("query", "invalid")
 	}
-	applications, err := repository.GetApplications(offsetAndLimit.Limit, offsetAndLimit.Offset)
+	page, err := pageFromQuery(&query)
 	if err != nil {
-		slog.Error("failed to get applications", "error", err)
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		return err
 	}
-	hasMore := false
-	if offsetAndLimit.Limit != nil && *offsetAndLimit.Limit == len(applications) {
-		hasMore = true
+	applications, err := repository.GetApplications(page)
+	if err != nil {
+		return paginationError(err, "failed to get applications")
 	}
-	return c.JSON(model.PaginationST[model.ApplicationST]{
-		HasMore: hasMore,
-		Items:   util.Map(applications, model.ApplicationFromRow),
-	})
+	return c.JSON(model.PaginationFromPage(applications, util.Map(applications.Items, model.ApplicationFromRow)))
 }
 
 // GetApplicationById
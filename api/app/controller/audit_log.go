@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"log/slog"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+func auditLog(c *fiber.Ctx, user *repository.UserRowST, action string, data map[string]interface{}) {
+	ip := c.IP()
+	_, err := repository.CreateAuditLog(repository.CreateAuditLogST{
+		ApplicationId: user.ApplicationId,
+		UserId:        &user.Id,
+		Action:        action,
+		Data:          data,
+		IP:            &ip,
+	})
+	if err != nil {
+		slog.Error("failed to create audit log", "action", action, "userId", user.Id, "error", err)
+	}
+}
@@ -0,0 +1,394 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var (
+	clientRegistrationGrantTypes = []string{
+		model.PasswordGrantType,
+		model.RefreshTokenGrantType,
+		model.PasswordlessGrantType,
+		model.IdentityProviderGrantType,
+	}
+	// clientRegistrationDefaultGrantTypes only sign users in through the tenent's consent screen
+	clientRegistrationDefaultGrantTypes = []string{
+		model.PasswordlessGrantType,
+		model.IdentityProviderGrantType,
+		model.RefreshTokenGrantType,
+	}
+)
+
+// PostRegisterClient
+//
+//	@Summary		Register a client
+//	@Description	Creates a third party tenent for the client, authorized with an initial access token as the bearer token. grant_types defaults to passwordless, identity-provider and refresh-token and token_endpoint_auth_method to client_secret_basic
+//	@ID				register-client
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			client	body		model.ClientRegistrationRequestST	true	"client metadata"
+//	@Success		201	{object}	model.ClientRegistrationST
+//	@Failure		400	{object}	model.ClientRegistrationErrorST
+//	@Failure		401	{object}	model.ClientRegistrationErrorST
+//	@Failure		500	{object}	model.ClientRegistrationErrorST
+//	@Router			/register [post]
+//
+//	@Security		Authorization
+func PostRegisterClient(c *fiber.Ctx) error {
+	_, token := middleware.GetAuthorizationFromContext(c)
+	if token == "" {
+		return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	initialAccessToken, err := repository.GetInitialAccessToken(token)
+	if err != nil {
+		slog.Error("failed to get initial access token", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if initialAccessToken == nil {
+		return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	registration, err := parseClientRegistration(c)
+	if err != nil {
+		return err
+	}
+	tenent, registrationAccessToken, err := repository.RegisterClient(initialAccessToken.ApplicationId, registration)
+	if err != nil {
+		slog.Error("failed to register client", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	response := clientRegistrationFromTenent(&tenent)
+	response.RegistrationAccessToken = registrationAccessToken
+	c.Status(http.StatusCreated)
+	return c.JSON(response)
+}
+
+// GetRegisteredClient
+//
+//	@Summary		Read a client's registration
+//	@Description	Authorized with the client's registration access token as the bearer token
+//	@ID				registered-client
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			clientId	path		string	true	"client id"
+//	@Success		200	{object}	model.ClientRegistrationST
+//	@Failure		401	{object}	model.ClientRegistrationErrorST
+//	@Failure		500	{object}	model.ClientRegistrationErrorST
+//	@Router			/register/{clientId} [get]
+//
+//	@Security		Authorization
+func GetRegisteredClient(c *fiber.Ctx) error {
+	tenent, err := getRegisteredClient(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(clientRegistrationFromTenent(tenent))
+}
+
+// PutUpdateRegisteredClient
+//
+//	@Summary		Replace a client's registration
+//	@Description	Authorized with the client's registration access token as the bearer token, metadata left out is reset to its default
+//	@ID				update-registered-client
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			clientId	path		string								true	"client id"
+//	@Param			client		body		model.ClientRegistrationRequestST	true	"client metadata"
+//	@Success		200	{object}	model.ClientRegistrationST
+//	@Failure		400	{object}	model.ClientRegistrationErrorST
+//	@Failure		401	{object}	model.ClientRegistrationErrorST
+//	@Failure		500	{object}	model.ClientRegistrationErrorST
+//	@Router			/register/{clientId} [put]
+//
+//	@Security		Authorization
+func PutUpdateRegisteredClient(c *fiber.Ctx) error {
+	tenent, err := getRegisteredClient(c)
+	if err != nil {
+		return err
+	}
+	registration, err := parseClientRegistration(c)
+	if err != nil {
+		return err
+	}
+	updated, err := repository.UpdateRegisteredClient(tenent.Id, registration)
+	if err != nil {
+		slog.Error("failed to update registered client", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if updated == nil {
+		return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	return c.JSON(clientRegistrationFromTenent(updated))
+}
+
+// DeleteRegisteredClient
+//
+//	@Summary		Delete a client's registration
+//	@Description	Authorized with the client's registration access token as the bearer token, deletes the client's tenent
+//	@ID				delete-registered-client
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			clientId	path		string	true	"client id"
+//	@Success		204
+//	@Failure		401	{object}	model.ClientRegistrationErrorST
+//	@Failure		500	{object}	model.ClientRegistrationErrorST
+//	@Router			/register/{clientId} [delete]
+//
+//	@Security		Authorization
+func DeleteRegisteredClient(c *fiber.Ctx) error {
+	tenent, err := getRegisteredClient(c)
+	if err != nil {
+		return err
+	}
+	if _, err := repository.DeleteTenent(tenent.Id); err != nil {
+		slog.Error("failed to delete registered client", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// GetInitialAccessTokens
+//
+//	@Summary		Get application initial access tokens
+//	@ID				initial-access-tokens
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Success		200	{array}		model.InitialAccessTokenST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/initial-access-tokens [get]
+//
+//	@Security		Authorization
+func GetInitialAccessTokens(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	tokens, err := repository.GetInitialAccessTokens(int32(applicationId))
+	if err != nil {
+		slog.Error("failed to get initial access tokens", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(tokens, model.InitialAccessTokenFromRow))
+}
+
+// PostCreateInitialAccessToken
+//
+//	@Summary		Create application initial access token
+//	@Description	Clients register themselves with the returned token, it is only returned once
+//	@ID				create-initial-access-token
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId		path		int									true	"application id"
+//	@Param			initialAccessToken	body		model.CreateInitialAccessTokenST	true	"create initial access token"
+//	@Success		201	{object}	model.CreatedInitialAccessTokenST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/initial-access-tokens [post]
+//
+//	@Security		Authorization
+func PostCreateInitialAccessToken(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var createInitialAccessToken model.CreateInitialAccessTokenST
+	if err := c.BodyParser(&createInitialAccessToken); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	create := createInitialAccessToken.CreateInitialAccessTokenST
+	create.Description = strings.TrimSpace(create.Description)
+	errors := model.NewError(http.StatusBadRequest)
+	if create.Description == "" {
+		errors.AddError("description", "required")
+	}
+	if create.ExpiresInSeconds != nil && *create.ExpiresInSeconds <= 0 {
+		errors.AddError("expires_in_seconds", "invalid")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	row, token, err := repository.CreateInitialAccessToken(int32(applicationId), create)
+	if err != nil {
+		slog.Error("failed to create initial access token", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Status(http.StatusCreated)
+	return c.JSON(model.CreatedInitialAccessTokenST{
+		InitialAccessTokenST: model.InitialAccessTokenFromRow(row),
+		Token:                token,
+	})
+}
+
+// DeleteInitialAccessToken
+//
+//	@Summary		Delete application initial access token
+//	@Description	Clients already registered with it are kept
+//	@ID				delete-initial-access-token
+//	@Tags			client-registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id				path		int	true	"initial access token id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/initial-access-tokens/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteInitialAccessToken(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	deleted, err := repository.DeleteInitialAccessToken(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to delete initial access token", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+func getRegisteredClient(c *fiber.Ctx) (*repository.TenentRowST, error) {
+	// RFC 7592 answers unknown clients and wrong tokens alike so neither can be probed
+	clientId, err := uuid.Parse(c.Params("clientId"))
+	if err != nil {
+		return nil, model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	_, token := middleware.GetAuthorizationFromContext(c)
+	if token == "" {
+		return nil, model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	tenent, err := repository.GetRegisteredClient(clientId, token)
+	if err != nil {
+		slog.Error("failed to get registered client", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil {
+		return nil, model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	return tenent, nil
+}
+
+func parseClientRegistration(c *fiber.Ctx) (repository.ClientRegistrationST, error) {
+	var request model.ClientRegistrationRequestST
+	if err := c.BodyParser(&request); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return repository.ClientRegistrationST{}, model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	registration := repository.ClientRegistrationST{
+		ClientName:              strings.TrimSpace(request.ClientName),
+		RedirectURIs:            request.RedirectURIs,
+		GrantTypes:              request.GrantTypes,
+		TokenEndpointAuthMethod: request.TokenEndpointAuthMethod,
+	}
+	if registration.ClientName == "" {
+		registration.ClientName = "Registered client"
+	}
+	if len(registration.GrantTypes) == 0 {
+		registration.GrantTypes = clientRegistrationDefaultGrantTypes
+	}
+	if registration.TokenEndpointAuthMethod == "" {
+		registration.TokenEndpointAuthMethod = model.TokenEndpointAuthMethodClientSecretBasic
+	}
+	errors := model.NewError(http.StatusBadRequest)
+	if len(registration.ClientName) > 255 {
+		errors.AddError("client_name", "invalid")
+	}
+	if len(registration.RedirectURIs) == 0 {
+		errors.AddError("redirect_uris", "required")
+	}
+	for _, redirectURI := range registration.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			errors.AddError("redirect_uris", "invalid", redirectURI)
+		}
+	}
+	for _, grantType := range registration.GrantTypes {
+		if !slices.Contains(clientRegistrationGrantTypes, grantType) {
+			errors.AddError("grant_types", "invalid", grantType)
+		}
+	}
+	if !slices.Contains(model.TokenEndpointAuthMethods, registration.TokenEndpointAuthMethod) {
+		errors.AddError("token_endpoint_auth_method", "invalid")
+	}
+	if errors.HasErrors() {
+		return registration, errors
+	}
+	return registration, nil
+}
+
+// validRedirectURI accepts absolute https uris without a fragment, http is only allowed for loopback hosts
+func validRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Host == "" || parsed.Fragment != "" || len(redirectURI) > 255 {
+		return false
+	}
+	switch parsed.Scheme {
+	case "https":
+		return true
+	case "http":
+		hostname := parsed.Hostname()
+		return hostname == "localhost" || hostname == "127.0.0.1" || hostname == "::1"
+	}
+	return false
+}
+
+func clientRegistrationFromTenent(tenent *repository.TenentRowST) *model.ClientRegistrationST {
+	response := model.ClientRegistrationST{
+		ClientId:                tenent.ClientId,
+		ClientSecret:            tenent.ClientSecret,
+		ClientIdIssuedAt:        tenent.CreatedAt.Unix(),
+		RegistrationClientURI:   strings.TrimSuffix(config.Get().URL, "/") + "/register/" + tenent.ClientId.String(),
+		ClientName:              tenent.Description,
+		RedirectURIs:            tenent.RedirectURIs,
+		GrantTypes:              tenent.GrantTypes,
+		TokenEndpointAuthMethod: tenent.TokenEndpointAuthMethod,
+	}
+	return &response
+}
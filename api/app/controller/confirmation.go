@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PostConfirmation
+//
+//	@Summary		Confirm an email or phone number with the link sent to it
+//	@Description	The token is the confirmation_token of the link sent with a confirmation code, it works once and stops working when a new code is sent
+//	@ID				confirm
+//	@Tags			confirmation
+//	@Accept			json
+//	@Produce		json
+//	@Param			confirmation	body	model.ConfirmationST	true	"confirmation"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		410	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/confirmation [post]
+//
+//	@Security		TenentId
+func PostConfirmation(c *fiber.Ctx) error {
+	var confirmation model.ConfirmationST
+	if err := c.BodyParser(&confirmation); err != nil {
+		slog.Error("invalid request body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	tenent := middleware.GetTenent(c)
+	claims, err := jwt.ParseClaimsFromToken[jwt.Claims](confirmation.Token, tenent)
+	if err != nil || claims.Type != jwt.ConfirmationTokenType {
+		slog.Error("invalid confirmation token", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("token", "invalid")
+	}
+	row, err := repository.UseConfirmationToken(claims.Subject, confirmation.Token)
+	if err != nil {
+		slog.Error("failed to use confirmation token", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if row == nil {
+		return model.NewError(http.StatusGone).AddError("token", "expired")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// sendConfirmationCode sends a numeric code and a signed link to the user's email or phone number, either confirms it
+func sendConfirmationCode(tenent *repository.TenentRowST, user *repository.UserRowST, target string, targetId int32, to string) error {
+	confirmationConfig := config.Get().Confirmation
+	stats, err := repository.GetConfirmationCodeSendStats(target, targetId)
+	if err != nil {
+		slog.Error("failed to get confirmation code stats", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if stats.LastSentAt != nil && time.Since(*stats.LastSentAt) < time.Duration(confirmationConfig.CodeResendSeconds)*time.Second {
+		return model.NewError(http.StatusTooManyRequests).AddError("confirmation", "rateLimited")
+	}
+	if stats.SentInLastHour >= confirmationConfig.CodeMaxSendsPerHour {
+		return model.NewError(http.StatusTooManyRequests).AddError("confirmation", "rateLimited")
+	}
+	now := time.Now().UTC()
+	claims := jwt.Claims{
+		Type:             jwt.ConfirmationTokenType,
+		Subject:          user.Id,
+		SubjectType:      jwt.UserSubject,
+		ClientId:         tenent.ClientId,
+		NotBeforeSeconds: now.Unix(),
+		IssuedAtSeconds:  now.Unix(),
+		ExpiresAtSeconds: now.Unix() + confirmationConfig.CodeExpiresInSeconds,
+		Issuer:           config.Get().URL,
+		Scope:            []string{},
+	}
+	token, err := jwt.CreateToken(&claims, tenent)
+	if err != nil {
+		slog.Error("failed to create confirmation token", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	code, err := repository.CreateConfirmationCode(user.ApplicationId, user.Id, target, targetId, token, confirmationConfig.CodeExpiresInSeconds)
+	if err != nil {
+		slog.Error("failed to create confirmation code", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	data := map[string]interface{}{
+		"expires_in_seconds": confirmationConfig.CodeExpiresInSeconds,
+		"target":             target,
+		"code":               code,
+		"token":              token,
+	}
+	if link, err := url.Parse(tenent.AuthorizationWebsite); err == nil {
+		query := link.Query()
+		query.Set("confirmation_token", token)
+		link.RawQuery = query.Encode()
+		data["link"] = link.String()
+	}
+	if target == repository.ConfirmationTargetPhoneNumber {
+		err = service.SendSMS(tenent, to, service.MessageKindConfirmation, data)
+	} else {
+		err = service.SendEmail(tenent, to, service.MessageKindConfirmation, data)
+	}
+	if err != nil {
+		slog.Error("failed to send confirmation code", "target", target, "error", err)
+		return messageError("confirmation", err)
+	}
+	return nil
+}
+
+// confirmationCodeError is the error for a confirmation code that didn't confirm, wrong codes can be retried while
+// expired and exhausted ones need a new code sent
+func confirmationCodeError(result string) error {
+	switch result {
+	case repository.MFACodeExpired:
+		return model.NewError(http.StatusGone).AddError("token", "expired")
+	case repository.MFACodeExceeded:
+		return model.NewError(http.StatusGone).AddError("token", "exceeded")
+	}
+	return model.NewError(http.StatusBadRequest).AddError("token", "invalid")
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PostConsent
+//
+//	@Summary		Approve a third party tenent's scopes
+//	@Description	Third party tenents get a consent token until the user approves the requested scopes, approving them records the consent and returns the tokens
+//	@ID				consent
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	model.TokenST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/consent [post]
+//
+//	@Security		Authorization
+func PostConsent(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	tenent := middleware.GetTenent(c)
+	claims := middleware.GetClaims[jwt.ConsentClaims](c)
+	consent, err := repository.GrantUserConsent(user.ApplicationId, user.Id, tenent.Id, claims.Scope)
+	if err != nil {
+		slog.Error("failed to grant user consent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	auditLog(c, user, repository.AuditActionConsentGranted, map[string]interface{}{
+		"tenent_id": tenent.Id,
+		"scopes":    consent.Scopes,
+	})
+	return sendToken(c, sendTokenST{
+		issuedTokenType: claims.GrantType,
+		scope:           strings.Join(claims.Scope, " "),
+		application:     middleware.GetApplication(c),
+		tenent:          tenent,
+		user:            user,
+		authTime:        claims.AuthTimeSeconds,
+	})
+}
@@ -73,10 +73,14 @@ func PatchResetPassword(c *fiber.Ctx) error {
 	if password != passwordConfirmation {
 		errors.AddError("password_confirmation", "mismatch", "body")
 	}
+	user := middleware.GetUser(c)
+	if err := validatePassword(errors, user.ApplicationId, middleware.GetTenent(c).Id, &user.Id, user.Username, password); err != nil {
+		slog.Error("failed to validate password", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
 	if errors.HasErrors() {
 		return errors
 	}
-	user := middleware.GetUser(c)
 	_, err := repository.UpdateUserPassword(user.ApplicationId, user.Id, password)
 	if err != nil {
 		slog.Error("failed to update user password", "error", err)
@@ -168,27 +172,7 @@ func PatchCurrentUserInfo(c *fiber.Ctx) error {
 		slog.Error("invalid request body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
-	updates := repository.UpdateUserInfoST{
-		Name:       userinfoUpdates.Name,
-		GivenName:  userinfoUpdates.GivenName,
-		FamilyName: userinfoUpdates.FamilyName,
-		MiddleName: userinfoUpdates.MiddleName,
-		Nickname:   userinfoUpdates.Nickname,
-		Profile:    userinfoUpdates.Profile,
-		Picture:    userinfoUpdates.Picture,
-		Website:    userinfoUpdates.Website,
-		Gender:     userinfoUpdates.Gender,
-		Birthdate:  userinfoUpdates.Birthdate,
-		Zoneinfo:   userinfoUpdates.Zoneinfo,
-		Locale:     userinfoUpdates.Locale,
-	}
-	if userinfoUpdates.Address != nil {
-		updates.Region = userinfoUpdates.Address.Region
-		updates.Locality = userinfoUpdates.Address.Locality
-		updates.PostalCode = userinfoUpdates.Address.PostalCode
-		updates.Country = userinfoUpdates.Address.Country
-		updates.StreetAddress = userinfoUpdates.Address.StreetAddress
-	}
+	updates := userinfoUpdates.ToUpdateUserInfo()
 	user := middleware.GetUser(c)
 	userInfoRow, err := repository.UpdateUserInfoByUserId(user.Id, updates)
 	if err != nil {
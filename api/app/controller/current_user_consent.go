@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCurrentUserConsents
+//
+//	@Summary		List the third party tenents the current user approved
+//	@ID				current-user-consents
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		model.UserConsentST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/consents [get]
+//
+//	@Security		Authorization
+func GetCurrentUserConsents(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	consents, err := repository.GetUserConsentsWithTenentByUserId(user.Id)
+	if err != nil {
+		slog.Error("failed to get user consents", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(consents, model.UserConsentFromRow))
+}
+
+// DeleteCurrentUserConsent
+//
+//	@Summary		Revoke a third party tenent's consent
+//	@Description	The tenent's refresh tokens for the user stop working and the user is asked to approve its scopes again on their next sign in
+//	@ID				current-user-revoke-consent
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"consent id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/consents/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteCurrentUserConsent(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	consent, err := repository.RevokeUserConsent(user.Id, int32(id))
+	if err != nil {
+		slog.Error("failed to revoke user consent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if consent == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	auditLog(c, user, repository.AuditActionConsentRevoked, map[string]interface{}{
+		"tenent_id": consent.TenentId,
+		"scopes":    consent.Scopes,
+	})
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
@@ -6,16 +6,17 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/aicacia/auth/api/app/config"
 	"github.com/aicacia/auth/api/app/middleware"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
-	"github.com/aicacia/auth/api/app/util"
 	"github.com/gofiber/fiber/v2"
 )
 
 // PatchCurrentUserEmailSendConfirmation
 //
-//	@Summary		Send confirmation token to user email
+//	@Summary		Send a confirmation code and link to user email
+//	@Description	Codes sent before stop working, sending is throttled per email
 //	@ID				send-confirmation-to-email
 //	@Tags			current-user
 //	@Accept			json
@@ -25,7 +26,10 @@ import (
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
 //	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		429	{object}	model.ErrorST
 //	@Failure		500	{object}	model.ErrorST
+//	@Failure		503	{object}	model.ErrorST
 //	@Router			/user/emails/{id}/send-confirmation [patch]
 //
 //	@Security		Authorization
@@ -35,15 +39,19 @@ func PatchCurrentUserEmailSendConfirmation(c *fiber.Ctx) error {
 	if err != nil {
 		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
 	}
-	confirmationToken, err := util.GenerateRandomHex(6)
+	email, err := repository.GetEmailById(user.Id, int32(id))
 	if err != nil {
+		slog.Error("failed to get email", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	// TODO: send to email
-	slog.Info("sending email", "userId", user.Id, "emailId", id, "token", confirmationToken)
-	_, err = repository.SetEmailConfirmation(user.Id, int32(id), confirmationToken)
-	if err != nil {
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	if email == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	if email.Confirmed {
+		return model.NewError(http.StatusBadRequest).AddError("email", "confirmed")
+	}
+	if err := sendConfirmationCode(middleware.GetTenent(c), user, repository.ConfirmationTargetEmail, email.Id, email.Email); err != nil {
+		return err
 	}
 	c.Status(http.StatusNoContent)
 	return c.Send(nil)
@@ -51,7 +59,8 @@ func PatchCurrentUserEmailSendConfirmation(c *fiber.Ctx) error {
 
 // PatchCurrentUserEmailConfirm
 //
-//	@Summary		Confirm email with token
+//	@Summary		Confirm email with code
+//	@Description	The token is the numeric code sent to the email. A wrong code is a 400 and can be retried, an expired code or one with too many wrong attempts is a 410 and a new code has to be sent
 //	@ID				confirm-email
 //	@Tags			current-user
 //	@Accept			json
@@ -62,6 +71,8 @@ func PatchCurrentUserEmailSendConfirmation(c *fiber.Ctx) error {
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
 //	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		410	{object}	model.ErrorST
 //	@Failure		500	{object}	model.ErrorST
 //	@Router			/user/emails/{id}/confirm [patch]
 //
@@ -77,11 +88,26 @@ func PatchCurrentUserEmailConfirm(c *fiber.Ctx) error {
 		slog.Error("invalid request body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
-	email, err := repository.ConfirmEmail(user.Id, int32(id), strings.ToLower(strings.TrimSpace(confirmEmail.Token)))
+	email, err := repository.GetEmailById(user.Id, int32(id))
 	if err != nil {
+		slog.Error("failed to get email", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	return c.JSON(model.EmailFromRow(email))
+	if email == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	if !email.Confirmed {
+		result, err := repository.UseConfirmationCode(user.Id, repository.ConfirmationTargetEmail, email.Id, strings.TrimSpace(confirmEmail.Token), config.Get().Confirmation.CodeMaxAttempts)
+		if err != nil {
+			slog.Error("failed to use confirmation code", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		if result != repository.MFACodeValid {
+			return confirmationCodeError(result)
+		}
+		email.Confirmed = true
+	}
+	return c.JSON(model.EmailFromRow(*email))
 }
 
 // PatchCurrentUserEmailSetPrimary
@@ -137,21 +163,31 @@ func PostCurrentUserCreateEmail(c *fiber.Ctx) error {
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
 	user := middleware.GetUser(c)
-	confirmationToken, err := util.GenerateRandomHex(6)
-	if err != nil {
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
-	}
 	email := strings.TrimSpace(createEmail.Email)
 	if email == "" {
 		return model.NewError(http.StatusBadRequest).AddError("email", "required")
 	}
-	emailRow, err := repository.CreateEmail(user.ApplicationId, user.Id, email, confirmationToken)
+	tenent := middleware.GetTenent(c)
+	taken, err := isEmailAliasTaken(tenent, user.ApplicationId, email)
+	if err != nil {
+		slog.Error("failed to check email aliases", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if taken {
+		return model.NewError(http.StatusBadRequest).AddError("email", "duplicate")
+	}
+	emailRow, err := repository.CreateEmail(user.ApplicationId, user.Id, email)
 	if err != nil {
+		if repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "emails_email_unique_idx") {
+			return model.NewError(http.StatusBadRequest).AddError("email", "duplicate")
+		}
 		slog.Error("failed to create email", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	// TODO: send to email
-	slog.Info("sending email", "userId", user.Id, "emailId", emailRow.Id, "token", strings.ToUpper(*emailRow.ConfirmationToken))
+	// the email is kept when sending fails, the code can be resent
+	if err := sendConfirmationCode(tenent, user, repository.ConfirmationTargetEmail, emailRow.Id, emailRow.Email); err != nil {
+		slog.Error("failed to send email confirmation", "error", err)
+	}
 	c.Status(http.StatusCreated)
 	return c.JSON(model.EmailFromRow(emailRow))
 }
@@ -189,3 +225,12 @@ func DeleteCurrentUserEmail(c *fiber.Ctx) error {
 	c.Status(http.StatusNoContent)
 	return c.Send(nil)
 }
+
+// isEmailAliasTaken reports whether the tenent folds email aliases and email folds to an address the application
+// already has, the unique index only catches addresses that differ in case
+func isEmailAliasTaken(tenent *repository.TenentRowST, applicationId int32, email string) (bool, error) {
+	if tenent == nil || !tenent.FoldEmailAliases {
+		return false, nil
+	}
+	return repository.IsEmailAliasTaken(applicationId, email)
+}
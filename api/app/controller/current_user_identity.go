@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCurrentUserIdentities
+//
+//	@Summary		List the upstream identities linked to the current user
+//	@ID				current-user-identities
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		model.UserIdentityST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/identities [get]
+//
+//	@Security		Authorization
+func GetCurrentUserIdentities(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	identities, err := repository.GetUserIdentitiesWithProviderByUserId(user.Id)
+	if err != nil {
+		slog.Error("failed to get user identities", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(identities, model.UserIdentityFromRow))
+}
+
+// PostCurrentUserLinkIdentity
+//
+//	@Summary		Start linking an upstream identity to the current user
+//	@Description	Requires the user to have signed in recently. Returns the url to send the user to, after signing in with the provider they are redirected to the tenent's authorization website with identity_provider_linked set to the provider's id or an identity_provider_error
+//	@ID				current-user-link-identity
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			linkIdentity	body		model.LinkUserIdentityST	true	"identity provider to link"
+//	@Success		200	{object}	model.IdentityProviderAuthorizationST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/identities [post]
+//
+//	@Security		Authorization
+func PostCurrentUserLinkIdentity(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	var linkIdentity model.LinkUserIdentityST
+	if err := c.BodyParser(&linkIdentity); err != nil {
+		slog.Error("invalid request body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	tenent := middleware.GetTenent(c)
+	provider, err := repository.GetIdentityProviderById(linkIdentity.IdentityProviderId)
+	if err != nil {
+		slog.Error("failed to get identity provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if provider == nil || provider.TenentId != tenent.Id || !provider.Enabled {
+		return model.NewError(http.StatusBadRequest).AddError("identity_provider_id", "invalid")
+	}
+	authorizationURL, err := startIdentityProviderLogin(provider, &user.Id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(model.IdentityProviderAuthorizationST{
+		AuthorizationURL: authorizationURL,
+	})
+}
+
+// DeleteCurrentUserIdentity
+//
+//	@Summary		Unlink an upstream identity from the current user
+//	@Description	Refused with credentials last when the user would have no password, passkey or other identity left to sign in with
+//	@ID				current-user-unlink-identity
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"user identity id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/identities/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteCurrentUserIdentity(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	deleted, err := repository.UnlinkUserIdentity(user.Id, int32(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrLastCredential) {
+			return model.NewError(http.StatusBadRequest).AddError("credentials", "last")
+		}
+		slog.Error("failed to unlink user identity", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	auditLog(c, user, repository.AuditActionIdentityUnlinked, map[string]interface{}{
+		"user_identity_id": id,
+	})
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
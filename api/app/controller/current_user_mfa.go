@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCurrentUserMFAs
+//
+//	@Summary		Get user's enrolled multi-factor authentication methods
+//	@ID				current-user-mfas
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}   	model.MFAST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/mfa [get]
+//
+//	@Security		Authorization
+func GetCurrentUserMFAs(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	mfas, err := repository.GetMFAs(user.Id)
+	if err != nil {
+		slog.Error("failed to find MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(mfas, model.MFAFromRow))
+}
+
+// PatchCurrentUserEnableEmailMFA
+//
+//	@Summary		Enables email multi-factor authentication using the confirmed primary email
+//	@ID				enable-email-mfa
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}   	model.MFAST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Failure		503	{object}	model.ErrorST
+//	@Router			/user/mfa/email [patch]
+//
+//	@Security		Authorization
+func PatchCurrentUserEnableEmailMFA(c *fiber.Ctx) error {
+	if !service.CanSendEmail(middleware.GetTenent(c)) {
+		return model.NewError(http.StatusServiceUnavailable).AddError("mfa", "unavailable")
+	}
+	user := middleware.GetUser(c)
+	email, err := repository.GetUserPrimaryEmail(user.Id)
+	if err != nil {
+		slog.Error("failed to get primary email", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if email == nil {
+		return model.NewError(http.StatusBadRequest).AddError("email", "required")
+	}
+	if !email.Confirmed {
+		return model.NewError(http.StatusBadRequest).AddError("email", "unconfirmed")
+	}
+	return enableMFA(c, user, email.Id, repository.MFATypeEmail)
+}
+
+// PatchCurrentUserEnableSMSMFA
+//
+//	@Summary		Enables sms multi-factor authentication using the confirmed primary phone number
+//	@ID				enable-sms-mfa
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}   	model.MFAST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Failure		503	{object}	model.ErrorST
+//	@Router			/user/mfa/sms [patch]
+//
+//	@Security		Authorization
+func PatchCurrentUserEnableSMSMFA(c *fiber.Ctx) error {
+	if !service.CanSendSMS(middleware.GetTenent(c)) {
+		return model.NewError(http.StatusServiceUnavailable).AddError("mfa", "unavailable")
+	}
+	user := middleware.GetUser(c)
+	phoneNumber, err := repository.GetUserPrimaryPhoneNumber(user.Id)
+	if err != nil {
+		slog.Error("failed to get primary phone number", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if phoneNumber == nil {
+		return model.NewError(http.StatusBadRequest).AddError("phoneNumber", "required")
+	}
+	if !phoneNumber.Confirmed {
+		return model.NewError(http.StatusBadRequest).AddError("phoneNumber", "unconfirmed")
+	}
+	return enableMFA(c, user, phoneNumber.Id, repository.MFATypeSMS)
+}
+
+// PatchCurrentUserEnablePassKeyMFA
+//
+//	@Summary		Enables passkey multi-factor authentication using the user's registered passkeys
+//	@ID				enable-passkey-mfa
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}   	model.MFAST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/mfa/passkey [patch]
+//
+//	@Security		Authorization
+func PatchCurrentUserEnablePassKeyMFA(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	passkeys, err := repository.GetUserPassKeys(user.Id)
+	if err != nil {
+		slog.Error("failed to get user passkeys", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if len(passkeys) == 0 {
+		return model.NewError(http.StatusBadRequest).AddError("passkey", "required")
+	}
+	return enableMFA(c, user, 0, repository.MFATypePassKey)
+}
+
+// PatchCurrentUserSetPreferredMFA
+//
+//	@Summary		Sets the multi-factor authentication method used by default
+//	@ID				set-preferred-mfa
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	path		string	true	"mfa type"
+//	@Param			id		path		int		true	"mfa id"
+//	@Success		200	{object}   	model.MFAST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/mfa/{type}/{id}/preferred [patch]
+//
+//	@Security		Authorization
+func PatchCurrentUserSetPreferredMFA(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	mfaType := c.Params("type")
+	if !slices.Contains(repository.MFATypes, mfaType) {
+		return model.NewError(http.StatusBadRequest).AddError("type", "invalid")
+	}
+	user := middleware.GetUser(c)
+	updated, err := repository.SetPreferredMFA(user.Id, mfaType, int32(id))
+	if err != nil {
+		slog.Error("failed to set preferred MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !updated {
+		return model.NewError(http.StatusNotFound).AddError("mfa", "notFound")
+	}
+	mfa, err := repository.GetMFA(user.Id, mfaType, int32(id))
+	if err != nil {
+		slog.Error("failed to find MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if mfa == nil {
+		return model.NewError(http.StatusNotFound).AddError("mfa", "notFound")
+	}
+	c.Status(http.StatusOK)
+	return c.JSON(model.MFAFromRow(*mfa))
+}
+
+// DeleteCurrentUserMFA
+//
+//	@Summary		Removes one of the user's multi-factor authentication methods
+//	@ID				delete-mfa
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	path		string	true	"mfa type"
+//	@Param			id		path		int		true	"mfa id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/mfa/{type}/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteCurrentUserMFA(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	mfaType := c.Params("type")
+	if !slices.Contains(repository.MFATypes, mfaType) {
+		return model.NewError(http.StatusBadRequest).AddError("type", "invalid")
+	}
+	user := middleware.GetUser(c)
+	deleted, err := repository.DeleteMFA(user.Id, mfaType, int32(id))
+	if err != nil {
+		slog.Error("failed to delete MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("mfa", "notFound")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// DeleteCurrentUserDisableMFA
+//
+//	@Summary		Disables user's multi-factor authentication, removing every enrolled method
+//	@ID				disable-mfa
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/mfa [delete]
+//
+//	@Security		Authorization
+func DeleteCurrentUserDisableMFA(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	deleted, err := repository.DeleteAllMFAs(user.Id)
+	if err != nil {
+		slog.Error("failed to disable MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("mfa", "disabled")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+func enableMFA(c *fiber.Ctx, user *repository.UserRowST, id int32, mfaType string) error {
+	_, err := repository.UpsertMFA(user.Id, id, mfaType)
+	if err != nil {
+		slog.Error("failed to enable MFA", "type", mfaType, "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	mfa, err := repository.GetMFA(user.Id, mfaType, id)
+	if err != nil || mfa == nil {
+		slog.Error("failed to find MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	recoveryCodes, err := ensureRecoveryCodes(c, user)
+	if err != nil {
+		slog.Error("failed to create recovery codes", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	result := model.MFAFromRow(*mfa)
+	result.RecoveryCodes = recoveryCodes
+	c.Status(http.StatusOK)
+	return c.JSON(result)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/aicacia/auth/api/app/config"
 	"github.com/aicacia/auth/api/app/middleware"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
@@ -15,7 +16,8 @@ import (
 
 // PatchCurrentUserPhoneNumberSendConfirmation
 //
-//	@Summary		Send confirmation token to user phone_number
+//	@Summary		Send a confirmation code and link to user phone_number
+//	@Description	Codes sent before stop working, sending is throttled per phone number
 //	@ID				send-confirmation-to-phone-number
 //	@Tags			current-user
 //	@Accept			json
@@ -25,7 +27,10 @@ import (
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
 //	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		429	{object}	model.ErrorST
 //	@Failure		500	{object}	model.ErrorST
+//	@Failure		503	{object}	model.ErrorST
 //	@Router			/user/phone-numbers/{id}/send-confirmation [patch]
 //
 //	@Security		Authorization
@@ -35,15 +40,19 @@ func PatchCurrentUserPhoneNumberSendConfirmation(c *fiber.Ctx) error {
 	if err != nil {
 		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
 	}
-	confirmationToken, err := util.GenerateRandomHex(6)
+	phoneNumber, err := repository.GetPhoneNumberById(user.Id, int32(id))
 	if err != nil {
+		slog.Error("failed to get phone_number", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	// TODO: send to phone_number
-	slog.Info("sending text", "userId", user.Id, "phoneNumberId", id, "token", confirmationToken)
-	_, err = repository.SetPhoneNumberConfirmation(user.Id, int32(id), confirmationToken)
-	if err != nil {
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	if phoneNumber == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	if phoneNumber.Confirmed {
+		return model.NewError(http.StatusBadRequest).AddError("phoneNumber", "confirmed")
+	}
+	if err := sendConfirmationCode(middleware.GetTenent(c), user, repository.ConfirmationTargetPhoneNumber, phoneNumber.Id, phoneNumber.PhoneNumber); err != nil {
+		return err
 	}
 	c.Status(http.StatusNoContent)
 	return c.Send(nil)
@@ -51,7 +60,8 @@ func PatchCurrentUserPhoneNumberSendConfirmation(c *fiber.Ctx) error {
 
 // PatchCurrentUserPhoneNumberConfirm
 //
-//	@Summary		Confirm phone_number with token
+//	@Summary		Confirm phone_number with code
+//	@Description	The token is the numeric code sent to the phone number. A wrong code is a 400 and can be retried, an expired code or one with too many wrong attempts is a 410 and a new code has to be sent
 //	@ID				confirm-phone-number
 //	@Tags			current-user
 //	@Accept			json
@@ -62,6 +72,8 @@ func PatchCurrentUserPhoneNumberSendConfirmation(c *fiber.Ctx) error {
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
 //	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		410	{object}	model.ErrorST
 //	@Failure		500	{object}	model.ErrorST
 //	@Router			/user/phone-numbers/{id}/confirm [patch]
 //
@@ -77,11 +89,26 @@ func PatchCurrentUserPhoneNumberConfirm(c *fiber.Ctx) error {
 		slog.Error("invalid request body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
-	phone_number, err := repository.ConfirmPhoneNumber(user.Id, int32(id), strings.ToLower(strings.TrimSpace(confirmPhoneNumber.Token)))
+	phoneNumber, err := repository.GetPhoneNumberById(user.Id, int32(id))
 	if err != nil {
+		slog.Error("failed to get phone_number", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	return c.JSON(model.PhoneNumberFromRow(phone_number))
+	if phoneNumber == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	if !phoneNumber.Confirmed {
+		result, err := repository.UseConfirmationCode(user.Id, repository.ConfirmationTargetPhoneNumber, phoneNumber.Id, strings.TrimSpace(confirmPhoneNumber.Token), config.Get().Confirmation.CodeMaxAttempts)
+		if err != nil {
+			slog.Error("failed to use confirmation code", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		if result != repository.MFACodeValid {
+			return confirmationCodeError(result)
+		}
+		phoneNumber.Confirmed = true
+	}
+	return c.JSON(model.PhoneNumberFromRow(*phoneNumber))
 }
 
 // PatchCurrentUserPhoneNumberSetPrimary
@@ -136,25 +163,26 @@ func PostCurrentUserCreatePhoneNumber(c *fiber.Ctx) error {
 		slog.Error("invalid request body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
-	phoneNumber := util.NumericRegex.ReplaceAllString(createPhoneNumber.PhoneNumber, "")
-	if phoneNumber == "" {
+	if strings.TrimSpace(createPhoneNumber.PhoneNumber) == "" {
 		return model.NewError(http.StatusBadRequest).AddError("phoneNumber", "required")
 	}
-	if len(phoneNumber) < 10 || len(phoneNumber) > 13 {
+	phoneNumber, err := util.NormalizePhoneNumber(createPhoneNumber.PhoneNumber, tenentPhoneRegion(middleware.GetTenent(c)))
+	if err != nil {
 		return model.NewError(http.StatusBadRequest).AddError("phoneNumber", "invalid")
 	}
 	user := middleware.GetUser(c)
-	confirmationToken, err := util.GenerateRandomHex(6)
-	if err != nil {
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
-	}
-	phoneNumberRow, err := repository.CreatePhoneNumber(user.ApplicationId, user.Id, phoneNumber, confirmationToken)
+	phoneNumberRow, err := repository.CreatePhoneNumber(user.ApplicationId, user.Id, phoneNumber)
 	if err != nil {
+		if repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "phone_numbers_email_unique_idx") {
+			return model.NewError(http.StatusBadRequest).AddError("phoneNumber", "duplicate")
+		}
 		slog.Error("failed to create phone_number", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	// TODO: send to phone number
-	slog.Info("send text", "userId", user.Id, "phoneNumberId", phoneNumberRow.Id, "token", strings.ToUpper(*phoneNumberRow.ConfirmationToken))
+	// the phone number is kept when sending fails, the code can be resent
+	if err := sendConfirmationCode(middleware.GetTenent(c), user, repository.ConfirmationTargetPhoneNumber, phoneNumberRow.Id, phoneNumberRow.PhoneNumber); err != nil {
+		slog.Error("failed to send phone_number confirmation", "error", err)
+	}
 	c.Status(http.StatusCreated)
 	return c.JSON(model.PhoneNumberFromRow(phoneNumberRow))
 }
@@ -192,3 +220,12 @@ func DeleteCurrentUserPhoneNumber(c *fiber.Ctx) error {
 	c.Status(http.StatusNoContent)
 	return c.Send(nil)
 }
+
+// tenentPhoneRegion returns the region national phone numbers are read as, without one only international numbers are
+// accepted
+func tenentPhoneRegion(tenent *repository.TenentRowST) string {
+	if tenent == nil || tenent.DefaultPhoneRegion == nil {
+		return ""
+	}
+	return *tenent.DefaultPhoneRegion
+}
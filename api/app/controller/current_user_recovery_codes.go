@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCurrentUserRecoveryCodes
+//
+//	@Summary		Get the number of unused recovery codes
+//	@ID				recovery-codes
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}   	model.RecoveryCodesStatusST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/recovery-codes [get]
+//
+//	@Security		Authorization
+func GetCurrentUserRecoveryCodes(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	remaining, err := repository.GetRecoveryCodesRemaining(user.Id)
+	if err != nil {
+		slog.Error("failed to get recovery codes", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.RecoveryCodesStatusST{
+		Remaining: remaining,
+	})
+}
+
+// PostCurrentUserRegenerateRecoveryCodes
+//
+//	@Summary		Regenerate recovery codes, invalidating any existing codes
+//	@ID				regenerate-recovery-codes
+//	@Tags			current-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}   	model.RecoveryCodesST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/user/recovery-codes [post]
+//
+//	@Security		Authorization
+func PostCurrentUserRegenerateRecoveryCodes(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	codes, err := repository.CreateRecoveryCodes(user.Id)
+	if err != nil {
+		slog.Error("failed to create recovery codes", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	auditLog(c, user, repository.AuditActionRecoveryCodesGenerated, nil)
+	c.Status(http.StatusCreated)
+	return c.JSON(model.RecoveryCodesST{
+		Codes: codes,
+	})
+}
+
+func ensureRecoveryCodes(c *fiber.Ctx, user *repository.UserRowST) ([]string, error) {
+	remaining, err := repository.GetRecoveryCodesRemaining(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if remaining > 0 {
+		return nil, nil
+	}
+	codes, err := repository.CreateRecoveryCodes(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	auditLog(c, user, repository.AuditActionRecoveryCodesGenerated, nil)
+	return codes, nil
+}
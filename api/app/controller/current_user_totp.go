@@ -65,13 +65,20 @@ func PostCurrentUserCreateTOTP(c *fiber.Ctx) error {
 		slog.Error("failed to create TOTP", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	_, err = repository.UpsertMFA(user.Id, totp.Id, "totp")
+	_, err = repository.UpsertMFA(user.Id, totp.Id, repository.MFATypeTOTP)
 	if err != nil {
 		slog.Error("failed to enable MFA for TOTP", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
+	recoveryCodes, err := ensureRecoveryCodes(c, user)
+	if err != nil {
+		slog.Error("failed to create recovery codes", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	result := model.TOTPWithSecretFromRow(totp)
+	result.RecoveryCodes = recoveryCodes
 	c.Status(http.StatusCreated)
-	return c.JSON(model.TOTPWithSecretFromRow(totp))
+	return c.JSON(result)
 }
 
 // PatchCurrentUserEnableTOTP
@@ -104,14 +111,21 @@ func PatchCurrentUserEnableTOTP(c *fiber.Ctx) error {
 	if totp == nil {
 		return model.NewError(http.StatusNotFound).AddError("tenentId", "invalid")
 	}
-	_, err = repository.UpsertMFA(user.Id, totp.Id, "totp")
+	_, err = repository.UpsertMFA(user.Id, totp.Id, repository.MFATypeTOTP)
 	if err != nil {
 		slog.Error("failed to enable MFA for TOTP", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
+	recoveryCodes, err := ensureRecoveryCodes(c, user)
+	if err != nil {
+		slog.Error("failed to create recovery codes", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
 	totp.Enabled = true
+	result := model.TOTPWithSecretFromRow(*totp)
+	result.RecoveryCodes = recoveryCodes
 	c.Status(http.StatusOK)
-	return c.JSON(model.TOTPWithSecretFromRow(*totp))
+	return c.JSON(result)
 }
 
 // DeleteCurrentUserDisableTOTP
@@ -144,7 +158,7 @@ func DeleteCurrentUserDisableTOTP(c *fiber.Ctx) error {
 	if totp == nil {
 		return model.NewError(http.StatusNotFound).AddError("tenentId", "invalid")
 	}
-	_, err = repository.DeleteMFA(user.Id)
+	_, err = repository.DeleteMFA(user.Id, repository.MFATypeTOTP, totp.Id)
 	if err != nil {
 		slog.Error("failed to disable MFA for TOTP", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
@@ -176,14 +190,22 @@ func DeleteCurrentUserTOTP(c *fiber.Ctx) error {
 	if err != nil {
 		return model.NewError(http.StatusBadRequest).AddError("tenentId", "invalid")
 	}
-	deleted, err := repository.DeleteTOTP(user.Id, int32(tenentId))
+	totp, err := repository.GetTOTPsByUserIdAndTenentId(user.Id, int32(tenentId))
 	if err != nil {
-		slog.Error("failed to delete totp", "error", err)
+		slog.Error("failed to find TOTP", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	if !deleted {
+	if totp == nil {
 		return model.NewError(http.StatusNotFound).AddError("tenentId", "invalid")
 	}
+	if _, err := repository.DeleteMFA(user.Id, repository.MFATypeTOTP, totp.Id); err != nil {
+		slog.Error("failed to disable MFA for TOTP", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if _, err := repository.DeleteTOTP(user.Id, int32(tenentId)); err != nil {
+		slog.Error("failed to delete totp", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
 	c.Status(http.StatusNoContent)
 	return c.Send(nil)
 }
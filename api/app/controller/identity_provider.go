@@ -0,0 +1,584 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTenentIdentityProviders
+//
+//	@Summary		List the identity providers users can sign in with
+//	@ID				tenent-identity-providers
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		model.PublicIdentityProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/identity-providers [get]
+//
+//	@Security		TenentId
+func GetTenentIdentityProviders(c *fiber.Ctx) error {
+	tenent := middleware.GetTenent(c)
+	providers, err := repository.GetEnabledIdentityProviders(tenent.Id)
+	if err != nil {
+		slog.Error("failed to get identity providers", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(providers, model.PublicIdentityProviderFromRow))
+}
+
+// PostIdentityProviderAuthorize
+//
+//	@Summary		Start signing in with an identity provider
+//	@Description	Returns the url to send the user to, the provider redirects back to the callback which redirects to the tenent's authorization website with an identity_provider_code to exchange with the identity-provider grant
+//	@ID				identity-provider-authorize
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"identity provider id"
+//	@Success		200	{object}	model.IdentityProviderAuthorizationST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/identity-providers/{id}/authorize [post]
+//
+//	@Security		TenentId
+func PostIdentityProviderAuthorize(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	tenent := middleware.GetTenent(c)
+	provider, err := repository.GetIdentityProviderById(int32(id))
+	if err != nil {
+		slog.Error("failed to get identity provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if provider == nil || provider.TenentId != tenent.Id || !provider.Enabled {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	authorizationURL, err := startIdentityProviderLogin(provider, nil)
+	if err != nil {
+		return err
+	}
+	return c.JSON(model.IdentityProviderAuthorizationST{
+		AuthorizationURL: authorizationURL,
+	})
+}
+
+// startIdentityProviderLogin returns the url to send the user to, when linkUserId is set the callback links the upstream
+// identity to that user
+func startIdentityProviderLogin(provider *repository.IdentityProviderRowST, linkUserId *int32) (string, error) {
+	endpoints, err := service.GetIdentityProviderEndpoints(provider)
+	if err != nil {
+		slog.Error("failed to get identity provider endpoints", "identityProviderId", provider.Id, "error", err)
+		return "", model.NewError(http.StatusInternalServerError).AddError("identity_provider", "unavailable")
+	}
+	state, err := util.GenerateRandomHex(32)
+	if err != nil {
+		slog.Error("failed to generate state", "error", err)
+		return "", model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	nonce, err := util.GenerateRandomHex(32)
+	if err != nil {
+		slog.Error("failed to generate nonce", "error", err)
+		return "", model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	codeVerifier, err := util.GenerateRandomHex(32)
+	if err != nil {
+		slog.Error("failed to generate code verifier", "error", err)
+		return "", model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	_, err = repository.CreateIdentityProviderLogin(provider.Id, linkUserId, state, nonce, codeVerifier, config.Get().IdentityProvider.LoginExpiresInSeconds)
+	if err != nil {
+		slog.Error("failed to create identity provider login", "error", err)
+		return "", model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	authorizationURL, err := service.IdentityProviderAuthorizationURL(provider, endpoints, identityProviderRedirectURI(), state, nonce, codeVerifier)
+	if err != nil {
+		slog.Error("failed to build authorization url", "identityProviderId", provider.Id, "error", err)
+		return "", model.NewError(http.StatusInternalServerError).AddError("identity_provider", "unavailable")
+	}
+	return authorizationURL, nil
+}
+
+// GetIdentityProviderCallback
+//
+//	@Summary		Identity provider redirect back
+//	@Description	Finds the user linked to the upstream identity, links a user with the same confirmed email when the provider allows it or creates one, then redirects to the tenent's authorization website with an identity_provider_code or an identity_provider_error. Logins started from /user/identities redirect with identity_provider_linked instead of a code
+//	@ID				identity-provider-callback
+//	@Tags			identity-provider
+//	@Param			state	query	string	true	"state"
+//	@Param			code	query	string	false	"authorization code"
+//	@Param			error	query	string	false	"error"
+//	@Success		303
+//	@Failure		400	{object}	model.ErrorST
+//	@Router			/identity-providers/callback [get]
+func GetIdentityProviderCallback(c *fiber.Ctx) error {
+	login, err := repository.UseIdentityProviderLoginState(c.Query("state"))
+	if err != nil {
+		slog.Error("failed to use identity provider state", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if login == nil {
+		return model.NewError(http.StatusBadRequest).AddError("state", "invalid")
+	}
+	provider, err := repository.GetIdentityProviderById(login.IdentityProviderId)
+	if err != nil || provider == nil {
+		slog.Error("failed to get identity provider", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("state", "invalid")
+	}
+	tenent, err := repository.GetTenentById(provider.TenentId)
+	if err != nil || tenent == nil {
+		slog.Error("failed to get tenent", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("state", "invalid")
+	}
+	if upstreamError := c.Query("error"); upstreamError != "" {
+		slog.Info("identity provider returned an error", "identityProviderId", provider.Id, "error", upstreamError, "description", c.Query("error_description"))
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "access_denied")
+	}
+	if !provider.Enabled {
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "access_denied")
+	}
+	endpoints, err := service.GetIdentityProviderEndpoints(provider)
+	if err != nil {
+		slog.Error("failed to get identity provider endpoints", "identityProviderId", provider.Id, "error", err)
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "server_error")
+	}
+	upstream, err := service.ExchangeIdentityProviderCode(provider, endpoints, identityProviderRedirectURI(), c.Query("code"), login.CodeVerifier, login.Nonce)
+	if err != nil {
+		slog.Error("failed to exchange identity provider code", "identityProviderId", provider.Id, "error", err)
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "access_denied")
+	}
+	if login.LinkUserId != nil {
+		return linkIdentityUser(c, tenent, provider, *login.LinkUserId, upstream)
+	}
+	user, errorCode := findOrCreateIdentityUser(c, provider, upstream)
+	if user == nil {
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", errorCode)
+	}
+	code, err := repository.CreateIdentityProviderLoginCode(login.Id, user.Id)
+	if err != nil {
+		slog.Error("failed to create identity provider login code", "error", err)
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "server_error")
+	}
+	return redirectIdentityProviderResult(c, tenent, "identity_provider_code", code)
+}
+
+// findOrCreateIdentityUser returns the user signing in with an upstream identity or an error code for the client
+func findOrCreateIdentityUser(c *fiber.Ctx, provider *repository.IdentityProviderRowST, upstream repository.UpstreamUserST) (*repository.UserRowST, string) {
+	identity, err := repository.GetUserIdentity(provider.Id, upstream.Subject)
+	if err != nil {
+		slog.Error("failed to get user identity", "error", err)
+		return nil, "server_error"
+	}
+	if identity != nil {
+		if _, err := repository.TouchUserIdentity(identity.Id, upstream.Email); err != nil {
+			slog.Error("failed to update user identity", "error", err)
+		}
+		user, err := repository.GetUserById(provider.ApplicationId, identity.UserId)
+		if err != nil || user == nil {
+			slog.Error("failed to get user", "error", err)
+			return nil, "server_error"
+		}
+		return user, ""
+	}
+	if email := upstream.VerifiedEmail(); provider.LinkByEmail && email != nil {
+		user, err := repository.GetUserByConfirmedEmail(provider.ApplicationId, *email, false)
+		if err != nil {
+			slog.Error("failed to get user by email", "error", err)
+			return nil, "server_error"
+		}
+		if user != nil {
+			if _, err := repository.LinkUserIdentity(provider.ApplicationId, user.Id, provider.Id, upstream); err != nil {
+				slog.Error("failed to link user identity", "error", err)
+				return nil, "server_error"
+			}
+			auditLog(c, user, repository.AuditActionIdentityLinked, map[string]interface{}{
+				"identity_provider_id": provider.Id,
+				"subject":              upstream.Subject,
+			})
+			return user, ""
+		}
+	}
+	if !provider.RegistrationEnabled {
+		return nil, "registration_disabled"
+	}
+	user, err := repository.CreateUserFromIdentity(provider.ApplicationId, provider.Id, upstream)
+	if err != nil {
+		slog.Error("failed to create user from identity", "error", err)
+		return nil, "server_error"
+	}
+	return &user, ""
+}
+
+// linkIdentityUser links an upstream identity to the user who started linking it, an identity can only be linked to one
+// user
+func linkIdentityUser(c *fiber.Ctx, tenent *repository.TenentRowST, provider *repository.IdentityProviderRowST, userId int32, upstream repository.UpstreamUserST) error {
+	user, err := repository.GetUserById(provider.ApplicationId, userId)
+	if err != nil || user == nil {
+		slog.Error("failed to get user", "error", err)
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "server_error")
+	}
+	identity, err := repository.GetUserIdentity(provider.Id, upstream.Subject)
+	if err != nil {
+		slog.Error("failed to get user identity", "error", err)
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "server_error")
+	}
+	if identity != nil && identity.UserId != user.Id {
+		return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "identity_already_linked")
+	}
+	if identity == nil {
+		if _, err := repository.LinkUserIdentity(provider.ApplicationId, user.Id, provider.Id, upstream); err != nil {
+			if repository.IsDuplicateKeyError(err) {
+				return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "identity_already_linked")
+			}
+			slog.Error("failed to link user identity", "error", err)
+			return redirectIdentityProviderResult(c, tenent, "identity_provider_error", "server_error")
+		}
+		auditLog(c, user, repository.AuditActionIdentityLinked, map[string]interface{}{
+			"identity_provider_id": provider.Id,
+			"subject":              upstream.Subject,
+		})
+	}
+	return redirectIdentityProviderResult(c, tenent, "identity_provider_linked", strconv.Itoa(int(provider.Id)))
+}
+
+func redirectIdentityProviderResult(c *fiber.Ctx, tenent *repository.TenentRowST, key, value string) error {
+	redirectURL, err := url.Parse(tenent.AuthorizationWebsite)
+	if err != nil {
+		slog.Error("invalid authorization website", "tenentId", tenent.Id, "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	query := redirectURL.Query()
+	query.Set(key, value)
+	redirectURL.RawQuery = query.Encode()
+	return c.Redirect(redirectURL.String(), http.StatusSeeOther)
+}
+
+func identityProviderRedirectURI() string {
+	return strings.TrimSuffix(config.Get().URL, "/") + "/identity-providers/callback"
+}
+
+func identityProviderToken(c *fiber.Ctx, tokenRequest model.TokenRequestST) error {
+	tenent := middleware.GetTenent(c)
+	code := strings.TrimSpace(tokenRequest.Code)
+	if code == "" {
+		return model.NewError(http.StatusUnauthorized).AddError("code", "invalid")
+	}
+	userId, err := repository.UseIdentityProviderLoginCode(tenent.Id, code)
+	if err != nil {
+		slog.Error("failed to use identity provider code", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if userId == nil {
+		return model.NewError(http.StatusUnauthorized).AddError("code", "invalid")
+	}
+	application := middleware.GetApplication(c)
+	user, err := repository.GetUserById(application.Id, *userId)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		return model.NewError(http.StatusUnauthorized).AddError("code", "invalid")
+	}
+	mfas, err := repository.GetEnabledMFAs(user.Id, tenent.Id)
+	if err != nil {
+		slog.Error("failed to get mfa", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return sendToken(c, sendTokenST{
+		mfas:            mfas,
+		issuedTokenType: tokenRequest.GrantType,
+		scope:           tokenRequest.Scope,
+		application:     application,
+		tenent:          tenent,
+		user:            user,
+	})
+}
+
+// GetIdentityProviders
+//
+//	@Summary		Get tenent identity providers
+//	@ID				identity-providers
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			tenentId		path		int	true	"tenent id"
+//	@Success		200	{array}		model.IdentityProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/identity-providers [get]
+//
+//	@Security		Authorization
+func GetIdentityProviders(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	tenent, err := getApplicationTenent(c)
+	if err != nil {
+		return err
+	}
+	providers, err := repository.GetIdentityProviders(tenent.Id)
+	if err != nil {
+		slog.Error("failed to get identity providers", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(providers, model.IdentityProviderFromRow))
+}
+
+// GetIdentityProviderById
+//
+//	@Summary		Get tenent identity provider by id
+//	@ID				identity-provider
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			tenentId		path		int	true	"tenent id"
+//	@Param			id				path		int	true	"identity provider id"
+//	@Success		200	{object}	model.IdentityProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/identity-providers/{id} [get]
+//
+//	@Security		Authorization
+func GetIdentityProviderById(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	provider, err := getTenentIdentityProvider(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(model.IdentityProviderFromRow(*provider))
+}
+
+// PostCreateIdentityProvider
+//
+//	@Summary		Create tenent identity provider
+//	@Description	github and google providers only need a client id and secret, oidc providers need an issuer supporting discovery or their endpoints
+//	@ID				create-identity-provider
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId		path		int								true	"application id"
+//	@Param			tenentId			path		int								true	"tenent id"
+//	@Param			identityProvider	body		model.CreateIdentityProviderST	true	"create identity provider"
+//	@Success		201	{object}	model.IdentityProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/identity-providers [post]
+//
+//	@Security		Authorization
+func PostCreateIdentityProvider(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	tenent, err := getApplicationTenent(c)
+	if err != nil {
+		return err
+	}
+	var createIdentityProvider model.CreateIdentityProviderST
+	if err := c.BodyParser(&createIdentityProvider); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	create := createIdentityProvider.CreateIdentityProviderST
+	create.Name = strings.TrimSpace(create.Name)
+	errors := model.NewError(http.StatusBadRequest)
+	if create.Name == "" {
+		errors.AddError("name", "required")
+	}
+	switch create.Kind {
+	case repository.IdentityProviderKindGitHub, repository.IdentityProviderKindGoogle:
+	case repository.IdentityProviderKindOIDC:
+		if isBlank(create.Issuer) && (isBlank(create.AuthorizationEndpoint) || isBlank(create.TokenEndpoint)) {
+			errors.AddError("issuer", "required")
+		}
+	default:
+		errors.AddError("kind", "invalid")
+	}
+	if strings.TrimSpace(create.ClientId) == "" {
+		errors.AddError("client_id", "required")
+	}
+	if strings.TrimSpace(create.ClientSecret) == "" {
+		errors.AddError("client_secret", "required")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	provider, err := repository.CreateIdentityProvider(tenent.ApplicationId, tenent.Id, create)
+	if err != nil {
+		if repository.IsDuplicateKeyError(err) {
+			return model.NewError(http.StatusBadRequest).AddError("name", "duplicate")
+		}
+		slog.Error("failed to create identity provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Status(http.StatusCreated)
+	return c.JSON(model.IdentityProviderFromRow(provider))
+}
+
+// PatchUpdateIdentityProvider
+//
+//	@Summary		Update tenent identity provider
+//	@ID				update-identity-provider
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId		path		int								true	"application id"
+//	@Param			tenentId			path		int								true	"tenent id"
+//	@Param			id					path		int								true	"identity provider id"
+//	@Param			identityProvider	body		model.UpdateIdentityProviderST	true	"update identity provider"
+//	@Success		200	{object}	model.IdentityProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/identity-providers/{id} [patch]
+//
+//	@Security		Authorization
+func PatchUpdateIdentityProvider(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	current, err := getTenentIdentityProvider(c)
+	if err != nil {
+		return err
+	}
+	var updateIdentityProvider model.UpdateIdentityProviderST
+	if err := c.BodyParser(&updateIdentityProvider); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	update := updateIdentityProvider.UpdateIdentityProviderST
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return model.NewError(http.StatusBadRequest).AddError("name", "required")
+		}
+		update.Name = &name
+	}
+	provider, err := repository.UpdateIdentityProvider(current.Id, update)
+	if err != nil {
+		if repository.IsDuplicateKeyError(err) {
+			return model.NewError(http.StatusBadRequest).AddError("name", "duplicate")
+		}
+		slog.Error("failed to update identity provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if provider == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	return c.JSON(model.IdentityProviderFromRow(*provider))
+}
+
+// DeleteIdentityProvider
+//
+//	@Summary		Delete tenent identity provider
+//	@Description	Also removes the identities users linked with it
+//	@ID				delete-identity-provider
+//	@Tags			identity-provider
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			tenentId		path		int	true	"tenent id"
+//	@Param			id				path		int	true	"identity provider id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/identity-providers/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteIdentityProvider(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	provider, err := getTenentIdentityProvider(c)
+	if err != nil {
+		return err
+	}
+	deleted, err := repository.DeleteIdentityProvider(provider.Id)
+	if err != nil {
+		slog.Error("failed to delete identity provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+func getApplicationTenent(c *fiber.Ctx) (*repository.TenentRowST, error) {
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return nil, model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	tenentId, err := strconv.Atoi(c.Params("tenentId"))
+	if err != nil {
+		return nil, model.NewError(http.StatusBadRequest).AddError("tenentId", "invalid")
+	}
+	tenent, err := repository.GetTenentById(int32(tenentId))
+	if err != nil {
+		slog.Error("failed to find tenent", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil || tenent.ApplicationId != int32(applicationId) {
+		return nil, model.NewError(http.StatusNotFound).AddError("tenentId", "invalid")
+	}
+	return tenent, nil
+}
+
+func getTenentIdentityProvider(c *fiber.Ctx) (*repository.IdentityProviderRowST, error) {
+	tenent, err := getApplicationTenent(c)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	provider, err := repository.GetIdentityProviderById(int32(id))
+	if err != nil {
+		slog.Error("failed to get identity provider", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if provider == nil || provider.TenentId != tenent.Id {
+		return nil, model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	return provider, nil
+}
+
+func isBlank(value *string) bool {
+	return value == nil || strings.TrimSpace(*value) == ""
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PostImpersonateUser
+//
+//	@Summary		Impersonate a user
+//	@Description	Issues a short lived access token for the user with an act claim identifying the caller. It can't be refreshed, can't change the user's password, MFA, emails, phone numbers or identities and every request made with it is audit logged
+//	@ID				impersonate-user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"user id"
+//	@Param			impersonateUser	body    model.ImpersonateUserST	true	"impersonate user"
+//	@Success		200	{object}   	model.TokenST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/users/{id}/impersonate [post]
+//
+//	@Security		Authorization
+func PostImpersonateUser(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "impersonate"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	var impersonateUser model.ImpersonateUserST
+	if err := c.BodyParser(&impersonateUser); err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	application, err := repository.GetApplicationById(int32(applicationId))
+	if err != nil {
+		slog.Error("failed to get application", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if application == nil {
+		return model.NewError(http.StatusNotFound).AddError("applicationId", "invalid")
+	}
+	// admins can't be impersonated, it would give the caller every permission the admin has
+	if application.IsAdmin {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	tenent, err := repository.GetTenentById(impersonateUser.TenentId)
+	if err != nil {
+		slog.Error("failed to get tenent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	// third party tenents would ask for the user's consent, which the caller must not give for them
+	if tenent == nil || tenent.ApplicationId != application.Id || tenent.ThirdParty {
+		return model.NewError(http.StatusBadRequest).AddError("tenent_id", "invalid")
+	}
+	user, err := repository.GetUserById(application.Id, int32(id))
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	claims := middleware.GetClaims[jwt.Claims](c)
+	actor := &jwt.Actor{
+		Subject:     claims.Subject,
+		SubjectType: claims.SubjectType,
+		ClientId:    claims.ClientId,
+	}
+	auditLog(c, user, repository.AuditActionImpersonationStarted, map[string]interface{}{
+		"actor_subject":      actor.Subject,
+		"actor_subject_type": actor.SubjectType,
+		"actor_client_id":    actor.ClientId,
+		"tenent_id":          tenent.Id,
+	})
+	scope := ""
+	if impersonateUser.Scope != nil {
+		scope = *impersonateUser.Scope
+	}
+	return sendToken(c, sendTokenST{
+		issuedTokenType: model.ImpersonationTokenType,
+		scope:           scope,
+		application:     application,
+		tenent:          tenent,
+		user:            user,
+		actor:           actor,
+	})
+}
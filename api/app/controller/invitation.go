@@ -0,0 +1,432 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetInvitations
+//
+//	@Summary		Get outstanding invitations
+//	@Description	Invitations that were neither accepted nor revoked, newest first. Expired invitations are included so they can be resent
+//	@ID				invitations
+//	@Tags			invitation
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			query	query		model.PaginationQueryST	false	"query"
+//	@Success		200	{object}   	model.PaginationST[model.InvitationST]
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/invitations [get]
+//
+//	@Security		Authorization
+func GetInvitations(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var query model.PaginationQueryST
+	if err := c.QueryParser(&query); err != nil {
+		slog.Error("failed to parse query", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("query", "invalid")
+	}
+	page, err := pageFromQuery(&query)
+	if err != nil {
+		return err
+	}
+	invitations, err := repository.GetOutstandingInvitations(int32(applicationId), page)
+	if err != nil {
+		return paginationError(err, "failed to get invitations")
+	}
+	return c.JSON(model.PaginationFromPage(invitations, util.Map(invitations.Items, model.InvitationFromRow)))
+}
+
+// PostCreateInvitation
+//
+//	@Summary		Invite a user
+//	@Description	Creates a pending user with the email and sends it a single use link to the tenent's authorization website, the user can't sign in until it accepts the invitation and sets its password
+//	@ID				create-invitation
+//	@Tags			invitation
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			createInvitation	body    model.CreateInvitationST	true	"create invitation"
+//	@Success		201	{object}   	model.InvitationST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/invitations [post]
+//
+//	@Security		Authorization
+func PostCreateInvitation(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var createInvitation model.CreateInvitationST
+	if err := c.BodyParser(&createInvitation); err != nil {
+		slog.Error("invalid request body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	create := repository.CreateInvitationST{
+		TenentId:         createInvitation.TenentId,
+		Email:            strings.TrimSpace(createInvitation.Email),
+		ExpiresInSeconds: config.Get().Invitation.ExpiresInSeconds,
+	}
+	errors := model.NewError(http.StatusBadRequest)
+	if create.Email == "" || !strings.Contains(create.Email, "@") {
+		errors.AddError("email", "invalid")
+	}
+	if !isBlank(createInvitation.Username) {
+		username := strings.TrimSpace(*createInvitation.Username)
+		create.Username = &username
+	}
+	tenent, err := repository.GetTenentById(create.TenentId)
+	if err != nil {
+		slog.Error("failed to get tenent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil || tenent.ApplicationId != int32(applicationId) {
+		errors.AddError("tenent_id", "invalid")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	existingUser, err := repository.GetUserByEmail(int32(applicationId), create.Email, false)
+	if err != nil {
+		slog.Error("failed to get user by email", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if existingUser != nil {
+		return model.NewError(http.StatusBadRequest).AddError("email", "duplicate")
+	}
+	data := map[string]interface{}{
+		"email":     create.Email,
+		"tenent_id": tenent.Id,
+	}
+	if middleware.IsUserSubject(c) {
+		actor := middleware.GetUser(c)
+		create.InvitedByUserId = &actor.Id
+		data["actor_user_id"] = actor.Id
+	} else if middleware.IsServiceAccount(c) {
+		actor := middleware.GetServiceAccount(c)
+		create.InvitedByServiceAccountId = &actor.Id
+		data["actor_service_account_id"] = actor.Id
+	}
+	result, err := repository.CreateInvitation(int32(applicationId), create)
+	if err != nil {
+		switch {
+		case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_username_unique_idx"):
+			return model.NewError(http.StatusBadRequest).AddError("username", "duplicate")
+		case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "emails_email_unique_idx"):
+			return model.NewError(http.StatusBadRequest).AddError("email", "duplicate")
+		}
+		slog.Error("failed to create invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	invitation, err := sendInvitation(tenent, &result.Invitation)
+	if err != nil {
+		slog.Error("failed to send invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	data["invitation_id"] = invitation.Id
+	auditLog(c, &result.User, repository.AuditActionInvitationCreated, data)
+	c.Status(http.StatusCreated)
+	return c.JSON(model.InvitationFromRow(*invitation))
+}
+
+// PostResendInvitation
+//
+//	@Summary		Resend an invitation
+//	@Description	Sends a new link and restarts the invitation's expiry, links sent before stop working
+//	@ID				resend-invitation
+//	@Tags			invitation
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"invitation id"
+//	@Success		200	{object}   	model.InvitationST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/invitations/{id}/resend [post]
+//
+//	@Security		Authorization
+func PostResendInvitation(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	invitation, err := repository.GetOutstandingInvitationById(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to get invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	// the invitation is useless once its user was deleted
+	if invitation == nil || invitation.UserId == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	tenent, err := repository.GetTenentById(invitation.TenentId)
+	if err != nil {
+		slog.Error("failed to get tenent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	user, err := repository.GetUserById(invitation.ApplicationId, *invitation.UserId)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil || user == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	invitation, err = sendInvitation(tenent, invitation)
+	if err != nil {
+		slog.Error("failed to send invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if invitation == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	auditLog(c, user, repository.AuditActionInvitationResent, map[string]interface{}{
+		"invitation_id": invitation.Id,
+	})
+	return c.JSON(model.InvitationFromRow(*invitation))
+}
+
+// DeleteInvitation
+//
+//	@Summary		Revoke an invitation
+//	@Description	The invitation's links stop working and its user is deleted
+//	@ID				delete-invitation
+//	@Tags			invitation
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"invitation id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/invitations/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteInvitation(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	invitation, err := repository.GetOutstandingInvitationById(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to get invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if invitation == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	// the user is looked up before it is deleted so the audit log records who the invitation was for
+	var user *repository.UserRowST
+	if invitation.UserId != nil {
+		user, err = repository.GetUserById(invitation.ApplicationId, *invitation.UserId)
+		if err != nil {
+			slog.Error("failed to get user", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+	}
+	invitation, err = repository.RevokeInvitation(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to revoke invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if invitation == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	if user != nil {
+		auditLog(c, user, repository.AuditActionInvitationRevoked, map[string]interface{}{
+			"invitation_id": invitation.Id,
+			"email":         invitation.Email,
+		})
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// PostAcceptInvitation
+//
+//	@Summary		Accept an invitation
+//	@Description	Sets the invited user's password, and optionally its username and info, activates it and signs it in. Each invitation link can only be used once
+//	@ID				accept-invitation
+//	@Tags			invitation
+//	@Accept			json
+//	@Produce		json
+//	@Param			acceptInvitation	body	model.AcceptInvitationST	true	"accept invitation"
+//	@Success		200	{object}	model.TokenST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/invitations/accept [post]
+//
+//	@Security		TenentId
+func PostAcceptInvitation(c *fiber.Ctx) error {
+	var acceptInvitation model.AcceptInvitationST
+	if err := c.BodyParser(&acceptInvitation); err != nil {
+		slog.Error("invalid request body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	tenent := middleware.GetTenent(c)
+	application := middleware.GetApplication(c)
+	claims, err := jwt.ParseClaimsFromToken[jwt.Claims](acceptInvitation.Token, tenent)
+	if err != nil || claims.Type != jwt.InvitationTokenType {
+		slog.Error("invalid invitation token", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("token", "invalid")
+	}
+	invitation, err := repository.GetInvitationByToken(acceptInvitation.Token)
+	if err != nil {
+		slog.Error("failed to get invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if invitation == nil || invitation.ApplicationId != application.Id || invitation.TenentId != tenent.Id ||
+		invitation.UserId == nil || *invitation.UserId != claims.Subject {
+		return model.NewError(http.StatusBadRequest).AddError("token", "invalid")
+	}
+	user, err := repository.GetUserById(application.Id, claims.Subject)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		return model.NewError(http.StatusBadRequest).AddError("token", "invalid")
+	}
+	password := strings.TrimSpace(acceptInvitation.Password)
+	passwordConfirmation := strings.TrimSpace(acceptInvitation.PasswordConfirmation)
+	errors := model.NewError(http.StatusBadRequest)
+	username := user.Username
+	var newUsername *string
+	if !isBlank(acceptInvitation.Username) {
+		username = strings.TrimSpace(*acceptInvitation.Username)
+		newUsername = &username
+	}
+	if password == "" {
+		errors.AddError("password", "required")
+	}
+	if password != passwordConfirmation {
+		errors.AddError("password_confirmation", "mismatch")
+	}
+	if err := validatePassword(errors, application.Id, tenent.Id, &user.Id, username, password); err != nil {
+		slog.Error("failed to validate password", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	user, err = repository.AcceptInvitation(acceptInvitation.Token, password, newUsername)
+	if err != nil {
+		if repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_username_unique_idx") {
+			return model.NewError(http.StatusBadRequest).AddError("username", "duplicate")
+		}
+		slog.Error("failed to accept invitation", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	// another request used the token first
+	if user == nil {
+		return model.NewError(http.StatusBadRequest).AddError("token", "invalid")
+	}
+	if acceptInvitation.Info != nil {
+		if _, err := repository.UpdateUserInfoByUserId(user.Id, acceptInvitation.Info.ToUpdateUserInfo()); err != nil {
+			slog.Error("failed to update user info", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+	}
+	auditLog(c, user, repository.AuditActionInvitationAccepted, map[string]interface{}{
+		"invitation_id": invitation.Id,
+	})
+	return sendToken(c, sendTokenST{
+		issuedTokenType: jwt.InvitationTokenType,
+		scope:           "openid",
+		application:     application,
+		tenent:          tenent,
+		user:            user,
+	})
+}
+
+// sendInvitation issues a new token for the invitation, only its hash is stored, and emails the invitation link to
+// the invited email. Returns nil if the invitation was accepted or revoked in the meantime
+func sendInvitation(tenent *repository.TenentRowST, invitation *repository.InvitationRowST) (*repository.InvitationRowST, error) {
+	expiresInSeconds := config.Get().Invitation.ExpiresInSeconds
+	now := time.Now().UTC()
+	claims := jwt.Claims{
+		Type:             jwt.InvitationTokenType,
+		Subject:          *invitation.UserId,
+		SubjectType:      jwt.UserSubject,
+		ClientId:         tenent.ClientId,
+		NotBeforeSeconds: now.Unix(),
+		IssuedAtSeconds:  now.Unix(),
+		ExpiresAtSeconds: now.Unix() + expiresInSeconds,
+		Issuer:           config.Get().URL,
+		Scope:            []string{},
+	}
+	token, err := jwt.CreateToken(&claims, tenent)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err = repository.SetInvitationToken(invitation.Id, token, expiresInSeconds)
+	if err != nil || invitation == nil {
+		return invitation, err
+	}
+	data := map[string]interface{}{
+		"expires_in_seconds": expiresInSeconds,
+		"token":              token,
+	}
+	if link, err := url.Parse(tenent.AuthorizationWebsite); err == nil {
+		query := link.Query()
+		query.Set("invitation_token", token)
+		link.RawQuery = query.Encode()
+		data["link"] = link.String()
+	}
+	// the invitation can be resent, a failed delivery doesn't undo it
+	if err := service.SendEmail(tenent, invitation.Email, service.MessageKindInvitation, data); err != nil {
+		slog.Error("failed to send invitation email", "error", err)
+	}
+	return invitation, nil
+}
@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ldapPasswordUser signs in a user who is not local or is flagged as ldap backed with the application's directory,
+// users the directory knows but we do not are provisioned when the connector allows registration
+func ldapPasswordUser(applicationId int32, flaggedUser *repository.UserRowST, username, password string) (*repository.UserRowST, error) {
+	invalid := model.NewError(http.StatusUnauthorized).AddError("username", "invalid").AddError("password", "invalid")
+	connector, err := repository.GetLDAPConnector(applicationId)
+	if err != nil {
+		slog.Error("failed to get ldap connector", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if connector == nil || !connector.Enabled {
+		return nil, invalid
+	}
+	ldapUser, err := service.AuthenticateLDAP(connector, username, password)
+	if err != nil {
+		if !errors.Is(err, service.ErrLDAPInvalidCredentials) {
+			slog.Error("failed to authenticate with ldap", "applicationId", applicationId, "error", err)
+		}
+		return nil, invalid
+	}
+	user, err := repository.GetUserByLDAPDN(applicationId, ldapUser.DN)
+	if err != nil {
+		slog.Error("failed to get user by ldap dn", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		user = flaggedUser
+	}
+	if user == nil {
+		if !connector.RegistrationEnabled {
+			return nil, invalid
+		}
+		createdUser, err := repository.CreateUserFromLDAP(applicationId, *ldapUser)
+		if err != nil {
+			slog.Error("failed to create user from ldap", "error", err)
+			return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		user = &createdUser
+	}
+	mappedRoles, roles, err := service.LDAPRoles(connector, ldapUser)
+	if err != nil {
+		slog.Error("failed to map ldap groups to roles", "error", err)
+		return user, nil
+	}
+	if err := repository.SyncLDAPUser(applicationId, user.Id, *ldapUser, mappedRoles, roles); err != nil {
+		slog.Error("failed to sync ldap user", "userId", user.Id, "error", err)
+	}
+	return user, nil
+}
+
+// GetLDAPConnector
+//
+//	@Summary		Get application LDAP connector
+//	@ID				ldap-connector
+//	@Tags			ldap
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Success		200	{object}	model.LDAPConnectorST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/ldap-connector [get]
+//
+//	@Security		Authorization
+func GetLDAPConnector(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "applications", "read"); err != nil {
+		return err
+	}
+	connector, err := getApplicationLDAPConnector(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(model.LDAPConnectorFromRow(*connector))
+}
+
+// PostCreateLDAPConnector
+//
+//	@Summary		Create application LDAP connector
+//	@Description	Password logins for users who are not local or are flagged as ldap backed are checked against the directory, {username} in user_filter is replaced with the login and {dn} and {username} in group_filter with the user's, group_role_mapping maps group names or dns to role uris
+//	@ID				create-ldap-connector
+//	@Tags			ldap
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int							true	"application id"
+//	@Param			ldapConnector	body		model.CreateLDAPConnectorST	true	"create ldap connector"
+//	@Success		201	{object}	model.LDAPConnectorST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/ldap-connector [post]
+//
+//	@Security		Authorization
+func PostCreateLDAPConnector(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "applications", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var createLDAPConnector model.CreateLDAPConnectorST
+	if err := c.BodyParser(&createLDAPConnector); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	create := createLDAPConnector.CreateLDAPConnectorST
+	create.URL = strings.TrimSpace(create.URL)
+	create.UserSearchBase = strings.TrimSpace(create.UserSearchBase)
+	errors := model.NewError(http.StatusBadRequest)
+	if create.UserSearchBase == "" {
+		errors.AddError("user_search_base", "required")
+	}
+	if create.UserFilter != nil && !strings.Contains(*create.UserFilter, "{username}") {
+		errors.AddError("user_filter", "invalid")
+	}
+	if err := validateLDAPConnector(errors, int32(applicationId), repository.LDAPConnectorRowST{
+		URL:       create.URL,
+		StartTLS:  create.StartTLS != nil && *create.StartTLS,
+		TLSRootCA: create.TLSRootCA,
+	}, create.GroupRoleMapping); err != nil {
+		return err
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	connector, err := repository.CreateLDAPConnector(int32(applicationId), create)
+	if err != nil {
+		if repository.IsDuplicateKeyError(err) {
+			return model.NewError(http.StatusBadRequest).AddError("applicationId", "duplicate")
+		}
+		slog.Error("failed to create ldap connector", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Status(http.StatusCreated)
+	return c.JSON(model.LDAPConnectorFromRow(connector))
+}
+
+// PatchUpdateLDAPConnector
+//
+//	@Summary		Update application LDAP connector
+//	@ID				update-ldap-connector
+//	@Tags			ldap
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int							true	"application id"
+//	@Param			ldapConnector	body		model.UpdateLDAPConnectorST	true	"update ldap connector"
+//	@Success		200	{object}	model.LDAPConnectorST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/ldap-connector [patch]
+//
+//	@Security		Authorization
+func PatchUpdateLDAPConnector(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "applications", "write"); err != nil {
+		return err
+	}
+	current, err := getApplicationLDAPConnector(c)
+	if err != nil {
+		return err
+	}
+	var updateLDAPConnector model.UpdateLDAPConnectorST
+	if err := c.BodyParser(&updateLDAPConnector); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	update := updateLDAPConnector.UpdateLDAPConnectorST
+	errors := model.NewError(http.StatusBadRequest)
+	updated := *current
+	if update.URL != nil {
+		ldapURL := strings.TrimSpace(*update.URL)
+		update.URL = &ldapURL
+		updated.URL = ldapURL
+	}
+	if update.StartTLS != nil {
+		updated.StartTLS = *update.StartTLS
+	}
+	if update.TLSRootCA != nil {
+		updated.TLSRootCA = update.TLSRootCA
+	}
+	if update.UserSearchBase != nil {
+		userSearchBase := strings.TrimSpace(*update.UserSearchBase)
+		if userSearchBase == "" {
+			errors.AddError("user_search_base", "required")
+		}
+		update.UserSearchBase = &userSearchBase
+	}
+	if update.UserFilter != nil && !strings.Contains(*update.UserFilter, "{username}") {
+		errors.AddError("user_filter", "invalid")
+	}
+	if err := validateLDAPConnector(errors, current.ApplicationId, updated, update.GroupRoleMapping); err != nil {
+		return err
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	connector, err := repository.UpdateLDAPConnector(current.Id, update)
+	if err != nil {
+		slog.Error("failed to update ldap connector", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if connector == nil {
+		return model.NewError(http.StatusNotFound).AddError("applicationId", "invalid")
+	}
+	return c.JSON(model.LDAPConnectorFromRow(*connector))
+}
+
+// DeleteLDAPConnector
+//
+//	@Summary		Delete application LDAP connector
+//	@Description	Users provisioned from the directory are kept but can no longer sign in with a password until they reset it
+//	@ID				delete-ldap-connector
+//	@Tags			ldap
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/ldap-connector [delete]
+//
+//	@Security		Authorization
+func DeleteLDAPConnector(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "applications", "write"); err != nil {
+		return err
+	}
+	connector, err := getApplicationLDAPConnector(c)
+	if err != nil {
+		return err
+	}
+	deleted, err := repository.DeleteLDAPConnector(connector.Id)
+	if err != nil {
+		slog.Error("failed to delete ldap connector", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("applicationId", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// PatchUserLDAP
+//
+//	@Summary		Flag a user as ldap backed
+//	@Description	Password logins for ldap backed users are checked against the application's directory instead of their local password
+//	@ID				update-user-ldap
+//	@Tags			ldap
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int						true	"application id"
+//	@Param			id				path		int						true	"user id"
+//	@Param			updateUserLDAP	body		model.UpdateUserLDAPST	true	"update user ldap"
+//	@Success		200	{object}	model.UserST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/users/{id}/ldap [patch]
+//
+//	@Security		Authorization
+func PatchUserLDAP(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	var updateUserLDAP model.UpdateUserLDAPST
+	if err := c.BodyParser(&updateUserLDAP); err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	user, err := repository.SetUserLDAPBacked(int32(applicationId), int32(id), updateUserLDAP.LDAPBacked)
+	if err != nil {
+		slog.Error("failed to update user ldap", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	emails, phoneNumbers, err := getUserEmailsAndPhoneNumbersById(user.Id)
+	if err != nil {
+		slog.Error("failed to get user emails and phone numbers", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.UserFromRow(*user, emails, phoneNumbers))
+}
+
+func validateLDAPConnector(errors *model.ErrorST, applicationId int32, connector repository.LDAPConnectorRowST, groupRoleMapping map[string][]string) error {
+	ldapURL, err := url.Parse(connector.URL)
+	if err != nil || (ldapURL.Scheme != "ldap" && ldapURL.Scheme != "ldaps") || ldapURL.Host == "" {
+		errors.AddError("url", "invalid")
+	} else if _, err := service.LDAPTLSConfig(&connector); err != nil {
+		errors.AddError("tls_root_ca", "invalid")
+	}
+	if len(groupRoleMapping) == 0 {
+		return nil
+	}
+	roles, err := repository.GetRoles(applicationId)
+	if err != nil {
+		slog.Error("failed to get roles", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	for _, roleURIs := range groupRoleMapping {
+		for _, roleURI := range roleURIs {
+			if !slices.ContainsFunc(roles, func(role repository.RoleRowST) bool { return role.URI == roleURI }) {
+				errors.AddError("group_role_mapping", "invalid")
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func getApplicationLDAPConnector(c *fiber.Ctx) (*repository.LDAPConnectorRowST, error) {
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return nil, model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	connector, err := repository.GetLDAPConnector(int32(applicationId))
+	if err != nil {
+		slog.Error("failed to get ldap connector", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if connector == nil {
+		return nil, model.NewError(http.StatusNotFound).AddError("applicationId", "invalid")
+	}
+	return connector, nil
+}
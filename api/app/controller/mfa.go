@@ -1,14 +1,20 @@
 package controller
 
 import (
+	"bytes"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/aicacia/auth/api/app/config"
 	"github.com/aicacia/auth/api/app/jwt"
 	"github.com/aicacia/auth/api/app/middleware"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/gofiber/fiber/v2"
 	"github.com/xlzd/gotp"
 )
@@ -32,12 +38,13 @@ import (
 //	@Security		Authorization
 func PostValidateMFA(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
-	mfa, err := repository.GetMFA(user.Id)
+	tenent := middleware.GetTenent(c)
+	mfas, err := repository.GetEnabledMFAs(user.Id, tenent.Id)
 	if err != nil {
 		slog.Error("failed to find MFA", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
-	if mfa == nil || !mfa.Enabled {
+	if len(mfas) == 0 {
 		slog.Error("MFA is not enabled")
 		return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
 	}
@@ -46,9 +53,38 @@ func PostValidateMFA(c *fiber.Ctx) error {
 		slog.Error("failed to parse body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("invalid", "body")
 	}
-	tenent := middleware.GetTenent(c)
-	switch mfa.Type {
-	case "totp":
+	mfaType := mfas[0].Type
+	if body.Type != nil {
+		mfaType = *body.Type
+	}
+	if mfaType != repository.MFATypeRecoveryCode && !mfaTypeEnabled(mfas, mfaType) {
+		slog.Error("MFA type is not enabled", "type", mfaType)
+		return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
+	}
+	switch mfaType {
+	case repository.MFATypeRecoveryCode:
+		{
+			recoveryCode, err := repository.UseRecoveryCode(user.Id, body.Code)
+			if err != nil {
+				slog.Error("failed to use recovery code", "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+			if recoveryCode == nil {
+				slog.Error("failed to validate MFA recovery code")
+				return model.NewError(http.StatusForbidden).AddError("mfa", "invalid")
+			}
+			remaining, err := repository.GetRecoveryCodesRemaining(user.Id)
+			if err != nil {
+				slog.Error("failed to get recovery codes", "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+			auditLog(c, user, repository.AuditActionRecoveryCodeUsed, map[string]interface{}{
+				"recovery_code_id": recoveryCode.Id,
+				"tenent_id":        tenent.Id,
+				"remaining":        remaining,
+			})
+		}
+	case repository.MFATypeTOTP:
 		{
 			totp, err := repository.GetTOTPsByUserIdAndTenentId(user.Id, tenent.Id)
 			if err != nil {
@@ -64,9 +100,32 @@ func PostValidateMFA(c *fiber.Ctx) error {
 				return model.NewError(http.StatusForbidden).AddError("mfa", "invalid")
 			}
 		}
+	case repository.MFATypeEmail, repository.MFATypeSMS:
+		{
+			result, err := repository.ValidateMFACode(user.Id, mfaType, strings.TrimSpace(body.Code), config.Get().MFA.CodeMaxAttempts)
+			if err != nil {
+				slog.Error("failed to validate MFA code", "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+			switch result {
+			case repository.MFACodeValid:
+			case repository.MFACodeExpired:
+				return model.NewError(http.StatusGone).AddError("mfa", "expired")
+			case repository.MFACodeExceeded:
+				return model.NewError(http.StatusTooManyRequests).AddError("mfa", "exceeded")
+			default:
+				return model.NewError(http.StatusForbidden).AddError("mfa", "invalid")
+			}
+		}
+	case repository.MFATypePassKey:
+		{
+			if err := validateMFAPassKey(user, tenent, body.Credential); err != nil {
+				return err
+			}
+		}
 	default:
-		slog.Error("unknown MFA type", "type", mfa.Type)
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		slog.Error("unknown MFA type", "type", mfaType)
+		return model.NewError(http.StatusBadRequest).AddError("type", "invalid")
 	}
 	claims := middleware.GetClaims[jwt.MFAClaims](c)
 	return sendToken(c, sendTokenST{
@@ -77,3 +136,221 @@ func PostValidateMFA(c *fiber.Ctx) error {
 		user:            user,
 	})
 }
+
+// PostResendMFA
+//
+//	@Summary		Resend multi-factor authentication code
+//	@Description	Resends the one-time code for email and sms multi-factor authentication, defaults to the preferred method
+//	@ID				resend-mfa
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	query		string	false	"mfa type, email or sms"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		429	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Failure		503	{object}	model.ErrorST
+//	@Router			/mfa/resend [post]
+//
+//	@Security		Authorization
+func PostResendMFA(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	tenent := middleware.GetTenent(c)
+	mfas, err := repository.GetEnabledMFAs(user.Id, tenent.Id)
+	if err != nil {
+		slog.Error("failed to find MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if len(mfas) == 0 {
+		slog.Error("MFA is not enabled")
+		return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
+	}
+	mfaType := c.Query("type", mfas[0].Type)
+	if mfaType != repository.MFATypeEmail && mfaType != repository.MFATypeSMS {
+		return model.NewError(http.StatusBadRequest).AddError("type", "invalid")
+	}
+	if !mfaTypeEnabled(mfas, mfaType) {
+		return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
+	}
+	if err := sendMFACode(user, tenent, mfaType); err != nil {
+		return err
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// PostMFAPassKeyBeginLogin
+//
+//	@Summary		Begin passkey multi-factor authentication
+//	@Description	Returns the assertion options to sign, the signed credential is sent to /mfa with type passkey
+//	@ID				mfa-passkey-begin-login
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	protocol.PublicKeyCredentialRequestOptions
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/mfa/passkey/begin-login [post]
+//
+//	@Security		Authorization
+func PostMFAPassKeyBeginLogin(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	tenent := middleware.GetTenent(c)
+	mfas, err := repository.GetEnabledMFAs(user.Id, tenent.Id)
+	if err != nil {
+		slog.Error("failed to find MFA", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !mfaTypeEnabled(mfas, repository.MFATypePassKey) {
+		return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
+	}
+	webauthn, err := service.WebAuthnFromTenent(tenent)
+	if err != nil {
+		slog.Error("failed to get webauthn", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	passkeys, err := repository.GetUserPassKeys(user.Id)
+	if err != nil {
+		slog.Error("failed to get user passkeys", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	options, session, err := webauthn.BeginLogin(service.NewWebAuthnUser(*user, passkeys))
+	if err != nil {
+		slog.Error("failed to begin login", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	service.WebAuthnSessions.Set(user.Id, session, time.Now().UTC().Add(time.Minute))
+
+	c.Status(http.StatusOK)
+	return c.JSON(options.Response)
+}
+
+func validateMFAPassKey(user *repository.UserRowST, tenent *repository.TenentRowST, credential []byte) error {
+	if len(credential) == 0 {
+		return model.NewError(http.StatusBadRequest).AddError("credential", "required")
+	}
+	session, ok := service.WebAuthnSessions.Get(user.Id)
+	service.WebAuthnSessions.Delete(user.Id)
+	if !ok {
+		return model.NewError(http.StatusNotFound).AddError("notFound", "session")
+	}
+	data, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(credential))
+	if err != nil {
+		slog.Error("failed to parse passkey credential", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("credential", "invalid")
+	}
+	webauthn, err := service.WebAuthnFromTenent(tenent)
+	if err != nil {
+		slog.Error("failed to get webauthn", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	passkeys, err := repository.GetUserPassKeys(user.Id)
+	if err != nil {
+		slog.Error("failed to get user passkeys", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	validated, err := webauthn.ValidateLogin(service.NewWebAuthnUser(*user, passkeys), *session, data)
+	if err != nil {
+		slog.Error("failed to validate passkey", "error", err)
+		return model.NewError(http.StatusForbidden).AddError("mfa", "invalid")
+	}
+	if _, err := repository.UpsertUserPassKey(service.WebAuthnCredentialsToUpsert(user.ApplicationId, user.Id, *validated)); err != nil {
+		slog.Error("failed to upsert user passkey", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return nil
+}
+
+func mfaTypeEnabled(mfas []repository.MFARowST, mfaType string) bool {
+	for _, mfa := range mfas {
+		if mfa.Type == mfaType {
+			return true
+		}
+	}
+	return false
+}
+
+func sendMFACode(user *repository.UserRowST, tenent *repository.TenentRowST, mfaType string) error {
+	mfaConfig := config.Get().MFA
+	stats, err := repository.GetMFACodeSendStats(user.Id)
+	if err != nil {
+		slog.Error("failed to get MFA code stats", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if stats.LastSentAt != nil && time.Since(*stats.LastSentAt) < time.Duration(mfaConfig.CodeResendSeconds)*time.Second {
+		return model.NewError(http.StatusTooManyRequests).AddError("mfa", "rateLimited")
+	}
+	if stats.SentInLastHour >= mfaConfig.CodeMaxSendsPerHour {
+		return model.NewError(http.StatusTooManyRequests).AddError("mfa", "rateLimited")
+	}
+	data := map[string]interface{}{
+		"expires_in_seconds": mfaConfig.CodeExpiresInSeconds,
+	}
+	switch mfaType {
+	case repository.MFATypeEmail:
+		email, err := repository.GetUserPrimaryEmail(user.Id)
+		if err != nil {
+			slog.Error("failed to get primary email", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		if email == nil || !email.Confirmed {
+			return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
+		}
+		code, err := repository.CreateMFACode(user.Id, mfaType, mfaConfig.CodeExpiresInSeconds)
+		if err != nil {
+			slog.Error("failed to create MFA code", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		data["code"] = code
+		err = service.SendEmail(tenent, email.Email, service.MessageKindMFACode, data)
+		if err != nil {
+			slog.Error("failed to send MFA code email", "error", err)
+			deleteUndeliveredMFACode(user.Id, code)
+			return messageError("mfa", err)
+		}
+	case repository.MFATypeSMS:
+		phoneNumber, err := repository.GetUserPrimaryPhoneNumber(user.Id)
+		if err != nil {
+			slog.Error("failed to get primary phone number", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		if phoneNumber == nil || !phoneNumber.Confirmed {
+			return model.NewError(http.StatusForbidden).AddError("mfa", "disabled")
+		}
+		code, err := repository.CreateMFACode(user.Id, mfaType, mfaConfig.CodeExpiresInSeconds)
+		if err != nil {
+			slog.Error("failed to create MFA code", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		data["code"] = code
+		err = service.SendSMS(tenent, phoneNumber.PhoneNumber, service.MessageKindMFACode, data)
+		if err != nil {
+			slog.Error("failed to send MFA code text", "error", err)
+			deleteUndeliveredMFACode(user.Id, code)
+			return messageError("mfa", err)
+		}
+	default:
+		return model.NewError(http.StatusBadRequest).AddError("mfa", "invalid")
+	}
+	return nil
+}
+
+func deleteUndeliveredMFACode(userId int32, code string) {
+	if _, err := repository.DeleteMFACode(userId, code); err != nil {
+		slog.Error("failed to delete undelivered MFA code", "error", err)
+	}
+}
+
+// messageError is the error for a message that couldn't be delivered, tenents without an endpoint for the channel
+// can't send the message at all
+func messageError(name string, err error) error {
+	if errors.Is(err, service.ErrNoMessageEndpoint) {
+		return model.NewError(http.StatusServiceUnavailable).AddError(name, "unavailable")
+	}
+	return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+}
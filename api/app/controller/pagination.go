@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+// pageFromQuery returns the page a list request asked for, limits default to and are capped by the pagination config
+func pageFromQuery(query *model.PaginationQueryST) (repository.PageST, error) {
+	errors := model.NewError(http.StatusBadRequest)
+	page := repository.PageST{
+		Limit:  max(config.Get().Pagination.DefaultLimit, 1),
+		Cursor: query.Cursor,
+	}
+	if query.Limit != nil {
+		if *query.Limit < 1 {
+			errors.AddError("limit", "invalid")
+		}
+		page.Limit = min(*query.Limit, max(config.Get().Pagination.MaxLimit, 1))
+	}
+	if query.Offset != nil {
+		if *query.Offset < 0 {
+			errors.AddError("offset", "invalid")
+		}
+		page.Offset = *query.Offset
+	}
+	if query.Total != nil && *query.Total != "" {
+		switch *query.Total {
+		case repository.TotalExact, repository.TotalEstimated:
+			page.Total = *query.Total
+		default:
+			errors.AddError("total", "invalid")
+		}
+	}
+	if errors.HasErrors() {
+		return page, errors
+	}
+	return page, nil
+}
+
+// paginationError returns the response for an error from a paginated repository query
+func paginationError(err error, message string) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return model.NewError(http.StatusBadRequest).AddError("cursor", "invalid")
+	}
+	slog.Error(message, "error", err)
+	return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+}
@@ -36,12 +36,15 @@ func PostRequestPasswordReset(c *fiber.Ctx) error {
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
 	email := strings.TrimSpace(requestPasswordReset.Email)
-	phoneNumber := util.NumericRegex.ReplaceAllString(requestPasswordReset.PhoneNumber, "")
+	phoneNumber, err := util.NormalizePhoneNumber(requestPasswordReset.PhoneNumber, tenentPhoneRegion(middleware.GetTenent(c)))
+	if err != nil {
+		phoneNumber = ""
+	}
 	application := middleware.GetApplication(c)
 	var user *repository.UserRowST
 	if email != "" {
 		var err error
-		user, err = repository.GetUserByEmail(application.Id, email)
+		user, err = repository.GetUserByEmail(application.Id, email, middleware.GetTenent(c).FoldEmailAliases)
 		if err != nil {
 			slog.Error("error fetching user by email", "error", err)
 			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
@@ -109,9 +112,6 @@ func PostPasswordReset(c *fiber.Ctx) error {
 	if password != passwordConfirmation {
 		errors.AddError("passwordConfirmation", "mismatch")
 	}
-	if errors.HasErrors() {
-		return errors
-	}
 	tenent := middleware.GetTenent(c)
 	claims, err := jwt.ParseClaimsFromToken[jwt.Claims](passwordReset.Token, tenent)
 	if err != nil {
@@ -123,7 +123,22 @@ func PostPasswordReset(c *fiber.Ctx) error {
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
 	application := middleware.GetApplication(c)
-	user, err := repository.UpdateUserPassword(application.Id, claims.Subject, passwordReset.Password)
+	user, err := repository.GetUserById(application.Id, claims.Subject)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	if err := validatePassword(errors, application.Id, tenent.Id, &user.Id, user.Username, password); err != nil {
+		slog.Error("failed to validate password", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	user, err = repository.UpdateUserPassword(application.Id, claims.Subject, password)
 	if err != nil {
 		slog.Error("error setting user reset password token", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
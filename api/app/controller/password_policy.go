@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetApplicationPasswordPolicy
+//
+//	@Summary		Get application password policy
+//	@Description	Returns the default policy when the application has none
+//	@ID				application-password-policy
+//	@Tags			password-policy
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Success		200	{object}   	model.PasswordPolicyST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/password-policy [get]
+//
+//	@Security		Authorization
+func GetApplicationPasswordPolicy(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "applications", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	policy, err := repository.GetApplicationPasswordPolicy(int32(applicationId))
+	if err != nil {
+		slog.Error("failed to get password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if policy == nil {
+		defaultPolicy := repository.DefaultPasswordPolicy(int32(applicationId))
+		policy = &defaultPolicy
+	}
+	return c.JSON(model.PasswordPolicyFromRow(*policy))
+}
+
+// PatchApplicationPasswordPolicy
+//
+//	@Summary		Update application password policy
+//	@ID				update-application-password-policy
+//	@Tags			password-policy
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			updatePasswordPolicy	body    model.UpdatePasswordPolicyST	true	"update password policy"
+//	@Success		200	{object}   	model.PasswordPolicyST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/password-policy [patch]
+//
+//	@Security		Authorization
+func PatchApplicationPasswordPolicy(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "applications", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var updatePasswordPolicy model.UpdatePasswordPolicyST
+	if err := c.BodyParser(&updatePasswordPolicy); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	current, err := repository.GetApplicationPasswordPolicy(int32(applicationId))
+	if err != nil {
+		slog.Error("failed to get password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	policy := repository.DefaultPasswordPolicy(int32(applicationId))
+	if current != nil {
+		policy = *current
+	}
+	return upsertPasswordPolicy(c, updatePasswordPolicy.Apply(policy))
+}
+
+// GetTenentPasswordPolicy
+//
+//	@Summary		Get the password policy used by a tenent
+//	@Description	Falls back to the application's policy and then the default policy
+//	@ID				tenent-password-policy
+//	@Tags			password-policy
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Success		200	{object}   	model.PasswordPolicyST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/password-policy [get]
+//
+//	@Security		Authorization
+func GetTenentPasswordPolicy(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	policy, err := repository.GetPasswordPolicy(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to get password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.PasswordPolicyFromRow(policy))
+}
+
+// PatchTenentPasswordPolicy
+//
+//	@Summary		Update a tenent's password policy
+//	@Description	Creates a tenent specific policy from the policy it currently uses when it has none
+//	@ID				update-tenent-password-policy
+//	@Tags			password-policy
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Param			updatePasswordPolicy	body    model.UpdatePasswordPolicyST	true	"update password policy"
+//	@Success		200	{object}   	model.PasswordPolicyST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/password-policy [patch]
+//
+//	@Security		Authorization
+func PatchTenentPasswordPolicy(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	var updatePasswordPolicy model.UpdatePasswordPolicyST
+	if err := c.BodyParser(&updatePasswordPolicy); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	tenent, err := repository.GetTenentById(int32(id))
+	if err != nil {
+		slog.Error("failed to find tenent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil || tenent.ApplicationId != int32(applicationId) {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	policy, err := repository.GetPasswordPolicy(tenent.ApplicationId, tenent.Id)
+	if err != nil {
+		slog.Error("failed to get password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	policy.TenentId = &tenent.Id
+	return upsertPasswordPolicy(c, updatePasswordPolicy.Apply(policy))
+}
+
+// DeleteTenentPasswordPolicy
+//
+//	@Summary		Delete a tenent's password policy so it uses the application's policy
+//	@ID				delete-tenent-password-policy
+//	@Tags			password-policy
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/password-policy [delete]
+//
+//	@Security		Authorization
+func DeleteTenentPasswordPolicy(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	deleted, err := repository.DeleteTenentPasswordPolicy(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to delete password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+func upsertPasswordPolicy(c *fiber.Ctx, policy repository.PasswordPolicyRowST) error {
+	errors := model.NewError(http.StatusBadRequest)
+	if policy.MinLength < 0 {
+		errors.AddError("min_length", "invalid")
+	}
+	if policy.MaxLength < 0 || (policy.MaxLength > 0 && policy.MaxLength < policy.MinLength) {
+		errors.AddError("max_length", "invalid")
+	}
+	if policy.HistoryCount < 0 || policy.HistoryCount > repository.PasswordHistoryMax {
+		errors.AddError("history_count", "invalid", repository.PasswordHistoryMax)
+	}
+	if policy.CheckBreached && config.Get().Password.BreachedPath == "" {
+		errors.AddError("check_breached", "unavailable")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	updated, err := repository.UpsertPasswordPolicy(policy)
+	if err != nil {
+		slog.Error("failed to update password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.PasswordPolicyFromRow(updated))
+}
+
+// validatePassword adds any policy violations to errors under "password",
+// userId is nil when the user does not exist yet
+func validatePassword(errors *model.ErrorST, applicationId, tenentId int32, userId *int32, username, password string) error {
+	policy, err := repository.GetPasswordPolicy(applicationId, tenentId)
+	if err != nil {
+		return err
+	}
+	var previousEncryptedPasswords []string
+	if userId != nil && policy.HistoryCount > 0 {
+		previousEncryptedPasswords, err = repository.GetRecentEncryptedPasswords(*userId, min(policy.HistoryCount, repository.PasswordHistoryMax))
+		if err != nil {
+			return err
+		}
+	}
+	violations, err := service.ValidatePassword(policy, username, password, previousEncryptedPasswords, config.Get().Password.BreachedPath)
+	if err != nil {
+		return err
+	}
+	for _, violation := range violations {
+		errors.AddError("password", violation.Message, violation.Parameters...)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PostRequestPasswordless
+//
+//	@Summary		Request a passwordless login
+//	@Description	Sends a one-time code and a login link to the email, always responds with 204 whether or not the email is known
+//	@ID				request-passwordless
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Param			requestPasswordless	body	model.RequestPasswordlessST	true	"request passwordless body"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Failure		503	{object}	model.ErrorST
+//	@Router			/passwordless [post]
+//
+//	@Security		TenentId
+func PostRequestPasswordless(c *fiber.Ctx) error {
+	tenent := middleware.GetTenent(c)
+	if !tenent.PasswordlessEnabled {
+		return model.NewError(http.StatusForbidden).AddError("passwordless", "disabled")
+	}
+	if !service.CanSendEmail(tenent) {
+		return model.NewError(http.StatusServiceUnavailable).AddError("passwordless", "unavailable")
+	}
+	var requestPasswordless model.RequestPasswordlessST
+	if err := c.BodyParser(&requestPasswordless); err != nil {
+		slog.Error("invalid request body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	email := strings.ToLower(strings.TrimSpace(requestPasswordless.Email))
+	if email == "" || !strings.Contains(email, "@") {
+		return model.NewError(http.StatusBadRequest).AddError("email", "invalid")
+	}
+	// the lookup and delivery happen in the background so the response
+	// and its timing do not reveal whether the email belongs to a user
+	go sendPasswordlessCode(middleware.GetApplication(c), tenent, email)
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+func sendPasswordlessCode(application *repository.ApplicationRowST, tenent *repository.TenentRowST, email string) {
+	if !tenent.PasswordlessRegistration {
+		user, err := getPasswordlessUser(application, tenent, email)
+		if err != nil {
+			slog.Error("failed to get user by email", "error", err)
+			return
+		}
+		if user == nil {
+			slog.Info("passwordless login requested for unknown email", "tenent_id", tenent.Id)
+			return
+		}
+	}
+	passwordlessConfig := config.Get().Passwordless
+	stats, err := repository.GetPasswordlessCodeSendStats(application.Id, email)
+	if err != nil {
+		slog.Error("failed to get passwordless code stats", "error", err)
+		return
+	}
+	if stats.LastSentAt != nil && time.Since(*stats.LastSentAt) < time.Duration(passwordlessConfig.CodeResendSeconds)*time.Second {
+		slog.Info("passwordless code rate limited", "tenent_id", tenent.Id)
+		return
+	}
+	if stats.SentInLastHour >= passwordlessConfig.CodeMaxSendsPerHour {
+		slog.Info("passwordless code rate limited", "tenent_id", tenent.Id)
+		return
+	}
+	code, err := repository.CreatePasswordlessCode(application.Id, email, passwordlessConfig.CodeExpiresInSeconds)
+	if err != nil {
+		slog.Error("failed to create passwordless code", "error", err)
+		return
+	}
+	data := map[string]interface{}{
+		"expires_in_seconds": passwordlessConfig.CodeExpiresInSeconds,
+		"code":               code.Code,
+		"token":              code.Token,
+	}
+	if link, err := url.Parse(tenent.AuthorizationWebsite); err == nil {
+		query := link.Query()
+		query.Set("passwordless_email", email)
+		query.Set("passwordless_token", code.Token)
+		link.RawQuery = query.Encode()
+		data["link"] = link.String()
+	}
+	if err := service.SendEmail(tenent, email, service.MessageKindPasswordlessLogin, data); err != nil {
+		slog.Error("failed to send passwordless email", "error", err)
+	}
+}
+
+func passwordlessToken(c *fiber.Ctx, tokenRequest model.TokenRequestST) error {
+	tenent := middleware.GetTenent(c)
+	if !tenent.PasswordlessEnabled {
+		return model.NewError(http.StatusBadRequest).AddError("grant_type", "invalid")
+	}
+	application := middleware.GetApplication(c)
+	email := strings.ToLower(strings.TrimSpace(tokenRequest.Email))
+	code := strings.TrimSpace(tokenRequest.Code)
+	if email == "" || code == "" {
+		return model.NewError(http.StatusUnauthorized).AddError("email", "invalid").AddError("code", "invalid")
+	}
+	result, err := repository.UsePasswordlessCode(application.Id, email, code, config.Get().Passwordless.CodeMaxAttempts)
+	if err != nil {
+		slog.Error("failed to use passwordless code", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if result != repository.MFACodeValid {
+		// expired, exhausted and wrong codes look the same so unknown emails cannot be told apart
+		slog.Error("invalid passwordless code", "result", result)
+		return model.NewError(http.StatusUnauthorized).AddError("email", "invalid").AddError("code", "invalid")
+	}
+	user, err := getPasswordlessUser(application, tenent, email)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		if !tenent.PasswordlessRegistration {
+			return model.NewError(http.StatusUnauthorized).AddError("email", "invalid").AddError("code", "invalid")
+		}
+		created, err := repository.CreateUserFromEmail(application.Id, email)
+		if repository.IsDuplicateKeyError(err) {
+			// the email is an unconfirmed email of another user, it can't sign in until they confirm it, confirming it
+			// here would let whoever registered the account first keep a way into it
+			return model.NewError(http.StatusConflict).AddError("email", "taken")
+		}
+		if err != nil {
+			slog.Error("failed to create user from email", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		user = &created.User
+	}
+	mfas, err := repository.GetEnabledMFAs(user.Id, tenent.Id)
+	if err != nil {
+		slog.Error("failed to get mfa", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return sendToken(c, sendTokenST{
+		mfas:            mfas,
+		issuedTokenType: tokenRequest.GrantType,
+		scope:           tokenRequest.Scope,
+		application:     application,
+		tenent:          tenent,
+		user:            user,
+	})
+}
+
+// getPasswordlessUser finds the user owning a confirmed email matching email, unconfirmed emails never sign in
+func getPasswordlessUser(application *repository.ApplicationRowST, tenent *repository.TenentRowST, email string) (*repository.UserRowST, error) {
+	return repository.GetUserByConfirmedEmail(application.Id, email, tenent.FoldEmailAliases)
+}
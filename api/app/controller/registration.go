@@ -1,19 +1,52 @@
 package controller
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/aicacia/auth/api/app/access"
 	"github.com/aicacia/auth/api/app/middleware"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
 	"github.com/gofiber/fiber/v2"
 )
 
+// GetRegistrationSchema
+//
+//	@Summary		Get what registering with the tenent requires
+//	@ID				registration-schema
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	model.RegistrationSchemaST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/registration [get]
+//
+//	@Security		TenentId
+func GetRegistrationSchema(c *fiber.Ctx) error {
+	tenent := middleware.GetTenent(c)
+	if tenent.RegistrationWebsite == nil {
+		return model.NewError(http.StatusForbidden).AddError("signup", "disabled", "application")
+	}
+	schema, err := repository.GetRegistrationSchema(tenent.ApplicationId, tenent.Id)
+	if err != nil {
+		slog.Error("failed to get registration schema", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.RegistrationSchemaFromRow(schema))
+}
+
 // PostRegistration
 //
 //	@Summary		Registration as a new user
+//	@Description	The tenent's registration schema decides which of email, phone number and info are required, which email domains are allowed and whether an invite code is needed. The user, its email, phone number and info are created together or not at all
 //	@ID				register-user
 //	@Tags			registration
 //	@Accept			json
@@ -22,9 +55,10 @@ import (
 //	@Success		201	{object}	model.TokenST
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
 //	@Failure		404	{object}	model.ErrorST
 //	@Failure		500	{object}	model.ErrorST
-//	@Router			/register [post]
+//	@Router			/registration [post]
 //
 //	@Security		TenentId
 func PostRegistration(c *fiber.Ctx) error {
@@ -32,6 +66,15 @@ func PostRegistration(c *fiber.Ctx) error {
 	if tenent.RegistrationWebsite == nil {
 		return model.NewError(http.StatusForbidden).AddError("signup", "disabled", "application")
 	}
+	application := middleware.GetApplication(c)
+	schema, err := repository.GetRegistrationSchema(application.Id, tenent.Id)
+	if err != nil {
+		slog.Error("failed to get registration schema", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if schema.Mode == repository.RegistrationModeClosed {
+		return model.NewError(http.StatusForbidden).AddError("signup", "disabled", "application")
+	}
 	var registrationRequest model.RegistrationRequestST
 	if err := c.BodyParser(&registrationRequest); err != nil {
 		slog.Error("failed to parse body", "error", err)
@@ -47,19 +90,74 @@ func PostRegistration(c *fiber.Ctx) error {
 	if password != passwordConfirmation {
 		errors.AddError("password_confirmation", "mismatch")
 	}
+	register := repository.RegisterUserST{
+		TenentId: tenent.Id,
+		Username: username,
+		Password: password,
+	}
+	if !isBlank(registrationRequest.Email) {
+		email := strings.TrimSpace(*registrationRequest.Email)
+		validateRegistrationEmail(errors, &schema, email)
+		register.Email = &email
+	} else if schema.RequireEmail {
+		errors.AddError("email", "required")
+	}
+	if !isBlank(registrationRequest.PhoneNumber) {
+		phoneNumber, err := util.NormalizePhoneNumber(*registrationRequest.PhoneNumber, tenentPhoneRegion(tenent))
+		if err != nil {
+			errors.AddError("phone_number", "invalid")
+		}
+		register.PhoneNumber = &phoneNumber
+	} else if schema.RequirePhoneNumber {
+		errors.AddError("phone_number", "required")
+	}
+	if registrationRequest.Info != nil {
+		register.Info = registrationRequest.Info.ToUpdateUserInfo()
+	}
+	for _, field := range schema.RequiredInfo {
+		if !register.Info.IsSet(field) {
+			errors.AddError(field, "required")
+		}
+	}
+	if schema.Mode == repository.RegistrationModeInviteCode {
+		if isBlank(registrationRequest.InviteCode) {
+			errors.AddError("invite_code", "required")
+		} else {
+			inviteCode := strings.TrimSpace(*registrationRequest.InviteCode)
+			register.InviteCode = &inviteCode
+		}
+	}
+	if err := validatePassword(errors, application.Id, tenent.Id, nil, username, password); err != nil {
+		slog.Error("failed to validate password", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
 	if errors.HasErrors() {
 		return errors
 	}
-	application := middleware.GetApplication(c)
-	createResult, err := repository.CreateUserWithPassword(application.Id, registrationRequest.Username, password)
-	if err != nil {
-		slog.Error("failed to create user", "error", err)
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	if register.Email != nil {
+		taken, err := isEmailAliasTaken(tenent, application.Id, *register.Email)
+		if err != nil {
+			slog.Error("failed to check email aliases", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		if taken {
+			return model.NewError(http.StatusBadRequest).AddError("email", "duplicate")
+		}
 	}
-	_, err = repository.AddUserToApplication(application.Id, createResult.User.Id)
+	createResult, err := repository.RegisterUser(application.Id, register)
 	if err != nil {
-		slog.Error("failed to add user to application", "error", err)
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		return registrationError(err)
+	}
+	// the user is registered either way, codes that failed to send can be resent
+	if createResult.User.EmailId != nil {
+		if err := sendConfirmationCode(tenent, &createResult.User, repository.ConfirmationTargetEmail, *createResult.User.EmailId, *register.Email); err != nil {
+			slog.Error("failed to send email confirmation", "error", err)
+		}
+	}
+	if createResult.User.PhoneNumberId != nil {
+		if err := sendConfirmationCode(tenent, &createResult.User, repository.ConfirmationTargetPhoneNumber, *createResult.User.PhoneNumberId, *register.PhoneNumber); err != nil {
+			slog.Error("failed to send phone_number confirmation", "error", err)
+		}
 	}
 	return sendToken(c, sendTokenST{
 		issuedTokenType: model.PasswordGrantType,
@@ -69,3 +167,365 @@ func PostRegistration(c *fiber.Ctx) error {
 		user:            &createResult.User,
 	})
 }
+
+func validateRegistrationEmail(errors *model.ErrorST, schema *repository.RegistrationSchemaRowST, email string) {
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		errors.AddError("email", "invalid")
+		return
+	}
+	domain := strings.ToLower(email[at+1:])
+	if len(schema.AllowedEmailDomains) > 0 && !emailDomainMatches(domain, schema.AllowedEmailDomains) {
+		errors.AddError("email", "domain")
+	} else if emailDomainMatches(domain, schema.BlockedEmailDomains) {
+		errors.AddError("email", "domain")
+	}
+}
+
+// emailDomainMatches reports whether domain is one of domains or a subdomain of one
+func emailDomainMatches(domain string, domains []string) bool {
+	for _, d := range domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func registrationError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrInvalidInviteCode):
+		return model.NewError(http.StatusBadRequest).AddError("invite_code", "invalid")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_username_unique_idx"):
+		return model.NewError(http.StatusBadRequest).AddError("username", "duplicate")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "emails_email_unique_idx"):
+		return model.NewError(http.StatusBadRequest).AddError("email", "duplicate")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "phone_numbers_email_unique_idx"):
+		return model.NewError(http.StatusBadRequest).AddError("phone_number", "duplicate")
+	}
+	slog.Error("failed to register user", "error", err)
+	return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+}
+
+// GetTenentRegistrationSchema
+//
+//	@Summary		Get a tenent's registration schema
+//	@Description	Returns the default schema when the tenent has none
+//	@ID				tenent-registration-schema
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Success		200	{object}   	model.RegistrationSchemaST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/registration-schema [get]
+//
+//	@Security		Authorization
+func GetTenentRegistrationSchema(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	schema, err := repository.GetRegistrationSchema(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to get registration schema", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.RegistrationSchemaFromRow(schema))
+}
+
+// PatchTenentRegistrationSchema
+//
+//	@Summary		Update a tenent's registration schema
+//	@Description	Creates the tenent's schema from the default schema when it has none. required_info lists user info fields, address fields are named street_address, locality, region, postal_code and country
+//	@ID				update-tenent-registration-schema
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Param			updateRegistrationSchema	body    model.UpdateRegistrationSchemaST	true	"update registration schema"
+//	@Success		200	{object}   	model.RegistrationSchemaST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/registration-schema [patch]
+//
+//	@Security		Authorization
+func PatchTenentRegistrationSchema(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	var updateRegistrationSchema model.UpdateRegistrationSchemaST
+	if err := c.BodyParser(&updateRegistrationSchema); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	tenent, err := repository.GetTenentById(int32(id))
+	if err != nil {
+		slog.Error("failed to find tenent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil || tenent.ApplicationId != int32(applicationId) {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	schema, err := repository.GetRegistrationSchema(tenent.ApplicationId, tenent.Id)
+	if err != nil {
+		slog.Error("failed to get registration schema", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	schema = updateRegistrationSchema.Apply(schema)
+	errors := model.NewError(http.StatusBadRequest)
+	if !slices.Contains(repository.RegistrationModes, schema.Mode) {
+		errors.AddError("mode", "invalid")
+	}
+	for _, field := range schema.RequiredInfo {
+		if !slices.Contains(repository.UserInfoFields, field) {
+			errors.AddError("required_info", "invalid", field)
+		}
+	}
+	schema.AllowedEmailDomains = normalizeEmailDomains(errors, "allowed_email_domains", schema.AllowedEmailDomains)
+	schema.BlockedEmailDomains = normalizeEmailDomains(errors, "blocked_email_domains", schema.BlockedEmailDomains)
+	if errors.HasErrors() {
+		return errors
+	}
+	updated, err := repository.UpsertRegistrationSchema(schema)
+	if err != nil {
+		slog.Error("failed to update registration schema", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.RegistrationSchemaFromRow(updated))
+}
+
+func normalizeEmailDomains(errors *model.ErrorST, name string, domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+		if domain == "" || strings.ContainsAny(domain, "@ ") {
+			errors.AddError(name, "invalid", domain)
+			continue
+		}
+		normalized = append(normalized, domain)
+	}
+	return normalized
+}
+
+// DeleteTenentRegistrationSchema
+//
+//	@Summary		Delete a tenent's registration schema so it uses the default schema
+//	@ID				delete-tenent-registration-schema
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/registration-schema [delete]
+//
+//	@Security		Authorization
+func DeleteTenentRegistrationSchema(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	deleted, err := repository.DeleteRegistrationSchema(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to delete registration schema", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+// GetRegistrationInviteCodes
+//
+//	@Summary		Get a tenent's registration invite codes
+//	@ID				registration-invite-codes
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Success		200	{array}   	model.RegistrationInviteCodeST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/registration-invite-codes [get]
+//
+//	@Security		Authorization
+func GetRegistrationInviteCodes(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	inviteCodes, err := repository.GetRegistrationInviteCodes(int32(applicationId), int32(id))
+	if err != nil {
+		slog.Error("failed to get registration invite codes", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(inviteCodes, model.RegistrationInviteCodeFromRow))
+}
+
+// PostCreateRegistrationInviteCode
+//
+//	@Summary		Create a registration invite code
+//	@Description	Users register with the returned code while the tenent's registration mode is invite_code, it is only returned once
+//	@ID				create-registration-invite-code
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Param			inviteCode	body		model.CreateRegistrationInviteCodeST	true	"create registration invite code"
+//	@Success		201	{object}	model.CreatedRegistrationInviteCodeST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/registration-invite-codes [post]
+//
+//	@Security		Authorization
+func PostCreateRegistrationInviteCode(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	var createInviteCode model.CreateRegistrationInviteCodeST
+	if err := c.BodyParser(&createInviteCode); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	create := createInviteCode.CreateRegistrationInviteCodeST
+	create.Description = strings.TrimSpace(create.Description)
+	errors := model.NewError(http.StatusBadRequest)
+	if create.Description == "" {
+		errors.AddError("description", "required")
+	}
+	if create.MaxUses != nil && *create.MaxUses <= 0 {
+		errors.AddError("max_uses", "invalid")
+	}
+	if create.ExpiresInSeconds != nil && *create.ExpiresInSeconds <= 0 {
+		errors.AddError("expires_in_seconds", "invalid")
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	tenent, err := repository.GetTenentById(int32(id))
+	if err != nil {
+		slog.Error("failed to find tenent", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil || tenent.ApplicationId != int32(applicationId) {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	row, code, err := repository.CreateRegistrationInviteCode(tenent.ApplicationId, tenent.Id, create)
+	if err != nil {
+		slog.Error("failed to create registration invite code", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Status(http.StatusCreated)
+	return c.JSON(model.CreatedRegistrationInviteCodeST{
+		RegistrationInviteCodeST: model.RegistrationInviteCodeFromRow(row),
+		Code:                     code,
+	})
+}
+
+// DeleteRegistrationInviteCode
+//
+//	@Summary		Delete a registration invite code
+//	@Description	Users already registered with it are kept
+//	@ID				delete-registration-invite-code
+//	@Tags			registration
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"tenent id"
+//	@Param			inviteCodeId	path		int	true	"registration invite code id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{id}/registration-invite-codes/{inviteCodeId} [delete]
+//
+//	@Security		Authorization
+func DeleteRegistrationInviteCode(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	inviteCodeId, err := strconv.Atoi(c.Params("inviteCodeId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("inviteCodeId", "invalid")
+	}
+	deleted, err := repository.DeleteRegistrationInviteCode(int32(applicationId), int32(id), int32(inviteCodeId))
+	if err != nil {
+		slog.Error("failed to delete registration invite code", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("inviteCodeId", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
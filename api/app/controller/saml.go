@@ -0,0 +1,510 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/google/uuid"
+)
+
+// GetSAMLMetadata
+//
+//	@Summary		SAML identity provider metadata
+//	@Description	Assertions are signed with the tenent's private key, tenents using an HS algorithm can not be SAML identity providers
+//	@ID				saml-metadata
+//	@Tags			saml
+//	@Produce		xml
+//	@Param			tenentClientId	path		string	true	"tenent client id"
+//	@Success		200	{string}	string
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/saml/{tenentClientId}/metadata [get]
+func GetSAMLMetadata(c *fiber.Ctx) error {
+	tenent, err := getSAMLTenent(c)
+	if err != nil {
+		return err
+	}
+	idp, err := getSAMLIdentityProvider(tenent)
+	if err != nil {
+		return err
+	}
+	metadata, err := idp.MetadataXML()
+	if err != nil {
+		slog.Error("failed to create saml metadata", "tenentId", tenent.Id, "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Set(fiber.HeaderContentType, "application/samlmetadata+xml")
+	return c.Send(metadata)
+}
+
+// SAMLSingleSignOn
+//
+//	@Summary		SAML single sign on
+//	@Description	Accepts an AuthnRequest with the HTTP-Redirect or HTTP-POST binding from a registered service provider and redirects to the tenent's authorization website with a saml_request, after signing the user in it completes the request with POST /saml/complete
+//	@ID				saml-sso
+//	@Tags			saml
+//	@Accept			x-www-form-urlencoded
+//	@Param			tenentClientId	path		string	true	"tenent client id"
+//	@Param			SAMLRequest		query		string	true	"saml request"
+//	@Param			RelayState		query		string	false	"relay state"
+//	@Success		303
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/saml/{tenentClientId}/sso [get]
+//	@Router			/saml/{tenentClientId}/sso [post]
+func SAMLSingleSignOn(c *fiber.Ctx) error {
+	tenent, err := getSAMLTenent(c)
+	if err != nil {
+		return err
+	}
+	idp, err := getSAMLIdentityProvider(tenent)
+	if err != nil {
+		return err
+	}
+	r, err := adaptor.ConvertRequest(c, false)
+	if err != nil {
+		slog.Error("failed to convert request", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	req, err := idp.ParseAuthnRequest(r)
+	if err != nil {
+		slog.Info("invalid saml request", "tenentId", tenent.Id, "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("SAMLRequest", "invalid")
+	}
+	token, err := repository.CreateSAMLRequest(idp.ServiceProvider().Id, string(req.RequestBuffer), req.RelayState, config.Get().SAML.RequestExpiresInSeconds)
+	if err != nil {
+		slog.Error("failed to create saml request", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return redirectIdentityProviderResult(c, tenent, "saml_request", token)
+}
+
+// PostCompleteSAMLRequest
+//
+//	@Summary		Complete a SAML request for the current user
+//	@Description	Returns the signed response the authorization website posts to the service provider's url as SAMLResponse and RelayState form fields
+//	@ID				saml-complete
+//	@Tags			saml
+//	@Accept			json
+//	@Produce		json
+//	@Param			completeSAMLRequest	body		model.CompleteSAMLRequestST	true	"saml request to complete"
+//	@Success		200	{object}	model.SAMLResponseST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/saml/complete [post]
+//
+//	@Security		Authorization
+func PostCompleteSAMLRequest(c *fiber.Ctx) error {
+	var completeSAMLRequest model.CompleteSAMLRequestST
+	if err := c.BodyParser(&completeSAMLRequest); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	user := middleware.GetUser(c)
+	tenent := middleware.GetTenent(c)
+	request, err := repository.UseSAMLRequest(tenent.Id, strings.TrimSpace(completeSAMLRequest.SAMLRequest))
+	if err != nil {
+		slog.Error("failed to use saml request", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if request == nil {
+		return model.NewError(http.StatusBadRequest).AddError("saml_request", "invalid")
+	}
+	idp, err := getSAMLIdentityProvider(tenent)
+	if err != nil {
+		return err
+	}
+	r, err := adaptor.ConvertRequest(c, false)
+	if err != nil {
+		slog.Error("failed to convert request", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	req, err := idp.RestoreAuthnRequest(r, request)
+	if err != nil {
+		slog.Info("invalid saml request", "tenentId", tenent.Id, "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("saml_request", "invalid")
+	}
+	serviceProvider := idp.ServiceProvider()
+	samlUser, err := getSAMLUser(user)
+	if err != nil {
+		slog.Error("failed to get saml user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if serviceProvider.NameIdFormat == repository.SAMLNameIdFormatEmail && samlUser.Email == nil {
+		return model.NewError(http.StatusBadRequest).AddError("email", "required")
+	}
+	form, err := service.MakeSAMLResponse(req, serviceProvider, samlUser)
+	if err != nil {
+		slog.Error("failed to make saml response", "samlServiceProviderId", serviceProvider.Id, "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	auditLog(c, user, repository.AuditActionSAMLAssertionIssued, map[string]interface{}{
+		"saml_service_provider_id": serviceProvider.Id,
+		"entity_id":                serviceProvider.EntityId,
+	})
+	return c.JSON(model.SAMLResponseST{
+		URL:          form.URL,
+		SAMLResponse: form.SAMLResponse,
+		RelayState:   form.RelayState,
+	})
+}
+
+func getSAMLTenent(c *fiber.Ctx) (*repository.TenentRowST, error) {
+	clientId, err := uuid.Parse(c.Params("tenentClientId"))
+	if err != nil {
+		return nil, model.NewError(http.StatusBadRequest).AddError("tenentClientId", "invalid")
+	}
+	tenent, err := repository.GetTenentByClientId(clientId)
+	if err != nil {
+		slog.Error("failed to find tenent", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if tenent == nil {
+		return nil, model.NewError(http.StatusNotFound).AddError("tenentClientId", "invalid")
+	}
+	return tenent, nil
+}
+
+func getSAMLIdentityProvider(tenent *repository.TenentRowST) (*service.SAMLIdentityProviderST, error) {
+	idp, err := service.GetSAMLIdentityProvider(tenent)
+	if err != nil {
+		if errors.Is(err, service.ErrSAMLSymmetricKey) {
+			return nil, model.NewError(http.StatusBadRequest).AddError("algorithm", "asymmetric")
+		}
+		slog.Error("failed to create saml identity provider", "tenentId", tenent.Id, "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return idp, nil
+}
+
+// getSAMLUser collects the user's confirmed primary email and phone number, info and roles
+func getSAMLUser(user *repository.UserRowST) (service.SAMLUserST, error) {
+	samlUser := service.SAMLUserST{
+		User: user,
+	}
+	email, err := repository.GetUserPrimaryEmail(user.Id)
+	if err != nil {
+		return samlUser, err
+	}
+	if email != nil && email.Confirmed {
+		samlUser.Email = &email.Email
+	}
+	phoneNumber, err := repository.GetUserPrimaryPhoneNumber(user.Id)
+	if err != nil {
+		return samlUser, err
+	}
+	if phoneNumber != nil && phoneNumber.Confirmed {
+		samlUser.PhoneNumber = &phoneNumber.PhoneNumber
+	}
+	samlUser.Info, err = repository.GetUserInfoByUserId(user.Id)
+	if err != nil {
+		return samlUser, err
+	}
+	roles, err := repository.GetUserRoles(user.Id)
+	if err != nil {
+		return samlUser, err
+	}
+	samlUser.Roles = util.Map(roles, func(role repository.RoleRowST) string {
+		return role.URI
+	})
+	return samlUser, nil
+}
+
+// GetSAMLServiceProviders
+//
+//	@Summary		Get tenent SAML service providers
+//	@ID				saml-service-providers
+//	@Tags			saml
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			tenentId		path		int	true	"tenent id"
+//	@Success		200	{array}		model.SAMLServiceProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/saml-service-providers [get]
+//
+//	@Security		Authorization
+func GetSAMLServiceProviders(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	tenent, err := getApplicationTenent(c)
+	if err != nil {
+		return err
+	}
+	serviceProviders, err := repository.GetSAMLServiceProviders(tenent.Id)
+	if err != nil {
+		slog.Error("failed to get saml service providers", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(util.Map(serviceProviders, model.SAMLServiceProviderFromRow))
+}
+
+// GetSAMLServiceProviderById
+//
+//	@Summary		Get tenent SAML service provider by id
+//	@ID				saml-service-provider
+//	@Tags			saml
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			tenentId		path		int	true	"tenent id"
+//	@Param			id				path		int	true	"saml service provider id"
+//	@Success		200	{object}	model.SAMLServiceProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/saml-service-providers/{id} [get]
+//
+//	@Security		Authorization
+func GetSAMLServiceProviderById(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "read"); err != nil {
+		return err
+	}
+	serviceProvider, err := getTenentSAMLServiceProvider(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(model.SAMLServiceProviderFromRow(*serviceProvider))
+}
+
+// PostCreateSAMLServiceProvider
+//
+//	@Summary		Register a tenent SAML service provider
+//	@Description	Service providers need their metadata or an acs url accepting the HTTP-POST binding, attribute_mapping maps SAML attribute names to id, username, email, phone_number, roles or a user info field
+//	@ID				create-saml-service-provider
+//	@Tags			saml
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId		path		int									true	"application id"
+//	@Param			tenentId			path		int									true	"tenent id"
+//	@Param			samlServiceProvider	body		model.CreateSAMLServiceProviderST	true	"create saml service provider"
+//	@Success		201	{object}	model.SAMLServiceProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/saml-service-providers [post]
+//
+//	@Security		Authorization
+func PostCreateSAMLServiceProvider(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	tenent, err := getApplicationTenent(c)
+	if err != nil {
+		return err
+	}
+	var createSAMLServiceProvider model.CreateSAMLServiceProviderST
+	if err := c.BodyParser(&createSAMLServiceProvider); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	create := createSAMLServiceProvider.CreateSAMLServiceProviderST
+	create.Name = strings.TrimSpace(create.Name)
+	create.EntityId = strings.TrimSpace(create.EntityId)
+	errors := model.NewError(http.StatusBadRequest)
+	if create.Name == "" {
+		errors.AddError("name", "required")
+	}
+	if create.EntityId == "" {
+		errors.AddError("entity_id", "required")
+	}
+	validateSAMLServiceProvider(errors, repository.SAMLServiceProviderRowST{
+		EntityId: create.EntityId,
+		Metadata: create.Metadata,
+		ACSURL:   create.ACSURL,
+	}, create.NameIdFormat, create.AttributeMapping)
+	if errors.HasErrors() {
+		return errors
+	}
+	serviceProvider, err := repository.CreateSAMLServiceProvider(tenent.ApplicationId, tenent.Id, create)
+	if err != nil {
+		if repository.IsDuplicateKeyError(err) {
+			return model.NewError(http.StatusBadRequest).AddError("entity_id", "duplicate")
+		}
+		slog.Error("failed to create saml service provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	c.Status(http.StatusCreated)
+	return c.JSON(model.SAMLServiceProviderFromRow(serviceProvider))
+}
+
+// PatchUpdateSAMLServiceProvider
+//
+//	@Summary		Update tenent SAML service provider
+//	@ID				update-saml-service-provider
+//	@Tags			saml
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId		path		int									true	"application id"
+//	@Param			tenentId			path		int									true	"tenent id"
+//	@Param			id					path		int									true	"saml service provider id"
+//	@Param			samlServiceProvider	body		model.UpdateSAMLServiceProviderST	true	"update saml service provider"
+//	@Success		200	{object}	model.SAMLServiceProviderST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/saml-service-providers/{id} [patch]
+//
+//	@Security		Authorization
+func PatchUpdateSAMLServiceProvider(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	current, err := getTenentSAMLServiceProvider(c)
+	if err != nil {
+		return err
+	}
+	var updateSAMLServiceProvider model.UpdateSAMLServiceProviderST
+	if err := c.BodyParser(&updateSAMLServiceProvider); err != nil {
+		slog.Error("failed to parse body", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	update := updateSAMLServiceProvider.UpdateSAMLServiceProviderST
+	errors := model.NewError(http.StatusBadRequest)
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			errors.AddError("name", "required")
+		}
+		update.Name = &name
+	}
+	updated := *current
+	if update.EntityId != nil {
+		entityId := strings.TrimSpace(*update.EntityId)
+		if entityId == "" {
+			errors.AddError("entity_id", "required")
+		}
+		update.EntityId = &entityId
+		updated.EntityId = entityId
+	}
+	if update.Metadata != nil {
+		updated.Metadata = update.Metadata
+	}
+	if update.ACSURL != nil {
+		updated.ACSURL = update.ACSURL
+	}
+	validateSAMLServiceProvider(errors, updated, update.NameIdFormat, update.AttributeMapping)
+	if errors.HasErrors() {
+		return errors
+	}
+	serviceProvider, err := repository.UpdateSAMLServiceProvider(current.Id, update)
+	if err != nil {
+		if repository.IsDuplicateKeyError(err) {
+			return model.NewError(http.StatusBadRequest).AddError("entity_id", "duplicate")
+		}
+		slog.Error("failed to update saml service provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if serviceProvider == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	return c.JSON(model.SAMLServiceProviderFromRow(*serviceProvider))
+}
+
+// DeleteSAMLServiceProvider
+//
+//	@Summary		Delete tenent SAML service provider
+//	@ID				delete-saml-service-provider
+//	@Tags			saml
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			tenentId		path		int	true	"tenent id"
+//	@Param			id				path		int	true	"saml service provider id"
+//	@Success		204
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/tenents/{tenentId}/saml-service-providers/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteSAMLServiceProvider(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "tenents", "write"); err != nil {
+		return err
+	}
+	serviceProvider, err := getTenentSAMLServiceProvider(c)
+	if err != nil {
+		return err
+	}
+	deleted, err := repository.DeleteSAMLServiceProvider(serviceProvider.Id)
+	if err != nil {
+		slog.Error("failed to delete saml service provider", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return c.Send(nil)
+}
+
+func validateSAMLServiceProvider(errors *model.ErrorST, serviceProvider repository.SAMLServiceProviderRowST, nameIdFormat *string, attributeMapping map[string]string) {
+	if isBlank(serviceProvider.Metadata) && isBlank(serviceProvider.ACSURL) {
+		errors.AddError("acs_url", "required")
+	} else if _, err := service.SAMLServiceProviderMetadata(&serviceProvider); err != nil {
+		errors.AddError("metadata", "invalid")
+	}
+	if nameIdFormat != nil {
+		switch *nameIdFormat {
+		case repository.SAMLNameIdFormatPersistent, repository.SAMLNameIdFormatEmail, repository.SAMLNameIdFormatUnspecified, repository.SAMLNameIdFormatTransient:
+		default:
+			errors.AddError("name_id_format", "invalid")
+		}
+	}
+	for name, source := range attributeMapping {
+		if strings.TrimSpace(name) == "" || !slices.Contains(service.SAMLAttributeSources, source) {
+			errors.AddError("attribute_mapping", "invalid")
+			break
+		}
+	}
+}
+
+func getTenentSAMLServiceProvider(c *fiber.Ctx) (*repository.SAMLServiceProviderRowST, error) {
+	tenent, err := getApplicationTenent(c)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	serviceProvider, err := repository.GetSAMLServiceProviderById(int32(id))
+	if err != nil {
+		slog.Error("failed to get saml service provider", "error", err)
+		return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if serviceProvider == nil || serviceProvider.TenentId != tenent.Id {
+		return nil, model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	return serviceProvider, nil
+}
@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/scim"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	scimDefaultCount = 100
+	scimMaxResults   = 200
+)
+
+// GetSCIMServiceProviderConfig
+//
+//	@Summary		SCIM service provider config
+//	@ID				scim-service-provider-config
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Success		200	{object}	model.SCIMServiceProviderConfigST
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/ServiceProviderConfig [get]
+//
+//	@Security		Authorization
+func GetSCIMServiceProviderConfig(c *fiber.Ctx) error {
+	return sendSCIM(c, http.StatusOK, model.SCIMServiceProviderConfigST{
+		Schemas: []string{scim.SchemaServiceProviderConfig},
+		Patch:   model.SCIMSupportedST{Supported: true},
+		Bulk:    model.SCIMBulkSupportedST{Supported: false},
+		Filter: model.SCIMFilterSupportedST{
+			Supported:  true,
+			MaxResults: scimMaxResults,
+		},
+		ChangePassword: model.SCIMSupportedST{Supported: true},
+		Sort:           model.SCIMSupportedST{Supported: false},
+		ETag:           model.SCIMSupportedST{Supported: false},
+		AuthenticationSchemes: []model.SCIMAuthenticationSchemeST{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "OAuth Bearer Token",
+				Description: "Bearer token of a service account from the service-account grant",
+				Primary:     true,
+			},
+		},
+		Meta: model.SCIMMetaST{
+			ResourceType: "ServiceProviderConfig",
+			Location:     scimLocation("ServiceProviderConfig", ""),
+		},
+	})
+}
+
+// GetSCIMResourceTypes
+//
+//	@Summary		SCIM resource types
+//	@ID				scim-resource-types
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Success		200	{object}	model.SCIMListResponseST[model.SCIMResourceTypeST]
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/ResourceTypes [get]
+//
+//	@Security		Authorization
+func GetSCIMResourceTypes(c *fiber.Ctx) error {
+	resourceTypes := scimResourceTypes()
+	return sendSCIM(c, http.StatusOK, model.SCIMListResponseST[model.SCIMResourceTypeST]{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: len(resourceTypes),
+		StartIndex:   1,
+		ItemsPerPage: len(resourceTypes),
+		Resources:    resourceTypes,
+	})
+}
+
+// GetSCIMSchemas
+//
+//	@Summary		SCIM schemas
+//	@ID				scim-schemas
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Success		200	{object}	model.SCIMListResponseST[model.SCIMSchemaST]
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Schemas [get]
+//
+//	@Security		Authorization
+func GetSCIMSchemas(c *fiber.Ctx) error {
+	schemas := scimSchemas()
+	return sendSCIM(c, http.StatusOK, model.SCIMListResponseST[model.SCIMSchemaST]{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: len(schemas),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	})
+}
+
+// GetSCIMSchemaById
+//
+//	@Summary		SCIM schema by id
+//	@ID				scim-schema
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Param			id	path		string	true	"schema urn"
+//	@Success		200	{object}	model.SCIMSchemaST
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Failure		404	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Schemas/{id} [get]
+//
+//	@Security		Authorization
+func GetSCIMSchemaById(c *fiber.Ctx) error {
+	for _, schema := range scimSchemas() {
+		if strings.EqualFold(schema.Id, c.Params("id")) {
+			return sendSCIM(c, http.StatusOK, schema)
+		}
+	}
+	return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+}
+
+func sendSCIM(c *fiber.Ctx, status int, body interface{}) error {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, scim.ContentType)
+	return c.Status(status).Send(bytes)
+}
+
+func scimLocation(resourceType, id string) string {
+	location := strings.TrimSuffix(config.Get().URL, "/") + "/scim/v2/" + resourceType
+	if id != "" {
+		location += "/" + id
+	}
+	return location
+}
+
+// parseSCIMListQuery returns the parsed filter and the 0 based offset and limit of a list request
+func parseSCIMListQuery(c *fiber.Ctx) (*scim.ExpressionST, int, int, error) {
+	var query model.SCIMListQueryST
+	if err := c.QueryParser(&query); err != nil {
+		return nil, 0, 0, scim.NewError(scim.ErrorTypeInvalidValue, "invalid query")
+	}
+	var filter *scim.ExpressionST
+	if query.Filter != nil && strings.TrimSpace(*query.Filter) != "" {
+		expression, err := scim.ParseFilter(*query.Filter)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		filter = expression
+	}
+	offset := 0
+	if query.StartIndex != nil && *query.StartIndex > 1 {
+		offset = *query.StartIndex - 1
+	}
+	limit := scimDefaultCount
+	if query.Count != nil {
+		limit = min(max(*query.Count, 0), scimMaxResults)
+	}
+	return filter, offset, limit, nil
+}
+
+// decodeSCIMResource decodes a request body into a generic resource so both full resources and
+// PATCH results go through the same normalization before being decoded into their model
+func decodeSCIMResource(body []byte) (map[string]interface{}, error) {
+	var resource map[string]interface{}
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return nil, scim.NewError(scim.ErrorTypeInvalidSyntax, "invalid json")
+	}
+	return resource, nil
+}
+
+func scimResourceToModel(resource map[string]interface{}, v interface{}) error {
+	// some clients send booleans as "True" and "False"
+	for key, value := range resource {
+		if strings.EqualFold(key, "active") {
+			if text, ok := value.(string); ok {
+				resource[key] = strings.EqualFold(text, "true")
+			}
+		}
+	}
+	bytes, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bytes, v); err != nil {
+		return scim.NewError(scim.ErrorTypeInvalidValue, "%s", err)
+	}
+	return nil
+}
+
+func scimModelToResource(v interface{}) (map[string]interface{}, error) {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var resource map[string]interface{}
+	if err := json.Unmarshal(bytes, &resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+func applySCIMPatch(c *fiber.Ctx, resource map[string]interface{}) error {
+	var patch model.SCIMPatchST
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		return scim.NewError(scim.ErrorTypeInvalidSyntax, "invalid json")
+	}
+	for _, operation := range patch.Operations {
+		var value interface{}
+		if len(operation.Value) > 0 {
+			if err := json.Unmarshal(operation.Value, &value); err != nil {
+				return scim.NewError(scim.ErrorTypeInvalidValue, "invalid value")
+			}
+		}
+		path := ""
+		if operation.Path != nil {
+			path = *operation.Path
+		}
+		if err := scim.ApplyPatch(resource, operation.Op, path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scimResourceTypes() []model.SCIMResourceTypeST {
+	return []model.SCIMResourceTypeST{
+		{
+			Schemas:     []string{scim.SchemaResourceType},
+			Id:          "User",
+			Name:        "User",
+			Endpoint:    "/Users",
+			Description: "User Account",
+			Schema:      scim.SchemaUser,
+			Meta:        model.SCIMMetaST{ResourceType: "ResourceType", Location: scimLocation("ResourceTypes", "User")},
+		},
+		{
+			Schemas:     []string{scim.SchemaResourceType},
+			Id:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Group, a role of the application",
+			Schema:      scim.SchemaGroup,
+			Meta:        model.SCIMMetaST{ResourceType: "ResourceType", Location: scimLocation("ResourceTypes", "Group")},
+		},
+	}
+}
+
+func scimAttribute(name, typ, mutability string, options ...func(*model.SCIMSchemaAttributeST)) model.SCIMSchemaAttributeST {
+	attribute := model.SCIMSchemaAttributeST{
+		Name:       name,
+		Type:       typ,
+		Mutability: mutability,
+		Returned:   "default",
+		Uniqueness: "none",
+	}
+	for _, option := range options {
+		option(&attribute)
+	}
+	return attribute
+}
+
+func scimRequired(attribute *model.SCIMSchemaAttributeST)      { attribute.Required = true }
+func scimMultiValued(attribute *model.SCIMSchemaAttributeST)   { attribute.MultiValued = true }
+func scimCaseExact(attribute *model.SCIMSchemaAttributeST)     { attribute.CaseExact = true }
+func scimUniqueServer(attribute *model.SCIMSchemaAttributeST)  { attribute.Uniqueness = "server" }
+func scimReturnedNever(attribute *model.SCIMSchemaAttributeST) { attribute.Returned = "never" }
+func scimSubAttributes(subAttributes ...model.SCIMSchemaAttributeST) func(*model.SCIMSchemaAttributeST) {
+	return func(attribute *model.SCIMSchemaAttributeST) { attribute.SubAttributes = subAttributes }
+}
+
+func scimSchemas() []model.SCIMSchemaST {
+	multiValued := func(valueMutability string) func(*model.SCIMSchemaAttributeST) {
+		return scimSubAttributes(
+			scimAttribute("value", "string", valueMutability),
+			scimAttribute("display", "string", "readOnly"),
+			scimAttribute("type", "string", "readWrite"),
+			scimAttribute("primary", "boolean", "readWrite"),
+		)
+	}
+	return []model.SCIMSchemaST{
+		{
+			Schemas:     []string{scim.SchemaSchema},
+			Id:          scim.SchemaUser,
+			Name:        "User",
+			Description: "User Account",
+			Attributes: []model.SCIMSchemaAttributeST{
+				scimAttribute("userName", "string", "readWrite", scimRequired, scimUniqueServer),
+				scimAttribute("externalId", "string", "readWrite", scimCaseExact),
+				scimAttribute("name", "complex", "readWrite", scimSubAttributes(
+					scimAttribute("formatted", "string", "readWrite"),
+					scimAttribute("familyName", "string", "readWrite"),
+					scimAttribute("givenName", "string", "readWrite"),
+					scimAttribute("middleName", "string", "readWrite"),
+				)),
+				scimAttribute("displayName", "string", "readWrite"),
+				scimAttribute("nickName", "string", "readWrite"),
+				scimAttribute("profileUrl", "reference", "readWrite"),
+				scimAttribute("locale", "string", "readWrite"),
+				scimAttribute("timezone", "string", "readWrite"),
+				scimAttribute("active", "boolean", "readWrite"),
+				scimAttribute("password", "string", "writeOnly", scimReturnedNever),
+				scimAttribute("emails", "complex", "readWrite", scimMultiValued, multiValued("readWrite")),
+				scimAttribute("phoneNumbers", "complex", "readWrite", scimMultiValued, multiValued("readWrite")),
+				scimAttribute("photos", "complex", "readWrite", scimMultiValued, multiValued("readWrite")),
+				scimAttribute("addresses", "complex", "readWrite", scimMultiValued, scimSubAttributes(
+					scimAttribute("formatted", "string", "readWrite"),
+					scimAttribute("streetAddress", "string", "readWrite"),
+					scimAttribute("locality", "string", "readWrite"),
+					scimAttribute("region", "string", "readWrite"),
+					scimAttribute("postalCode", "string", "readWrite"),
+					scimAttribute("country", "string", "readWrite"),
+					scimAttribute("type", "string", "readWrite"),
+					scimAttribute("primary", "boolean", "readWrite"),
+				)),
+				scimAttribute("groups", "complex", "readOnly", scimMultiValued, multiValued("readOnly")),
+			},
+			Meta: model.SCIMMetaST{ResourceType: "Schema", Location: scimLocation("Schemas", scim.SchemaUser)},
+		},
+		{
+			Schemas:     []string{scim.SchemaSchema},
+			Id:          scim.SchemaGroup,
+			Name:        "Group",
+			Description: "Group, a role of the application",
+			Attributes: []model.SCIMSchemaAttributeST{
+				scimAttribute("displayName", "string", "readWrite", scimRequired, scimUniqueServer),
+				scimAttribute("externalId", "string", "readWrite", scimCaseExact),
+				scimAttribute("members", "complex", "readWrite", scimMultiValued, multiValued("immutable")),
+			},
+			Meta: model.SCIMMetaST{ResourceType: "Schema", Location: scimLocation("Schemas", scim.SchemaGroup)},
+		},
+	}
+}
@@ -0,0 +1,360 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/scim"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetSCIMGroups
+//
+//	@Summary		List SCIM groups
+//	@ID				scim-groups
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Param			filter				query		string	false	"filter, for example displayName eq \"admin\""
+//	@Param			startIndex			query		int		false	"1 based index of the first result"
+//	@Param			count				query		int		false	"max results"
+//	@Param			excludedAttributes	query		string	false	"members to skip loading members"
+//	@Success		200					{object}	model.SCIMListResponseST[model.SCIMGroupST]
+//	@Failure		400					{object}	model.SCIMErrorST
+//	@Failure		401					{object}	model.SCIMErrorST
+//	@Failure		403					{object}	model.SCIMErrorST
+//	@Failure		500					{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Groups [get]
+//
+//	@Security		Authorization
+func GetSCIMGroups(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "roles", "read"); err != nil {
+		return err
+	}
+	filter, offset, limit, err := parseSCIMListQuery(c)
+	if err != nil {
+		return err
+	}
+	application := middleware.GetApplication(c)
+	roles, total, err := repository.GetSCIMGroups(application.Id, filter, offset, limit)
+	if err != nil {
+		return err
+	}
+	groups, err := scimGroupsFromRows(roles, !scimExcludesMembers(c))
+	if err != nil {
+		return err
+	}
+	return sendSCIM(c, http.StatusOK, model.SCIMListResponseST[model.SCIMGroupST]{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   offset + 1,
+		ItemsPerPage: len(groups),
+		Resources:    groups,
+	})
+}
+
+// GetSCIMGroupById
+//
+//	@Summary		Get SCIM group
+//	@ID				scim-group
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Param			id					path		int		true	"Role id"
+//	@Param			excludedAttributes	query		string	false	"members to skip loading members"
+//	@Success		200					{object}	model.SCIMGroupST
+//	@Failure		401					{object}	model.SCIMErrorST
+//	@Failure		403					{object}	model.SCIMErrorST
+//	@Failure		404					{object}	model.SCIMErrorST
+//	@Failure		500					{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Groups/{id} [get]
+//
+//	@Security		Authorization
+func GetSCIMGroupById(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "roles", "read"); err != nil {
+		return err
+	}
+	group, err := getSCIMGroup(c, !scimExcludesMembers(c))
+	if err != nil {
+		return err
+	}
+	return sendSCIM(c, http.StatusOK, group)
+}
+
+// PostSCIMGroup
+//
+//	@Summary		Create SCIM group
+//	@ID				scim-create-group
+//	@Tags			scim
+//	@Accept			application/scim+json
+//	@Produce		application/scim+json
+//	@Param			group	body		model.SCIMGroupST	true	"group"
+//	@Success		201		{object}	model.SCIMGroupST
+//	@Failure		400		{object}	model.SCIMErrorST
+//	@Failure		401		{object}	model.SCIMErrorST
+//	@Failure		403		{object}	model.SCIMErrorST
+//	@Failure		409		{object}	model.SCIMErrorST
+//	@Failure		500		{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Groups [post]
+//
+//	@Security		Authorization
+func PostSCIMGroup(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "roles", "write"); err != nil {
+		return err
+	}
+	resource, err := decodeSCIMResource(c.Body())
+	if err != nil {
+		return err
+	}
+	saveGroup, err := scimGroupToRepository(resource)
+	if err != nil {
+		return err
+	}
+	application := middleware.GetApplication(c)
+	role, err := repository.CreateSCIMGroup(application.Id, saveGroup)
+	if err != nil {
+		return scimGroupSaveError(err)
+	}
+	groups, err := scimGroupsFromRows([]repository.RoleRowST{role}, true)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderLocation, groups[0].Meta.Location)
+	return sendSCIM(c, http.StatusCreated, groups[0])
+}
+
+// PutSCIMGroup
+//
+//	@Summary		Replace SCIM group
+//	@ID				scim-replace-group
+//	@Tags			scim
+//	@Accept			application/scim+json
+//	@Produce		application/scim+json
+//	@Param			id		path		int					true	"Role id"
+//	@Param			group	body		model.SCIMGroupST	true	"group"
+//	@Success		200		{object}	model.SCIMGroupST
+//	@Failure		400		{object}	model.SCIMErrorST
+//	@Failure		401		{object}	model.SCIMErrorST
+//	@Failure		403		{object}	model.SCIMErrorST
+//	@Failure		404		{object}	model.SCIMErrorST
+//	@Failure		409		{object}	model.SCIMErrorST
+//	@Failure		500		{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Groups/{id} [put]
+//
+//	@Security		Authorization
+func PutSCIMGroup(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "roles", "write"); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	resource, err := decodeSCIMResource(c.Body())
+	if err != nil {
+		return err
+	}
+	return saveSCIMGroup(c, int32(id), resource)
+}
+
+// PatchSCIMGroup
+//
+//	@Summary		Patch SCIM group
+//	@ID				scim-patch-group
+//	@Tags			scim
+//	@Accept			application/scim+json
+//	@Produce		application/scim+json
+//	@Param			id		path		int					true	"Role id"
+//	@Param			patch	body		model.SCIMPatchST	true	"patch operations"
+//	@Success		200		{object}	model.SCIMGroupST
+//	@Failure		400		{object}	model.SCIMErrorST
+//	@Failure		401		{object}	model.SCIMErrorST
+//	@Failure		403		{object}	model.SCIMErrorST
+//	@Failure		404		{object}	model.SCIMErrorST
+//	@Failure		409		{object}	model.SCIMErrorST
+//	@Failure		500		{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Groups/{id} [patch]
+//
+//	@Security		Authorization
+func PatchSCIMGroup(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "roles", "write"); err != nil {
+		return err
+	}
+	group, err := getSCIMGroup(c, true)
+	if err != nil {
+		return err
+	}
+	resource, err := scimModelToResource(group)
+	if err != nil {
+		return err
+	}
+	if err := applySCIMPatch(c, resource); err != nil {
+		return err
+	}
+	id, _ := strconv.Atoi(group.Id)
+	return saveSCIMGroup(c, int32(id), resource)
+}
+
+// DeleteSCIMGroup
+//
+//	@Summary		Delete SCIM group
+//	@ID				scim-delete-group
+//	@Tags			scim
+//	@Param			id	path	int	true	"Role id"
+//	@Success		204
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Failure		403	{object}	model.SCIMErrorST
+//	@Failure		404	{object}	model.SCIMErrorST
+//	@Failure		500	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Groups/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteSCIMGroup(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "roles", "write"); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	application := middleware.GetApplication(c)
+	deleted, err := repository.DeleteApplicationRole(application.Id, int32(id))
+	if err != nil {
+		slog.Error("failed to delete role", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func scimExcludesMembers(c *fiber.Ctx) bool {
+	for _, attribute := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if scim.NormalizePath(attribute) == "members" {
+			return true
+		}
+	}
+	return false
+}
+
+func getSCIMGroup(c *fiber.Ctx, withMembers bool) (model.SCIMGroupST, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.SCIMGroupST{}, model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	application := middleware.GetApplication(c)
+	role, err := repository.GetApplicationRoleById(application.Id, int32(id))
+	if err != nil {
+		slog.Error("failed to get role", "error", err)
+		return model.SCIMGroupST{}, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if role == nil {
+		return model.SCIMGroupST{}, model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	groups, err := scimGroupsFromRows([]repository.RoleRowST{*role}, withMembers)
+	if err != nil {
+		return model.SCIMGroupST{}, err
+	}
+	return groups[0], nil
+}
+
+func saveSCIMGroup(c *fiber.Ctx, id int32, resource map[string]interface{}) error {
+	saveGroup, err := scimGroupToRepository(resource)
+	if err != nil {
+		return err
+	}
+	application := middleware.GetApplication(c)
+	role, err := repository.UpdateSCIMGroup(application.Id, id, saveGroup)
+	if err != nil {
+		return scimGroupSaveError(err)
+	}
+	if role == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	groups, err := scimGroupsFromRows([]repository.RoleRowST{*role}, true)
+	if err != nil {
+		return err
+	}
+	return sendSCIM(c, http.StatusOK, groups[0])
+}
+
+func scimGroupSaveError(err error) error {
+	switch {
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "roles_application_id_uri_unique_idx"):
+		return scim.NewUniquenessError("displayName is already taken")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "roles_application_id_external_id_unique_idx"):
+		return scim.NewUniquenessError("externalId is already taken")
+	}
+	slog.Error("failed to save scim group", "error", err)
+	return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+}
+
+func scimGroupToRepository(resource map[string]interface{}) (repository.SaveSCIMGroupST, error) {
+	var group model.SCIMGroupST
+	if err := scimResourceToModel(resource, &group); err != nil {
+		return repository.SaveSCIMGroupST{}, err
+	}
+	result := repository.SaveSCIMGroupST{
+		DisplayName: strings.TrimSpace(group.DisplayName),
+		ExternalId:  group.ExternalId,
+	}
+	if result.DisplayName == "" {
+		return result, scim.NewError(scim.ErrorTypeInvalidValue, "displayName is required")
+	}
+	for _, member := range group.Members {
+		userId, err := strconv.ParseInt(member.Value, 10, 32)
+		if err != nil {
+			return result, scim.NewError(scim.ErrorTypeInvalidValue, "invalid member %q", member.Value)
+		}
+		result.MemberIds = append(result.MemberIds, int32(userId))
+	}
+	return result, nil
+}
+
+func scimGroupsFromRows(roles []repository.RoleRowST, withMembers bool) ([]model.SCIMGroupST, error) {
+	members := make(map[int32][]model.SCIMReferenceST)
+	if withMembers && len(roles) > 0 {
+		roleIds := make([]int32, 0, len(roles))
+		for _, role := range roles {
+			roleIds = append(roleIds, role.Id)
+		}
+		memberRows, err := repository.GetRolesMembers(roleIds)
+		if err != nil {
+			slog.Error("failed to get role members", "error", err)
+			return nil, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		for _, member := range memberRows {
+			userId := strconv.Itoa(int(member.UserId))
+			display := member.Username
+			members[member.RoleId] = append(members[member.RoleId], model.SCIMReferenceST{
+				Value:   userId,
+				Ref:     scimLocation("Users", userId),
+				Display: &display,
+			})
+		}
+	}
+	groups := make([]model.SCIMGroupST, 0, len(roles))
+	for _, role := range roles {
+		id := strconv.Itoa(int(role.Id))
+		groups = append(groups, model.SCIMGroupST{
+			Schemas:     []string{scim.SchemaGroup},
+			Id:          id,
+			ExternalId:  role.ExternalId,
+			DisplayName: role.URI,
+			Members:     members[role.Id],
+			Meta: &model.SCIMMetaST{
+				ResourceType: "Group",
+				Created:      &role.CreatedAt,
+				LastModified: &role.UpdatedAt,
+				Location:     scimLocation("Groups", id),
+			},
+		})
+	}
+	return groups, nil
+}
@@ -0,0 +1,480 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/scim"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetSCIMUsers
+//
+//	@Summary		List SCIM users
+//	@ID				scim-users
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Param			filter		query		string	false	"filter, for example userName eq \"bjensen\""
+//	@Param			startIndex	query		int		false	"1 based index of the first result"
+//	@Param			count		query		int		false	"max results"
+//	@Success		200			{object}	model.SCIMListResponseST[model.SCIMUserST]
+//	@Failure		400			{object}	model.SCIMErrorST
+//	@Failure		401			{object}	model.SCIMErrorST
+//	@Failure		403			{object}	model.SCIMErrorST
+//	@Failure		500			{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Users [get]
+//
+//	@Security		Authorization
+func GetSCIMUsers(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "read"); err != nil {
+		return err
+	}
+	filter, offset, limit, err := parseSCIMListQuery(c)
+	if err != nil {
+		return err
+	}
+	application := middleware.GetApplication(c)
+	userRows, total, err := repository.GetSCIMUsers(application.Id, filter, offset, limit)
+	if err != nil {
+		return err
+	}
+	users, err := repository.GetUsersDetails(userRows)
+	if err != nil {
+		slog.Error("failed to get users details", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	resources := make([]model.SCIMUserST, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, scimUserFromDetails(user))
+	}
+	return sendSCIM(c, http.StatusOK, model.SCIMListResponseST[model.SCIMUserST]{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   offset + 1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetSCIMUserById
+//
+//	@Summary		Get SCIM user
+//	@ID				scim-user
+//	@Tags			scim
+//	@Produce		application/scim+json
+//	@Param			id	path		int	true	"User id"
+//	@Success		200	{object}	model.SCIMUserST
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Failure		403	{object}	model.SCIMErrorST
+//	@Failure		404	{object}	model.SCIMErrorST
+//	@Failure		500	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Users/{id} [get]
+//
+//	@Security		Authorization
+func GetSCIMUserById(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "read"); err != nil {
+		return err
+	}
+	user, err := getSCIMUser(c)
+	if err != nil {
+		return err
+	}
+	return sendSCIM(c, http.StatusOK, user)
+}
+
+// PostSCIMUser
+//
+//	@Summary		Create SCIM user
+//	@ID				scim-create-user
+//	@Tags			scim
+//	@Accept			application/scim+json
+//	@Produce		application/scim+json
+//	@Param			user	body		model.SCIMUserST	true	"user"
+//	@Success		201		{object}	model.SCIMUserST
+//	@Failure		400		{object}	model.SCIMErrorST
+//	@Failure		401		{object}	model.SCIMErrorST
+//	@Failure		403		{object}	model.SCIMErrorST
+//	@Failure		409		{object}	model.SCIMErrorST
+//	@Failure		500		{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Users [post]
+//
+//	@Security		Authorization
+func PostSCIMUser(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	resource, err := decodeSCIMResource(c.Body())
+	if err != nil {
+		return err
+	}
+	application := middleware.GetApplication(c)
+	saveUser, err := scimUserToRepository(application.Id, resource, true)
+	if err != nil {
+		return err
+	}
+	userRow, err := repository.CreateSCIMUser(application.Id, saveUser)
+	if err != nil {
+		return scimUserSaveError(err)
+	}
+	user, err := getSCIMUserDetails(userRow)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderLocation, user.Meta.Location)
+	return sendSCIM(c, http.StatusCreated, user)
+}
+
+// PutSCIMUser
+//
+//	@Summary		Replace SCIM user
+//	@ID				scim-replace-user
+//	@Tags			scim
+//	@Accept			application/scim+json
+//	@Produce		application/scim+json
+//	@Param			id		path		int					true	"User id"
+//	@Param			user	body		model.SCIMUserST	true	"user"
+//	@Success		200		{object}	model.SCIMUserST
+//	@Failure		400		{object}	model.SCIMErrorST
+//	@Failure		401		{object}	model.SCIMErrorST
+//	@Failure		403		{object}	model.SCIMErrorST
+//	@Failure		404		{object}	model.SCIMErrorST
+//	@Failure		409		{object}	model.SCIMErrorST
+//	@Failure		500		{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Users/{id} [put]
+//
+//	@Security		Authorization
+func PutSCIMUser(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	resource, err := decodeSCIMResource(c.Body())
+	if err != nil {
+		return err
+	}
+	return saveSCIMUser(c, int32(id), resource)
+}
+
+// PatchSCIMUser
+//
+//	@Summary		Patch SCIM user
+//	@ID				scim-patch-user
+//	@Tags			scim
+//	@Accept			application/scim+json
+//	@Produce		application/scim+json
+//	@Param			id		path		int					true	"User id"
+//	@Param			patch	body		model.SCIMPatchST	true	"patch operations"
+//	@Success		200		{object}	model.SCIMUserST
+//	@Failure		400		{object}	model.SCIMErrorST
+//	@Failure		401		{object}	model.SCIMErrorST
+//	@Failure		403		{object}	model.SCIMErrorST
+//	@Failure		404		{object}	model.SCIMErrorST
+//	@Failure		409		{object}	model.SCIMErrorST
+//	@Failure		500		{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Users/{id} [patch]
+//
+//	@Security		Authorization
+func PatchSCIMUser(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	user, err := getSCIMUser(c)
+	if err != nil {
+		return err
+	}
+	resource, err := scimModelToResource(user)
+	if err != nil {
+		return err
+	}
+	if err := applySCIMPatch(c, resource); err != nil {
+		return err
+	}
+	id, _ := strconv.Atoi(user.Id)
+	return saveSCIMUser(c, int32(id), resource)
+}
+
+// DeleteSCIMUser
+//
+//	@Summary		Delete SCIM user
+//	@ID				scim-delete-user
+//	@Tags			scim
+//	@Param			id	path	int	true	"User id"
+//	@Success		204
+//	@Failure		401	{object}	model.SCIMErrorST
+//	@Failure		403	{object}	model.SCIMErrorST
+//	@Failure		404	{object}	model.SCIMErrorST
+//	@Failure		500	{object}	model.SCIMErrorST
+//	@Router			/scim/v2/Users/{id} [delete]
+//
+//	@Security		Authorization
+func DeleteSCIMUser(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	application := middleware.GetApplication(c)
+	deleted, err := repository.DeleteUserById(application.Id, int32(id))
+	if err != nil {
+		slog.Error("failed to delete user", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if !deleted {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func getSCIMUser(c *fiber.Ctx) (model.SCIMUserST, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.SCIMUserST{}, model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	application := middleware.GetApplication(c)
+	userRow, err := repository.GetUserById(application.Id, int32(id))
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		return model.SCIMUserST{}, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if userRow == nil {
+		return model.SCIMUserST{}, model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	return getSCIMUserDetails(*userRow)
+}
+
+func getSCIMUserDetails(userRow repository.UserRowST) (model.SCIMUserST, error) {
+	users, err := repository.GetUsersDetails([]repository.UserRowST{userRow})
+	if err != nil || len(users) != 1 {
+		slog.Error("failed to get user details", "error", err)
+		return model.SCIMUserST{}, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return scimUserFromDetails(users[0]), nil
+}
+
+func saveSCIMUser(c *fiber.Ctx, id int32, resource map[string]interface{}) error {
+	application := middleware.GetApplication(c)
+	saveUser, err := scimUserToRepository(application.Id, resource, false)
+	if err != nil {
+		return err
+	}
+	userRow, err := repository.UpdateSCIMUser(application.Id, id, saveUser)
+	if err != nil {
+		return scimUserSaveError(err)
+	}
+	if userRow == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	user, err := getSCIMUserDetails(*userRow)
+	if err != nil {
+		return err
+	}
+	return sendSCIM(c, http.StatusOK, user)
+}
+
+func scimUserSaveError(err error) error {
+	switch {
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_username_unique_idx"):
+		return scim.NewUniquenessError("userName is already taken")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_application_id_external_id_unique_idx"):
+		return scim.NewUniquenessError("externalId is already taken")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "emails_email_unique_idx"):
+		return scim.NewUniquenessError("email is already taken")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "phone_numbers_"):
+		return scim.NewUniquenessError("phone number is already taken")
+	}
+	slog.Error("failed to save scim user", "error", err)
+	return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+}
+
+// scimUserToRepository validates a SCIM user, a new user without a password gets a random one
+func scimUserToRepository(applicationId int32, resource map[string]interface{}, create bool) (repository.SaveSCIMUserST, error) {
+	var user model.SCIMUserST
+	if err := scimResourceToModel(resource, &user); err != nil {
+		return repository.SaveSCIMUserST{}, err
+	}
+	result := repository.SaveSCIMUserST{
+		Username:   strings.TrimSpace(user.UserName),
+		ExternalId: user.ExternalId,
+		Active:     user.Active == nil || *user.Active,
+	}
+	if result.Username == "" {
+		return result, scim.NewError(scim.ErrorTypeInvalidValue, "userName is required")
+	}
+	if user.Password != nil {
+		policy, err := repository.GetPasswordPolicy(applicationId, 0)
+		if err != nil {
+			slog.Error("failed to get password policy", "error", err)
+			return result, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		violations, err := service.ValidatePassword(policy, result.Username, *user.Password, nil, config.Get().Password.BreachedPath)
+		if err != nil {
+			slog.Error("failed to validate password", "error", err)
+			return result, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		if len(violations) > 0 {
+			return result, scim.NewError(scim.ErrorTypeInvalidValue, "password %s", violations[0].Message)
+		}
+		encryptedPassword, err := util.EncryptPassword(*user.Password)
+		if err != nil {
+			slog.Error("failed to encrypt password", "error", err)
+			return result, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		result.EncryptedPassword = &encryptedPassword
+	} else if create {
+		password, err := util.GenerateRandomHex(32)
+		if err != nil {
+			slog.Error("failed to generate password", "error", err)
+			return result, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		encryptedPassword, err := util.EncryptPassword(password)
+		if err != nil {
+			slog.Error("failed to encrypt password", "error", err)
+			return result, model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		result.EncryptedPassword = &encryptedPassword
+	}
+	for _, email := range user.Emails {
+		value := strings.TrimSpace(email.Value)
+		if !strings.Contains(value, "@") {
+			return result, scim.NewError(scim.ErrorTypeInvalidValue, "invalid email %q", email.Value)
+		}
+		result.Emails = appendSCIMValue(result.Emails, value, email.Primary)
+	}
+	for _, phoneNumber := range user.PhoneNumbers {
+		// SCIM requests aren't made for a tenent so there is no default region, numbers must be international
+		value, err := util.NormalizePhoneNumber(phoneNumber.Value, "")
+		if err != nil {
+			return result, scim.NewError(scim.ErrorTypeInvalidValue, "invalid phone number %q", phoneNumber.Value)
+		}
+		result.PhoneNumbers = appendSCIMValue(result.PhoneNumbers, value, phoneNumber.Primary)
+	}
+	info := &result.Info
+	info.Name = user.DisplayName
+	if user.Name != nil {
+		if user.DisplayName == nil {
+			info.Name = user.Name.Formatted
+		}
+		info.GivenName = user.Name.GivenName
+		info.FamilyName = user.Name.FamilyName
+		info.MiddleName = user.Name.MiddleName
+	}
+	info.Nickname = user.NickName
+	info.Profile = user.ProfileUrl
+	info.Locale = user.Locale
+	info.Zoneinfo = user.Timezone
+	for _, photo := range user.Photos {
+		if info.Picture == nil || photo.Primary {
+			info.Picture = &photo.Value
+		}
+	}
+	for _, address := range user.Addresses {
+		if info.StreetAddress == nil && info.Locality == nil && info.Country == nil || address.Primary {
+			info.StreetAddress = address.StreetAddress
+			info.Locality = address.Locality
+			info.Region = address.Region
+			info.PostalCode = address.PostalCode
+			info.Country = address.Country
+		}
+	}
+	return result, nil
+}
+
+// appendSCIMValue appends a value once, the primary value first
+func appendSCIMValue(values []string, value string, primary bool) []string {
+	for i, existing := range values {
+		if strings.EqualFold(existing, value) {
+			if primary {
+				values = append(values[:i], values[i+1:]...)
+				return append([]string{value}, values...)
+			}
+			return values
+		}
+	}
+	if primary {
+		return append([]string{value}, values...)
+	}
+	return append(values, value)
+}
+
+func scimUserFromDetails(details repository.UserDetailsST) model.SCIMUserST {
+	id := strconv.Itoa(int(details.User.Id))
+	active := details.User.IsActive()
+	location := scimLocation("Users", id)
+	user := model.SCIMUserST{
+		Schemas:    []string{scim.SchemaUser},
+		Id:         id,
+		ExternalId: details.User.ExternalId,
+		UserName:   details.User.Username,
+		Active:     &active,
+		Meta: &model.SCIMMetaST{
+			ResourceType: "User",
+			Created:      &details.User.CreatedAt,
+			LastModified: &details.User.UpdatedAt,
+			Location:     location,
+		},
+	}
+	for _, email := range details.Emails {
+		user.Emails = append(user.Emails, model.SCIMMultiValuedST{
+			Value:   email.Email,
+			Primary: details.User.EmailId != nil && *details.User.EmailId == email.Id,
+		})
+	}
+	for _, phoneNumber := range details.PhoneNumbers {
+		user.PhoneNumbers = append(user.PhoneNumbers, model.SCIMMultiValuedST{
+			Value:   phoneNumber.PhoneNumber,
+			Primary: details.User.PhoneNumberId != nil && *details.User.PhoneNumberId == phoneNumber.Id,
+		})
+	}
+	for _, role := range details.Roles {
+		display := role.URI
+		user.Groups = append(user.Groups, model.SCIMReferenceST{
+			Value:   strconv.Itoa(int(role.Id)),
+			Ref:     scimLocation("Groups", strconv.Itoa(int(role.Id))),
+			Display: &display,
+		})
+	}
+	if info := details.Info; info != nil {
+		user.DisplayName = info.Name
+		if info.Name != nil || info.GivenName != nil || info.FamilyName != nil || info.MiddleName != nil {
+			user.Name = &model.SCIMNameST{
+				Formatted:  info.Name,
+				GivenName:  info.GivenName,
+				FamilyName: info.FamilyName,
+				MiddleName: info.MiddleName,
+			}
+		}
+		user.NickName = info.Nickname
+		user.ProfileUrl = info.Profile
+		user.Locale = info.Locale
+		user.Timezone = info.Zoneinfo
+		if info.Picture != nil {
+			user.Photos = []model.SCIMMultiValuedST{{Value: *info.Picture, Primary: true}}
+		}
+		if info.StreetAddress != nil || info.Locality != nil || info.Region != nil || info.PostalCode != nil || info.Country != nil {
+			user.Addresses = []model.SCIMAddressST{{
+				StreetAddress: info.StreetAddress,
+				Locality:      info.Locality,
+				Region:        info.Region,
+				PostalCode:    info.PostalCode,
+				Country:       info.Country,
+				Primary:       true,
+			}}
+		}
+	}
+	return user
+}
@@ -22,7 +22,7 @@ import (
 //	@Accept			json
 //	@Produce		json
 //	@Param			applicationId	path		int	true	"application id"
-//	@Param			query	query		model.OffsetAndLimitQueryST	false	"query"
+//	@Param			query	query		model.PaginationQueryST	false	"query"
 //	@Success		200	{object}   	model.PaginationST[model.TenentST]
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
@@ -40,24 +40,20 @@ func GetTenents(c *fiber.Ctx) error {
 		slog.Error("failed to parse applicationId", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
 	}
-	var offsetAndLimit model.OffsetAndLimitQueryST
-	if err := c.QueryParser(&offsetAndLimit); err != nil {
+	var query model.PaginationQueryST
+	if err := c.QueryParser(&query); err != nil {
 		slog.Error("failed to parse query", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("query", "invalid")
 	}
-	tenents, err := repository.GetTenents(int32(applicationId), offsetAndLimit.Limit, offsetAndLimit.Offset)
+	page, err := pageFromQuery(&query)
 	if err != nil {
-		slog.Error("failed to get applications", "error", err)
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		return err
 	}
-	hasMore := false
-	if offsetAndLimit.Limit != nil && *offsetAndLimit.Limit == len(tenents) {
-		hasMore = true
+	tenents, err := repository.GetTenents(int32(applicationId), page)
+	if err != nil {
+		return paginationError(err, "failed to get tenents")
 	}
-	return c.JSON(model.PaginationST[model.TenentST]{
-		HasMore: hasMore,
-		Items:   util.Map(tenents, model.TenentFromRow),
-	})
+	return c.JSON(model.PaginationFromPage(tenents, util.Map(tenents.Items, model.TenentFromRow)))
 }
 
 // GetTenentById
@@ -170,6 +166,11 @@ func PostCreateTenent(c *fiber.Ctx) error {
 		slog.Error("failed to parse body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
+	errors := model.NewError(http.StatusBadRequest)
+	createTenent.DefaultPhoneRegion = validatePhoneRegion(errors, createTenent.DefaultPhoneRegion)
+	if errors.HasErrors() {
+		return errors
+	}
 	tenent, err := repository.CreateTenent(int32(applicationId), createTenent.CreateTenentST)
 	if err != nil {
 		slog.Error("failed to create tenent", "error", err)
@@ -245,6 +246,7 @@ func PatchUpdateTenent(c *fiber.Ctx) error {
 			errors.AddError("publicKey", "invalid")
 		}
 	}
+	updateTenent.DefaultPhoneRegion = validatePhoneRegion(errors, updateTenent.DefaultPhoneRegion)
 	if errors.HasErrors() {
 		return errors
 	}
@@ -299,3 +301,16 @@ func DeleteTenent(c *fiber.Ctx) error {
 	c.Status(http.StatusNoContent)
 	return c.Send(nil)
 }
+
+// validatePhoneRegion returns the upper case region, national phone numbers of the tenent's users are read as
+// numbers of it
+func validatePhoneRegion(errors *model.ErrorST, region *string) *string {
+	if region == nil {
+		return nil
+	}
+	upperRegion := strings.ToUpper(strings.TrimSpace(*region))
+	if !util.IsPhoneRegion(upperRegion) {
+		errors.AddError("default_phone_region", "invalid")
+	}
+	return &upperRegion
+}
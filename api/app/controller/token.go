@@ -1,9 +1,12 @@
 package controller
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
 	"time"
@@ -30,6 +33,7 @@ import (
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
 //	@Failure		404	{object}	model.ErrorST
+//	@Failure		409	{object}	model.ErrorST
 //	@Failure		500	{object}	model.ErrorST
 //	@Router			/token [post]
 //
@@ -40,11 +44,22 @@ func PostToken(c *fiber.Ctx) error {
 		slog.Error("invalid request body", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
 	}
+	tenent := middleware.GetTenent(c)
+	if len(tenent.GrantTypes) > 0 && !slices.Contains(tenent.GrantTypes, tokenRequest.GrantType) {
+		return model.NewError(http.StatusBadRequest).AddError("grant_type", "invalid")
+	}
+	if err := authenticateClient(c, tenent, tokenRequest); err != nil {
+		return err
+	}
 	switch tokenRequest.GrantType {
 	case model.PasswordGrantType:
 		return passwordToken(c, tokenRequest)
 	case model.PassKeyGrantType:
 		return model.NewError(http.StatusBadRequest).AddError("grant_type", "invalid")
+	case model.PasswordlessGrantType:
+		return passwordlessToken(c, tokenRequest)
+	case model.IdentityProviderGrantType:
+		return identityProviderToken(c, tokenRequest)
 	case model.ServieAccountGrantType:
 		return serviceAccountToken(c, tokenRequest)
 	case model.RefreshTokenGrantType:
@@ -53,35 +68,92 @@ func PostToken(c *fiber.Ctx) error {
 	return model.NewError(http.StatusBadRequest).AddError("grant_type", "invalid")
 }
 
+// authenticateClient checks the client credentials required by the tenent's token_endpoint_auth_method
+func authenticateClient(c *fiber.Ctx, tenent *repository.TenentRowST, tokenRequest model.TokenRequestST) error {
+	var clientId, clientSecret string
+	switch tenent.TokenEndpointAuthMethod {
+	case model.TokenEndpointAuthMethodNone:
+		return nil
+	case model.TokenEndpointAuthMethodClientSecretBasic:
+		var ok bool
+		clientId, clientSecret, ok = basicClientCredentials(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="token"`)
+			return model.NewError(http.StatusUnauthorized).AddError("client", "invalid")
+		}
+	case model.TokenEndpointAuthMethodClientSecretPost:
+		clientId, clientSecret = tokenRequest.ClientId, tokenRequest.ClientSecret
+	}
+	parsedClientId, err := uuid.Parse(clientId)
+	if err != nil || parsedClientId != tenent.ClientId || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(tenent.ClientSecret)) != 1 {
+		return model.NewError(http.StatusUnauthorized).AddError("client", "invalid")
+	}
+	return nil
+}
+
+// basicClientCredentials reads the form encoded client id and secret of a Basic authorization header, RFC 6749 2.3.1
+func basicClientCredentials(authorization string) (string, string, bool) {
+	scheme, credentials, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(credentials))
+	if err != nil {
+		return "", "", false
+	}
+	clientId, clientSecret, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", "", false
+	}
+	clientId, err = url.QueryUnescape(clientId)
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err = url.QueryUnescape(clientSecret)
+	if err != nil {
+		return "", "", false
+	}
+	return clientId, clientSecret, true
+}
+
 func passwordToken(c *fiber.Ctx, tokenRequest model.TokenRequestST) error {
 	application := middleware.GetApplication(c)
-	user, err := repository.GetUserByUsernameOrEmail(application.Id, strings.TrimSpace(tokenRequest.Username))
+	user, err := repository.GetUserByUsernameOrEmail(application.Id, strings.TrimSpace(tokenRequest.Username), middleware.GetTenent(c).FoldEmailAliases)
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
 		return model.NewError(http.StatusUnauthorized).AddError("username", "invalid").AddError("password", "invalid")
 	}
-	if user == nil {
-		slog.Error("failed to get user", "error", err)
-		return model.NewError(http.StatusUnauthorized).AddError("username", "invalid").AddError("password", "invalid")
-	}
-	verified, err := util.VerifyPassword(strings.TrimSpace(tokenRequest.Password), user.EncryptedPassword)
-	if !verified || err != nil {
+	if user == nil || user.LDAPBacked {
+		user, err = ldapPasswordUser(application.Id, user, strings.TrimSpace(tokenRequest.Username), strings.TrimSpace(tokenRequest.Password))
 		if err != nil {
-			slog.Error("failed to verify password", "error", err)
+			return err
+		}
+	} else {
+		verified, err := util.VerifyPassword(strings.TrimSpace(tokenRequest.Password), user.EncryptedPassword)
+		if !verified || err != nil {
+			if err != nil {
+				slog.Error("failed to verify password", "error", err)
+			}
+			return model.NewError(http.StatusUnauthorized).AddError("username", "invalid").AddError("password", "invalid")
+		}
+		if util.PasswordNeedsRehash(user.EncryptedPassword) {
+			if _, err := repository.RehashUserPassword(user.Id, user.EncryptedPassword, strings.TrimSpace(tokenRequest.Password)); err != nil {
+				slog.Error("failed to rehash password", "error", err)
+			}
 		}
-		return model.NewError(http.StatusUnauthorized).AddError("username", "invalid").AddError("password", "invalid")
 	}
-	mfa, err := repository.GetMFA(user.Id)
+	tenent := middleware.GetTenent(c)
+	mfas, err := repository.GetEnabledMFAs(user.Id, tenent.Id)
 	if err != nil {
 		slog.Error("failed to get mfa", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 	}
 	return sendToken(c, sendTokenST{
-		mfa:             mfa,
+		mfas:            mfas,
 		issuedTokenType: tokenRequest.GrantType,
 		scope:           tokenRequest.Scope,
 		application:     application,
-		tenent:          middleware.GetTenent(c),
+		tenent:          tenent,
 		user:            user,
 	})
 }
@@ -97,6 +169,9 @@ func serviceAccountToken(c *fiber.Ctx, tokenRequest model.TokenRequestST) error
 		slog.Error("failed to get user", "error", err)
 		return model.NewError(http.StatusUnauthorized).AddError("key", "invalid").AddError("secret", "invalid")
 	}
+	if serviceAccount == nil || serviceAccount.ApplicationId != middleware.GetApplication(c).Id {
+		return model.NewError(http.StatusUnauthorized).AddError("key", "invalid").AddError("secret", "invalid")
+	}
 	verified, err := util.VerifyPassword(strings.TrimSpace(tokenRequest.Secret), serviceAccount.EncryptedSecret)
 	if !verified || err != nil {
 		if err != nil {
@@ -120,38 +195,86 @@ func refreshToken(c *fiber.Ctx, tokenRequest model.TokenRequestST) error {
 		slog.Error("failed to get refresh token claims", "error", err)
 		return model.NewError(http.StatusUnauthorized).AddError("refresh_token", "invalid")
 	}
+	if claims.Type != jwt.RefreshTokenType {
+		return model.NewError(http.StatusUnauthorized).AddError("refresh_token", "invalid")
+	}
 	user, err := repository.GetUserById(tenent.ApplicationId, claims.Subject)
 	if err != nil {
 		slog.Error("failed to get user", "error", err)
 		return model.NewError(http.StatusUnauthorized).AddError("refresh_token", "invalid")
 	}
+	if claims.SubjectType == jwt.UserSubject && (user == nil || user.TokenRevoked(claims.IssuedAtSeconds)) {
+		return model.NewError(http.StatusUnauthorized).AddError("refresh_token", "invalid")
+	}
+	if claims.SubjectType == jwt.UserSubject && user != nil && tenent.ThirdParty {
+		consent, err := repository.GetUserConsent(user.Id, tenent.Id)
+		if err != nil {
+			slog.Error("failed to get user consent", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		// revoking consent deletes it, so tokens issued before it was granted again must not be refreshed
+		if consent == nil || claims.IssuedAtSeconds < consent.CreatedAt.Unix() {
+			return model.NewError(http.StatusUnauthorized).AddError("refresh_token", "invalid")
+		}
+	}
+	// refresh tokens issued before auth_time was added only know when they were issued
+	authTime := claims.AuthTimeSeconds
+	if authTime == 0 {
+		authTime = claims.IssuedAtSeconds
+	}
 	return sendToken(c, sendTokenST{
 		issuedTokenType: tokenRequest.GrantType,
 		scope:           tokenRequest.Scope,
 		application:     middleware.GetApplication(c),
 		tenent:          tenent,
 		user:            user,
+		authTime:        authTime,
 	})
 }
 
 type sendTokenST struct {
-	mfa             *repository.MFARowST
+	mfas            []repository.MFARowST
 	issuedTokenType string
 	scope           string
 	application     *repository.ApplicationRowST
 	tenent          *repository.TenentRowST
 	user            *repository.UserRowST
 	serviceAccount  *repository.ServiceAccountRowST
+	// authTime is when the user last authenticated, zero when they just did
+	authTime int64
+	// actor is set when an admin impersonates the user, the token is short lived and can't be refreshed
+	actor *jwt.Actor
 }
 
 func (sendToken *sendTokenST) MFAEnabled() bool {
-	return sendToken.mfa != nil && sendToken.mfa.Enabled
+	return len(sendToken.mfas) > 0
+}
+
+// MFAMethods lists the distinct types the user can complete the challenge with, the preferred first
+func (sendToken *sendTokenST) MFAMethods() ([]string, error) {
+	methods := make([]string, 0, len(sendToken.mfas)+1)
+	for _, mfa := range sendToken.mfas {
+		if !slices.Contains(methods, mfa.Type) {
+			methods = append(methods, mfa.Type)
+		}
+	}
+	remaining, err := repository.GetRecoveryCodesRemaining(sendToken.user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if remaining > 0 {
+		methods = append(methods, repository.MFATypeRecoveryCode)
+	}
+	return methods, nil
 }
 
 func sendToken(
 	c *fiber.Ctx,
 	params sendTokenST,
 ) error {
+	if params.user != nil && !params.user.IsActive() {
+		return model.NewError(http.StatusUnauthorized).AddError("user", params.user.CurrentStatus())
+	}
 	now := time.Now().UTC()
 	scopes := jwt.ParseScopes(params.scope)
 	audiences := []string{
@@ -169,6 +292,14 @@ func sendToken(
 		subject = params.serviceAccount.Id
 		subjectType = jwt.ServiceAccountSubject
 	}
+	authTime := params.authTime
+	if authTime == 0 {
+		authTime = now.Unix()
+	}
+	expiresIn := params.tenent.ExpiresInSeconds
+	if params.actor != nil {
+		expiresIn = min(expiresIn, config.Get().Impersonation.ExpiresInSeconds)
+	}
 	baseClaims := jwt.Claims{
 		Subject:          subject,
 		SubjectType:      subjectType,
@@ -177,21 +308,73 @@ func sendToken(
 		Audiences:        audiences,
 		NotBeforeSeconds: now.Unix(),
 		IssuedAtSeconds:  now.Unix(),
-		ExpiresAtSeconds: now.Unix() + int64(params.tenent.ExpiresInSeconds),
+		AuthTimeSeconds:  authTime,
+		ExpiresAtSeconds: now.Unix() + expiresIn,
 		Issuer:           config.Get().URL,
 		Scope:            scopes,
+		Actor:            params.actor,
 	}
 	tokenType := jwt.BearerTokenType
 	var claims jwt.ToMapClaims = &baseClaims
+	var mfaMethods []string
+	consentRequired := false
+	var verificationRequired []string
 	if params.MFAEnabled() {
+		preferred := params.mfas[0]
+		if preferred.Type == repository.MFATypeEmail || preferred.Type == repository.MFATypeSMS {
+			// the user still gets the MFA token, /mfa/resend reports why the code couldn't be delivered
+			if err := sendMFACode(params.user, params.tenent, preferred.Type); err != nil {
+				slog.Error("failed to send MFA code", "error", err)
+			}
+		}
+		methods, err := params.MFAMethods()
+		if err != nil {
+			slog.Error("failed to get MFA methods", "error", err)
+			return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		}
+		mfaMethods = methods
 		baseClaims.Type = jwt.MFATokenType
-		tokenType = fmt.Sprintf("%s:%s", jwt.MFATokenType, params.mfa.Type)
+		tokenType = fmt.Sprintf("%s:%s", jwt.MFATokenType, preferred.Type)
 		mfaClaims := jwt.MFAClaims{
 			Claims:    baseClaims,
 			GrantType: params.issuedTokenType,
 		}
 		claims = &mfaClaims
+	} else if params.user != nil {
+		// impersonation is for looking at the user's account as it is, it doesn't need the user to confirm anything
+		if params.actor == nil {
+			unconfirmed, err := unconfirmedRequirements(params.user, params.tenent)
+			if err != nil {
+				slog.Error("failed to get unconfirmed requirements", "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+			verificationRequired = unconfirmed
+		}
+		if len(verificationRequired) > 0 {
+			baseClaims.Type = jwt.VerificationTokenType
+			tokenType = jwt.VerificationTokenType
+			claims = &jwt.VerificationClaims{
+				Claims:    baseClaims,
+				GrantType: params.issuedTokenType,
+			}
+		} else if params.tenent.ThirdParty {
+			consent, err := repository.GetUserConsent(params.user.Id, params.tenent.Id)
+			if err != nil {
+				slog.Error("failed to get user consent", "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+			if consent == nil || !consent.Covers(scopes) {
+				consentRequired = true
+				baseClaims.Type = jwt.ConsentTokenType
+				tokenType = jwt.ConsentTokenType
+				claims = &jwt.ConsentClaims{
+					Claims:    baseClaims,
+					GrantType: params.issuedTokenType,
+				}
+			}
+		}
 	}
+	complete := !params.MFAEnabled() && !consentRequired && len(verificationRequired) == 0
 	accessToken, err := jwt.CreateToken(claims, params.tenent)
 	if err != nil {
 		slog.Error("failed to create access token", "error", err)
@@ -199,7 +382,7 @@ func sendToken(
 	}
 	var refreshToken *string
 	var refreshTokenExpiresIn *int64
-	if !params.MFAEnabled() {
+	if complete && params.actor == nil {
 		token, err := jwt.CreateToken(baseClaims.ToRefreshClaims(params.application, params.tenent), params.tenent)
 		if err != nil {
 			slog.Error("failed to create refresh token", "error", err)
@@ -209,7 +392,7 @@ func sendToken(
 		refreshTokenExpiresIn = &params.tenent.RefreshExpiresInSeconds
 	}
 	var idToken *string
-	if !params.MFAEnabled() && slices.Contains(scopes, "openid") {
+	if complete && slices.Contains(scopes, "openid") {
 		if subjectType == jwt.UserSubject {
 			openIdClaims, err := jwt.OpenIdClaimsForUser(&baseClaims, params.user.Id)
 			if err != nil {
@@ -231,10 +414,37 @@ func sendToken(
 		AccessToken:           accessToken,
 		TokenType:             tokenType,
 		IssuedTokenType:       params.issuedTokenType,
-		ExpiresIn:             params.tenent.ExpiresInSeconds,
+		ExpiresIn:             expiresIn,
 		Scope:                 scopes,
 		RefreshToken:          refreshToken,
 		RefreshTokenExpiresIn: refreshTokenExpiresIn,
 		IdToken:               idToken,
+		MFAMethods:            mfaMethods,
+		VerificationRequired:  verificationRequired,
 	})
 }
+
+// unconfirmedRequirements lists which of the primary email and phone number the tenent requires the user to have
+// confirmed it has not
+func unconfirmedRequirements(user *repository.UserRowST, tenent *repository.TenentRowST) ([]string, error) {
+	var unconfirmed []string
+	if tenent.RequireConfirmedEmail {
+		email, err := repository.GetUserPrimaryEmail(user.Id)
+		if err != nil {
+			return nil, err
+		}
+		if email == nil || !email.Confirmed {
+			unconfirmed = append(unconfirmed, "email")
+		}
+	}
+	if tenent.RequireConfirmedPhoneNumber {
+		phoneNumber, err := repository.GetUserPrimaryPhoneNumber(user.Id)
+		if err != nil {
+			return nil, err
+		}
+		if phoneNumber == nil || !phoneNumber.Confirmed {
+			unconfirmed = append(unconfirmed, "phone_number")
+		}
+	}
+	return unconfirmed, nil
+}
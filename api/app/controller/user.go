@@ -3,12 +3,16 @@ package controller
 import (
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aicacia/auth/api/app/access"
 	"github.com/aicacia/auth/api/app/middleware"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -20,7 +24,7 @@ import (
 //	@Accept			json
 //	@Produce		json
 //	@Param			applicationId	path		int	true	"application id"
-//	@Param			query	query		model.OffsetAndLimitQueryST	false	"query"
+//	@Param			query	query		model.UsersQueryST	false	"query"
 //	@Success		200	{object}   	model.PaginationST[model.UserST]
 //	@Failure		400	{object}	model.ErrorST
 //	@Failure		401	{object}	model.ErrorST
@@ -33,8 +37,8 @@ func GetUsers(c *fiber.Ctx) error {
 	if err := access.HasAction(c, "users", "read"); err != nil {
 		return err
 	}
-	var offsetAndLimit model.OffsetAndLimitQueryST
-	if err := c.QueryParser(&offsetAndLimit); err != nil {
+	var query model.UsersQueryST
+	if err := c.QueryParser(&query); err != nil {
 		slog.Error("failed to parse query", "error", err)
 		return model.NewError(http.StatusBadRequest).AddError("query", "invalid")
 	}
@@ -42,12 +46,22 @@ func GetUsers(c *fiber.Ctx) error {
 	if err != nil {
 		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
 	}
-	userRows, err := repository.GetUsers(int32(applicationId), offsetAndLimit.Limit, offsetAndLimit.Offset)
+	filter, err := usersQueryToFilter(&query)
 	if err != nil {
-		slog.Error("failed to get users", "error", err)
-		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+		return err
+	}
+	page, err := pageFromQuery(&query.PaginationQueryST)
+	if err != nil {
+		return err
+	}
+	userRows, err := repository.GetUsers(int32(applicationId), filter, page)
+	if err != nil {
+		return paginationError(err, "failed to get users")
 	}
-	emails, err := repository.GetUsersEmails(int32(applicationId), offsetAndLimit.Limit, offsetAndLimit.Offset)
+	userIds := util.Map(userRows.Items, func(userRow repository.UserRowST) int32 {
+		return userRow.Id
+	})
+	emails, err := repository.GetUsersEmails(userIds)
 	if err != nil {
 		slog.Error("failed to get users emails", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
@@ -56,7 +70,7 @@ func GetUsers(c *fiber.Ctx) error {
 	for _, email := range emails {
 		emailsByUserId[email.UserId] = append(emailsByUserId[email.UserId], email)
 	}
-	phoneNumbers, err := repository.GetUsersPhoneNumbers(int32(applicationId), offsetAndLimit.Limit, offsetAndLimit.Offset)
+	phoneNumbers, err := repository.GetUsersPhoneNumbers(userIds)
 	if err != nil {
 		slog.Error("failed to get users phone numbers", "error", err)
 		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
@@ -65,18 +79,85 @@ func GetUsers(c *fiber.Ctx) error {
 	for _, phoneNumber := range phoneNumbers {
 		phoneNumbersByUserId[phoneNumber.UserId] = append(phoneNumbersByUserId[phoneNumber.UserId], phoneNumber)
 	}
-	users := make([]model.UserST, 0, len(userRows))
-	for _, userRow := range userRows {
+	users := make([]model.UserST, 0, len(userRows.Items))
+	for _, userRow := range userRows.Items {
 		users = append(users, model.UserFromRow(userRow, emailsByUserId[userRow.Id], phoneNumbersByUserId[userRow.Id]))
 	}
-	hasMore := false
-	if offsetAndLimit.Limit != nil && *offsetAndLimit.Limit == len(users) {
-		hasMore = true
+	return c.JSON(model.PaginationFromPage(userRows, users))
+}
+
+// usersQueryToFilter validates the list query's filters and sort
+func usersQueryToFilter(query *model.UsersQueryST) (repository.UserFilterST, error) {
+	errors := model.NewError(http.StatusBadRequest)
+	filter := repository.UserFilterST{
+		EmailConfirmed:       query.EmailConfirmed,
+		PhoneNumberConfirmed: query.PhoneNumberConfirmed,
+		MFAEnabled:           query.MFAEnabled,
+		Sort:                 "updated_at",
+		Descending:           true,
+	}
+	if !isBlank(query.Username) {
+		filter.Username = query.Username
+	}
+	if !isBlank(query.Email) {
+		filter.Email = query.Email
+	}
+	if !isBlank(query.PhoneNumber) {
+		phoneNumber := util.NormalizePhoneNumberPrefix(*query.PhoneNumber)
+		if phoneNumber == "" {
+			errors.AddError("phone_number", "invalid")
+		}
+		filter.PhoneNumber = &phoneNumber
+	}
+	if !isBlank(query.Status) {
+		if !slices.Contains(repository.UserStatuses, *query.Status) {
+			errors.AddError("status", "invalid")
+		}
+		filter.Status = query.Status
+	}
+	if !isBlank(query.Role) {
+		filter.Role = query.Role
+	}
+	if !isBlank(query.Q) {
+		q := strings.TrimSpace(*query.Q)
+		filter.Query = &q
+	}
+	filter.CreatedAfter = parseQueryTime(errors, "created_after", query.CreatedAfter)
+	filter.CreatedBefore = parseQueryTime(errors, "created_before", query.CreatedBefore)
+	filter.UpdatedAfter = parseQueryTime(errors, "updated_after", query.UpdatedAfter)
+	filter.UpdatedBefore = parseQueryTime(errors, "updated_before", query.UpdatedBefore)
+	if !isBlank(query.Sort) {
+		if !slices.Contains(repository.UserSorts, *query.Sort) {
+			errors.AddError("sort", "invalid")
+		}
+		filter.Sort = *query.Sort
+	}
+	if !isBlank(query.Order) {
+		switch *query.Order {
+		case "asc":
+			filter.Descending = false
+		case "desc":
+			filter.Descending = true
+		default:
+			errors.AddError("order", "invalid")
+		}
+	}
+	if errors.HasErrors() {
+		return filter, errors
+	}
+	return filter, nil
+}
+
+func parseQueryTime(errors *model.ErrorST, name string, value *string) *time.Time {
+	if isBlank(value) {
+		return nil
 	}
-	return c.JSON(model.PaginationST[model.UserST]{
-		HasMore: hasMore,
-		Items:   users,
-	})
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		errors.AddError(name, "invalid")
+		return nil
+	}
+	return &t
 }
 
 // GetUserById
@@ -202,6 +283,101 @@ func PatchUpdateUserById(c *fiber.Ctx) error {
 	return c.JSON(model.UserFromRow(*user, emails, phoneNumbers))
 }
 
+// PatchUserStatus
+//
+//	@Summary		Changes a user's status
+//	@Description	Users that are not active can't sign in and the tokens they were issued are revoked, a status with an expiry becomes active again once it passes
+//	@ID				update-user-status
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			id	path		int	true	"user id"
+//	@Param			updateUserStatus	body    model.UpdateUserStatusST	true	"update user status"
+//	@Success		200	{object}   	model.UserST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		404	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/users/{id}/status [patch]
+//
+//	@Security		Authorization
+func PatchUserStatus(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("id", "invalid")
+	}
+	var updateUserStatus model.UpdateUserStatusST
+	if err := c.BodyParser(&updateUserStatus); err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	errors := model.NewError(http.StatusBadRequest)
+	if !slices.Contains(repository.UserStatuses, updateUserStatus.Status) {
+		errors.AddError("status", "invalid")
+	}
+	update := repository.UpdateUserStatusST{
+		Status: updateUserStatus.Status,
+	}
+	if updateUserStatus.Status != repository.UserStatusActive {
+		if !isBlank(updateUserStatus.Reason) {
+			reason := strings.TrimSpace(*updateUserStatus.Reason)
+			if len(reason) > 255 {
+				errors.AddError("reason", "invalid")
+			}
+			update.Reason = &reason
+		}
+		if updateUserStatus.ExpiresAt != nil {
+			if !updateUserStatus.ExpiresAt.After(time.Now()) {
+				errors.AddError("expires_at", "invalid")
+			}
+			update.ExpiresAt = updateUserStatus.ExpiresAt
+		}
+	}
+	if errors.HasErrors() {
+		return errors
+	}
+	data := map[string]interface{}{
+		"status":     update.Status,
+		"reason":     update.Reason,
+		"expires_at": update.ExpiresAt,
+	}
+	if middleware.IsUserSubject(c) {
+		actor := middleware.GetUser(c)
+		if actor.Id == int32(id) && update.Status != repository.UserStatusActive {
+			return model.NewError(http.StatusBadRequest).AddError("id", "self")
+		}
+		update.ChangedByUserId = &actor.Id
+		data["actor_user_id"] = actor.Id
+	} else if middleware.IsServiceAccount(c) {
+		actor := middleware.GetServiceAccount(c)
+		update.ChangedByServiceAccountId = &actor.Id
+		data["actor_service_account_id"] = actor.Id
+	}
+	user, err := repository.SetUserStatus(int32(applicationId), int32(id), update)
+	if err != nil {
+		slog.Error("failed to update user status", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if user == nil {
+		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
+	}
+	auditLog(c, user, repository.AuditActionUserStatusChanged, data)
+	emails, phoneNumbers, err := getUserEmailsAndPhoneNumbersById(user.Id)
+	if err != nil {
+		slog.Error("failed to get user emails and phone numbers", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	return c.JSON(model.UserFromRow(*user, emails, phoneNumbers))
+}
+
 // DeleteUserById
 //
 //	@Summary		Delets a user by id
@@ -323,27 +499,7 @@ func PatchUserInfo(c *fiber.Ctx) error {
 	if user == nil {
 		return model.NewError(http.StatusNotFound).AddError("id", "invalid")
 	}
-	updates := repository.UpdateUserInfoST{
-		Name:       userinfoUpdates.Name,
-		GivenName:  userinfoUpdates.GivenName,
-		FamilyName: userinfoUpdates.FamilyName,
-		MiddleName: userinfoUpdates.MiddleName,
-		Nickname:   userinfoUpdates.Nickname,
-		Profile:    userinfoUpdates.Profile,
-		Picture:    userinfoUpdates.Picture,
-		Website:    userinfoUpdates.Website,
-		Gender:     userinfoUpdates.Gender,
-		Birthdate:  userinfoUpdates.Birthdate,
-		Zoneinfo:   userinfoUpdates.Zoneinfo,
-		Locale:     userinfoUpdates.Locale,
-	}
-	if userinfoUpdates.Address != nil {
-		updates.Region = userinfoUpdates.Address.Region
-		updates.Locality = userinfoUpdates.Address.Locality
-		updates.PostalCode = userinfoUpdates.Address.PostalCode
-		updates.Country = userinfoUpdates.Address.Country
-		updates.StreetAddress = userinfoUpdates.Address.StreetAddress
-	}
+	updates := userinfoUpdates.ToUpdateUserInfo()
 	userInfoRow, err := repository.UpdateUserInfoByUserId(user.Id, updates)
 	if err != nil {
 		slog.Error("failed to fetch user info", "error", err)
@@ -0,0 +1,624 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicacia/auth/api/app/access"
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// userCSVColumns are the columns of the csv import and export, lists are separated by userCSVListSeparator
+var userCSVColumns = []string{
+	"username", "password", "encrypted_password",
+	"emails", "confirmed_emails", "phone_numbers", "confirmed_phone_numbers", "roles",
+	"name", "given_name", "family_name", "middle_name", "nickname", "profile", "picture", "website",
+	"gender", "birthdate", "zoneinfo", "locale", "street_address", "locality", "region", "postal_code", "country",
+}
+
+// userCSVExportColumns adds the columns written by the export and ignored by the import
+var userCSVExportColumns = append(append([]string{"id"}, userCSVColumns...), "updated_at", "created_at")
+
+const userCSVListSeparator = ";"
+
+// importUserHashWorkers bounds how many plain passwords of an import are hashed at once
+const importUserHashWorkers = 4
+
+type importUserLineST struct {
+	line   int
+	user   model.ImportUserST
+	errors *model.ErrorST
+}
+
+// PostImportUsers
+//
+//	@Summary		Import users
+//	@Description	Imports users from csv or json lines, valid users are inserted in batches and every invalid line is reported with its errors, at most user.import_max_rows lines per request
+//	@ID				import-users
+//	@Tags			user
+//	@Accept			text/csv,application/x-ndjson
+//	@Produce		json
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			query	query		model.ImportUsersQueryST	false	"query"
+//	@Param			users	body    model.ImportUserST	true	"one user per line, or csv with a header row"
+//	@Success		200	{object}   	model.ImportUsersResultST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/users/import [post]
+//
+//	@Security		Authorization
+func PostImportUsers(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "write"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var query model.ImportUsersQueryST
+	if err := c.QueryParser(&query); err != nil {
+		slog.Error("failed to parse query", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("query", "invalid")
+	}
+	queryErrors := model.NewError(http.StatusBadRequest)
+	phoneRegion := ""
+	if region := validatePhoneRegion(queryErrors, query.DefaultPhoneRegion); region != nil {
+		phoneRegion = *region
+	}
+	if queryErrors.HasErrors() {
+		return queryErrors
+	}
+	format := userImportFormat(query.Format, c.Get(fiber.HeaderContentType))
+	var lines []importUserLineST
+	switch format {
+	case model.UserImportFormatCSV:
+		lines, err = readImportUsersCSV(bytes.NewReader(c.Body()), config.Get().User.ImportMaxRows)
+	case model.UserImportFormatJSONL:
+		lines, err = readImportUsersJSONL(bytes.NewReader(c.Body()), config.Get().User.ImportMaxRows)
+	default:
+		return model.NewError(http.StatusBadRequest).AddError("format", "invalid")
+	}
+	if err != nil {
+		var errorST *model.ErrorST
+		if errors.As(err, &errorST) {
+			return errorST
+		}
+		slog.Error("failed to read users", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("request", "invalid")
+	}
+	policy, err := repository.GetPasswordPolicy(int32(applicationId), 0)
+	if err != nil {
+		slog.Error("failed to get password policy", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	result := model.ImportUsersResultST{
+		Errors: make([]model.ImportUserErrorST, 0),
+	}
+	addLineErrors := func(line importUserLineST) {
+		result.Failed++
+		result.Errors = append(result.Errors, model.ImportUserErrorST{
+			Line:     line.line,
+			Username: line.user.Username,
+			Errors:   line.errors.Errors,
+		})
+	}
+	batch := make([]importUserLineST, 0, repository.ImportUsersBatchSize)
+	importBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := hashImportPasswords(batch); err != nil {
+			return err
+		}
+		users := make([]repository.ImportUserST, len(batch))
+		for i, line := range batch {
+			users[i] = importUserToRepository(line.user)
+		}
+		results, err := repository.ImportUsers(int32(applicationId), users)
+		if err != nil {
+			return err
+		}
+		for i, userResult := range results {
+			if userResult.Error != nil {
+				batch[i].errors = importUserError(batch[i].line, userResult.Error)
+				addLineErrors(batch[i])
+			} else {
+				result.Imported++
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for _, line := range lines {
+		if !line.errors.HasErrors() {
+			if err := validateImportUser(&line, policy, phoneRegion); err != nil {
+				slog.Error("failed to validate imported user", "line", line.line, "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+		}
+		if line.errors.HasErrors() {
+			addLineErrors(line)
+			continue
+		}
+		batch = append(batch, line)
+		if len(batch) >= repository.ImportUsersBatchSize {
+			if err := importBatch(); err != nil {
+				slog.Error("failed to import users", "error", err)
+				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+			}
+		}
+	}
+	if err := importBatch(); err != nil {
+		slog.Error("failed to import users", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if middleware.IsUserSubject(c) {
+		user := middleware.GetUser(c)
+		auditLog(c, user, repository.AuditActionUsersImported, map[string]interface{}{
+			"application_id": applicationId,
+			"imported":       result.Imported,
+			"failed":         result.Failed,
+		})
+	}
+	return c.JSON(result)
+}
+
+// GetExportUsers
+//
+//	@Summary		Export users
+//	@Description	Streams every user of the application as csv or json lines in the format the import accepts
+//	@ID				export-users
+//	@Tags			user
+//	@Accept			json
+//	@Produce		text/csv,application/x-ndjson
+//	@Param			applicationId	path		int	true	"application id"
+//	@Param			query	query		model.ExportUsersQueryST	false	"query"
+//	@Success		200	{object}   	model.ExportUserST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		403	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/applications/{applicationId}/users/export [get]
+//
+//	@Security		Authorization
+func GetExportUsers(c *fiber.Ctx) error {
+	if err := access.HasAction(c, "users", "read"); err != nil {
+		return err
+	}
+	applicationId, err := strconv.Atoi(c.Params("applicationId"))
+	if err != nil {
+		return model.NewError(http.StatusBadRequest).AddError("applicationId", "invalid")
+	}
+	var query model.ExportUsersQueryST
+	if err := c.QueryParser(&query); err != nil {
+		slog.Error("failed to parse query", "error", err)
+		return model.NewError(http.StatusBadRequest).AddError("query", "invalid")
+	}
+	includePasswordHashes := query.IncludePasswordHashes != nil && *query.IncludePasswordHashes
+	if includePasswordHashes {
+		if err := access.HasAction(c, "users", "write"); err != nil {
+			return err
+		}
+	}
+	format := model.UserImportFormatJSONL
+	if query.Format != nil {
+		format = *query.Format
+	}
+	var writeUser func(w *bufio.Writer, user model.ExportUserST) error
+	switch format {
+	case model.UserImportFormatCSV:
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.csv"`)
+		writeUser = writeExportUserCSV
+	case model.UserImportFormatJSONL:
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="users.jsonl"`)
+		writeUser = writeExportUserJSONL
+	default:
+		return model.NewError(http.StatusBadRequest).AddError("format", "invalid")
+	}
+	if middleware.IsUserSubject(c) {
+		user := middleware.GetUser(c)
+		auditLog(c, user, repository.AuditActionUsersExported, map[string]interface{}{
+			"application_id":          applicationId,
+			"include_password_hashes": includePasswordHashes,
+		})
+	}
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if format == model.UserImportFormatCSV {
+			writer := csv.NewWriter(w)
+			if err := writer.Write(userCSVExportColumns); err != nil {
+				slog.Error("failed to write users export header", "error", err)
+				return
+			}
+			writer.Flush()
+		}
+		afterId := int32(0)
+		for {
+			users, err := repository.GetExportUsers(int32(applicationId), afterId, repository.ImportUsersBatchSize)
+			if err != nil {
+				slog.Error("failed to get users to export", "error", err)
+				return
+			}
+			for _, user := range users {
+				if err := writeUser(w, model.ExportUserFromRow(user, includePasswordHashes)); err != nil {
+					slog.Error("failed to write exported user", "error", err)
+					return
+				}
+				afterId = user.User.Id
+			}
+			if err := w.Flush(); err != nil {
+				slog.Error("failed to flush users export", "error", err)
+				return
+			}
+			if len(users) < repository.ImportUsersBatchSize {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func userImportFormat(format *string, contentType string) string {
+	if format != nil {
+		return *format
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return model.UserImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return model.UserImportFormatJSONL
+	default:
+		return ""
+	}
+}
+
+// tooManyImportUsers is returned by the readers as soon as they reach a line past maxRows
+func tooManyImportUsers(maxRows int) error {
+	return model.NewError(http.StatusBadRequest).AddError("users", "tooMany", maxRows)
+}
+
+func readImportUsersJSONL(reader io.Reader, maxRows int) ([]importUserLineST, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []importUserLineST
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(lines) >= maxRows {
+			return nil, tooManyImportUsers(maxRows)
+		}
+		line := importUserLineST{line: lineNumber, errors: model.NewError(http.StatusBadRequest)}
+		if err := json.Unmarshal(text, &line.user); err != nil {
+			line.errors.AddError("line", "invalid")
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func readImportUsersCSV(reader io.Reader, maxRows int) ([]importUserLineST, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(userCSVExportColumns, column) {
+			return nil, model.NewError(http.StatusBadRequest).AddError("header", "invalid", column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, model.NewError(http.StatusBadRequest).AddError("header", "required", "username")
+	}
+	var lines []importUserLineST
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(lines) >= maxRows {
+			return nil, tooManyImportUsers(maxRows)
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			if !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				return nil, model.NewError(http.StatusBadRequest).AddError("line", "invalid", parseErr.StartLine)
+			}
+			line := importUserLineST{line: parseErr.StartLine, errors: model.NewError(http.StatusBadRequest)}
+			line.errors.AddError("line", "invalid")
+			lines = append(lines, line)
+			continue
+		}
+		lineNumber, _ := csvReader.FieldPos(0)
+		line := importUserLineST{line: lineNumber, errors: model.NewError(http.StatusBadRequest)}
+		line.user = importUserFromCSV(columns, record, line.errors)
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func importUserFromCSV(columns map[string]int, record []string, errors *model.ErrorST) model.ImportUserST {
+	get := func(column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	optional := func(column string) *string {
+		if value := get(column); value != "" {
+			return &value
+		}
+		return nil
+	}
+	list := func(column string) []string {
+		var values []string
+		for _, value := range strings.Split(get(column), userCSVListSeparator) {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	user := model.ImportUserST{
+		Username:          get("username"),
+		Password:          optional("password"),
+		EncryptedPassword: optional("encrypted_password"),
+		Roles:             list("roles"),
+	}
+	confirmedEmails := list("confirmed_emails")
+	for _, email := range appendMissing(list("emails"), confirmedEmails) {
+		user.Emails = append(user.Emails, model.ImportUserEmailST{Email: email, Confirmed: slices.Contains(confirmedEmails, email)})
+	}
+	confirmedPhoneNumbers := list("confirmed_phone_numbers")
+	for _, phoneNumber := range appendMissing(list("phone_numbers"), confirmedPhoneNumbers) {
+		user.PhoneNumbers = append(user.PhoneNumbers, model.ImportUserPhoneNumberST{PhoneNumber: phoneNumber, Confirmed: slices.Contains(confirmedPhoneNumbers, phoneNumber)})
+	}
+	info := repository.UpdateUserInfoST{
+		Name:          optional("name"),
+		GivenName:     optional("given_name"),
+		FamilyName:    optional("family_name"),
+		MiddleName:    optional("middle_name"),
+		Nickname:      optional("nickname"),
+		Profile:       optional("profile"),
+		Picture:       optional("picture"),
+		Website:       optional("website"),
+		Gender:        optional("gender"),
+		Zoneinfo:      optional("zoneinfo"),
+		Locale:        optional("locale"),
+		StreetAddress: optional("street_address"),
+		Locality:      optional("locality"),
+		Region:        optional("region"),
+		PostalCode:    optional("postal_code"),
+		Country:       optional("country"),
+	}
+	if birthdate := get("birthdate"); birthdate != "" {
+		parsed, err := time.Parse(time.DateOnly, birthdate)
+		if err != nil {
+			parsed, err = time.Parse(time.RFC3339, birthdate)
+		}
+		if err != nil {
+			errors.AddError("birthdate", "invalid")
+		} else {
+			info.Birthdate = &parsed
+		}
+	}
+	user.Info = &info
+	return user
+}
+
+// validateImportUser checks a parsed line, plain passwords are hashed by hashImportPasswords once the line is valid
+func validateImportUser(line *importUserLineST, policy repository.PasswordPolicyRowST, phoneRegion string) error {
+	user := &line.user
+	user.Username = strings.TrimSpace(user.Username)
+	if user.Username == "" {
+		line.errors.AddError("username", "required")
+	}
+	if user.Password != nil && user.EncryptedPassword != nil {
+		line.errors.AddError("password", "invalid")
+	}
+	if user.EncryptedPassword != nil && util.CheckPasswordHash(*user.EncryptedPassword) != nil {
+		line.errors.AddError("encrypted_password", "invalid")
+	}
+	for i, email := range user.Emails {
+		if !strings.Contains(email.Email, "@") {
+			line.errors.AddError("emails", "invalid", i)
+		}
+	}
+	for i, phoneNumber := range user.PhoneNumbers {
+		normalized, err := util.NormalizePhoneNumber(phoneNumber.PhoneNumber, phoneRegion)
+		if err != nil {
+			line.errors.AddError("phone_numbers", "invalid", i)
+		}
+		user.PhoneNumbers[i].PhoneNumber = normalized
+	}
+	user.Roles = appendMissing(nil, user.Roles)
+	if line.errors.HasErrors() {
+		return nil
+	}
+	if user.Password != nil {
+		violations, err := service.ValidatePassword(policy, user.Username, *user.Password, nil, config.Get().Password.BreachedPath)
+		if err != nil {
+			return err
+		}
+		for _, violation := range violations {
+			line.errors.AddError("password", violation.Message, violation.Parameters...)
+		}
+	}
+	return nil
+}
+
+// hashImportPasswords replaces the plain passwords of the batch with their hashes, hashing at most
+// importUserHashWorkers at once so a large import can't tie up every cpu
+func hashImportPasswords(batch []importUserLineST) error {
+	indexes := make(chan int)
+	errs := make([]error, len(batch))
+	var wg sync.WaitGroup
+	for range min(importUserHashWorkers, len(batch)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				user := &batch[i].user
+				if user.Password == nil {
+					continue
+				}
+				encryptedPassword, err := util.EncryptPassword(*user.Password)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				user.Password = nil
+				user.EncryptedPassword = &encryptedPassword
+			}
+		}()
+	}
+	for i := range batch {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func importUserToRepository(user model.ImportUserST) repository.ImportUserST {
+	// users imported without a password can't sign in with one until they set it
+	encryptedPassword := util.NoPassword
+	if user.EncryptedPassword != nil {
+		encryptedPassword = *user.EncryptedPassword
+	}
+	result := repository.ImportUserST{
+		Username:          user.Username,
+		EncryptedPassword: encryptedPassword,
+		Roles:             user.Roles,
+	}
+	for _, email := range user.Emails {
+		result.Emails = append(result.Emails, repository.ImportUserEmailST{Email: email.Email, Confirmed: email.Confirmed})
+	}
+	for _, phoneNumber := range user.PhoneNumbers {
+		result.PhoneNumbers = append(result.PhoneNumbers, repository.ImportUserPhoneNumberST{PhoneNumber: phoneNumber.PhoneNumber, Confirmed: phoneNumber.Confirmed})
+	}
+	if user.Info != nil {
+		result.Info = *user.Info
+	}
+	return result
+}
+
+func importUserError(line int, err error) *model.ErrorST {
+	importErrors := model.NewError(http.StatusBadRequest)
+	switch {
+	case errors.Is(err, repository.ErrImportUnknownRole):
+		importErrors.AddError("roles", "invalid")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_username_unique_idx"):
+		importErrors.AddError("username", "duplicate")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "emails_email_unique_idx"):
+		importErrors.AddError("emails", "duplicate")
+	case repository.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "phone_numbers_email_unique_idx"):
+		importErrors.AddError("phone_numbers", "duplicate")
+	default:
+		slog.Error("failed to import user", "line", line, "error", err)
+		importErrors.AddError("line", "invalid")
+	}
+	return importErrors
+}
+
+func writeExportUserJSONL(w *bufio.Writer, user model.ExportUserST) error {
+	bytes, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(bytes); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+func writeExportUserCSV(w *bufio.Writer, user model.ExportUserST) error {
+	value := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+	var emails, confirmedEmails, phoneNumbers, confirmedPhoneNumbers []string
+	for _, email := range user.Emails {
+		emails = append(emails, email.Email)
+		if email.Confirmed {
+			confirmedEmails = append(confirmedEmails, email.Email)
+		}
+	}
+	for _, phoneNumber := range user.PhoneNumbers {
+		phoneNumbers = append(phoneNumbers, phoneNumber.PhoneNumber)
+		if phoneNumber.Confirmed {
+			confirmedPhoneNumbers = append(confirmedPhoneNumbers, phoneNumber.PhoneNumber)
+		}
+	}
+	info := repository.UpdateUserInfoST{}
+	if user.Info != nil {
+		info = *user.Info
+	}
+	birthdate := ""
+	if info.Birthdate != nil {
+		birthdate = info.Birthdate.Format(time.RFC3339)
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		strconv.Itoa(int(user.Id)),
+		user.Username, "", value(user.EncryptedPassword),
+		strings.Join(emails, userCSVListSeparator), strings.Join(confirmedEmails, userCSVListSeparator),
+		strings.Join(phoneNumbers, userCSVListSeparator), strings.Join(confirmedPhoneNumbers, userCSVListSeparator),
+		strings.Join(user.Roles, userCSVListSeparator),
+		value(info.Name), value(info.GivenName), value(info.FamilyName), value(info.MiddleName), value(info.Nickname),
+		value(info.Profile), value(info.Picture), value(info.Website), value(info.Gender), birthdate,
+		value(info.Zoneinfo), value(info.Locale), value(info.StreetAddress), value(info.Locality), value(info.Region),
+		value(info.PostalCode), value(info.Country),
+		user.UpdatedAt.Format(time.RFC3339), user.CreatedAt.Format(time.RFC3339),
+	}); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// appendMissing appends the values not already in result, keeping the first occurrence of each
+func appendMissing(result []string, values []string) []string {
+	for _, value := range values {
+		if !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/middleware"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PostVerification
+//
+//	@Summary		Finish signing in after confirming the required email or phone number
+//	@Description	Tenents requiring a confirmed primary email or phone number issue a verification token to users that haven't confirmed it, the token can only be used to add, confirm and set primary emails and phone numbers under /verification. Once they are confirmed this returns the tokens
+//	@ID				verification
+//	@Tags			token
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	model.TokenST
+//	@Failure		400	{object}	model.ErrorST
+//	@Failure		401	{object}	model.ErrorST
+//	@Failure		500	{object}	model.ErrorST
+//	@Router			/verification [post]
+//
+//	@Security		Authorization
+func PostVerification(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	tenent := middleware.GetTenent(c)
+	claims := middleware.GetClaims[jwt.VerificationClaims](c)
+	unconfirmed, err := unconfirmedRequirements(user, tenent)
+	if err != nil {
+		slog.Error("failed to get unconfirmed requirements", "error", err)
+		return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
+	}
+	if len(unconfirmed) > 0 {
+		errors := model.NewError(http.StatusBadRequest)
+		for _, name := range unconfirmed {
+			errors.AddError(name, "unconfirmed")
+		}
+		return errors
+	}
+	return sendToken(c, sendTokenST{
+		issuedTokenType: claims.GrantType,
+		scope:           strings.Join(claims.Scope, " "),
+		application:     middleware.GetApplication(c),
+		tenent:          tenent,
+		user:            user,
+		authTime:        claims.AuthTimeSeconds,
+	})
+}
@@ -52,11 +52,7 @@ func GetOpenIDConfiguration(c *fiber.Ctx) error {
 		IdTokenSigningAlgValuesSupported: []string{
 			tenent.Algorithm,
 		},
-		TokenEndpointAuthMethodsSupported: []string{
-			"client_secret_basic",
-			"client_secret_post",
-			"client_secret_jwt",
-		},
+		TokenEndpointAuthMethodsSupported: model.TokenEndpointAuthMethods,
 		ClaimsSupported: []string{
 			"sub",
 			"type",
@@ -89,5 +85,7 @@ func GetOpenIDConfiguration(c *fiber.Ctx) error {
 			"plain",
 			"S256",
 		},
+		RequireConfirmedEmail:       tenent.RequireConfirmedEmail,
+		RequireConfirmedPhoneNumber: tenent.RequireConfirmedPhoneNumber,
 	})
 }
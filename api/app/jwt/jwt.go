@@ -39,6 +39,10 @@ var (
 	RefreshTokenType       = "refresh"
 	PasswordResetTokenType = "password-reset"
 	MFATokenType           = "mfa"
+	ConsentTokenType       = "consent"
+	InvitationTokenType    = "invitation"
+	VerificationTokenType  = "verification"
+	ConfirmationTokenType  = "confirmation"
 )
 
 type Claims struct {
@@ -49,9 +53,18 @@ type Claims struct {
 	Audiences        []string  `json:"aud" validate:"required"`
 	NotBeforeSeconds int64     `json:"nbf" validate:"required"`
 	IssuedAtSeconds  int64     `json:"iat" validate:"required"`
+	AuthTimeSeconds  int64     `json:"auth_time,omitempty"`
 	Issuer           string    `json:"iss" validate:"required"`
 	ExpiresAtSeconds int64     `json:"exp" validate:"required"`
 	Scope            []string  `json:"scope" validate:"required"`
+	Actor            *Actor    `json:"act,omitempty"`
+}
+
+// Actor is who is acting as the subject, set on impersonation tokens (RFC 8693)
+type Actor struct {
+	Subject     int32     `json:"sub" validate:"required"`
+	SubjectType string    `json:"sub_type" validate:"required"`
+	ClientId    uuid.UUID `json:"client_id" validate:"required"`
 }
 
 func (claims *Claims) ToMapClaims() (jwt.MapClaims, error) {
@@ -73,6 +86,24 @@ func (claims *MFAClaims) ToMapClaims() (jwt.MapClaims, error) {
 	return anyToMapClaims(claims)
 }
 
+type ConsentClaims struct {
+	Claims
+	GrantType string `json:"grant_type" validate:"required"`
+}
+
+func (claims *ConsentClaims) ToMapClaims() (jwt.MapClaims, error) {
+	return anyToMapClaims(claims)
+}
+
+type VerificationClaims struct {
+	Claims
+	GrantType string `json:"grant_type" validate:"required"`
+}
+
+func (claims *VerificationClaims) ToMapClaims() (jwt.MapClaims, error) {
+	return anyToMapClaims(claims)
+}
+
 type OpenIdClaimsAddress struct {
 	StreetAddress *string `json:"street_address"`
 	Locality      *string `json:"locality"`
@@ -4,7 +4,9 @@ import (
 	"log/slog"
 	"net/http"
 	"slices"
+	"time"
 
+	"github.com/aicacia/auth/api/app/config"
 	"github.com/aicacia/auth/api/app/jwt"
 	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
@@ -54,6 +56,12 @@ func AuthorizedMiddleware() fiber.Handler {
 				slog.Error("failed to fetch user", "error", err)
 				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 			}
+			if err := userAuthorized(user, claims.IssuedAtSeconds); err != nil {
+				return err
+			}
+			if claims.Actor != nil {
+				auditImpersonation(c, user, claims.Actor)
+			}
 			permissions, err := repository.GetUserPermissions(user.Id)
 			if err != nil {
 				slog.Error("failed to fetch user permissions", "error", err)
@@ -81,6 +89,48 @@ func AuthorizedMiddleware() fiber.Handler {
 	}
 }
 
+// userAuthorized rejects tokens of users that are deleted, not active or whose tokens were revoked when they were deactivated
+func userAuthorized(user *repository.UserRowST, issuedAtSeconds int64) error {
+	if user == nil || user.TokenRevoked(issuedAtSeconds) {
+		return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
+	}
+	if !user.IsActive() {
+		return model.NewError(http.StatusUnauthorized).AddError("user", user.CurrentStatus())
+	}
+	return nil
+}
+
+// auditImpersonation records every request made with an impersonation token against the impersonated user
+func auditImpersonation(c *fiber.Ctx, user *repository.UserRowST, actor *jwt.Actor) {
+	ip := c.IP()
+	_, err := repository.CreateAuditLog(repository.CreateAuditLogST{
+		ApplicationId: user.ApplicationId,
+		UserId:        &user.Id,
+		Action:        repository.AuditActionImpersonationUsed,
+		Data: map[string]interface{}{
+			"actor_subject":      actor.Subject,
+			"actor_subject_type": actor.SubjectType,
+			"actor_client_id":    actor.ClientId,
+			"method":             c.Method(),
+			"path":               c.Path(),
+		},
+		IP: &ip,
+	})
+	if err != nil {
+		slog.Error("failed to create audit log", "action", repository.AuditActionImpersonationUsed, "userId", user.Id, "error", err)
+	}
+}
+
+// NotImpersonatedMiddleware rejects impersonation tokens on endpoints that change how the user signs in
+func NotImpersonatedMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetClaims[jwt.Claims](c).Actor != nil {
+			return model.NewError(http.StatusForbidden).AddError("authorization", "impersonated")
+		}
+		return c.Next()
+	}
+}
+
 func OpenIdMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		if HasScope(c, "openid") {
@@ -141,6 +191,17 @@ func IsUserMiddleware() fiber.Handler {
 	}
 }
 
+// FreshAuthenticationMiddleware requires the user to have signed in recently, refreshing a token does not count
+func FreshAuthenticationMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := GetClaims[jwt.Claims](c)
+		if time.Now().Unix()-claims.AuthTimeSeconds <= config.Get().User.ReauthenticationMaxAgeSeconds {
+			return c.Next()
+		}
+		return model.NewError(http.StatusUnauthorized).AddError("authorization", "reauthentication_required")
+	}
+}
+
 func IsServiceAccountMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		if IsServiceAccount(c) {
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientRegistrationMiddleware answers errors from the handlers after it in the RFC 7591 error format
+func ClientRegistrationMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err == nil {
+			return nil
+		}
+		status := http.StatusInternalServerError
+		response := model.ClientRegistrationErrorST{
+			Error: "server_error",
+		}
+		var modelError *model.ErrorST
+		var fiberError *fiber.Error
+		switch {
+		case errors.As(err, &modelError):
+			status = modelError.StatusCode
+			response.ErrorDescription = errorDetail(modelError)
+		case errors.As(err, &fiberError):
+			status = fiberError.Code
+			response.ErrorDescription = fiberError.Message
+		default:
+			slog.Error("unhandled client registration error", "error", err)
+		}
+		switch {
+		case status == http.StatusUnauthorized:
+			response.Error = "invalid_token"
+		case modelError != nil && modelError.Errors["redirect_uris"] != nil:
+			response.Error = "invalid_redirect_uri"
+		case status == http.StatusBadRequest:
+			response.Error = "invalid_client_metadata"
+		}
+		return c.Status(status).JSON(response)
+	}
+}
@@ -11,6 +11,22 @@ import (
 )
 
 func MFAAuthorizedMiddleware() fiber.Handler {
+	return stepAuthorizedMiddleware[jwt.MFAClaims](jwt.MFATokenType)
+}
+
+// ConsentAuthorizedMiddleware accepts the token issued while a user has not yet approved a third party tenent's scopes
+func ConsentAuthorizedMiddleware() fiber.Handler {
+	return stepAuthorizedMiddleware[jwt.ConsentClaims](jwt.ConsentTokenType)
+}
+
+// VerificationAuthorizedMiddleware accepts the token issued while a user has not yet confirmed the email or phone
+// number its tenent requires
+func VerificationAuthorizedMiddleware() fiber.Handler {
+	return stepAuthorizedMiddleware[jwt.VerificationClaims](jwt.VerificationTokenType)
+}
+
+// stepAuthorizedMiddleware accepts a user's token of tokenType issued part way through signing in
+func stepAuthorizedMiddleware[C any](tokenType string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		_, tokenString := GetAuthorizationFromContext(c)
 		unvalidatedClaims, err := jwt.ParseClaimsFromTokenNoValidation(tokenString)
@@ -18,7 +34,7 @@ func MFAAuthorizedMiddleware() fiber.Handler {
 			slog.Error("failed to get authorization header", "error", err)
 			return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
 		}
-		if unvalidatedClaims.Type != jwt.MFATokenType {
+		if unvalidatedClaims.Type != tokenType {
 			return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
 		}
 		tenent, err := repository.GetTenentByClientId(unvalidatedClaims.ClientId)
@@ -26,7 +42,7 @@ func MFAAuthorizedMiddleware() fiber.Handler {
 			slog.Error("failed to fetch application tenent", "error", err)
 			return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
 		}
-		claims, err := jwt.ParseClaimsFromToken[jwt.MFAClaims](tokenString, tenent)
+		claims, err := jwt.ParseClaimsFromToken[C](tokenString, tenent)
 		if err != nil {
 			slog.Error("failed to parse claims from token", "error", err)
 			return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
@@ -40,13 +56,16 @@ func MFAAuthorizedMiddleware() fiber.Handler {
 		c.Locals(tenentLocalKey, tenent)
 		c.Locals(baseClaimsLocalKey, claims)
 
-		switch claims.SubjectType {
+		switch unvalidatedClaims.SubjectType {
 		case jwt.UserSubject:
-			user, err := repository.GetUserById(application.Id, claims.Subject)
+			user, err := repository.GetUserById(application.Id, unvalidatedClaims.Subject)
 			if err != nil {
 				slog.Error("failed to fetch user", "error", err)
 				return model.NewError(http.StatusInternalServerError).AddError("internal", "application")
 			}
+			if err := userAuthorized(user, unvalidatedClaims.IssuedAtSeconds); err != nil {
+				return err
+			}
 			permissions, err := repository.GetUserPermissions(user.Id)
 			if err != nil {
 				slog.Error("failed to fetch user permissions", "error", err)
@@ -56,7 +75,7 @@ func MFAAuthorizedMiddleware() fiber.Handler {
 			c.Locals(permissionsMapLocalKey, PermissionsFromRows(permissions))
 			c.Locals(userLocalKey, user)
 		default:
-			slog.Error("invalid subject type", "type", claims.SubjectType)
+			slog.Error("invalid subject type", "type", unvalidatedClaims.SubjectType)
 			return model.NewError(http.StatusUnauthorized).AddError("authorization", "invalid")
 		}
 		return c.Next()
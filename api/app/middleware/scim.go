@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/scim"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SCIMMiddleware answers errors from the handlers after it in the SCIM error format
+func SCIMMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err == nil {
+			return nil
+		}
+		status := http.StatusInternalServerError
+		response := model.SCIMErrorST{
+			Schemas: []string{scim.SchemaError},
+		}
+		var scimError *scim.ErrorST
+		var modelError *model.ErrorST
+		var fiberError *fiber.Error
+		switch {
+		case errors.As(err, &scimError):
+			status = http.StatusBadRequest
+			if scimError.Status != 0 {
+				status = scimError.Status
+			}
+			response.ScimType = scimError.Type
+			response.Detail = scimError.Detail
+		case errors.As(err, &modelError):
+			status = modelError.StatusCode
+			response.Detail = errorDetail(modelError)
+		case errors.As(err, &fiberError):
+			status = fiberError.Code
+			response.Detail = fiberError.Message
+		default:
+			slog.Error("unhandled scim error", "error", err)
+			response.Detail = http.StatusText(status)
+		}
+		response.Status = strconv.Itoa(status)
+		bytes, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, scim.ContentType)
+		return c.Status(status).Send(bytes)
+	}
+}
+
+func errorDetail(e *model.ErrorST) string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	details := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, message := range e.Errors[name] {
+			details = append(details, name+" "+message.Message)
+		}
+	}
+	return strings.Join(details, ", ")
+}
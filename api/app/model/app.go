@@ -16,7 +16,9 @@ type VersionST struct {
 	Build   string `json:"build" validate:"required"`
 } // @name Version
 
-type OffsetAndLimitQueryST struct {
-	Offset *int `query:"offset"`
-	Limit  *int `query:"limit"`
-} // @name OffsetAndLimitQuery
+type PaginationQueryST struct {
+	Offset *int    `query:"offset"`
+	Limit  *int    `query:"limit"`
+	Cursor *string `query:"cursor"`
+	Total  *string `query:"total" enums:"exact,estimated"`
+} // @name PaginationQuery
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	TokenEndpointAuthMethodNone              = "none"
+	TokenEndpointAuthMethodClientSecretBasic = "client_secret_basic"
+	TokenEndpointAuthMethodClientSecretPost  = "client_secret_post"
+)
+
+// TokenEndpointAuthMethods are the ways a client can authenticate at the token endpoint
+var TokenEndpointAuthMethods = []string{
+	TokenEndpointAuthMethodNone,
+	TokenEndpointAuthMethodClientSecretBasic,
+	TokenEndpointAuthMethodClientSecretPost,
+}
+
+type ClientRegistrationRequestST struct {
+	ClientName              string   `json:"client_name"`
+	RedirectURIs            []string `json:"redirect_uris" validate:"required"`
+	GrantTypes              []string `json:"grant_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method" enums:"none,client_secret_basic,client_secret_post"`
+} // @name ClientRegistrationRequest
+
+type ClientRegistrationST struct {
+	ClientId                uuid.UUID `json:"client_id" validate:"required"`
+	ClientSecret            string    `json:"client_secret" validate:"required"`
+	ClientIdIssuedAt        int64     `json:"client_id_issued_at" validate:"required"`
+	ClientSecretExpiresAt   int64     `json:"client_secret_expires_at" validate:"required"`
+	RegistrationAccessToken string    `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string    `json:"registration_client_uri" validate:"required"`
+	ClientName              string    `json:"client_name" validate:"required"`
+	RedirectURIs            []string  `json:"redirect_uris" validate:"required"`
+	GrantTypes              []string  `json:"grant_types" validate:"required"`
+	TokenEndpointAuthMethod string    `json:"token_endpoint_auth_method" validate:"required"`
+} // @name ClientRegistration
+
+type ClientRegistrationErrorST struct {
+	Error            string `json:"error" validate:"required"`
+	ErrorDescription string `json:"error_description,omitempty"`
+} // @name ClientRegistrationError
+
+type InitialAccessTokenST struct {
+	Id            int32      `json:"id" validate:"required"`
+	ApplicationId int32      `json:"application_id" validate:"required"`
+	Description   string     `json:"description" validate:"required"`
+	ExpiresAt     *time.Time `json:"expires_at" format:"date-time"`
+	UpdatedAt     time.Time  `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt     time.Time  `json:"created_at" validate:"required" format:"date-time"`
+} // @name InitialAccessToken
+
+func InitialAccessTokenFromRow(row repository.InitialAccessTokenRowST) InitialAccessTokenST {
+	return InitialAccessTokenST{
+		Id:            row.Id,
+		ApplicationId: row.ApplicationId,
+		Description:   row.Description,
+		ExpiresAt:     row.ExpiresAt,
+		UpdatedAt:     row.UpdatedAt,
+		CreatedAt:     row.CreatedAt,
+	}
+}
+
+type CreateInitialAccessTokenST struct {
+	repository.CreateInitialAccessTokenST
+} // @name CreateInitialAccessToken
+
+type CreatedInitialAccessTokenST struct {
+	InitialAccessTokenST
+	Token string `json:"token" validate:"required"`
+} // @name CreatedInitialAccessToken
@@ -0,0 +1,5 @@
+package model
+
+type ConfirmationST struct {
+	Token string `json:"token" validate:"required"`
+} // @name Confirmation
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+type UserConsentST struct {
+	Id                int32     `json:"id" validate:"required"`
+	TenentId          int32     `json:"tenent_id" validate:"required"`
+	TenentDescription string    `json:"tenent_description" validate:"required"`
+	TenentURI         string    `json:"tenent_uri" validate:"required"`
+	TenentClientId    uuid.UUID `json:"tenent_client_id" validate:"required"`
+	Scopes            []string  `json:"scopes" validate:"required"`
+	UpdatedAt         time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt         time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name UserConsent
+
+func UserConsentFromRow(row repository.UserConsentWithTenentRowST) UserConsentST {
+	return UserConsentST{
+		Id:                row.Id,
+		TenentId:          row.TenentId,
+		TenentDescription: row.TenentDescription,
+		TenentURI:         row.TenentURI,
+		TenentClientId:    row.TenentClientId,
+		Scopes:            row.Scopes,
+		UpdatedAt:         row.UpdatedAt,
+		CreatedAt:         row.CreatedAt,
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+type IdentityProviderST struct {
+	Id                    int32     `json:"id" validate:"required"`
+	ApplicationId         int32     `json:"application_id" validate:"required"`
+	TenentId              int32     `json:"tenent_id" validate:"required"`
+	Name                  string    `json:"name" validate:"required"`
+	Kind                  string    `json:"kind" validate:"required" enums:"oidc,github,google"`
+	Issuer                *string   `json:"issuer"`
+	AuthorizationEndpoint *string   `json:"authorization_endpoint"`
+	TokenEndpoint         *string   `json:"token_endpoint"`
+	UserinfoEndpoint      *string   `json:"userinfo_endpoint"`
+	JWKSURI               *string   `json:"jwks_uri"`
+	ClientId              string    `json:"client_id" validate:"required"`
+	Scopes                []string  `json:"scopes" validate:"required"`
+	Enabled               bool      `json:"enabled" validate:"required"`
+	RegistrationEnabled   bool      `json:"registration_enabled" validate:"required"`
+	LinkByEmail           bool      `json:"link_by_email" validate:"required"`
+	UpdatedAt             time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt             time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name IdentityProvider
+
+func IdentityProviderFromRow(row repository.IdentityProviderRowST) IdentityProviderST {
+	return IdentityProviderST{
+		Id:                    row.Id,
+		ApplicationId:         row.ApplicationId,
+		TenentId:              row.TenentId,
+		Name:                  row.Name,
+		Kind:                  row.Kind,
+		Issuer:                row.Issuer,
+		AuthorizationEndpoint: row.AuthorizationEndpoint,
+		TokenEndpoint:         row.TokenEndpoint,
+		UserinfoEndpoint:      row.UserinfoEndpoint,
+		JWKSURI:               row.JWKSURI,
+		ClientId:              row.ClientId,
+		Scopes:                row.Scopes,
+		Enabled:               row.Enabled,
+		RegistrationEnabled:   row.RegistrationEnabled,
+		LinkByEmail:           row.LinkByEmail,
+		UpdatedAt:             row.UpdatedAt,
+		CreatedAt:             row.CreatedAt,
+	}
+}
+
+type CreateIdentityProviderST struct {
+	repository.CreateIdentityProviderST
+} // @name CreateIdentityProvider
+
+type UpdateIdentityProviderST struct {
+	repository.UpdateIdentityProviderST
+} // @name UpdateIdentityProvider
+
+type PublicIdentityProviderST struct {
+	Id   int32  `json:"id" validate:"required"`
+	Name string `json:"name" validate:"required"`
+	Kind string `json:"kind" validate:"required" enums:"oidc,github,google"`
+} // @name PublicIdentityProvider
+
+func PublicIdentityProviderFromRow(row repository.IdentityProviderRowST) PublicIdentityProviderST {
+	return PublicIdentityProviderST{
+		Id:   row.Id,
+		Name: row.Name,
+		Kind: row.Kind,
+	}
+}
+
+type IdentityProviderAuthorizationST struct {
+	AuthorizationURL string `json:"authorization_url" validate:"required"`
+} // @name IdentityProviderAuthorization
+
+type UserIdentityST struct {
+	Id                   int32      `json:"id" validate:"required"`
+	IdentityProviderId   int32      `json:"identity_provider_id" validate:"required"`
+	IdentityProviderName string     `json:"identity_provider_name" validate:"required"`
+	IdentityProviderKind string     `json:"identity_provider_kind" validate:"required" enums:"oidc,github,google"`
+	Subject              string     `json:"subject" validate:"required"`
+	Email                *string    `json:"email"`
+	LastLoginAt          *time.Time `json:"last_login_at" format:"date-time"`
+	UpdatedAt            time.Time  `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt            time.Time  `json:"created_at" validate:"required" format:"date-time"`
+} // @name UserIdentity
+
+func UserIdentityFromRow(row repository.UserIdentityWithProviderRowST) UserIdentityST {
+	return UserIdentityST{
+		Id:                   row.Id,
+		IdentityProviderId:   row.IdentityProviderId,
+		IdentityProviderName: row.IdentityProviderName,
+		IdentityProviderKind: row.IdentityProviderKind,
+		Subject:              row.Subject,
+		Email:                row.Email,
+		LastLoginAt:          row.LastLoginAt,
+		UpdatedAt:            row.UpdatedAt,
+		CreatedAt:            row.CreatedAt,
+	}
+}
+
+type LinkUserIdentityST struct {
+	IdentityProviderId int32 `json:"identity_provider_id" validate:"required"`
+} // @name LinkUserIdentity
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+type InvitationST struct {
+	Id                        int32     `json:"id" validate:"required"`
+	ApplicationId             int32     `json:"application_id" validate:"required"`
+	TenentId                  int32     `json:"tenent_id" validate:"required"`
+	UserId                    *int32    `json:"user_id,omitempty"`
+	Email                     string    `json:"email" validate:"required"`
+	InvitedByUserId           *int32    `json:"invited_by_user_id,omitempty"`
+	InvitedByServiceAccountId *int32    `json:"invited_by_service_account_id,omitempty"`
+	Expired                   bool      `json:"expired" validate:"required"`
+	ExpiresAt                 time.Time `json:"expires_at" validate:"required" format:"date-time"`
+	UpdatedAt                 time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt                 time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name Invitation
+
+func InvitationFromRow(row repository.InvitationRowST) InvitationST {
+	return InvitationST{
+		Id:                        row.Id,
+		ApplicationId:             row.ApplicationId,
+		TenentId:                  row.TenentId,
+		UserId:                    row.UserId,
+		Email:                     row.Email,
+		InvitedByUserId:           row.InvitedByUserId,
+		InvitedByServiceAccountId: row.InvitedByServiceAccountId,
+		Expired:                   row.IsExpired(),
+		ExpiresAt:                 row.ExpiresAt,
+		UpdatedAt:                 row.UpdatedAt,
+		CreatedAt:                 row.CreatedAt,
+	}
+}
+
+type CreateInvitationST struct {
+	TenentId int32   `json:"tenent_id" validate:"required"`
+	Email    string  `json:"email" validate:"required"`
+	Username *string `json:"username,omitempty"`
+} // @name CreateInvitation
+
+type AcceptInvitationST struct {
+	Token                string                   `json:"token" validate:"required"`
+	Password             string                   `json:"password" validate:"required"`
+	PasswordConfirmation string                   `json:"password_confirmation" validate:"required"`
+	Username             *string                  `json:"username,omitempty"`
+	Info                 *UpdateUserInfoRequestST `json:"info,omitempty"`
+} // @name AcceptInvitation
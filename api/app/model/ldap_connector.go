@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+type LDAPConnectorST struct {
+	Id                  int32               `json:"id" validate:"required"`
+	ApplicationId       int32               `json:"application_id" validate:"required"`
+	URL                 string              `json:"url" validate:"required"`
+	StartTLS            bool                `json:"start_tls" validate:"required"`
+	TLSSkipVerify       bool                `json:"tls_skip_verify" validate:"required"`
+	TLSRootCA           *string             `json:"tls_root_ca"`
+	BindDN              string              `json:"bind_dn" validate:"required"`
+	UserSearchBase      string              `json:"user_search_base" validate:"required"`
+	UserFilter          string              `json:"user_filter" validate:"required"`
+	UsernameAttribute   string              `json:"username_attribute" validate:"required"`
+	EmailAttribute      string              `json:"email_attribute" validate:"required"`
+	NameAttribute       string              `json:"name_attribute" validate:"required"`
+	GivenNameAttribute  string              `json:"given_name_attribute" validate:"required"`
+	FamilyNameAttribute string              `json:"family_name_attribute" validate:"required"`
+	GroupSearchBase     *string             `json:"group_search_base"`
+	GroupFilter         string              `json:"group_filter" validate:"required"`
+	GroupNameAttribute  string              `json:"group_name_attribute" validate:"required"`
+	GroupRoleMapping    map[string][]string `json:"group_role_mapping" validate:"required"`
+	RegistrationEnabled bool                `json:"registration_enabled" validate:"required"`
+	Enabled             bool                `json:"enabled" validate:"required"`
+	UpdatedAt           time.Time           `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt           time.Time           `json:"created_at" validate:"required" format:"date-time"`
+} // @name LDAPConnector
+
+func LDAPConnectorFromRow(row repository.LDAPConnectorRowST) LDAPConnectorST {
+	groupRoleMapping, err := row.GroupRoles()
+	if err != nil {
+		groupRoleMapping = make(map[string][]string)
+	}
+	return LDAPConnectorST{
+		Id:                  row.Id,
+		ApplicationId:       row.ApplicationId,
+		URL:                 row.URL,
+		StartTLS:            row.StartTLS,
+		TLSSkipVerify:       row.TLSSkipVerify,
+		TLSRootCA:           row.TLSRootCA,
+		BindDN:              row.BindDN,
+		UserSearchBase:      row.UserSearchBase,
+		UserFilter:          row.UserFilter,
+		UsernameAttribute:   row.UsernameAttribute,
+		EmailAttribute:      row.EmailAttribute,
+		NameAttribute:       row.NameAttribute,
+		GivenNameAttribute:  row.GivenNameAttribute,
+		FamilyNameAttribute: row.FamilyNameAttribute,
+		GroupSearchBase:     row.GroupSearchBase,
+		GroupFilter:         row.GroupFilter,
+		GroupNameAttribute:  row.GroupNameAttribute,
+		GroupRoleMapping:    groupRoleMapping,
+		RegistrationEnabled: row.RegistrationEnabled,
+		Enabled:             row.Enabled,
+		UpdatedAt:           row.UpdatedAt,
+		CreatedAt:           row.CreatedAt,
+	}
+}
+
+type CreateLDAPConnectorST struct {
+	repository.CreateLDAPConnectorST
+} // @name CreateLDAPConnector
+
+type UpdateLDAPConnectorST struct {
+	repository.UpdateLDAPConnectorST
+} // @name UpdateLDAPConnector
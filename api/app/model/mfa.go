@@ -1,5 +1,43 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
 type ValidateMFAST struct {
-	Code string `json:"code" validate:"required"`
+	Type       *string         `json:"type"`
+	Code       string          `json:"code"`
+	Credential json.RawMessage `json:"credential,omitempty" swaggertype:"object"`
+}
+
+type RecoveryCodesST struct {
+	Codes []string `json:"codes" validate:"required"`
+} // @name RecoveryCodes
+
+type RecoveryCodesStatusST struct {
+	Remaining int `json:"remaining" validate:"required"`
+} // @name RecoveryCodesStatus
+
+type MFAST struct {
+	Id            int32     `json:"id" validate:"required"`
+	Type          string    `json:"type" validate:"required"`
+	Preferred     bool      `json:"preferred" validate:"required"`
+	Enabled       bool      `json:"enabled" validate:"required"`
+	RecoveryCodes []string  `json:"recovery_codes,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt     time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name MFA
+
+func MFAFromRow(row repository.MFARowST) MFAST {
+	return MFAST{
+		Id:        row.Id,
+		Type:      row.Type,
+		Preferred: row.Preferred,
+		Enabled:   row.Enabled,
+		UpdatedAt: row.UpdatedAt,
+		CreatedAt: row.CreatedAt,
+	}
 }
@@ -1,6 +1,23 @@
 package model
 
+import "github.com/aicacia/auth/api/app/repository"
+
 type PaginationST[T any] struct {
-	HasMore bool `json:"has_more" validate:"required"`
-	Items   []T  `json:"items" validate:"required"`
+	HasMore        bool    `json:"has_more" validate:"required"`
+	Next           *string `json:"next,omitempty"`
+	Prev           *string `json:"prev,omitempty"`
+	Total          *int64  `json:"total,omitempty"`
+	TotalEstimated bool    `json:"total_estimated,omitempty"`
+	Items          []T     `json:"items" validate:"required"`
 } // @name Pagination
+
+func PaginationFromPage[R any, T any](page *repository.PageResultST[R], items []T) PaginationST[T] {
+	return PaginationST[T]{
+		HasMore:        page.Next != nil,
+		Next:           page.Next,
+		Prev:           page.Prev,
+		Total:          page.Total,
+		TotalEstimated: page.TotalEstimated,
+		Items:          items,
+	}
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+type PasswordPolicyST struct {
+	ApplicationId    int32     `json:"application_id" validate:"required"`
+	TenentId         *int32    `json:"tenent_id"`
+	MinLength        int32     `json:"min_length" validate:"required"`
+	MaxLength        int32     `json:"max_length" validate:"required"`
+	RequireLowercase bool      `json:"require_lowercase" validate:"required"`
+	RequireUppercase bool      `json:"require_uppercase" validate:"required"`
+	RequireDigit     bool      `json:"require_digit" validate:"required"`
+	RequireSymbol    bool      `json:"require_symbol" validate:"required"`
+	DisallowUsername bool      `json:"disallow_username" validate:"required"`
+	HistoryCount     int32     `json:"history_count" validate:"required"`
+	CheckBreached    bool      `json:"check_breached" validate:"required"`
+	UpdatedAt        time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt        time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name PasswordPolicy
+
+func PasswordPolicyFromRow(row repository.PasswordPolicyRowST) PasswordPolicyST {
+	return PasswordPolicyST{
+		ApplicationId:    row.ApplicationId,
+		TenentId:         row.TenentId,
+		MinLength:        row.MinLength,
+		MaxLength:        row.MaxLength,
+		RequireLowercase: row.RequireLowercase,
+		RequireUppercase: row.RequireUppercase,
+		RequireDigit:     row.RequireDigit,
+		RequireSymbol:    row.RequireSymbol,
+		DisallowUsername: row.DisallowUsername,
+		HistoryCount:     row.HistoryCount,
+		CheckBreached:    row.CheckBreached,
+		UpdatedAt:        row.UpdatedAt,
+		CreatedAt:        row.CreatedAt,
+	}
+}
+
+type UpdatePasswordPolicyST struct {
+	repository.UpsertPasswordPolicyST
+} // @name UpdatePasswordPolicy
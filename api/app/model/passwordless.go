@@ -0,0 +1,5 @@
+package model
+
+type RequestPasswordlessST struct {
+	Email string `json:"email" validate:"required"`
+} // @name RequestPasswordless
@@ -1,7 +1,84 @@
 package model
 
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
 type RegistrationRequestST struct {
-	Username             string `json:"username"`
-	Password             string `json:"password"`
-	PasswordConfirmation string `json:"password_confirmation"`
+	Username             string                   `json:"username"`
+	Password             string                   `json:"password"`
+	PasswordConfirmation string                   `json:"password_confirmation"`
+	Email                *string                  `json:"email,omitempty"`
+	PhoneNumber          *string                  `json:"phone_number,omitempty"`
+	Info                 *UpdateUserInfoRequestST `json:"info,omitempty"`
+	InviteCode           *string                  `json:"invite_code,omitempty"`
 } // @name RegistrationRequest
+
+type RegistrationSchemaST struct {
+	ApplicationId       int32     `json:"application_id" validate:"required"`
+	TenentId            int32     `json:"tenent_id" validate:"required"`
+	Mode                string    `json:"mode" validate:"required" enums:"open,invite_code,closed"`
+	RequireEmail        bool      `json:"require_email" validate:"required"`
+	RequirePhoneNumber  bool      `json:"require_phone_number" validate:"required"`
+	RequiredInfo        []string  `json:"required_info" validate:"required"`
+	AllowedEmailDomains []string  `json:"allowed_email_domains" validate:"required"`
+	BlockedEmailDomains []string  `json:"blocked_email_domains" validate:"required"`
+	UpdatedAt           time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt           time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name RegistrationSchema
+
+func RegistrationSchemaFromRow(row repository.RegistrationSchemaRowST) RegistrationSchemaST {
+	return RegistrationSchemaST{
+		ApplicationId:       row.ApplicationId,
+		TenentId:            row.TenentId,
+		Mode:                row.Mode,
+		RequireEmail:        row.RequireEmail,
+		RequirePhoneNumber:  row.RequirePhoneNumber,
+		RequiredInfo:        row.RequiredInfo,
+		AllowedEmailDomains: row.AllowedEmailDomains,
+		BlockedEmailDomains: row.BlockedEmailDomains,
+		UpdatedAt:           row.UpdatedAt,
+		CreatedAt:           row.CreatedAt,
+	}
+}
+
+type UpdateRegistrationSchemaST struct {
+	repository.UpsertRegistrationSchemaST
+} // @name UpdateRegistrationSchema
+
+type RegistrationInviteCodeST struct {
+	Id            int32      `json:"id" validate:"required"`
+	ApplicationId int32      `json:"application_id" validate:"required"`
+	TenentId      int32      `json:"tenent_id" validate:"required"`
+	Description   string     `json:"description" validate:"required"`
+	MaxUses       *int32     `json:"max_uses"`
+	Uses          int32      `json:"uses" validate:"required"`
+	ExpiresAt     *time.Time `json:"expires_at" format:"date-time"`
+	UpdatedAt     time.Time  `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt     time.Time  `json:"created_at" validate:"required" format:"date-time"`
+} // @name RegistrationInviteCode
+
+func RegistrationInviteCodeFromRow(row repository.RegistrationInviteCodeRowST) RegistrationInviteCodeST {
+	return RegistrationInviteCodeST{
+		Id:            row.Id,
+		ApplicationId: row.ApplicationId,
+		TenentId:      row.TenentId,
+		Description:   row.Description,
+		MaxUses:       row.MaxUses,
+		Uses:          row.Uses,
+		ExpiresAt:     row.ExpiresAt,
+		UpdatedAt:     row.UpdatedAt,
+		CreatedAt:     row.CreatedAt,
+	}
+}
+
+type CreateRegistrationInviteCodeST struct {
+	repository.CreateRegistrationInviteCodeST
+} // @name CreateRegistrationInviteCode
+
+type CreatedRegistrationInviteCodeST struct {
+	RegistrationInviteCodeST
+	Code string `json:"code" validate:"required"`
+} // @name CreatedRegistrationInviteCode
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+type SAMLServiceProviderST struct {
+	Id               int32             `json:"id" validate:"required"`
+	ApplicationId    int32             `json:"application_id" validate:"required"`
+	TenentId         int32             `json:"tenent_id" validate:"required"`
+	Name             string            `json:"name" validate:"required"`
+	EntityId         string            `json:"entity_id" validate:"required"`
+	Metadata         *string           `json:"metadata"`
+	ACSURL           *string           `json:"acs_url"`
+	NameIdFormat     string            `json:"name_id_format" validate:"required" enums:"persistent,email,unspecified,transient"`
+	AttributeMapping map[string]string `json:"attribute_mapping" validate:"required"`
+	Enabled          bool              `json:"enabled" validate:"required"`
+	UpdatedAt        time.Time         `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt        time.Time         `json:"created_at" validate:"required" format:"date-time"`
+} // @name SAMLServiceProvider
+
+func SAMLServiceProviderFromRow(row repository.SAMLServiceProviderRowST) SAMLServiceProviderST {
+	attributeMapping, err := row.Attributes()
+	if err != nil {
+		attributeMapping = make(map[string]string)
+	}
+	return SAMLServiceProviderST{
+		Id:               row.Id,
+		ApplicationId:    row.ApplicationId,
+		TenentId:         row.TenentId,
+		Name:             row.Name,
+		EntityId:         row.EntityId,
+		Metadata:         row.Metadata,
+		ACSURL:           row.ACSURL,
+		NameIdFormat:     row.NameIdFormat,
+		AttributeMapping: attributeMapping,
+		Enabled:          row.Enabled,
+		UpdatedAt:        row.UpdatedAt,
+		CreatedAt:        row.CreatedAt,
+	}
+}
+
+type CreateSAMLServiceProviderST struct {
+	repository.CreateSAMLServiceProviderST
+} // @name CreateSAMLServiceProvider
+
+type UpdateSAMLServiceProviderST struct {
+	repository.UpdateSAMLServiceProviderST
+} // @name UpdateSAMLServiceProvider
+
+type CompleteSAMLRequestST struct {
+	SAMLRequest string `json:"saml_request" validate:"required"`
+} // @name CompleteSAMLRequest
+
+type SAMLResponseST struct {
+	URL          string `json:"url" validate:"required"`
+	SAMLResponse string `json:"saml_response" validate:"required"`
+	RelayState   string `json:"relay_state" validate:"required"`
+} // @name SAMLResponse
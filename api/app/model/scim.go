@@ -0,0 +1,173 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type SCIMErrorST struct {
+	Schemas  []string `json:"schemas" validate:"required"`
+	Status   string   `json:"status" validate:"required"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+} // @name SCIMError
+
+type SCIMMetaST struct {
+	ResourceType string     `json:"resourceType" validate:"required"`
+	Created      *time.Time `json:"created,omitempty" format:"date-time"`
+	LastModified *time.Time `json:"lastModified,omitempty" format:"date-time"`
+	Location     string     `json:"location,omitempty"`
+} // @name SCIMMeta
+
+type SCIMListResponseST[T any] struct {
+	Schemas      []string `json:"schemas" validate:"required"`
+	TotalResults int      `json:"totalResults" validate:"required"`
+	StartIndex   int      `json:"startIndex" validate:"required"`
+	ItemsPerPage int      `json:"itemsPerPage" validate:"required"`
+	Resources    []T      `json:"Resources" validate:"required"`
+} // @name SCIMListResponse
+
+type SCIMListQueryST struct {
+	Filter             *string `query:"filter"`
+	StartIndex         *int    `query:"startIndex"`
+	Count              *int    `query:"count"`
+	ExcludedAttributes *string `query:"excludedAttributes"`
+} // @name SCIMListQuery
+
+type SCIMNameST struct {
+	Formatted  *string `json:"formatted,omitempty"`
+	FamilyName *string `json:"familyName,omitempty"`
+	GivenName  *string `json:"givenName,omitempty"`
+	MiddleName *string `json:"middleName,omitempty"`
+} // @name SCIMName
+
+type SCIMMultiValuedST struct {
+	Value   string  `json:"value" validate:"required"`
+	Display *string `json:"display,omitempty"`
+	Type    *string `json:"type,omitempty"`
+	Primary bool    `json:"primary,omitempty"`
+} // @name SCIMMultiValued
+
+type SCIMAddressST struct {
+	Formatted     *string `json:"formatted,omitempty"`
+	StreetAddress *string `json:"streetAddress,omitempty"`
+	Locality      *string `json:"locality,omitempty"`
+	Region        *string `json:"region,omitempty"`
+	PostalCode    *string `json:"postalCode,omitempty"`
+	Country       *string `json:"country,omitempty"`
+	Type          *string `json:"type,omitempty"`
+	Primary       bool    `json:"primary,omitempty"`
+} // @name SCIMAddress
+
+type SCIMReferenceST struct {
+	Value   string  `json:"value" validate:"required"`
+	Ref     string  `json:"$ref,omitempty"`
+	Display *string `json:"display,omitempty"`
+	Type    *string `json:"type,omitempty"`
+} // @name SCIMReference
+
+type SCIMUserST struct {
+	Schemas      []string            `json:"schemas" validate:"required"`
+	Id           string              `json:"id,omitempty"`
+	ExternalId   *string             `json:"externalId,omitempty"`
+	UserName     string              `json:"userName" validate:"required"`
+	Name         *SCIMNameST         `json:"name,omitempty"`
+	DisplayName  *string             `json:"displayName,omitempty"`
+	NickName     *string             `json:"nickName,omitempty"`
+	ProfileUrl   *string             `json:"profileUrl,omitempty"`
+	Locale       *string             `json:"locale,omitempty"`
+	Timezone     *string             `json:"timezone,omitempty"`
+	Active       *bool               `json:"active,omitempty"`
+	Password     *string             `json:"password,omitempty"`
+	Emails       []SCIMMultiValuedST `json:"emails,omitempty"`
+	PhoneNumbers []SCIMMultiValuedST `json:"phoneNumbers,omitempty"`
+	Photos       []SCIMMultiValuedST `json:"photos,omitempty"`
+	Addresses    []SCIMAddressST     `json:"addresses,omitempty"`
+	Groups       []SCIMReferenceST   `json:"groups,omitempty"`
+	Meta         *SCIMMetaST         `json:"meta,omitempty"`
+} // @name SCIMUser
+
+type SCIMGroupST struct {
+	Schemas     []string          `json:"schemas" validate:"required"`
+	Id          string            `json:"id,omitempty"`
+	ExternalId  *string           `json:"externalId,omitempty"`
+	DisplayName string            `json:"displayName" validate:"required"`
+	Members     []SCIMReferenceST `json:"members,omitempty"`
+	Meta        *SCIMMetaST       `json:"meta,omitempty"`
+} // @name SCIMGroup
+
+type SCIMPatchOperationST struct {
+	Op    string          `json:"op" validate:"required"`
+	Path  *string         `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+} // @name SCIMPatchOperation
+
+type SCIMPatchST struct {
+	Schemas    []string               `json:"schemas" validate:"required"`
+	Operations []SCIMPatchOperationST `json:"Operations" validate:"required"`
+} // @name SCIMPatch
+
+type SCIMSupportedST struct {
+	Supported bool `json:"supported" validate:"required"`
+} // @name SCIMSupported
+
+type SCIMFilterSupportedST struct {
+	Supported  bool `json:"supported" validate:"required"`
+	MaxResults int  `json:"maxResults" validate:"required"`
+} // @name SCIMFilterSupported
+
+type SCIMBulkSupportedST struct {
+	Supported      bool `json:"supported" validate:"required"`
+	MaxOperations  int  `json:"maxOperations" validate:"required"`
+	MaxPayloadSize int  `json:"maxPayloadSize" validate:"required"`
+} // @name SCIMBulkSupported
+
+type SCIMAuthenticationSchemeST struct {
+	Type        string `json:"type" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description" validate:"required"`
+	Primary     bool   `json:"primary,omitempty"`
+} // @name SCIMAuthenticationScheme
+
+type SCIMServiceProviderConfigST struct {
+	Schemas               []string                     `json:"schemas" validate:"required"`
+	Patch                 SCIMSupportedST              `json:"patch" validate:"required"`
+	Bulk                  SCIMBulkSupportedST          `json:"bulk" validate:"required"`
+	Filter                SCIMFilterSupportedST        `json:"filter" validate:"required"`
+	ChangePassword        SCIMSupportedST              `json:"changePassword" validate:"required"`
+	Sort                  SCIMSupportedST              `json:"sort" validate:"required"`
+	ETag                  SCIMSupportedST              `json:"etag" validate:"required"`
+	AuthenticationSchemes []SCIMAuthenticationSchemeST `json:"authenticationSchemes" validate:"required"`
+	Meta                  SCIMMetaST                   `json:"meta" validate:"required"`
+} // @name SCIMServiceProviderConfig
+
+type SCIMResourceTypeST struct {
+	Schemas     []string   `json:"schemas" validate:"required"`
+	Id          string     `json:"id" validate:"required"`
+	Name        string     `json:"name" validate:"required"`
+	Endpoint    string     `json:"endpoint" validate:"required"`
+	Description string     `json:"description" validate:"required"`
+	Schema      string     `json:"schema" validate:"required"`
+	Meta        SCIMMetaST `json:"meta" validate:"required"`
+} // @name SCIMResourceType
+
+type SCIMSchemaAttributeST struct {
+	Name          string                  `json:"name" validate:"required"`
+	Type          string                  `json:"type" validate:"required"`
+	MultiValued   bool                    `json:"multiValued"`
+	Required      bool                    `json:"required"`
+	CaseExact     bool                    `json:"caseExact"`
+	Mutability    string                  `json:"mutability" validate:"required"`
+	Returned      string                  `json:"returned" validate:"required"`
+	Uniqueness    string                  `json:"uniqueness" validate:"required"`
+	SubAttributes []SCIMSchemaAttributeST `json:"subAttributes,omitempty"`
+} // @name SCIMSchemaAttribute
+
+type SCIMSchemaST struct {
+	Schemas     []string                `json:"schemas" validate:"required"`
+	Id          string                  `json:"id" validate:"required"`
+	Name        string                  `json:"name" validate:"required"`
+	Description string                  `json:"description" validate:"required"`
+	Attributes  []SCIMSchemaAttributeST `json:"attributes" validate:"required"`
+	Meta        SCIMMetaST              `json:"meta" validate:"required"`
+} // @name SCIMSchema
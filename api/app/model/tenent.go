@@ -20,6 +20,17 @@ type TenentST struct {
 	ExpiresInSeconds              int64     `json:"expires_in_seconds" validate:"required"`
 	RefreshExpiresInSeconds       int64     `json:"refresh_expires_in_seconds" validate:"required"`
 	PasswordResetExpiresInSeconds int64     `json:"password_reset_expires_in_seconds" validate:"required"`
+	PasswordlessEnabled           bool      `json:"passwordless_enabled" validate:"required"`
+	PasswordlessRegistration      bool      `json:"passwordless_registration_enabled" validate:"required"`
+	ThirdParty                    bool      `json:"third_party" validate:"required"`
+	RedirectURIs                  []string  `json:"redirect_uris" validate:"required"`
+	GrantTypes                    []string  `json:"grant_types" validate:"required"`
+	TokenEndpointAuthMethod       string    `json:"token_endpoint_auth_method" validate:"required"`
+	Registered                    bool      `json:"registered" validate:"required"`
+	RequireConfirmedEmail         bool      `json:"require_confirmed_email" validate:"required"`
+	RequireConfirmedPhoneNumber   bool      `json:"require_confirmed_phone_number" validate:"required"`
+	DefaultPhoneRegion            *string   `json:"default_phone_region,omitempty"`
+	FoldEmailAliases              bool      `json:"fold_email_aliases" validate:"required"`
 	UpdatedAt                     time.Time `json:"updated_at" validate:"required" format:"date-time"`
 	CreatedAt                     time.Time `json:"created_at" validate:"required" format:"date-time"`
 } // @name Tenent
@@ -38,6 +49,17 @@ func TenentFromRow(row repository.TenentRowST) TenentST {
 		ExpiresInSeconds:              row.ExpiresInSeconds,
 		RefreshExpiresInSeconds:       row.RefreshExpiresInSeconds,
 		PasswordResetExpiresInSeconds: row.PasswordResetExpiresInSeconds,
+		PasswordlessEnabled:           row.PasswordlessEnabled,
+		PasswordlessRegistration:      row.PasswordlessRegistration,
+		ThirdParty:                    row.ThirdParty,
+		RedirectURIs:                  row.RedirectURIs,
+		GrantTypes:                    row.GrantTypes,
+		TokenEndpointAuthMethod:       row.TokenEndpointAuthMethod,
+		Registered:                    row.EncryptedRegistrationAccessToken != nil,
+		RequireConfirmedEmail:         row.RequireConfirmedEmail,
+		RequireConfirmedPhoneNumber:   row.RequireConfirmedPhoneNumber,
+		DefaultPhoneRegion:            row.DefaultPhoneRegion,
+		FoldEmailAliases:              row.FoldEmailAliases,
 		UpdatedAt:                     row.UpdatedAt,
 		CreatedAt:                     row.CreatedAt,
 	}
@@ -52,6 +74,6 @@ type UpdateTenentST struct {
 } // @name UpdateTenent
 
 type TenentsIdsQueryST struct {
-	OffsetAndLimitQueryST
+	PaginationQueryST
 	Ids []int32 `query:"ids"`
 } // @name TenentsIdsQuery
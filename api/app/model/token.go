@@ -1,19 +1,26 @@
 package model
 
 var (
-	PasswordGrantType      = "password"
-	ServieAccountGrantType = "service-account"
-	RefreshTokenGrantType  = "refresh-token"
-	PassKeyGrantType       = "pass-key-token"
+	PasswordGrantType         = "password"
+	ServieAccountGrantType    = "service-account"
+	RefreshTokenGrantType     = "refresh-token"
+	PassKeyGrantType          = "pass-key-token"
+	PasswordlessGrantType     = "passwordless"
+	IdentityProviderGrantType = "identity-provider"
+	// ImpersonationTokenType is only issued by the admin impersonation endpoint, never accepted as a grant type
+	ImpersonationTokenType = "impersonation"
 )
 
 type TokenRequestST struct {
 	GrantType          string `json:"grant_type" validate:"required"`
+	ClientId           string `json:"client_id"`
+	ClientSecret       string `json:"client_secret"`
 	Code               string `json:"code"`
 	RefreshToken       string `json:"refresh_token"`
 	Key                string `json:"key"`
 	Secret             string `json:"secret"`
 	Username           string `json:"username"`
+	Email              string `json:"email"`
 	Password           string `json:"password"`
 	Scope              string `json:"scope"`
 	Assertion          string `json:"assertion"`
@@ -36,4 +43,6 @@ type TokenST struct {
 	RefreshToken          *string  `json:"refresh_token,omitempty" validate:"required"`
 	RefreshTokenExpiresIn *int64   `json:"refresh_token_expires_in,omitempty" validate:"required"`
 	IdToken               *string  `json:"id_token,omitempty"`
+	MFAMethods            []string `json:"mfa_methods,omitempty"`
+	VerificationRequired  []string `json:"verification_required,omitempty" enums:"email,phone_number"`
 } // @name Token
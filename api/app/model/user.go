@@ -46,7 +46,8 @@ func TOTPFromRow(row repository.TOTPRowST) TOTPST {
 
 type TOTPWithSecretST struct {
 	TOTPST
-	Secret string `json:"secret" validate:"required"`
+	Secret        string   `json:"secret" validate:"required"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 } // @name TOTPWithSecret
 
 func TOTPWithSecretFromRow(row repository.TOTPRowST) TOTPWithSecretST {
@@ -57,17 +58,40 @@ func TOTPWithSecretFromRow(row repository.TOTPRowST) TOTPWithSecretST {
 }
 
 type UserST struct {
-	Id            int32           `json:"id" validate:"required"`
-	ApplicationId int32           `json:"application_id" validate:"required"`
-	Email         *EmailST        `json:"email"`
-	Emails        []EmailST       `json:"emails" validate:"required"`
-	PhoneNumber   *PhoneNumberST  `json:"phone_number"`
-	PhoneNumbers  []PhoneNumberST `json:"phone_numbers" validate:"required"`
-	Username      string          `json:"username" validate:"required"`
-	UpdatedAt     time.Time       `json:"updated_at" validate:"required" format:"date-time"`
-	CreatedAt     time.Time       `json:"created_at" validate:"required" format:"date-time"`
+	Id              int32           `json:"id" validate:"required"`
+	ApplicationId   int32           `json:"application_id" validate:"required"`
+	Email           *EmailST        `json:"email"`
+	Emails          []EmailST       `json:"emails" validate:"required"`
+	PhoneNumber     *PhoneNumberST  `json:"phone_number"`
+	PhoneNumbers    []PhoneNumberST `json:"phone_numbers" validate:"required"`
+	Username        string          `json:"username" validate:"required"`
+	LDAPBacked      bool            `json:"ldap_backed" validate:"required"`
+	Status          string          `json:"status" validate:"required" enums:"active,disabled,locked,pending"`
+	StatusReason    *string         `json:"status_reason,omitempty"`
+	StatusExpiresAt *time.Time      `json:"status_expires_at,omitempty" format:"date-time"`
+	UpdatedAt       time.Time       `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt       time.Time       `json:"created_at" validate:"required" format:"date-time"`
 } // @name User
 
+type UsersQueryST struct {
+	PaginationQueryST
+	Username             *string `query:"username" form:"username"`
+	Email                *string `query:"email" form:"email"`
+	PhoneNumber          *string `query:"phone_number" form:"phone_number"`
+	EmailConfirmed       *bool   `query:"email_confirmed" form:"email_confirmed"`
+	PhoneNumberConfirmed *bool   `query:"phone_number_confirmed" form:"phone_number_confirmed"`
+	Role                 *string `query:"role" form:"role"`
+	MFAEnabled           *bool   `query:"mfa_enabled" form:"mfa_enabled"`
+	Status               *string `query:"status" form:"status" enums:"active,disabled,locked,pending"`
+	CreatedAfter         *string `query:"created_after" form:"created_after" format:"date-time"`
+	CreatedBefore        *string `query:"created_before" form:"created_before" format:"date-time"`
+	UpdatedAfter         *string `query:"updated_after" form:"updated_after" format:"date-time"`
+	UpdatedBefore        *string `query:"updated_before" form:"updated_before" format:"date-time"`
+	Q                    *string `query:"q" form:"q"`
+	Sort                 *string `query:"sort" form:"sort" enums:"created_at,updated_at,username"`
+	Order                *string `query:"order" form:"order" enums:"asc,desc"`
+} // @name UsersQuery
+
 type UserWithPermissionsST struct {
 	UserST
 	Permissions map[string][]string `json:"permissions" validate:"required"`
@@ -94,16 +118,27 @@ func UserFromRow(userRow repository.UserRowST, emailRows []repository.EmailRowST
 			phoneNumbers = append(phoneNumbers, phoneNumber)
 		}
 	}
+	status := userRow.CurrentStatus()
+	var statusReason *string
+	var statusExpiresAt *time.Time
+	if status != repository.UserStatusActive {
+		statusReason = userRow.StatusReason
+		statusExpiresAt = userRow.StatusExpiresAt
+	}
 	return UserST{
-		Id:            userRow.Id,
-		ApplicationId: userRow.ApplicationId,
-		Email:         primaryEmail,
-		Emails:        emails,
-		PhoneNumber:   primaryPhoneNumber,
-		PhoneNumbers:  phoneNumbers,
-		Username:      userRow.Username,
-		UpdatedAt:     userRow.UpdatedAt,
-		CreatedAt:     userRow.CreatedAt,
+		Id:              userRow.Id,
+		ApplicationId:   userRow.ApplicationId,
+		Email:           primaryEmail,
+		Emails:          emails,
+		PhoneNumber:     primaryPhoneNumber,
+		PhoneNumbers:    phoneNumbers,
+		Username:        userRow.Username,
+		LDAPBacked:      userRow.LDAPBacked,
+		Status:          status,
+		StatusReason:    statusReason,
+		StatusExpiresAt: statusExpiresAt,
+		UpdatedAt:       userRow.UpdatedAt,
+		CreatedAt:       userRow.CreatedAt,
 	}
 }
 
@@ -137,6 +172,21 @@ type UpdateUserST struct {
 	Username string `json:"username" validate:"required"`
 } // @name UpdateUser
 
+type UpdateUserLDAPST struct {
+	LDAPBacked bool `json:"ldap_backed" validate:"required"`
+} // @name UpdateUserLDAP
+
+type UpdateUserStatusST struct {
+	Status    string     `json:"status" validate:"required" enums:"active,disabled,locked,pending"`
+	Reason    *string    `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" format:"date-time"`
+} // @name UpdateUserStatus
+
+type ImpersonateUserST struct {
+	TenentId int32   `json:"tenent_id" validate:"required"`
+	Scope    *string `json:"scope,omitempty"`
+} // @name ImpersonateUser
+
 type CreateEmailST struct {
 	Email string `json:"email" validate:"required"`
 } // @name CreateEmail
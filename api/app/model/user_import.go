@@ -0,0 +1,119 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+const (
+	UserImportFormatCSV   = "csv"
+	UserImportFormatJSONL = "jsonl"
+)
+
+type ImportUserEmailST struct {
+	Email     string `json:"email" validate:"required"`
+	Confirmed bool   `json:"confirmed"`
+} // @name ImportUserEmail
+
+type ImportUserPhoneNumberST struct {
+	PhoneNumber string `json:"phone_number" validate:"required"`
+	Confirmed   bool   `json:"confirmed"`
+} // @name ImportUserPhoneNumber
+
+type ImportUserST struct {
+	Username          string                       `json:"username" validate:"required"`
+	Password          *string                      `json:"password,omitempty"`
+	EncryptedPassword *string                      `json:"encrypted_password,omitempty"`
+	Emails            []ImportUserEmailST          `json:"emails,omitempty"`
+	PhoneNumbers      []ImportUserPhoneNumberST    `json:"phone_numbers,omitempty"`
+	Roles             []string                     `json:"roles,omitempty"`
+	Info              *repository.UpdateUserInfoST `json:"info,omitempty"`
+} // @name ImportUser
+
+type ImportUsersQueryST struct {
+	Format *string `query:"format" enums:"csv,jsonl"`
+	// DefaultPhoneRegion is the region phone numbers without a calling code are read as, without one they must be international
+	DefaultPhoneRegion *string `query:"default_phone_region"`
+} // @name ImportUsersQuery
+
+type ImportUserErrorST struct {
+	Line     int                          `json:"line" validate:"required"`
+	Username string                       `json:"username"`
+	Errors   map[string][]*ErrorMessageST `json:"errors" validate:"required"`
+} // @name ImportUserError
+
+type ImportUsersResultST struct {
+	Imported int                 `json:"imported" validate:"required"`
+	Failed   int                 `json:"failed" validate:"required"`
+	Errors   []ImportUserErrorST `json:"errors" validate:"required"`
+} // @name ImportUsersResult
+
+type ExportUsersQueryST struct {
+	Format                *string `query:"format" enums:"csv,jsonl"`
+	IncludePasswordHashes *bool   `query:"include_password_hashes"`
+} // @name ExportUsersQuery
+
+type ExportUserST struct {
+	Id int32 `json:"id" validate:"required"`
+	ImportUserST
+	UpdatedAt time.Time `json:"updated_at" validate:"required" format:"date-time"`
+	CreatedAt time.Time `json:"created_at" validate:"required" format:"date-time"`
+} // @name ExportUser
+
+// ExportUserFromRow builds an export line in the same shape the import accepts, the primary email and
+// phone number first so they stay primary when imported again
+func ExportUserFromRow(row repository.UserDetailsST, includePasswordHash bool) ExportUserST {
+	user := ExportUserST{
+		Id: row.User.Id,
+		ImportUserST: ImportUserST{
+			Username: row.User.Username,
+		},
+		UpdatedAt: row.User.UpdatedAt,
+		CreatedAt: row.User.CreatedAt,
+	}
+	for _, role := range row.Roles {
+		user.Roles = append(user.Roles, role.URI)
+	}
+	if includePasswordHash {
+		user.EncryptedPassword = &row.User.EncryptedPassword
+	}
+	for _, email := range row.Emails {
+		importEmail := ImportUserEmailST{Email: email.Email, Confirmed: email.Confirmed}
+		if row.User.EmailId != nil && *row.User.EmailId == email.Id {
+			user.Emails = append([]ImportUserEmailST{importEmail}, user.Emails...)
+		} else {
+			user.Emails = append(user.Emails, importEmail)
+		}
+	}
+	for _, phoneNumber := range row.PhoneNumbers {
+		importPhoneNumber := ImportUserPhoneNumberST{PhoneNumber: phoneNumber.PhoneNumber, Confirmed: phoneNumber.Confirmed}
+		if row.User.PhoneNumberId != nil && *row.User.PhoneNumberId == phoneNumber.Id {
+			user.PhoneNumbers = append([]ImportUserPhoneNumberST{importPhoneNumber}, user.PhoneNumbers...)
+		} else {
+			user.PhoneNumbers = append(user.PhoneNumbers, importPhoneNumber)
+		}
+	}
+	if row.Info != nil {
+		user.Info = &repository.UpdateUserInfoST{
+			Name:          row.Info.Name,
+			GivenName:     row.Info.GivenName,
+			FamilyName:    row.Info.FamilyName,
+			MiddleName:    row.Info.MiddleName,
+			Nickname:      row.Info.Nickname,
+			Profile:       row.Info.Profile,
+			Picture:       row.Info.Picture,
+			Website:       row.Info.Website,
+			Gender:        row.Info.Gender,
+			Birthdate:     row.Info.Birthdate,
+			Zoneinfo:      row.Info.Zoneinfo,
+			Locale:        row.Info.Locale,
+			StreetAddress: row.Info.StreetAddress,
+			Locality:      row.Info.Locality,
+			Region:        row.Info.Region,
+			PostalCode:    row.Info.PostalCode,
+			Country:       row.Info.Country,
+		}
+	}
+	return user
+}
@@ -30,6 +30,31 @@ type UpdateUserInfoRequestST struct {
 	Address    *UserInfoAddressST `json:"address"`
 } // @name UpdateUserInfoRequest
 
+func (request *UpdateUserInfoRequestST) ToUpdateUserInfo() repository.UpdateUserInfoST {
+	updates := repository.UpdateUserInfoST{
+		Name:       request.Name,
+		GivenName:  request.GivenName,
+		FamilyName: request.FamilyName,
+		MiddleName: request.MiddleName,
+		Nickname:   request.Nickname,
+		Profile:    request.Profile,
+		Picture:    request.Picture,
+		Website:    request.Website,
+		Gender:     request.Gender,
+		Birthdate:  request.Birthdate,
+		Zoneinfo:   request.Zoneinfo,
+		Locale:     request.Locale,
+	}
+	if request.Address != nil {
+		updates.Region = request.Address.Region
+		updates.Locality = request.Address.Locality
+		updates.PostalCode = request.Address.PostalCode
+		updates.Country = request.Address.Country
+		updates.StreetAddress = request.Address.StreetAddress
+	}
+	return updates
+}
+
 type UserInfoST struct {
 	UserId            int32             `json:"user_id" validate:"required"`
 	PreferredUsername string            `json:"preferred_username" validate:"required"`
@@ -15,4 +15,6 @@ type OpenIDConfigurationST = struct {
 	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported" validate:"required"`
 	ClaimsSupported                   []string `json:"claims_supported" validate:"required"`
 	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported" validate:"required"`
+	RequireConfirmedEmail             bool     `json:"require_confirmed_email" validate:"required"`
+	RequireConfirmedPhoneNumber       bool     `json:"require_confirmed_phone_number" validate:"required"`
 } // @name OpenIDConfiguration
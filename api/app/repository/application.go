@@ -14,45 +14,22 @@ type ApplicationRowST struct {
 	CreatedAt   time.Time `db:"created_at"`
 }
 
-func GetApplications(limit, offset *int) ([]ApplicationRowST, error) {
-	if limit == nil && offset == nil {
-		return All[ApplicationRowST](`SELECT a.* 
-			FROM applications a 
-			ORDER BY a.updated_at DESC ;`)
-	}
-	if limit == nil {
-		limit = new(int)
-		*limit = 10
-	}
-	if offset == nil {
-		offset = new(int)
-		*offset = 0
-	}
-	return All[ApplicationRowST](`SELECT a.* 
-		FROM applications a 
-		ORDER BY a.updated_at DESC 
-		LIMIT $1 OFFSET $2;`, limit, offset)
+var applicationsKeyset = KeysetST[ApplicationRowST]{
+	Name:       "updated_at",
+	Column:     "a.updated_at",
+	IdColumn:   "a.id",
+	Time:       true,
+	Descending: true,
+	Key:        func(row ApplicationRowST) interface{} { return row.UpdatedAt },
+	Id:         func(row ApplicationRowST) int32 { return row.Id },
 }
 
-func GetApplicationById(id int32) (*ApplicationRowST, error) {
-	return GetOptional[ApplicationRowST]("SELECT a.* FROM applications a WHERE a.id=$1 LIMIT 1;", id)
+func GetApplications(page PageST) (*PageResultST[ApplicationRowST], error) {
+	return Paginate(`SELECT a.* FROM applications a`, ``, nil, applicationsKeyset, page)
 }
 
-func GetApplicationUsers(id int32) ([]UserRowST, error) {
-	return All[UserRowST](`SELECT u.* 
-		FROM application_users au 
-		JOIN users u ON u.id=au.user_id
-		WHERE au.application_id=$1;`,
-		id)
-}
-
-func GetUserApplications(userId int32, limit, offset int) ([]ApplicationRowST, error) {
-	return All[ApplicationRowST](`SELECT a.* 
-		FROM applications a 
-		JOIN application_users au ON au.application_id=a.id
-		JOIN users u ON u.id=au.user_id
-		WHERE u.id=$1
-		LIMIT $2 OFFSET $3;`, userId, limit, offset)
+func GetApplicationById(id int32) (*ApplicationRowST, error) {
+	return GetOptional[ApplicationRowST]("SELECT a.* FROM applications a WHERE a.id=$1 LIMIT 1;", id)
 }
 
 type CreateApplicationST struct {
@@ -86,11 +63,3 @@ func UpdateApplication(id int32, update UpdateApplicationST) (*ApplicationRowST,
 func DeleteApplication(id int32) (bool, error) {
 	return Execute(`DELETE FROM applications WHERE id=$1;`, id)
 }
-
-func AddUserToApplication(applicationId, userId int32) (bool, error) {
-	return Execute(`INSERT INTO application_users (application_id, user_id) VALUES ($1, $2);`, applicationId, userId)
-}
-
-func RemoveUserFromApplication(applicationId, userId int32) (bool, error) {
-	return Execute(`DELETE FROM application_users WHERE application_id=$1 AND user_id=$2;`, applicationId, userId)
-}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionRecoveryCodeUsed       = "recovery_code.used"
+	AuditActionRecoveryCodesGenerated = "recovery_codes.generated"
+	AuditActionUsersImported          = "users.imported"
+	AuditActionUsersExported          = "users.exported"
+	AuditActionIdentityLinked         = "identity.linked"
+	AuditActionIdentityUnlinked       = "identity.unlinked"
+	AuditActionSAMLAssertionIssued    = "saml.assertion_issued"
+	AuditActionConsentGranted         = "consent.granted"
+	AuditActionConsentRevoked         = "consent.revoked"
+	AuditActionUserStatusChanged      = "user.status_changed"
+	AuditActionImpersonationStarted   = "impersonation.started"
+	AuditActionImpersonationUsed      = "impersonation.used"
+	AuditActionInvitationCreated      = "invitation.created"
+	AuditActionInvitationResent       = "invitation.resent"
+	AuditActionInvitationRevoked      = "invitation.revoked"
+	AuditActionInvitationAccepted     = "invitation.accepted"
+)
+
+type AuditLogRowST struct {
+	Id            int32     `db:"id"`
+	ApplicationId int32     `db:"application_id"`
+	UserId        *int32    `db:"user_id"`
+	Action        string    `db:"action"`
+	Data          string    `db:"data"`
+	IP            *string   `db:"ip"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+type CreateAuditLogST struct {
+	ApplicationId int32
+	UserId        *int32
+	Action        string
+	Data          map[string]interface{}
+	IP            *string
+}
+
+func CreateAuditLog(create CreateAuditLogST) (AuditLogRowST, error) {
+	if create.Data == nil {
+		create.Data = make(map[string]interface{})
+	}
+	data, err := json.Marshal(create.Data)
+	if err != nil {
+		return AuditLogRowST{}, err
+	}
+	return Get[AuditLogRowST](`INSERT INTO audit_logs (application_id, user_id, action, data, ip)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *;`,
+		create.ApplicationId, create.UserId, create.Action, string(data), create.IP)
+}
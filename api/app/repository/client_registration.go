@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type InitialAccessTokenRowST struct {
+	Id             int32      `db:"id"`
+	ApplicationId  int32      `db:"application_id"`
+	Description    string     `db:"description"`
+	EncryptedToken string     `db:"encrypted_token"`
+	ExpiresAt      *time.Time `db:"expires_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+func GetInitialAccessTokens(applicationId int32) ([]InitialAccessTokenRowST, error) {
+	return All[InitialAccessTokenRowST](`SELECT iat.*
+		FROM initial_access_tokens iat
+		WHERE iat.application_id = $1
+		ORDER BY iat.created_at DESC;`,
+		applicationId)
+}
+
+// GetInitialAccessToken returns the unexpired initial access token
+func GetInitialAccessToken(token string) (*InitialAccessTokenRowST, error) {
+	return GetOptional[InitialAccessTokenRowST](`SELECT iat.*
+		FROM initial_access_tokens iat
+		WHERE iat.encrypted_token = $1 AND (iat.expires_at IS NULL OR iat.expires_at > NOW())
+		LIMIT 1;`,
+		util.HashToken(token))
+}
+
+type CreateInitialAccessTokenST struct {
+	Description      string `json:"description" validate:"required"`
+	ExpiresInSeconds *int64 `json:"expires_in_seconds"`
+}
+
+// CreateInitialAccessToken returns the token clients register with, only its hash is stored
+func CreateInitialAccessToken(applicationId int32, create CreateInitialAccessTokenST) (InitialAccessTokenRowST, string, error) {
+	token, err := util.GenerateRandomHex(32)
+	if err != nil {
+		return InitialAccessTokenRowST{}, "", err
+	}
+	row, err := Get[InitialAccessTokenRowST](`INSERT INTO initial_access_tokens (application_id, description, encrypted_token, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		RETURNING *;`,
+		applicationId, create.Description, util.HashToken(token), create.ExpiresInSeconds)
+	return row, token, err
+}
+
+func DeleteInitialAccessToken(applicationId, id int32) (bool, error) {
+	return Execute(`DELETE FROM initial_access_tokens WHERE application_id = $1 AND id = $2;`, applicationId, id)
+}
+
+// ClientRegistrationST is the client metadata a client registers itself with
+type ClientRegistrationST struct {
+	ClientName              string
+	RedirectURIs            []string
+	GrantTypes              []string
+	TokenEndpointAuthMethod string
+}
+
+// RegisterClient creates a third party tenent for a client registering itself and returns its registration access
+// token, only the token's hash is stored
+func RegisterClient(applicationId int32, registration ClientRegistrationST) (TenentRowST, string, error) {
+	registrationAccessToken, err := util.GenerateRandomHex(32)
+	if err != nil {
+		return TenentRowST{}, "", err
+	}
+	clientId := uuid.New()
+	tenent, err := Get[TenentRowST](`INSERT INTO tenents
+		(application_id, client_id, description, uri, authorization_website, third_party, redirect_uris, grant_types, token_endpoint_auth_method, encrypted_registration_access_token)
+		VALUES ($1, $2, $3, $4, $5, true, $6, $7, $8, $9)
+		RETURNING *;`,
+		applicationId, clientId, registration.ClientName, clientId.String(), registration.RedirectURIs[0],
+		pq.StringArray(registration.RedirectURIs), pq.StringArray(registration.GrantTypes), registration.TokenEndpointAuthMethod,
+		util.HashToken(registrationAccessToken))
+	return tenent, registrationAccessToken, err
+}
+
+// GetRegisteredClient returns the tenent a client registered if registrationAccessToken is its registration access token
+func GetRegisteredClient(clientId uuid.UUID, registrationAccessToken string) (*TenentRowST, error) {
+	return GetOptional[TenentRowST](`SELECT t.*
+		FROM tenents t
+		WHERE t.client_id = $1 AND t.encrypted_registration_access_token = $2
+		LIMIT 1;`,
+		clientId, util.HashToken(registrationAccessToken))
+}
+
+// UpdateRegisteredClient replaces a registered client's metadata
+func UpdateRegisteredClient(id int32, registration ClientRegistrationST) (*TenentRowST, error) {
+	return GetOptional[TenentRowST](`UPDATE tenents SET
+		description = $2,
+		authorization_website = $3,
+		redirect_uris = $4,
+		grant_types = $5,
+		token_endpoint_auth_method = $6
+		WHERE id = $1
+		RETURNING *;`,
+		id, registration.ClientName, registration.RedirectURIs[0], pq.StringArray(registration.RedirectURIs),
+		pq.StringArray(registration.GrantTypes), registration.TokenEndpointAuthMethod)
+}
@@ -49,3 +49,11 @@ func listenerEventCallback(event pq.ListenerEventType, err error) {
 		log.Println("Connection attempt failed")
 	}
 }
+
+func UpsertConfig(key, value string) (bool, error) {
+	return Execute(`INSERT INTO configs ("key", "value")
+		VALUES ($1, $2)
+		ON CONFLICT ("key") DO UPDATE
+		SET "value" = $2;`,
+		key, value)
+}
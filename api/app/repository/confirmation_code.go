@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	ConfirmationTargetEmail       = "email"
+	ConfirmationTargetPhoneNumber = "phone_number"
+)
+
+type ConfirmationCodeRowST struct {
+	Id             int32      `db:"id"`
+	ApplicationId  int32      `db:"application_id"`
+	UserId         int32      `db:"user_id"`
+	EmailId        *int32     `db:"email_id"`
+	PhoneNumberId  *int32     `db:"phone_number_id"`
+	EncryptedCode  string     `db:"encrypted_code"`
+	EncryptedToken string     `db:"encrypted_token"`
+	Attempts       int32      `db:"attempts"`
+	UsedAt         *time.Time `db:"used_at"`
+	ExpiresAt      time.Time  `db:"expires_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+// confirmationTargetColumn is the confirmation_codes column referencing the target, target is one of the
+// ConfirmationTarget constants and never user input
+func confirmationTargetColumn(target string) string {
+	if target == ConfirmationTargetPhoneNumber {
+		return "phone_number_id"
+	}
+	return "email_id"
+}
+
+func confirmationTargetTable(target string) string {
+	if target == ConfirmationTargetPhoneNumber {
+		return "phone_numbers"
+	}
+	return "emails"
+}
+
+func GetConfirmationCodeSendStats(target string, targetId int32) (MFACodeSendStatsST, error) {
+	return Get[MFACodeSendStatsST](`SELECT MAX(cc.created_at) AS last_sent_at,
+			COUNT(*) FILTER (WHERE cc.created_at > NOW() - INTERVAL '1 hour') AS sent_in_last_hour
+		FROM confirmation_codes cc
+		WHERE cc.`+confirmationTargetColumn(target)+` = $1;`,
+		targetId)
+}
+
+// CreateConfirmationCode creates a numeric code to type in for the target and stores the hash of the signed link
+// token sent with it, codes sent before for the target stop working
+func CreateConfirmationCode(applicationId, userId int32, target string, targetId int32, token string, expiresInSeconds int64) (string, error) {
+	code, err := util.GenerateRandomDigits(6)
+	if err != nil {
+		return "", err
+	}
+	column := confirmationTargetColumn(target)
+	_, err = Transaction(func(tx *sqlx.Tx) (bool, error) {
+		_, err := tx.Exec(`UPDATE confirmation_codes SET expires_at = NOW()
+			WHERE `+column+` = $1 AND used_at IS NULL AND expires_at > NOW();`,
+			targetId)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(`INSERT INTO confirmation_codes (application_id, user_id, `+column+`, encrypted_code, encrypted_token, expires_at)
+			VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6));`,
+			applicationId, userId, targetId, util.HashToken(code), util.HashToken(token), expiresInSeconds)
+		return err == nil, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// UseConfirmationCode confirms the user's target if code matches its latest code, returns one of the MFACode results
+func UseConfirmationCode(userId int32, target string, targetId int32, code string, maxAttempts int32) (string, error) {
+	column := confirmationTargetColumn(target)
+	return Transaction(func(tx *sqlx.Tx) (string, error) {
+		var row ConfirmationCodeRowST
+		err := tx.Get(&row, `SELECT cc.*
+			FROM confirmation_codes cc
+			WHERE cc.user_id = $1 AND cc.`+column+` = $2 AND cc.used_at IS NULL AND cc.expires_at > NOW()
+			ORDER BY cc.created_at DESC
+			LIMIT 1
+			FOR UPDATE;`,
+			userId, targetId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return MFACodeExpired, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if row.Attempts >= maxAttempts {
+			return MFACodeExceeded, nil
+		}
+		if row.EncryptedCode != util.HashToken(code) {
+			_, err := tx.Exec(`UPDATE confirmation_codes SET attempts = attempts + 1 WHERE id = $1;`, row.Id)
+			if err != nil {
+				return "", err
+			}
+			return MFACodeInvalid, nil
+		}
+		if err := useConfirmationCode(tx, target, &row); err != nil {
+			return "", err
+		}
+		return MFACodeValid, nil
+	})
+}
+
+// UseConfirmationToken confirms the target of the code the link token was sent with, returns nil if the token was
+// already used, replaced by a newer code or expired
+func UseConfirmationToken(userId int32, token string) (*ConfirmationCodeRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (*ConfirmationCodeRowST, error) {
+		var row ConfirmationCodeRowST
+		err := tx.Get(&row, `SELECT cc.*
+			FROM confirmation_codes cc
+			WHERE cc.user_id = $1 AND cc.encrypted_token = $2 AND cc.used_at IS NULL AND cc.expires_at > NOW()
+			LIMIT 1
+			FOR UPDATE;`,
+			userId, util.HashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		target := ConfirmationTargetEmail
+		if row.PhoneNumberId != nil {
+			target = ConfirmationTargetPhoneNumber
+		}
+		if err := useConfirmationCode(tx, target, &row); err != nil {
+			return nil, err
+		}
+		return &row, nil
+	})
+}
+
+func useConfirmationCode(tx *sqlx.Tx, target string, row *ConfirmationCodeRowST) error {
+	targetId := row.EmailId
+	if target == ConfirmationTargetPhoneNumber {
+		targetId = row.PhoneNumberId
+	}
+	_, err := tx.Exec(`UPDATE confirmation_codes SET used_at = NOW() WHERE id = $1;`, row.Id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE `+confirmationTargetTable(target)+` SET confirmed = true WHERE user_id = $1 AND id = $2;`,
+		row.UserId, *targetId)
+	return err
+}
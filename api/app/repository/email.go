@@ -5,14 +5,13 @@ import (
 )
 
 type EmailRowST struct {
-	Id                int32     `db:"id"`
-	ApplicationId     int32     `db:"application_id"`
-	UserId            int32     `db:"user_id"`
-	Email             string    `db:"email"`
-	Confirmed         bool      `db:"confirmed"`
-	ConfirmationToken *string   `db:"confirmation_token"`
-	UpdatedAt         time.Time `db:"updated_at"`
-	CreatedAt         time.Time `db:"created_at"`
+	Id            int32     `db:"id"`
+	ApplicationId int32     `db:"application_id"`
+	UserId        int32     `db:"user_id"`
+	Email         string    `db:"email"`
+	Confirmed     bool      `db:"confirmed"`
+	UpdatedAt     time.Time `db:"updated_at"`
+	CreatedAt     time.Time `db:"created_at"`
 }
 
 func GetEmailsByUserId(userId int32) ([]EmailRowST, error) {
@@ -31,31 +30,25 @@ func GetUserPrimaryEmail(userId int32) (*EmailRowST, error) {
 		userId)
 }
 
-func CreateEmail(applicationId, userId int32, email, confirmationToken string) (EmailRowST, error) {
-	return Get[EmailRowST](`INSERT INTO emails (application_id, user_id, email, confirmation_token)
-		VALUES ($1, $2, $3, $4)
-		RETURNING *;`,
-		applicationId, userId, email, confirmationToken)
+// IsEmailAliasTaken reports whether any email of the application folds to the same address as email
+func IsEmailAliasTaken(applicationId int32, email string) (bool, error) {
+	return Get[bool](`SELECT EXISTS(SELECT 1 FROM emails e WHERE e.application_id = $1 AND folded_email(e.email) = folded_email($2));`,
+		applicationId, email)
 }
 
-func SetEmailConfirmation(userId, id int32, confirmationToken string) (EmailRowST, error) {
-	return Get[EmailRowST](`UPDATE emails SET
-		confirmation_token=COALESCE($3, confirmation_token)
-		WHERE user_id=$1 
-			AND id=$2
-		RETURNING *;`,
-		userId, id, confirmationToken)
+func GetEmailById(userId, id int32) (*EmailRowST, error) {
+	return GetOptional[EmailRowST](`SELECT e.*
+		FROM emails e
+		WHERE e.user_id = $1 AND e.id = $2
+		LIMIT 1;`,
+		userId, id)
 }
 
-func ConfirmEmail(userId, id int32, confirmationToken string) (EmailRowST, error) {
-	return Get[EmailRowST](`UPDATE emails SET
-		confirmed=true,
-		confirmation_token=NULL
-		WHERE user_id=$1 
-			AND id=$2
-			AND confirmation_token=$3
+func CreateEmail(applicationId, userId int32, email string) (EmailRowST, error) {
+	return Get[EmailRowST](`INSERT INTO emails (application_id, user_id, email)
+		VALUES ($1, $2, $3)
 		RETURNING *;`,
-		userId, id, confirmationToken)
+		applicationId, userId, email)
 }
 
 func SetPrimaryEmail(userId, id int32) (bool, error) {
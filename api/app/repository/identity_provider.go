@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/lib/pq"
+)
+
+const (
+	IdentityProviderKindOIDC   = "oidc"
+	IdentityProviderKindGitHub = "github"
+	IdentityProviderKindGoogle = "google"
+)
+
+type IdentityProviderRowST struct {
+	Id                    int32          `db:"id"`
+	ApplicationId         int32          `db:"application_id"`
+	TenentId              int32          `db:"tenent_id"`
+	Name                  string         `db:"name"`
+	Kind                  string         `db:"kind"`
+	Issuer                *string        `db:"issuer"`
+	AuthorizationEndpoint *string        `db:"authorization_endpoint"`
+	TokenEndpoint         *string        `db:"token_endpoint"`
+	UserinfoEndpoint      *string        `db:"userinfo_endpoint"`
+	JWKSURI               *string        `db:"jwks_uri"`
+	ClientId              string         `db:"client_id"`
+	ClientSecret          string         `db:"client_secret"`
+	Scopes                pq.StringArray `db:"scopes"`
+	Enabled               bool           `db:"enabled"`
+	RegistrationEnabled   bool           `db:"registration_enabled"`
+	LinkByEmail           bool           `db:"link_by_email"`
+	UpdatedAt             time.Time      `db:"updated_at"`
+	CreatedAt             time.Time      `db:"created_at"`
+}
+
+func GetIdentityProviders(tenentId int32) ([]IdentityProviderRowST, error) {
+	return All[IdentityProviderRowST](`SELECT ip.*
+		FROM identity_providers ip
+		WHERE ip.tenent_id = $1
+		ORDER BY ip.name;`,
+		tenentId)
+}
+
+func GetEnabledIdentityProviders(tenentId int32) ([]IdentityProviderRowST, error) {
+	return All[IdentityProviderRowST](`SELECT ip.*
+		FROM identity_providers ip
+		WHERE ip.tenent_id = $1 AND ip.enabled
+		ORDER BY ip.name;`,
+		tenentId)
+}
+
+func GetIdentityProviderById(id int32) (*IdentityProviderRowST, error) {
+	return GetOptional[IdentityProviderRowST](`SELECT ip.*
+		FROM identity_providers ip
+		WHERE ip.id = $1
+		LIMIT 1;`,
+		id)
+}
+
+type CreateIdentityProviderST struct {
+	Name                  string   `json:"name" validate:"required"`
+	Kind                  string   `json:"kind" validate:"required" enums:"oidc,github,google"`
+	Issuer                *string  `json:"issuer"`
+	AuthorizationEndpoint *string  `json:"authorization_endpoint"`
+	TokenEndpoint         *string  `json:"token_endpoint"`
+	UserinfoEndpoint      *string  `json:"userinfo_endpoint"`
+	JWKSURI               *string  `json:"jwks_uri"`
+	ClientId              string   `json:"client_id" validate:"required"`
+	ClientSecret          string   `json:"client_secret" validate:"required"`
+	Scopes                []string `json:"scopes"`
+	Enabled               *bool    `json:"enabled"`
+	RegistrationEnabled   *bool    `json:"registration_enabled"`
+	LinkByEmail           *bool    `json:"link_by_email"`
+}
+
+func CreateIdentityProvider(applicationId, tenentId int32, create CreateIdentityProviderST) (IdentityProviderRowST, error) {
+	return Get[IdentityProviderRowST](`INSERT INTO identity_providers
+		(application_id, tenent_id, name, kind, issuer, authorization_endpoint, token_endpoint, userinfo_endpoint, jwks_uri,
+			client_id, client_secret, scopes, enabled, registration_enabled, link_by_email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, ARRAY[]::VARCHAR[]), COALESCE($13, true), COALESCE($14, true), COALESCE($15, false))
+		RETURNING *;`,
+		applicationId, tenentId, create.Name, create.Kind, create.Issuer, create.AuthorizationEndpoint, create.TokenEndpoint,
+		create.UserinfoEndpoint, create.JWKSURI, create.ClientId, create.ClientSecret, pq.StringArray(create.Scopes),
+		create.Enabled, create.RegistrationEnabled, create.LinkByEmail)
+}
+
+type UpdateIdentityProviderST struct {
+	Name                  *string  `json:"name"`
+	Issuer                *string  `json:"issuer"`
+	AuthorizationEndpoint *string  `json:"authorization_endpoint"`
+	TokenEndpoint         *string  `json:"token_endpoint"`
+	UserinfoEndpoint      *string  `json:"userinfo_endpoint"`
+	JWKSURI               *string  `json:"jwks_uri"`
+	ClientId              *string  `json:"client_id"`
+	ClientSecret          *string  `json:"client_secret"`
+	Scopes                []string `json:"scopes"`
+	Enabled               *bool    `json:"enabled"`
+	RegistrationEnabled   *bool    `json:"registration_enabled"`
+	LinkByEmail           *bool    `json:"link_by_email"`
+}
+
+func UpdateIdentityProvider(id int32, update UpdateIdentityProviderST) (*IdentityProviderRowST, error) {
+	var scopes interface{}
+	if update.Scopes != nil {
+		scopes = pq.StringArray(update.Scopes)
+	}
+	return GetOptional[IdentityProviderRowST](`UPDATE identity_providers SET
+		name = COALESCE($2, name),
+		issuer = COALESCE($3, issuer),
+		authorization_endpoint = COALESCE($4, authorization_endpoint),
+		token_endpoint = COALESCE($5, token_endpoint),
+		userinfo_endpoint = COALESCE($6, userinfo_endpoint),
+		jwks_uri = COALESCE($7, jwks_uri),
+		client_id = COALESCE($8, client_id),
+		client_secret = COALESCE($9, client_secret),
+		scopes = COALESCE($10, scopes),
+		enabled = COALESCE($11, enabled),
+		registration_enabled = COALESCE($12, registration_enabled),
+		link_by_email = COALESCE($13, link_by_email)
+		WHERE id = $1
+		RETURNING *;`,
+		id, update.Name, update.Issuer, update.AuthorizationEndpoint, update.TokenEndpoint, update.UserinfoEndpoint,
+		update.JWKSURI, update.ClientId, update.ClientSecret, scopes, update.Enabled, update.RegistrationEnabled,
+		update.LinkByEmail)
+}
+
+func DeleteIdentityProvider(id int32) (bool, error) {
+	return Execute(`DELETE FROM identity_providers WHERE id = $1;`, id)
+}
+
+type IdentityProviderLoginRowST struct {
+	Id                 int32      `db:"id"`
+	IdentityProviderId int32      `db:"identity_provider_id"`
+	EncryptedState     string     `db:"encrypted_state"`
+	Nonce              string     `db:"nonce"`
+	CodeVerifier       string     `db:"code_verifier"`
+	UserId             *int32     `db:"user_id"`
+	LinkUserId         *int32     `db:"link_user_id"`
+	EncryptedCode      *string    `db:"encrypted_code"`
+	UsedAt             *time.Time `db:"used_at"`
+	ExpiresAt          time.Time  `db:"expires_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+	CreatedAt          time.Time  `db:"created_at"`
+}
+
+// CreateIdentityProviderLogin starts a login with an upstream provider, the state is stored hashed, when linkUserId is
+// set the upstream identity is linked to that user instead of signing in
+func CreateIdentityProviderLogin(identityProviderId int32, linkUserId *int32, state, nonce, codeVerifier string, expiresInSeconds int64) (IdentityProviderLoginRowST, error) {
+	return Get[IdentityProviderLoginRowST](`INSERT INTO identity_provider_logins (identity_provider_id, link_user_id, encrypted_state, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6))
+		RETURNING *;`,
+		identityProviderId, linkUserId, util.HashToken(state), nonce, codeVerifier, expiresInSeconds)
+}
+
+// UseIdentityProviderLoginState returns the pending login for the state the upstream provider redirected back with,
+// a state can only be used once
+func UseIdentityProviderLoginState(state string) (*IdentityProviderLoginRowST, error) {
+	return GetOptional[IdentityProviderLoginRowST](`UPDATE identity_provider_logins SET
+		used_at = NOW()
+		WHERE encrypted_state = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING *;`,
+		util.HashToken(state))
+}
+
+// CreateIdentityProviderLoginCode completes a login for the user and returns the code the client exchanges for tokens
+func CreateIdentityProviderLoginCode(id, userId int32) (string, error) {
+	code, err := util.GenerateRandomHex(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = Execute(`UPDATE identity_provider_logins SET
+		user_id = $2,
+		encrypted_code = $3
+		WHERE id = $1;`,
+		id, userId, util.HashToken(code))
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// UseIdentityProviderLoginCode returns the user a login code was issued to if it was issued by a provider of the tenent,
+// a code can only be used once
+func UseIdentityProviderLoginCode(tenentId int32, code string) (*int32, error) {
+	return GetOptional[int32](`UPDATE identity_provider_logins ipl SET
+		encrypted_code = NULL
+		FROM identity_providers ip
+		WHERE ip.id = ipl.identity_provider_id AND ip.tenent_id = $1 AND ipl.encrypted_code = $2 AND ipl.expires_at > NOW()
+		RETURNING ipl.user_id;`,
+		tenentId, util.HashToken(code))
+}
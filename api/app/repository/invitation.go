@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+type InvitationRowST struct {
+	Id                        int32      `db:"id"`
+	ApplicationId             int32      `db:"application_id"`
+	TenentId                  int32      `db:"tenent_id"`
+	UserId                    *int32     `db:"user_id"`
+	Email                     string     `db:"email"`
+	EncryptedToken            *string    `db:"encrypted_token"`
+	InvitedByUserId           *int32     `db:"invited_by_user_id"`
+	InvitedByServiceAccountId *int32     `db:"invited_by_service_account_id"`
+	ExpiresAt                 time.Time  `db:"expires_at"`
+	AcceptedAt                *time.Time `db:"accepted_at"`
+	RevokedAt                 *time.Time `db:"revoked_at"`
+	UpdatedAt                 time.Time  `db:"updated_at"`
+	CreatedAt                 time.Time  `db:"created_at"`
+}
+
+func (invitation *InvitationRowST) IsExpired() bool {
+	return !invitation.ExpiresAt.After(time.Now())
+}
+
+var invitationsKeyset = KeysetST[InvitationRowST]{
+	Name:       "created_at",
+	Column:     "i.created_at",
+	IdColumn:   "i.id",
+	Time:       true,
+	Descending: true,
+	Key:        func(row InvitationRowST) interface{} { return row.CreatedAt },
+	Id:         func(row InvitationRowST) int32 { return row.Id },
+}
+
+// GetOutstandingInvitations returns the application's invitations that were neither accepted nor revoked, expired
+// invitations are included so they can be resent
+func GetOutstandingInvitations(applicationId int32, page PageST) (*PageResultST[InvitationRowST], error) {
+	return Paginate(`SELECT i.* FROM invitations i`,
+		`i.application_id = $1 AND i.accepted_at IS NULL AND i.revoked_at IS NULL`,
+		[]interface{}{applicationId}, invitationsKeyset, page)
+}
+
+func GetOutstandingInvitationById(applicationId, id int32) (*InvitationRowST, error) {
+	return GetOptional[InvitationRowST](`SELECT i.*
+		FROM invitations i
+		WHERE i.application_id = $1 AND i.id = $2 AND i.accepted_at IS NULL AND i.revoked_at IS NULL
+		LIMIT 1;`,
+		applicationId, id)
+}
+
+// GetInvitationByToken returns the invitation the token was last issued for if it can still be accepted
+func GetInvitationByToken(token string) (*InvitationRowST, error) {
+	return GetOptional[InvitationRowST](`SELECT i.*
+		FROM invitations i
+		WHERE i.encrypted_token = $1 AND i.accepted_at IS NULL AND i.revoked_at IS NULL AND i.expires_at > NOW()
+		LIMIT 1;`,
+		util.HashToken(token))
+}
+
+type CreateInvitationST struct {
+	TenentId                  int32
+	Email                     string
+	Username                  *string
+	InvitedByUserId           *int32
+	InvitedByServiceAccountId *int32
+	ExpiresInSeconds          int64
+}
+
+type InvitationAndUserST struct {
+	Invitation InvitationRowST
+	User       UserRowST
+}
+
+// CreateInvitation creates a pending user with the email as its unconfirmed primary email and the invitation for it,
+// the user has no usable password until the invitation is accepted. The invitation can't be accepted until its token
+// is set with SetInvitationToken
+func CreateInvitation(applicationId int32, create CreateInvitationST) (InvitationAndUserST, error) {
+	return Transaction(func(tx *sqlx.Tx) (InvitationAndUserST, error) {
+		var result InvitationAndUserST
+		var username string
+		if create.Username != nil {
+			username = *create.Username
+		} else {
+			var err error
+			username, err = availableUsername(tx, applicationId, strings.Split(create.Email, "@")[0])
+			if err != nil {
+				return result, err
+			}
+		}
+		password, err := util.GenerateRandomHex(32)
+		if err != nil {
+			return result, err
+		}
+		encryptedPassword, err := util.EncryptPassword(password)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result.User, `INSERT INTO users (application_id, username, encrypted_password, password_set, status, status_changed_by_user_id, status_changed_by_service_account_id, status_changed_at)
+			VALUES ($1, $2, $3, false, 'pending', $4, $5, CURRENT_TIMESTAMP)
+			RETURNING *;`,
+			applicationId, username, encryptedPassword, create.InvitedByUserId, create.InvitedByServiceAccountId)
+		if err != nil {
+			return result, err
+		}
+		var emailId int32
+		err = tx.Get(&emailId, `INSERT INTO emails (application_id, user_id, email, confirmed)
+			VALUES ($1, $2, $3, false)
+			RETURNING id;`,
+			applicationId, result.User.Id, create.Email)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result.User, `UPDATE users SET email_id = $2 WHERE id = $1 RETURNING *;`, result.User.Id, emailId)
+		if err != nil {
+			return result, err
+		}
+		_, err = tx.Exec(`INSERT INTO user_infos (application_id, user_id) VALUES ($1, $2);`, applicationId, result.User.Id)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result.Invitation, `INSERT INTO invitations (application_id, tenent_id, user_id, email, invited_by_user_id, invited_by_service_account_id, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW() + make_interval(secs => $7))
+			RETURNING *;`,
+			applicationId, create.TenentId, result.User.Id, create.Email, create.InvitedByUserId, create.InvitedByServiceAccountId, create.ExpiresInSeconds)
+		if err != nil {
+			return result, err
+		}
+		return result, nil
+	})
+}
+
+// SetInvitationToken stores the hash of the invitation's new token and restarts its expiry, links sent before stop
+// working
+func SetInvitationToken(id int32, token string, expiresInSeconds int64) (*InvitationRowST, error) {
+	return GetOptional[InvitationRowST](`UPDATE invitations
+		SET encrypted_token = $2, expires_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING *;`,
+		id, util.HashToken(token), expiresInSeconds)
+}
+
+// AcceptInvitation uses the token's invitation and activates its user with the password, the username replaces the
+// generated one when set and the invited email is confirmed since the token was delivered to it. Returns nil if
+// the token was already used, revoked or expired
+func AcceptInvitation(token, password string, username *string) (*UserRowST, error) {
+	encryptedPassword, err := util.EncryptPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return Transaction(func(tx *sqlx.Tx) (*UserRowST, error) {
+		var invitation InvitationRowST
+		err := tx.Get(&invitation, `UPDATE invitations
+			SET accepted_at = NOW(), encrypted_token = NULL
+			WHERE encrypted_token = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW() AND user_id IS NOT NULL
+			RETURNING *;`,
+			util.HashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// an admin may have disabled the user since inviting it, only the pending status is lifted
+		_, err = tx.Exec(`UPDATE users
+			SET status = 'active',
+				status_reason = NULL,
+				status_expires_at = NULL,
+				status_changed_by_user_id = NULL,
+				status_changed_by_service_account_id = NULL,
+				status_changed_at = CURRENT_TIMESTAMP
+			WHERE application_id = $1 AND id = $2 AND status = 'pending';`,
+			invitation.ApplicationId, *invitation.UserId)
+		if err != nil {
+			return nil, err
+		}
+		var user UserRowST
+		err = tx.Get(&user, `UPDATE users
+			SET encrypted_password = $3, password_set = true, username = COALESCE($4, username)
+			WHERE application_id = $1 AND id = $2
+			RETURNING *;`,
+			invitation.ApplicationId, *invitation.UserId, encryptedPassword, username)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE emails SET confirmed = true WHERE application_id = $1 AND user_id = $2 AND email = $3;`,
+			invitation.ApplicationId, user.Id, invitation.Email)
+		if err != nil {
+			return nil, err
+		}
+		return &user, nil
+	})
+}
+
+// RevokeInvitation stops the invitation's links from working and deletes its user if it never accepted
+func RevokeInvitation(applicationId, id int32) (*InvitationRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (*InvitationRowST, error) {
+		var invitation InvitationRowST
+		err := tx.Get(&invitation, `UPDATE invitations
+			SET revoked_at = NOW(), encrypted_token = NULL
+			WHERE application_id = $1 AND id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+			RETURNING *;`,
+			applicationId, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if invitation.UserId != nil {
+			_, err = tx.Exec(`DELETE FROM users WHERE application_id = $1 AND id = $2 AND status = 'pending' AND password_set = false;`,
+				applicationId, *invitation.UserId)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &invitation, nil
+	})
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type LDAPConnectorRowST struct {
+	Id                  int32     `db:"id"`
+	ApplicationId       int32     `db:"application_id"`
+	URL                 string    `db:"url"`
+	StartTLS            bool      `db:"start_tls"`
+	TLSSkipVerify       bool      `db:"tls_skip_verify"`
+	TLSRootCA           *string   `db:"tls_root_ca"`
+	BindDN              string    `db:"bind_dn"`
+	BindPassword        string    `db:"bind_password"`
+	UserSearchBase      string    `db:"user_search_base"`
+	UserFilter          string    `db:"user_filter"`
+	UsernameAttribute   string    `db:"username_attribute"`
+	EmailAttribute      string    `db:"email_attribute"`
+	NameAttribute       string    `db:"name_attribute"`
+	GivenNameAttribute  string    `db:"given_name_attribute"`
+	FamilyNameAttribute string    `db:"family_name_attribute"`
+	GroupSearchBase     *string   `db:"group_search_base"`
+	GroupFilter         string    `db:"group_filter"`
+	GroupNameAttribute  string    `db:"group_name_attribute"`
+	GroupRoleMapping    string    `db:"group_role_mapping"`
+	RegistrationEnabled bool      `db:"registration_enabled"`
+	Enabled             bool      `db:"enabled"`
+	UpdatedAt           time.Time `db:"updated_at"`
+	CreatedAt           time.Time `db:"created_at"`
+}
+
+// GroupRoles returns the role uris users of each directory group get
+func (row *LDAPConnectorRowST) GroupRoles() (map[string][]string, error) {
+	groupRoles := make(map[string][]string)
+	if err := json.Unmarshal([]byte(row.GroupRoleMapping), &groupRoles); err != nil {
+		return nil, err
+	}
+	return groupRoles, nil
+}
+
+func GetLDAPConnector(applicationId int32) (*LDAPConnectorRowST, error) {
+	return GetOptional[LDAPConnectorRowST](`SELECT lc.*
+		FROM ldap_connectors lc
+		WHERE lc.application_id = $1
+		LIMIT 1;`,
+		applicationId)
+}
+
+type CreateLDAPConnectorST struct {
+	URL                 string              `json:"url" validate:"required"`
+	StartTLS            *bool               `json:"start_tls"`
+	TLSSkipVerify       *bool               `json:"tls_skip_verify"`
+	TLSRootCA           *string             `json:"tls_root_ca"`
+	BindDN              *string             `json:"bind_dn"`
+	BindPassword        *string             `json:"bind_password"`
+	UserSearchBase      string              `json:"user_search_base" validate:"required"`
+	UserFilter          *string             `json:"user_filter"`
+	UsernameAttribute   *string             `json:"username_attribute"`
+	EmailAttribute      *string             `json:"email_attribute"`
+	NameAttribute       *string             `json:"name_attribute"`
+	GivenNameAttribute  *string             `json:"given_name_attribute"`
+	FamilyNameAttribute *string             `json:"family_name_attribute"`
+	GroupSearchBase     *string             `json:"group_search_base"`
+	GroupFilter         *string             `json:"group_filter"`
+	GroupNameAttribute  *string             `json:"group_name_attribute"`
+	GroupRoleMapping    map[string][]string `json:"group_role_mapping"`
+	RegistrationEnabled *bool               `json:"registration_enabled"`
+	Enabled             *bool               `json:"enabled"`
+}
+
+func CreateLDAPConnector(applicationId int32, create CreateLDAPConnectorST) (LDAPConnectorRowST, error) {
+	if create.GroupRoleMapping == nil {
+		create.GroupRoleMapping = make(map[string][]string)
+	}
+	groupRoleMapping, err := json.Marshal(create.GroupRoleMapping)
+	if err != nil {
+		return LDAPConnectorRowST{}, err
+	}
+	return Get[LDAPConnectorRowST](`INSERT INTO ldap_connectors
+		(application_id, url, start_tls, tls_skip_verify, tls_root_ca, bind_dn, bind_password, user_search_base, user_filter,
+			username_attribute, email_attribute, name_attribute, given_name_attribute, family_name_attribute, group_search_base,
+			group_filter, group_name_attribute, group_role_mapping, registration_enabled, enabled)
+		VALUES ($1, $2, COALESCE($3, false), COALESCE($4, false), $5, COALESCE($6, ''), COALESCE($7, ''), $8,
+			COALESCE($9, '(&(objectClass=person)(|(uid={username})(mail={username})))'), COALESCE($10, 'uid'), COALESCE($11, 'mail'),
+			COALESCE($12, 'cn'), COALESCE($13, 'givenName'), COALESCE($14, 'sn'), $15,
+			COALESCE($16, '(|(member={dn})(uniqueMember={dn}))'), COALESCE($17, 'cn'), $18, COALESCE($19, true), COALESCE($20, true))
+		RETURNING *;`,
+		applicationId, create.URL, create.StartTLS, create.TLSSkipVerify, create.TLSRootCA, create.BindDN, create.BindPassword,
+		create.UserSearchBase, create.UserFilter, create.UsernameAttribute, create.EmailAttribute, create.NameAttribute,
+		create.GivenNameAttribute, create.FamilyNameAttribute, create.GroupSearchBase, create.GroupFilter,
+		create.GroupNameAttribute, string(groupRoleMapping), create.RegistrationEnabled, create.Enabled)
+}
+
+type UpdateLDAPConnectorST struct {
+	URL                 *string             `json:"url"`
+	StartTLS            *bool               `json:"start_tls"`
+	TLSSkipVerify       *bool               `json:"tls_skip_verify"`
+	TLSRootCA           *string             `json:"tls_root_ca"`
+	BindDN              *string             `json:"bind_dn"`
+	BindPassword        *string             `json:"bind_password"`
+	UserSearchBase      *string             `json:"user_search_base"`
+	UserFilter          *string             `json:"user_filter"`
+	UsernameAttribute   *string             `json:"username_attribute"`
+	EmailAttribute      *string             `json:"email_attribute"`
+	NameAttribute       *string             `json:"name_attribute"`
+	GivenNameAttribute  *string             `json:"given_name_attribute"`
+	FamilyNameAttribute *string             `json:"family_name_attribute"`
+	GroupSearchBase     *string             `json:"group_search_base"`
+	GroupFilter         *string             `json:"group_filter"`
+	GroupNameAttribute  *string             `json:"group_name_attribute"`
+	GroupRoleMapping    map[string][]string `json:"group_role_mapping"`
+	RegistrationEnabled *bool               `json:"registration_enabled"`
+	Enabled             *bool               `json:"enabled"`
+}
+
+func UpdateLDAPConnector(id int32, update UpdateLDAPConnectorST) (*LDAPConnectorRowST, error) {
+	var groupRoleMapping *string
+	if update.GroupRoleMapping != nil {
+		bytes, err := json.Marshal(update.GroupRoleMapping)
+		if err != nil {
+			return nil, err
+		}
+		value := string(bytes)
+		groupRoleMapping = &value
+	}
+	return GetOptional[LDAPConnectorRowST](`UPDATE ldap_connectors SET
+		url = COALESCE($2, url),
+		start_tls = COALESCE($3, start_tls),
+		tls_skip_verify = COALESCE($4, tls_skip_verify),
+		tls_root_ca = COALESCE($5, tls_root_ca),
+		bind_dn = COALESCE($6, bind_dn),
+		bind_password = COALESCE($7, bind_password),
+		user_search_base = COALESCE($8, user_search_base),
+		user_filter = COALESCE($9, user_filter),
+		username_attribute = COALESCE($10, username_attribute),
+		email_attribute = COALESCE($11, email_attribute),
+		name_attribute = COALESCE($12, name_attribute),
+		given_name_attribute = COALESCE($13, given_name_attribute),
+		family_name_attribute = COALESCE($14, family_name_attribute),
+		group_search_base = COALESCE($15, group_search_base),
+		group_filter = COALESCE($16, group_filter),
+		group_name_attribute = COALESCE($17, group_name_attribute),
+		group_role_mapping = COALESCE($18::JSONB, group_role_mapping),
+		registration_enabled = COALESCE($19, registration_enabled),
+		enabled = COALESCE($20, enabled)
+		WHERE id = $1
+		RETURNING *;`,
+		id, update.URL, update.StartTLS, update.TLSSkipVerify, update.TLSRootCA, update.BindDN, update.BindPassword,
+		update.UserSearchBase, update.UserFilter, update.UsernameAttribute, update.EmailAttribute, update.NameAttribute,
+		update.GivenNameAttribute, update.FamilyNameAttribute, update.GroupSearchBase, update.GroupFilter,
+		update.GroupNameAttribute, groupRoleMapping, update.RegistrationEnabled, update.Enabled)
+}
+
+func DeleteLDAPConnector(id int32) (bool, error) {
+	return Execute(`DELETE FROM ldap_connectors WHERE id = $1;`, id)
+}
+
+// LDAPUserST is what the directory returned for the user signing in
+type LDAPUserST struct {
+	DN         string
+	Username   string
+	Email      *string
+	Name       *string
+	GivenName  *string
+	FamilyName *string
+	Groups     []string
+}
+
+func GetUserByLDAPDN(applicationId int32, dn string) (*UserRowST, error) {
+	return GetOptional[UserRowST](`SELECT u.*
+		FROM users u
+		WHERE u.application_id = $1 AND u.ldap_dn = $2
+		LIMIT 1;`,
+		applicationId, dn)
+}
+
+// CreateUserFromLDAP provisions a directory user with an unusable random password, the directory email becomes the
+// confirmed primary email when no other user has it
+func CreateUserFromLDAP(applicationId int32, ldapUser LDAPUserST) (UserRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (UserRowST, error) {
+		var result UserRowST
+		username, err := availableUsername(tx, applicationId, ldapUser.Username)
+		if err != nil {
+			return result, err
+		}
+		password, err := util.GenerateRandomHex(32)
+		if err != nil {
+			return result, err
+		}
+		encryptedPassword, err := util.EncryptPassword(password)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result, `INSERT INTO users (application_id, username, encrypted_password, password_set, ldap_backed, ldap_dn)
+			VALUES ($1, $2, $3, false, true, $4)
+			RETURNING *;`,
+			applicationId, username, encryptedPassword, ldapUser.DN)
+		if err != nil {
+			return result, err
+		}
+		if ldapUser.Email != nil {
+			var emailIds []int32
+			err = tx.Select(&emailIds, `INSERT INTO emails (application_id, user_id, email, confirmed)
+				VALUES ($1, $2, $3, true)
+				ON CONFLICT (application_id, canonical_email(email)) DO NOTHING
+				RETURNING id;`,
+				applicationId, result.Id, *ldapUser.Email)
+			if err != nil {
+				return result, err
+			}
+			if len(emailIds) > 0 {
+				err = tx.Get(&result, `UPDATE users SET email_id = $2 WHERE id = $1 RETURNING *;`, result.Id, emailIds[0])
+				if err != nil {
+					return result, err
+				}
+			}
+		}
+		_, err = tx.Exec(`INSERT INTO user_infos (application_id, user_id, name, given_name, family_name)
+			VALUES ($1, $2, $3, $4, $5);`,
+			applicationId, result.Id, ldapUser.Name, ldapUser.GivenName, ldapUser.FamilyName)
+		return result, err
+	})
+}
+
+// SyncLDAPUser updates a directory user's dn and info from the directory, of the mappedRoles the user keeps only
+// those in roles, roles not mapped from a group are left alone
+func SyncLDAPUser(applicationId, userId int32, ldapUser LDAPUserST, mappedRoles, roles []string) error {
+	_, err := Transaction(func(tx *sqlx.Tx) (bool, error) {
+		if _, err := tx.Exec(`UPDATE users SET ldap_backed = true, ldap_dn = $2 WHERE id = $1;`, userId, ldapUser.DN); err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(`UPDATE user_infos SET
+			name = COALESCE($2, name),
+			given_name = COALESCE($3, given_name),
+			family_name = COALESCE($4, family_name)
+			WHERE user_id = $1;`,
+			userId, ldapUser.Name, ldapUser.GivenName, ldapUser.FamilyName); err != nil {
+			return false, err
+		}
+		if len(mappedRoles) == 0 {
+			return true, nil
+		}
+		if _, err := tx.Exec(`DELETE FROM user_roles ur
+			USING roles r
+			WHERE r.id = ur.role_id AND ur.user_id = $1 AND r.uri = ANY($2) AND NOT (r.uri = ANY($3));`,
+			userId, pq.Array(mappedRoles), pq.Array(roles)); err != nil {
+			return false, err
+		}
+		_, err := tx.Exec(`INSERT INTO user_roles (user_id, role_id)
+			SELECT $1, r.id FROM roles r WHERE r.application_id = $2 AND r.uri = ANY($3)
+			ON CONFLICT (user_id, role_id) DO NOTHING;`,
+			userId, applicationId, pq.Array(roles))
+		return true, err
+	})
+	return err
+}
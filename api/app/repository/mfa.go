@@ -1,36 +1,134 @@
 package repository
 
-import "time"
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
 
 const (
-	MFATypeTOTP = "totp"
+	MFATypeTOTP         = "totp"
+	MFATypeEmail        = "email"
+	MFATypeSMS          = "sms"
+	MFATypePassKey      = "passkey"
+	MFATypeRecoveryCode = "recovery_code"
 )
 
+// MFATypes are the types of method a user can enroll
+var MFATypes = []string{MFATypeTOTP, MFATypeEmail, MFATypeSMS, MFATypePassKey}
+
 type MFARowST struct {
 	UserId    int32     `db:"user_id"`
 	Id        int32     `db:"id"`
 	Type      string    `db:"type"`
+	Preferred bool      `db:"preferred"`
 	Enabled   bool      `db:"enabled"`
 	UpdatedAt time.Time `db:"updated_at"`
 	CreatedAt time.Time `db:"created_at"`
 }
 
-func GetMFA(userId int32) (*MFARowST, error) {
-	return GetOptional[MFARowST](`SELECT m.*, (t.id IS NOT NULL) AS enabled
+const mfaSelect = `SELECT m.*, (CASE m.type
+			WHEN 'totp' THEN t.id IS NOT NULL
+			WHEN 'email' THEN COALESCE(e.confirmed, false)
+			WHEN 'sms' THEN COALESCE(p.confirmed, false)
+			WHEN 'passkey' THEN EXISTS(SELECT 1 FROM passkeys pk WHERE pk.user_id = m.user_id)
+			ELSE false
+		END) AS enabled
 		FROM user_mfas m
+		JOIN users u ON u.id = m.user_id
 		LEFT JOIN totps t ON m.type = 'totp' AND m.id = t.id
-		WHERE m.user_id = $1;`,
+		LEFT JOIN emails e ON m.type = 'email' AND e.id = u.email_id
+		LEFT JOIN phone_numbers p ON m.type = 'sms' AND p.id = u.phone_number_id`
+
+func GetMFAs(userId int32) ([]MFARowST, error) {
+	return All[MFARowST](mfaSelect+`
+		WHERE m.user_id = $1
+		ORDER BY m.preferred DESC, m.created_at ASC;`,
 		userId)
 }
 
+// GetEnabledMFAs returns the methods the user can complete a challenge with
+// on the given tenent, the preferred method first
+func GetEnabledMFAs(userId, tenentId int32) ([]MFARowST, error) {
+	return All[MFARowST](`SELECT * FROM (`+mfaSelect+`
+		WHERE m.user_id = $1 AND (m.type <> 'totp' OR t.tenent_id = $2)) mfas
+		WHERE mfas.enabled
+		ORDER BY mfas.preferred DESC, mfas.created_at ASC;`,
+		userId, tenentId)
+}
+
+func GetMFA(userId int32, typ string, id int32) (*MFARowST, error) {
+	return GetOptional[MFARowST](mfaSelect+`
+		WHERE m.user_id = $1 AND m.type = $2 AND m.id = $3;`,
+		userId, typ, id)
+}
+
+// UpsertMFA enrolls a method, the first method enrolled becomes the preferred one
 func UpsertMFA(userId, id int32, typ string) (bool, error) {
-	return Execute(`INSERT INTO user_mfas (user_id, id, type)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (user_id) DO UPDATE
-		SET id = $2, type = $3;`,
+	return Execute(`INSERT INTO user_mfas (user_id, id, type, preferred)
+		VALUES ($1, $2, $3, NOT EXISTS(SELECT 1 FROM user_mfas WHERE user_id = $1))
+		ON CONFLICT (user_id, type, id) DO UPDATE
+		SET updated_at = NOW();`,
 		userId, id, typ)
 }
 
-func DeleteMFA(userId int32) (bool, error) {
-	return Execute(`DELETE FROM user_mfas WHERE user_id = $1;`, userId)
+func SetPreferredMFA(userId int32, typ string, id int32) (bool, error) {
+	return Transaction(func(tx *sqlx.Tx) (bool, error) {
+		exists := false
+		if err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM user_mfas WHERE user_id = $1 AND type = $2 AND id = $3);`, userId, typ, id); err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, nil
+		}
+		if _, err := tx.Exec(`UPDATE user_mfas SET preferred = false WHERE user_id = $1 AND preferred;`, userId); err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(`UPDATE user_mfas SET preferred = true WHERE user_id = $1 AND type = $2 AND id = $3;`, userId, typ, id); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// DeleteMFA removes a method, if it was the preferred one the oldest remaining method becomes preferred, if it was the
+// last one the recovery codes are deleted
+func DeleteMFA(userId int32, typ string, id int32) (bool, error) {
+	return Transaction(func(tx *sqlx.Tx) (bool, error) {
+		result, err := tx.Exec(`DELETE FROM user_mfas WHERE user_id = $1 AND type = $2 AND id = $3;`, userId, typ, id)
+		if err != nil {
+			return false, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(`UPDATE user_mfas SET preferred = true
+			WHERE user_id = $1 AND NOT EXISTS(SELECT 1 FROM user_mfas WHERE user_id = $1 AND preferred)
+			AND (type, id) = (SELECT type, id FROM user_mfas WHERE user_id = $1 ORDER BY created_at ASC LIMIT 1);`, userId); err != nil {
+			return false, err
+		}
+		if err := deleteRecoveryCodesWithoutMFA(tx, userId); err != nil {
+			return false, err
+		}
+		return rows > 0, nil
+	})
+}
+
+// DeleteAllMFAs removes every method and the recovery codes
+func DeleteAllMFAs(userId int32) (bool, error) {
+	return Transaction(func(tx *sqlx.Tx) (bool, error) {
+		result, err := tx.Exec(`DELETE FROM user_mfas WHERE user_id = $1;`, userId)
+		if err != nil {
+			return false, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		if err := deleteRecoveryCodesWithoutMFA(tx, userId); err != nil {
+			return false, err
+		}
+		return rows > 0, nil
+	})
 }
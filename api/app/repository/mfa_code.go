@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	MFACodeValid    = "valid"
+	MFACodeInvalid  = "invalid"
+	MFACodeExpired  = "expired"
+	MFACodeExceeded = "exceeded"
+)
+
+type MFACodeRowST struct {
+	Id            int32      `db:"id"`
+	UserId        int32      `db:"user_id"`
+	Type          string     `db:"type"`
+	EncryptedCode string     `db:"encrypted_code"`
+	Attempts      int32      `db:"attempts"`
+	UsedAt        *time.Time `db:"used_at"`
+	ExpiresAt     time.Time  `db:"expires_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+type MFACodeSendStatsST struct {
+	LastSentAt     *time.Time `db:"last_sent_at"`
+	SentInLastHour int        `db:"sent_in_last_hour"`
+}
+
+func GetMFACodeSendStats(userId int32) (MFACodeSendStatsST, error) {
+	return Get[MFACodeSendStatsST](`SELECT MAX(mc.created_at) AS last_sent_at,
+			COUNT(*) FILTER (WHERE mc.created_at > NOW() - INTERVAL '1 hour') AS sent_in_last_hour
+		FROM mfa_codes mc
+		WHERE mc.user_id = $1;`,
+		userId)
+}
+
+func CreateMFACode(userId int32, typ string, expiresInSeconds int64) (string, error) {
+	code, err := util.GenerateRandomDigits(6)
+	if err != nil {
+		return "", err
+	}
+	_, err = Execute(`INSERT INTO mfa_codes (user_id, type, encrypted_code, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4));`,
+		userId, typ, util.HashToken(code), expiresInSeconds)
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// DeleteMFACode removes a code that couldn't be delivered so it doesn't count against the send limits
+func DeleteMFACode(userId int32, code string) (bool, error) {
+	return Execute(`DELETE FROM mfa_codes WHERE user_id = $1 AND encrypted_code = $2;`, userId, util.HashToken(code))
+}
+
+func ValidateMFACode(userId int32, typ, code string, maxAttempts int32) (string, error) {
+	return Transaction(func(tx *sqlx.Tx) (string, error) {
+		var row MFACodeRowST
+		err := tx.Get(&row, `SELECT mc.*
+			FROM mfa_codes mc
+			WHERE mc.user_id = $1 AND mc.type = $2 AND mc.used_at IS NULL AND mc.expires_at > NOW()
+			ORDER BY mc.created_at DESC
+			LIMIT 1
+			FOR UPDATE;`,
+			userId, typ)
+		if errors.Is(err, sql.ErrNoRows) {
+			return MFACodeExpired, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if row.Attempts >= maxAttempts {
+			return MFACodeExceeded, nil
+		}
+		if row.EncryptedCode != util.HashToken(code) {
+			_, err := tx.Exec(`UPDATE mfa_codes SET attempts = attempts + 1 WHERE id = $1;`, row.Id)
+			if err != nil {
+				return "", err
+			}
+			return MFACodeInvalid, nil
+		}
+		_, err = tx.Exec(`UPDATE mfa_codes SET used_at = NOW() WHERE id = $1;`, row.Id)
+		if err != nil {
+			return "", err
+		}
+		return MFACodeValid, nil
+	})
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	TotalExact     = "exact"
+	TotalEstimated = "estimated"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// KeysetST is the order of a paginated list, rows are ordered by Column then IdColumn in the same direction so
+// every row has a unique position a cursor can point at
+type KeysetST[T any] struct {
+	Name       string
+	Column     string
+	IdColumn   string
+	Time       bool
+	Descending bool
+	Key        func(T) interface{}
+	Id         func(T) int32
+}
+
+type PageST struct {
+	Limit  int
+	Offset int
+	Cursor *string
+	Total  string
+}
+
+type PageResultST[T any] struct {
+	Items          []T
+	Next           *string
+	Prev           *string
+	Total          *int64
+	TotalEstimated bool
+}
+
+type cursorST struct {
+	Sort     string `json:"s"`
+	Key      string `json:"k"`
+	Id       int32  `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// Paginate runs selectFrom filtered by where and returns the page of rows after or before the page's cursor, or
+// at the page's offset when there is no cursor. $1 to $len(args) in where are args
+func Paginate[T any](selectFrom, where string, args []interface{}, keyset KeysetST[T], page PageST) (*PageResultST[T], error) {
+	if where == "" {
+		where = "true"
+	}
+	query := selectFrom + ` WHERE ` + where
+	var cursor *cursorST
+	if page.Cursor != nil && *page.Cursor != "" {
+		decoded, err := decodeCursor(*page.Cursor, keyset)
+		if err != nil {
+			return nil, err
+		}
+		cursor = decoded
+	}
+	backward := cursor != nil && cursor.Backward
+	descending := keyset.Descending != backward
+	pageArgs := slices.Clone(args)
+	pageQuery := query
+	if cursor != nil {
+		key, err := cursorKey(cursor, keyset)
+		if err != nil {
+			return nil, err
+		}
+		operator := ">"
+		if descending {
+			operator = "<"
+		}
+		pageArgs = append(pageArgs, key, cursor.Id)
+		pageQuery += fmt.Sprintf(` AND (%s, %s) %s ($%d, $%d)`, keyset.Column, keyset.IdColumn, operator, len(pageArgs)-1, len(pageArgs))
+	}
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+	pageQuery += fmt.Sprintf(` ORDER BY %s %s, %s %s`, keyset.Column, direction, keyset.IdColumn, direction)
+	pageArgs = append(pageArgs, page.Limit+1)
+	pageQuery += fmt.Sprintf(` LIMIT $%d`, len(pageArgs))
+	if cursor == nil && page.Offset > 0 {
+		pageArgs = append(pageArgs, page.Offset)
+		pageQuery += fmt.Sprintf(` OFFSET $%d`, len(pageArgs))
+	}
+	rows, err := All[T](pageQuery+`;`, pageArgs...)
+	if err != nil {
+		return nil, err
+	}
+	more := len(rows) > page.Limit
+	if more {
+		rows = rows[:page.Limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+	result := &PageResultST[T]{Items: rows}
+	if result.Items == nil {
+		result.Items = make([]T, 0)
+	}
+	if len(rows) > 0 {
+		hasNext, hasPrev := more, cursor != nil || page.Offset > 0
+		if backward {
+			hasNext, hasPrev = true, more
+		}
+		if hasNext {
+			next, err := encodeCursor(rows[len(rows)-1], keyset, false)
+			if err != nil {
+				return nil, err
+			}
+			result.Next = &next
+		}
+		if hasPrev {
+			prev, err := encodeCursor(rows[0], keyset, true)
+			if err != nil {
+				return nil, err
+			}
+			result.Prev = &prev
+		}
+	}
+	switch page.Total {
+	case TotalExact:
+		total, err := Get[int64](`SELECT COUNT(*) FROM (`+query+`) t;`, args...)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	case TotalEstimated:
+		total, err := estimateRows(query, args)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+		result.TotalEstimated = true
+	}
+	return result, nil
+}
+
+// estimateRows returns the planner's estimate of the number of rows query returns, which is much cheaper than
+// counting them on large tables
+func estimateRows(query string, args []interface{}) (int64, error) {
+	planJSON, err := Get[string](`EXPLAIN (FORMAT JSON) `+query+`;`, args...)
+	if err != nil {
+		return 0, err
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(planJSON), &plans); err != nil {
+		return 0, err
+	}
+	if len(plans) == 0 {
+		return 0, nil
+	}
+	return int64(plans[0].Plan.Rows), nil
+}
+
+func keysetSort[T any](keyset KeysetST[T]) string {
+	if keyset.Descending {
+		return keyset.Name + ":desc"
+	}
+	return keyset.Name + ":asc"
+}
+
+func encodeCursor[T any](row T, keyset KeysetST[T], backward bool) (string, error) {
+	cursor := cursorST{
+		Sort:     keysetSort(keyset),
+		Id:       keyset.Id(row),
+		Backward: backward,
+	}
+	switch key := keyset.Key(row).(type) {
+	case time.Time:
+		cursor.Key = key.UTC().Format(time.RFC3339Nano)
+	case string:
+		cursor.Key = key
+	default:
+		return "", fmt.Errorf("unsupported cursor key %T", key)
+	}
+	cursorJSON, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(cursorJSON), nil
+}
+
+func decodeCursor[T any](encoded string, keyset KeysetST[T]) (*cursorST, error) {
+	cursorJSON, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor cursorST
+	if err := json.Unmarshal(cursorJSON, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// a cursor only points at a position in the order it was created for
+	if cursor.Sort != keysetSort(keyset) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func cursorKey[T any](cursor *cursorST, keyset KeysetST[T]) (interface{}, error) {
+	if keyset.Time {
+		key, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return key, nil
+	}
+	return cursor.Key, nil
+}
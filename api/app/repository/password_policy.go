@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"time"
+)
+
+const (
+	// PasswordHistoryMax bounds the previous passwords kept and checked, each check is a full password hash
+	PasswordHistoryMax = 5
+)
+
+type PasswordPolicyRowST struct {
+	Id               int32     `db:"id"`
+	ApplicationId    int32     `db:"application_id"`
+	TenentId         *int32    `db:"tenent_id"`
+	MinLength        int32     `db:"min_length"`
+	MaxLength        int32     `db:"max_length"`
+	RequireLowercase bool      `db:"require_lowercase"`
+	RequireUppercase bool      `db:"require_uppercase"`
+	RequireDigit     bool      `db:"require_digit"`
+	RequireSymbol    bool      `db:"require_symbol"`
+	DisallowUsername bool      `db:"disallow_username"`
+	HistoryCount     int32     `db:"history_count"`
+	CheckBreached    bool      `db:"check_breached"`
+	UpdatedAt        time.Time `db:"updated_at"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// DefaultPasswordPolicy is used when neither the tenent nor the application has a policy, it doesn't restrict passwords
+// so applications keep the rules from before policies existed until they set one, it matches the column defaults
+func DefaultPasswordPolicy(applicationId int32) PasswordPolicyRowST {
+	return PasswordPolicyRowST{
+		ApplicationId: applicationId,
+	}
+}
+
+func GetApplicationPasswordPolicy(applicationId int32) (*PasswordPolicyRowST, error) {
+	return GetOptional[PasswordPolicyRowST](`SELECT pp.*
+		FROM password_policies pp
+		WHERE pp.application_id = $1 AND pp.tenent_id IS NULL
+		LIMIT 1;`,
+		applicationId)
+}
+
+func GetTenentPasswordPolicy(applicationId, tenentId int32) (*PasswordPolicyRowST, error) {
+	return GetOptional[PasswordPolicyRowST](`SELECT pp.*
+		FROM password_policies pp
+		WHERE pp.application_id = $1 AND pp.tenent_id = $2
+		LIMIT 1;`,
+		applicationId, tenentId)
+}
+
+// GetPasswordPolicy returns the tenent's policy, falling back to the application's and then the default policy
+func GetPasswordPolicy(applicationId, tenentId int32) (PasswordPolicyRowST, error) {
+	policy, err := GetOptional[PasswordPolicyRowST](`SELECT pp.*
+		FROM password_policies pp
+		WHERE pp.application_id = $1 AND (pp.tenent_id = $2 OR pp.tenent_id IS NULL)
+		ORDER BY pp.tenent_id NULLS LAST
+		LIMIT 1;`,
+		applicationId, tenentId)
+	if err != nil {
+		return DefaultPasswordPolicy(applicationId), err
+	}
+	if policy == nil {
+		return DefaultPasswordPolicy(applicationId), nil
+	}
+	return *policy, nil
+}
+
+type UpsertPasswordPolicyST struct {
+	MinLength        *int32 `json:"min_length"`
+	MaxLength        *int32 `json:"max_length"`
+	RequireLowercase *bool  `json:"require_lowercase"`
+	RequireUppercase *bool  `json:"require_uppercase"`
+	RequireDigit     *bool  `json:"require_digit"`
+	RequireSymbol    *bool  `json:"require_symbol"`
+	DisallowUsername *bool  `json:"disallow_username"`
+	HistoryCount     *int32 `json:"history_count"`
+	CheckBreached    *bool  `json:"check_breached"`
+}
+
+// Apply returns the policy with the set fields of upsert applied
+func (upsert *UpsertPasswordPolicyST) Apply(policy PasswordPolicyRowST) PasswordPolicyRowST {
+	if upsert.MinLength != nil {
+		policy.MinLength = *upsert.MinLength
+	}
+	if upsert.MaxLength != nil {
+		policy.MaxLength = *upsert.MaxLength
+	}
+	if upsert.RequireLowercase != nil {
+		policy.RequireLowercase = *upsert.RequireLowercase
+	}
+	if upsert.RequireUppercase != nil {
+		policy.RequireUppercase = *upsert.RequireUppercase
+	}
+	if upsert.RequireDigit != nil {
+		policy.RequireDigit = *upsert.RequireDigit
+	}
+	if upsert.RequireSymbol != nil {
+		policy.RequireSymbol = *upsert.RequireSymbol
+	}
+	if upsert.DisallowUsername != nil {
+		policy.DisallowUsername = *upsert.DisallowUsername
+	}
+	if upsert.HistoryCount != nil {
+		policy.HistoryCount = *upsert.HistoryCount
+	}
+	if upsert.CheckBreached != nil {
+		policy.CheckBreached = *upsert.CheckBreached
+	}
+	return policy
+}
+
+func UpsertPasswordPolicy(policy PasswordPolicyRowST) (PasswordPolicyRowST, error) {
+	conflict := `ON CONFLICT (application_id) WHERE tenent_id IS NULL`
+	if policy.TenentId != nil {
+		conflict = `ON CONFLICT (application_id, tenent_id) WHERE tenent_id IS NOT NULL`
+	}
+	return NamedGet[PasswordPolicyRowST](`INSERT INTO password_policies
+		(application_id, tenent_id, min_length, max_length, require_lowercase, require_uppercase, require_digit, require_symbol, disallow_username, history_count, check_breached)
+		VALUES (:application_id, :tenent_id, :min_length, :max_length, :require_lowercase, :require_uppercase, :require_digit, :require_symbol, :disallow_username, :history_count, :check_breached)
+		`+conflict+` DO UPDATE SET
+			min_length = :min_length,
+			max_length = :max_length,
+			require_lowercase = :require_lowercase,
+			require_uppercase = :require_uppercase,
+			require_digit = :require_digit,
+			require_symbol = :require_symbol,
+			disallow_username = :disallow_username,
+			history_count = :history_count,
+			check_breached = :check_breached
+		RETURNING *;`, policy)
+}
+
+func DeleteTenentPasswordPolicy(applicationId, tenentId int32) (bool, error) {
+	return Execute(`DELETE FROM password_policies WHERE application_id = $1 AND tenent_id = $2;`, applicationId, tenentId)
+}
+
+// GetRecentEncryptedPasswords returns the user's current password followed by up to limit - 1 previous passwords
+func GetRecentEncryptedPasswords(userId int32, limit int32) ([]string, error) {
+	return All[string](`SELECT p.encrypted_password FROM (
+			SELECT u.encrypted_password, NOW() AS created_at FROM users u WHERE u.id = $1
+			UNION ALL
+			SELECT ph.encrypted_password, ph.created_at FROM password_histories ph WHERE ph.user_id = $1
+		) p
+		ORDER BY p.created_at DESC
+		LIMIT $2;`,
+		userId, limit)
+}
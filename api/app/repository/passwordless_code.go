@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordlessCodeRowST struct {
+	Id             int32      `db:"id"`
+	ApplicationId  int32      `db:"application_id"`
+	Email          string     `db:"email"`
+	EncryptedCode  string     `db:"encrypted_code"`
+	EncryptedToken string     `db:"encrypted_token"`
+	Attempts       int32      `db:"attempts"`
+	UsedAt         *time.Time `db:"used_at"`
+	ExpiresAt      time.Time  `db:"expires_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+type PasswordlessCodeST struct {
+	Code  string
+	Token string
+}
+
+func GetPasswordlessCodeSendStats(applicationId int32, email string) (MFACodeSendStatsST, error) {
+	return Get[MFACodeSendStatsST](`SELECT MAX(pc.created_at) AS last_sent_at,
+			COUNT(*) FILTER (WHERE pc.created_at > NOW() - INTERVAL '1 hour') AS sent_in_last_hour
+		FROM passwordless_codes pc
+		WHERE pc.application_id = $1 AND pc.email = $2;`,
+		applicationId, email)
+}
+
+// CreatePasswordlessCode creates a numeric code to type in and a token for the link, both are stored hashed
+func CreatePasswordlessCode(applicationId int32, email string, expiresInSeconds int64) (PasswordlessCodeST, error) {
+	var result PasswordlessCodeST
+	code, err := util.GenerateRandomDigits(6)
+	if err != nil {
+		return result, err
+	}
+	token, err := util.GenerateRandomHex(32)
+	if err != nil {
+		return result, err
+	}
+	_, err = Execute(`INSERT INTO passwordless_codes (application_id, email, encrypted_code, encrypted_token, expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5));`,
+		applicationId, email, util.HashToken(code), util.HashToken(token), expiresInSeconds)
+	if err != nil {
+		return result, err
+	}
+	result.Code = code
+	result.Token = token
+	return result, nil
+}
+
+// UsePasswordlessCode validates either the code or the link token of the latest code sent to the email
+func UsePasswordlessCode(applicationId int32, email, codeOrToken string, maxAttempts int32) (string, error) {
+	return Transaction(func(tx *sqlx.Tx) (string, error) {
+		var row PasswordlessCodeRowST
+		err := tx.Get(&row, `SELECT pc.*
+			FROM passwordless_codes pc
+			WHERE pc.application_id = $1 AND pc.email = $2 AND pc.used_at IS NULL AND pc.expires_at > NOW()
+			ORDER BY pc.created_at DESC
+			LIMIT 1
+			FOR UPDATE;`,
+			applicationId, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return MFACodeExpired, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if row.Attempts >= maxAttempts {
+			return MFACodeExceeded, nil
+		}
+		hashed := util.HashToken(codeOrToken)
+		if row.EncryptedCode != hashed && row.EncryptedToken != hashed {
+			_, err := tx.Exec(`UPDATE passwordless_codes SET attempts = attempts + 1 WHERE id = $1;`, row.Id)
+			if err != nil {
+				return "", err
+			}
+			return MFACodeInvalid, nil
+		}
+		_, err = tx.Exec(`UPDATE passwordless_codes SET used_at = NOW() WHERE id = $1;`, row.Id)
+		if err != nil {
+			return "", err
+		}
+		return MFACodeValid, nil
+	})
+}
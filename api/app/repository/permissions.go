@@ -16,11 +16,11 @@ func GetUserPermissions(userId int32) ([]PermissionRowST, error) {
 		ORDER BY rrp.updated_at DESC;`, userId)
 }
 
-func GetServiceAccountPermissions(userId int32) ([]PermissionRowST, error) {
+func GetServiceAccountPermissions(serviceAccountId int32) ([]PermissionRowST, error) {
 	return All[PermissionRowST](`SELECT resources.uri as resource, rrp.actions
 		FROM service_account_roles sar
 		JOIN role_resource_permissions rrp ON rrp.role_id = sar.role_id
 		JOIN resources ON resources.id = rrp.resource_id
-		WHERE sar.user_id = $1
-		ORDER BY rrp.updated_at DESC;`, userId)
+		WHERE sar.service_account_id = $1
+		ORDER BY rrp.updated_at DESC;`, serviceAccountId)
 }
@@ -5,14 +5,13 @@ import (
 )
 
 type PhoneNumberRowST struct {
-	Id                int32     `db:"id"`
-	ApplicationId     int32     `db:"application_id"`
-	UserId            int32     `db:"user_id"`
-	PhoneNumber       string    `db:"phone_number"`
-	Confirmed         bool      `db:"confirmed"`
-	ConfirmationToken *string   `db:"confirmation_token"`
-	UpdatedAt         time.Time `db:"updated_at"`
-	CreatedAt         time.Time `db:"created_at"`
+	Id            int32     `db:"id"`
+	ApplicationId int32     `db:"application_id"`
+	UserId        int32     `db:"user_id"`
+	PhoneNumber   string    `db:"phone_number"`
+	Confirmed     bool      `db:"confirmed"`
+	UpdatedAt     time.Time `db:"updated_at"`
+	CreatedAt     time.Time `db:"created_at"`
 }
 
 func GetPhoneNumbersByUserId(userId int32) ([]PhoneNumberRowST, error) {
@@ -32,31 +31,19 @@ func GetUserPrimaryPhoneNumber(userId int32) (*PhoneNumberRowST, error) {
 		userId)
 }
 
-func CreatePhoneNumber(applicationId, userId int32, phoneNumber, confirmationToken string) (PhoneNumberRowST, error) {
-	return Get[PhoneNumberRowST](`INSERT INTO phone_numbers (application_id, user_id, phone_number, confirmation_token)
-		VALUES ($1, $2, $3, $4)
-		RETURNING *;`,
-		applicationId, userId, phoneNumber, confirmationToken)
-}
-
-func SetPhoneNumberConfirmation(userId, id int32, confirmationToken string) (PhoneNumberRowST, error) {
-	return Get[PhoneNumberRowST](`UPDATE phone_numbers SET
-		confirmation_token=COALESCE($3, confirmation_token)
-		WHERE user_id=$1 
-			AND id=$2
-		RETURNING *;`,
-		userId, id, confirmationToken)
+func GetPhoneNumberById(userId, id int32) (*PhoneNumberRowST, error) {
+	return GetOptional[PhoneNumberRowST](`SELECT p.*
+		FROM phone_numbers p
+		WHERE p.user_id = $1 AND p.id = $2
+		LIMIT 1;`,
+		userId, id)
 }
 
-func ConfirmPhoneNumber(userId, id int32, confirmationToken string) (PhoneNumberRowST, error) {
-	return Get[PhoneNumberRowST](`UPDATE phone_numbers SET
-		confirmed=true,
-		confirmation_token=NULL
-		WHERE user_id=$1 
-			AND id=$2
-			AND confirmation_token=$3
+func CreatePhoneNumber(applicationId, userId int32, phoneNumber string) (PhoneNumberRowST, error) {
+	return Get[PhoneNumberRowST](`INSERT INTO phone_numbers (application_id, user_id, phone_number)
+		VALUES ($1, $2, $3)
 		RETURNING *;`,
-		userId, id, confirmationToken)
+		applicationId, userId, phoneNumber)
 }
 
 func SetPrimaryPhoneNumber(userId, id int32) (bool, error) {
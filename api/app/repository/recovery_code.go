@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	RecoveryCodeCount = 10
+)
+
+type RecoveryCodeRowST struct {
+	Id            int32      `db:"id"`
+	UserId        int32      `db:"user_id"`
+	EncryptedCode string     `db:"encrypted_code"`
+	UsedAt        *time.Time `db:"used_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+func GetRecoveryCodesRemaining(userId int32) (int, error) {
+	return Get[int](`SELECT COUNT(*)
+		FROM recovery_codes rc
+		WHERE rc.user_id = $1 AND rc.used_at IS NULL;`,
+		userId)
+}
+
+func CreateRecoveryCodes(userId int32) ([]string, error) {
+	return Transaction(func(tx *sqlx.Tx) ([]string, error) {
+		_, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1;`, userId)
+		if err != nil {
+			return nil, err
+		}
+		codes := make([]string, 0, RecoveryCodeCount)
+		for len(codes) < RecoveryCodeCount {
+			code, err := util.GenerateRandomHex(5)
+			if err != nil {
+				return nil, err
+			}
+			_, err = tx.Exec(`INSERT INTO recovery_codes (user_id, encrypted_code)
+				VALUES ($1, $2);`,
+				userId, util.HashToken(code))
+			if err != nil {
+				return nil, err
+			}
+			codes = append(codes, code[:5]+"-"+code[5:])
+		}
+		return codes, nil
+	})
+}
+
+func UseRecoveryCode(userId int32, code string) (*RecoveryCodeRowST, error) {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return GetOptional[RecoveryCodeRowST](`UPDATE recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND encrypted_code = $2 AND used_at IS NULL
+		RETURNING *;`,
+		userId, util.HashToken(code))
+}
+
+// deleteRecoveryCodesWithoutMFA deletes the user's recovery codes once they have no MFA method left, so codes issued
+// for removed methods can't be used if MFA is enabled again
+func deleteRecoveryCodesWithoutMFA(tx *sqlx.Tx, userId int32) error {
+	_, err := tx.Exec(`DELETE FROM recovery_codes
+		WHERE user_id = $1 AND NOT EXISTS(SELECT 1 FROM user_mfas WHERE user_id = $1);`,
+		userId)
+	return err
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	RegistrationModeOpen       = "open"
+	RegistrationModeInviteCode = "invite_code"
+	RegistrationModeClosed     = "closed"
+)
+
+var RegistrationModes = []string{RegistrationModeOpen, RegistrationModeInviteCode, RegistrationModeClosed}
+
+var ErrInvalidInviteCode = errors.New("invalid invite code")
+
+type RegistrationSchemaRowST struct {
+	Id                  int32          `db:"id"`
+	ApplicationId       int32          `db:"application_id"`
+	TenentId            int32          `db:"tenent_id"`
+	Mode                string         `db:"mode"`
+	RequireEmail        bool           `db:"require_email"`
+	RequirePhoneNumber  bool           `db:"require_phone_number"`
+	RequiredInfo        pq.StringArray `db:"required_info"`
+	AllowedEmailDomains pq.StringArray `db:"allowed_email_domains"`
+	BlockedEmailDomains pq.StringArray `db:"blocked_email_domains"`
+	UpdatedAt           time.Time      `db:"updated_at"`
+	CreatedAt           time.Time      `db:"created_at"`
+}
+
+// DefaultRegistrationSchema is used when the tenent has no schema, it matches the column defaults
+func DefaultRegistrationSchema(applicationId, tenentId int32) RegistrationSchemaRowST {
+	return RegistrationSchemaRowST{
+		ApplicationId:       applicationId,
+		TenentId:            tenentId,
+		Mode:                RegistrationModeOpen,
+		RequiredInfo:        pq.StringArray{},
+		AllowedEmailDomains: pq.StringArray{},
+		BlockedEmailDomains: pq.StringArray{},
+	}
+}
+
+// GetRegistrationSchema returns the tenent's schema, falling back to the default schema
+func GetRegistrationSchema(applicationId, tenentId int32) (RegistrationSchemaRowST, error) {
+	schema, err := GetOptional[RegistrationSchemaRowST](`SELECT rs.*
+		FROM registration_schemas rs
+		WHERE rs.application_id = $1 AND rs.tenent_id = $2
+		LIMIT 1;`,
+		applicationId, tenentId)
+	if err != nil {
+		return DefaultRegistrationSchema(applicationId, tenentId), err
+	}
+	if schema == nil {
+		return DefaultRegistrationSchema(applicationId, tenentId), nil
+	}
+	return *schema, nil
+}
+
+type UpsertRegistrationSchemaST struct {
+	Mode                *string   `json:"mode" enums:"open,invite_code,closed"`
+	RequireEmail        *bool     `json:"require_email"`
+	RequirePhoneNumber  *bool     `json:"require_phone_number"`
+	RequiredInfo        *[]string `json:"required_info"`
+	AllowedEmailDomains *[]string `json:"allowed_email_domains"`
+	BlockedEmailDomains *[]string `json:"blocked_email_domains"`
+}
+
+// Apply returns the schema with the set fields of upsert applied
+func (upsert *UpsertRegistrationSchemaST) Apply(schema RegistrationSchemaRowST) RegistrationSchemaRowST {
+	if upsert.Mode != nil {
+		schema.Mode = *upsert.Mode
+	}
+	if upsert.RequireEmail != nil {
+		schema.RequireEmail = *upsert.RequireEmail
+	}
+	if upsert.RequirePhoneNumber != nil {
+		schema.RequirePhoneNumber = *upsert.RequirePhoneNumber
+	}
+	if upsert.RequiredInfo != nil {
+		schema.RequiredInfo = *upsert.RequiredInfo
+	}
+	if upsert.AllowedEmailDomains != nil {
+		schema.AllowedEmailDomains = *upsert.AllowedEmailDomains
+	}
+	if upsert.BlockedEmailDomains != nil {
+		schema.BlockedEmailDomains = *upsert.BlockedEmailDomains
+	}
+	return schema
+}
+
+func UpsertRegistrationSchema(schema RegistrationSchemaRowST) (RegistrationSchemaRowST, error) {
+	return NamedGet[RegistrationSchemaRowST](`INSERT INTO registration_schemas
+		(application_id, tenent_id, mode, require_email, require_phone_number, required_info, allowed_email_domains, blocked_email_domains)
+		VALUES (:application_id, :tenent_id, :mode, :require_email, :require_phone_number, :required_info, :allowed_email_domains, :blocked_email_domains)
+		ON CONFLICT (tenent_id) DO UPDATE SET
+			mode = :mode,
+			require_email = :require_email,
+			require_phone_number = :require_phone_number,
+			required_info = :required_info,
+			allowed_email_domains = :allowed_email_domains,
+			blocked_email_domains = :blocked_email_domains
+		RETURNING *;`, schema)
+}
+
+func DeleteRegistrationSchema(applicationId, tenentId int32) (bool, error) {
+	return Execute(`DELETE FROM registration_schemas WHERE application_id = $1 AND tenent_id = $2;`, applicationId, tenentId)
+}
+
+type RegistrationInviteCodeRowST struct {
+	Id            int32      `db:"id"`
+	ApplicationId int32      `db:"application_id"`
+	TenentId      int32      `db:"tenent_id"`
+	Description   string     `db:"description"`
+	EncryptedCode string     `db:"encrypted_code"`
+	MaxUses       *int32     `db:"max_uses"`
+	Uses          int32      `db:"uses"`
+	ExpiresAt     *time.Time `db:"expires_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+func GetRegistrationInviteCodes(applicationId, tenentId int32) ([]RegistrationInviteCodeRowST, error) {
+	return All[RegistrationInviteCodeRowST](`SELECT ric.*
+		FROM registration_invite_codes ric
+		WHERE ric.application_id = $1 AND ric.tenent_id = $2
+		ORDER BY ric.created_at DESC;`,
+		applicationId, tenentId)
+}
+
+type CreateRegistrationInviteCodeST struct {
+	Description      string `json:"description" validate:"required"`
+	MaxUses          *int32 `json:"max_uses"`
+	ExpiresInSeconds *int64 `json:"expires_in_seconds"`
+}
+
+// CreateRegistrationInviteCode returns the code users register with, only its hash is stored
+func CreateRegistrationInviteCode(applicationId, tenentId int32, create CreateRegistrationInviteCodeST) (RegistrationInviteCodeRowST, string, error) {
+	code, err := util.GenerateRandomHex(16)
+	if err != nil {
+		return RegistrationInviteCodeRowST{}, "", err
+	}
+	row, err := Get[RegistrationInviteCodeRowST](`INSERT INTO registration_invite_codes (application_id, tenent_id, description, encrypted_code, max_uses, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6))
+		RETURNING *;`,
+		applicationId, tenentId, create.Description, util.HashToken(code), create.MaxUses, create.ExpiresInSeconds)
+	return row, code, err
+}
+
+func DeleteRegistrationInviteCode(applicationId, tenentId, id int32) (bool, error) {
+	return Execute(`DELETE FROM registration_invite_codes WHERE application_id = $1 AND tenent_id = $2 AND id = $3;`, applicationId, tenentId, id)
+}
+
+type RegisterUserST struct {
+	TenentId    int32
+	Username    string
+	Password    string
+	Email       *string
+	PhoneNumber *string
+	Info        UpdateUserInfoST
+	// InviteCode is used up by the registration when set, ErrInvalidInviteCode is returned if it can't be
+	InviteCode *string
+}
+
+// RegisterUser creates the user with its password, unconfirmed primary email and phone number and info in one
+// transaction, nothing is created if any of it fails
+func RegisterUser(applicationId int32, register RegisterUserST) (UserAndUserInfoST, error) {
+	encryptedPassword, err := util.EncryptPassword(register.Password)
+	if err != nil {
+		return UserAndUserInfoST{}, err
+	}
+	return Transaction(func(tx *sqlx.Tx) (UserAndUserInfoST, error) {
+		var result UserAndUserInfoST
+		if register.InviteCode != nil {
+			var inviteCodeId int32
+			err := tx.Get(&inviteCodeId, `UPDATE registration_invite_codes
+				SET uses = uses + 1
+				WHERE application_id = $1 AND tenent_id = $2 AND encrypted_code = $3
+					AND (max_uses IS NULL OR uses < max_uses)
+					AND (expires_at IS NULL OR expires_at > NOW())
+				RETURNING id;`,
+				applicationId, register.TenentId, util.HashToken(*register.InviteCode))
+			if errors.Is(err, sql.ErrNoRows) {
+				return result, ErrInvalidInviteCode
+			}
+			if err != nil {
+				return result, err
+			}
+		}
+		err := tx.Get(&result.User, `INSERT INTO users (application_id, username, encrypted_password)
+			VALUES ($1, $2, $3)
+			RETURNING *;`,
+			applicationId, register.Username, encryptedPassword)
+		if err != nil {
+			return result, err
+		}
+		if register.Email != nil {
+			var emailId int32
+			err = tx.Get(&emailId, `INSERT INTO emails (application_id, user_id, email)
+				VALUES ($1, $2, $3)
+				RETURNING id;`,
+				applicationId, result.User.Id, *register.Email)
+			if err != nil {
+				return result, err
+			}
+			err = tx.Get(&result.User, `UPDATE users SET email_id = $2 WHERE id = $1 RETURNING *;`, result.User.Id, emailId)
+			if err != nil {
+				return result, err
+			}
+		}
+		if register.PhoneNumber != nil {
+			var phoneNumberId int32
+			err = tx.Get(&phoneNumberId, `INSERT INTO phone_numbers (application_id, user_id, phone_number)
+				VALUES ($1, $2, $3)
+				RETURNING id;`,
+				applicationId, result.User.Id, *register.PhoneNumber)
+			if err != nil {
+				return result, err
+			}
+			err = tx.Get(&result.User, `UPDATE users SET phone_number_id = $2 WHERE id = $1 RETURNING *;`, result.User.Id, phoneNumberId)
+			if err != nil {
+				return result, err
+			}
+		}
+		_, err = tx.Exec(`INSERT INTO user_infos (application_id, user_id) VALUES ($1, $2);`, applicationId, result.User.Id)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result.UserInfo, updateUserInfoSQL, register.Info.args(result.User.Id)...)
+		if err != nil {
+			return result, err
+		}
+		return result, nil
+	})
+}
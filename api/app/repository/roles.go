@@ -9,6 +9,7 @@ type RoleRowST struct {
 	ApplicationId int32     `db:"application_id"`
 	Description   string    `db:"description"`
 	URI           string    `db:"uri"`
+	ExternalId    *string   `db:"external_id"`
 	UpdatedAt     time.Time `db:"updated_at"`
 	CreatedAt     time.Time `db:"created_at"`
 }
@@ -20,6 +21,14 @@ func GetRoles(applicationId int32) ([]RoleRowST, error) {
 		ORDER BY r.updated_at DESC;`, applicationId)
 }
 
+func GetUserRoles(userId int32) ([]RoleRowST, error) {
+	return All[RoleRowST](`SELECT r.*
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.uri ASC;`, userId)
+}
+
 func GetRoleById(id int32) ([]RoleRowST, error) {
 	return All[RoleRowST](`SELECT r.*
 		FROM roles r
@@ -27,6 +36,17 @@ func GetRoleById(id int32) ([]RoleRowST, error) {
 		LIMIT 1;`, id)
 }
 
+func GetApplicationRoleById(applicationId, id int32) (*RoleRowST, error) {
+	return GetOptional[RoleRowST](`SELECT r.*
+		FROM roles r
+		WHERE r.application_id = $1 AND r.id = $2
+		LIMIT 1;`, applicationId, id)
+}
+
+func DeleteApplicationRole(applicationId, id int32) (bool, error) {
+	return Execute(`DELETE FROM roles WHERE application_id = $1 AND id = $2;`, applicationId, id)
+}
+
 type CreateRoleST struct {
 	ApplicationId int32  `db:"application_id"`
 	Description   string `db:"description"`
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+)
+
+const (
+	SAMLNameIdFormatPersistent  = "persistent"
+	SAMLNameIdFormatEmail       = "email"
+	SAMLNameIdFormatUnspecified = "unspecified"
+	SAMLNameIdFormatTransient   = "transient"
+)
+
+type SAMLServiceProviderRowST struct {
+	Id               int32     `db:"id"`
+	ApplicationId    int32     `db:"application_id"`
+	TenentId         int32     `db:"tenent_id"`
+	Name             string    `db:"name"`
+	EntityId         string    `db:"entity_id"`
+	Metadata         *string   `db:"metadata"`
+	ACSURL           *string   `db:"acs_url"`
+	NameIdFormat     string    `db:"name_id_format"`
+	AttributeMapping string    `db:"attribute_mapping"`
+	Enabled          bool      `db:"enabled"`
+	UpdatedAt        time.Time `db:"updated_at"`
+	CreatedAt        time.Time `db:"created_at"`
+}
+
+// Attributes returns the SAML attribute names mapped to the user fields they are filled from
+func (row *SAMLServiceProviderRowST) Attributes() (map[string]string, error) {
+	attributes := make(map[string]string)
+	if err := json.Unmarshal([]byte(row.AttributeMapping), &attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+func GetSAMLServiceProviders(tenentId int32) ([]SAMLServiceProviderRowST, error) {
+	return All[SAMLServiceProviderRowST](`SELECT ssp.*
+		FROM saml_service_providers ssp
+		WHERE ssp.tenent_id = $1
+		ORDER BY ssp.name;`,
+		tenentId)
+}
+
+func GetSAMLServiceProviderById(id int32) (*SAMLServiceProviderRowST, error) {
+	return GetOptional[SAMLServiceProviderRowST](`SELECT ssp.*
+		FROM saml_service_providers ssp
+		WHERE ssp.id = $1
+		LIMIT 1;`,
+		id)
+}
+
+func GetSAMLServiceProviderByEntityId(tenentId int32, entityId string) (*SAMLServiceProviderRowST, error) {
+	return GetOptional[SAMLServiceProviderRowST](`SELECT ssp.*
+		FROM saml_service_providers ssp
+		WHERE ssp.tenent_id = $1 AND ssp.entity_id = $2
+		LIMIT 1;`,
+		tenentId, entityId)
+}
+
+type CreateSAMLServiceProviderST struct {
+	Name             string            `json:"name" validate:"required"`
+	EntityId         string            `json:"entity_id" validate:"required"`
+	Metadata         *string           `json:"metadata"`
+	ACSURL           *string           `json:"acs_url"`
+	NameIdFormat     *string           `json:"name_id_format" enums:"persistent,email,unspecified,transient"`
+	AttributeMapping map[string]string `json:"attribute_mapping"`
+	Enabled          *bool             `json:"enabled"`
+}
+
+func CreateSAMLServiceProvider(applicationId, tenentId int32, create CreateSAMLServiceProviderST) (SAMLServiceProviderRowST, error) {
+	if create.AttributeMapping == nil {
+		create.AttributeMapping = make(map[string]string)
+	}
+	attributeMapping, err := json.Marshal(create.AttributeMapping)
+	if err != nil {
+		return SAMLServiceProviderRowST{}, err
+	}
+	return Get[SAMLServiceProviderRowST](`INSERT INTO saml_service_providers
+		(application_id, tenent_id, name, entity_id, metadata, acs_url, name_id_format, attribute_mapping, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, 'persistent'), $8, COALESCE($9, true))
+		RETURNING *;`,
+		applicationId, tenentId, create.Name, create.EntityId, create.Metadata, create.ACSURL, create.NameIdFormat,
+		string(attributeMapping), create.Enabled)
+}
+
+type UpdateSAMLServiceProviderST struct {
+	Name             *string           `json:"name"`
+	EntityId         *string           `json:"entity_id"`
+	Metadata         *string           `json:"metadata"`
+	ACSURL           *string           `json:"acs_url"`
+	NameIdFormat     *string           `json:"name_id_format" enums:"persistent,email,unspecified,transient"`
+	AttributeMapping map[string]string `json:"attribute_mapping"`
+	Enabled          *bool             `json:"enabled"`
+}
+
+func UpdateSAMLServiceProvider(id int32, update UpdateSAMLServiceProviderST) (*SAMLServiceProviderRowST, error) {
+	var attributeMapping *string
+	if update.AttributeMapping != nil {
+		bytes, err := json.Marshal(update.AttributeMapping)
+		if err != nil {
+			return nil, err
+		}
+		value := string(bytes)
+		attributeMapping = &value
+	}
+	return GetOptional[SAMLServiceProviderRowST](`UPDATE saml_service_providers SET
+		name = COALESCE($2, name),
+		entity_id = COALESCE($3, entity_id),
+		metadata = COALESCE($4, metadata),
+		acs_url = COALESCE($5, acs_url),
+		name_id_format = COALESCE($6, name_id_format),
+		attribute_mapping = COALESCE($7::JSONB, attribute_mapping),
+		enabled = COALESCE($8, enabled)
+		WHERE id = $1
+		RETURNING *;`,
+		id, update.Name, update.EntityId, update.Metadata, update.ACSURL, update.NameIdFormat, attributeMapping,
+		update.Enabled)
+}
+
+func DeleteSAMLServiceProvider(id int32) (bool, error) {
+	return Execute(`DELETE FROM saml_service_providers WHERE id = $1;`, id)
+}
+
+type SAMLRequestRowST struct {
+	Id                    int32      `db:"id"`
+	SAMLServiceProviderId int32      `db:"saml_service_provider_id"`
+	EncryptedToken        string     `db:"encrypted_token"`
+	Request               string     `db:"request"`
+	RelayState            string     `db:"relay_state"`
+	UsedAt                *time.Time `db:"used_at"`
+	ExpiresAt             time.Time  `db:"expires_at"`
+	UpdatedAt             time.Time  `db:"updated_at"`
+	CreatedAt             time.Time  `db:"created_at"`
+}
+
+// CreateSAMLRequest stores a validated AuthnRequest until the user signs in and returns the token the authorization
+// website completes it with, the token is stored hashed
+func CreateSAMLRequest(samlServiceProviderId int32, request, relayState string, expiresInSeconds int64) (string, error) {
+	token, err := util.GenerateRandomHex(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = Execute(`INSERT INTO saml_requests (saml_service_provider_id, encrypted_token, request, relay_state, expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + make_interval(secs => $5));`,
+		samlServiceProviderId, util.HashToken(token), request, relayState, expiresInSeconds)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// UseSAMLRequest returns the pending request if it was sent to a service provider of the tenent, a request can only
+// be used once
+func UseSAMLRequest(tenentId int32, token string) (*SAMLRequestRowST, error) {
+	return GetOptional[SAMLRequestRowST](`UPDATE saml_requests sr SET
+		used_at = NOW()
+		FROM saml_service_providers ssp
+		WHERE ssp.id = sr.saml_service_provider_id AND ssp.tenent_id = $1 AND sr.encrypted_token = $2 AND sr.used_at IS NULL AND sr.expires_at > NOW()
+		RETURNING sr.*;`,
+		tenentId, util.HashToken(token))
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/aicacia/auth/api/app/scim"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var scimUserAttributes = map[string]scim.SQLAttributeST{
+	"id":                {Column: "u.id::text", CaseExact: true},
+	"externalid":        {Column: "u.external_id", CaseExact: true},
+	"username":          {Column: "u.username"},
+	"active":            {Column: userActiveSQL, Type: scim.AttributeTypeBoolean},
+	"displayname":       {Column: "ui.name"},
+	"name.formatted":    {Column: "ui.name"},
+	"name.givenname":    {Column: "ui.given_name"},
+	"name.familyname":   {Column: "ui.family_name"},
+	"name.middlename":   {Column: "ui.middle_name"},
+	"nickname":          {Column: "ui.nickname"},
+	"profileurl":        {Column: "ui.profile"},
+	"locale":            {Column: "ui.locale"},
+	"timezone":          {Column: "ui.zoneinfo"},
+	"meta.created":      {Column: "u.created_at", Type: scim.AttributeTypeDateTime},
+	"meta.lastmodified": {Column: "u.updated_at", Type: scim.AttributeTypeDateTime},
+	"emails": {
+		Exists: "SELECT 1 FROM emails e WHERE e.user_id = u.id AND %s",
+		SubAttributes: map[string]scim.SQLAttributeST{
+			"value":   {Column: "e.email"},
+			"primary": {Column: "(e.id = u.email_id)", Type: scim.AttributeTypeBoolean},
+		},
+	},
+	"phonenumbers": {
+		Exists: "SELECT 1 FROM phone_numbers p WHERE p.user_id = u.id AND %s",
+		SubAttributes: map[string]scim.SQLAttributeST{
+			"value":   {Column: "p.phone_number"},
+			"primary": {Column: "(p.id = u.phone_number_id)", Type: scim.AttributeTypeBoolean},
+		},
+	},
+	"groups": {
+		Exists: "SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = u.id AND %s",
+		SubAttributes: map[string]scim.SQLAttributeST{
+			"value":   {Column: "r.id::text", CaseExact: true},
+			"display": {Column: "r.uri"},
+		},
+	},
+}
+
+var scimGroupAttributes = map[string]scim.SQLAttributeST{
+	"id":                {Column: "r.id::text", CaseExact: true},
+	"externalid":        {Column: "r.external_id", CaseExact: true},
+	"displayname":       {Column: "r.uri"},
+	"meta.created":      {Column: "r.created_at", Type: scim.AttributeTypeDateTime},
+	"meta.lastmodified": {Column: "r.updated_at", Type: scim.AttributeTypeDateTime},
+	"members": {
+		Exists: "SELECT 1 FROM user_roles ur WHERE ur.role_id = r.id AND %s",
+		SubAttributes: map[string]scim.SQLAttributeST{
+			"value": {Column: "ur.user_id::text", CaseExact: true},
+		},
+	},
+}
+
+// scimWhere builds the where clause for a list request, $1 is always the application id
+func scimWhere(applicationId int32, filter *scim.ExpressionST, attributes map[string]scim.SQLAttributeST, applicationColumn string) (string, []interface{}, error) {
+	args := []interface{}{applicationId}
+	where := fmt.Sprintf("%s = $1", applicationColumn)
+	if filter != nil {
+		condition, err := scim.ToSQL(filter, attributes, &args)
+		if err != nil {
+			return "", nil, err
+		}
+		where += " AND " + condition
+	}
+	return where, args, nil
+}
+
+// GetSCIMUsers returns the users matching filter starting at offset and the total number of matches
+func GetSCIMUsers(applicationId int32, filter *scim.ExpressionST, offset, limit int) ([]UserRowST, int, error) {
+	where, args, err := scimWhere(applicationId, filter, scimUserAttributes, "u.application_id")
+	if err != nil {
+		return nil, 0, err
+	}
+	from := `FROM users u
+		LEFT JOIN user_infos ui ON ui.user_id = u.id
+		WHERE ` + where
+	total, err := Get[int](`SELECT COUNT(*) `+from+`;`, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit == 0 {
+		return nil, total, nil
+	}
+	users, err := All[UserRowST](fmt.Sprintf(`SELECT u.* %s
+		ORDER BY u.id ASC
+		LIMIT $%d OFFSET $%d;`, from, len(args)+1, len(args)+2),
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetSCIMGroups returns the roles matching filter starting at offset and the total number of matches
+func GetSCIMGroups(applicationId int32, filter *scim.ExpressionST, offset, limit int) ([]RoleRowST, int, error) {
+	where, args, err := scimWhere(applicationId, filter, scimGroupAttributes, "r.application_id")
+	if err != nil {
+		return nil, 0, err
+	}
+	from := `FROM roles r
+		WHERE ` + where
+	total, err := Get[int](`SELECT COUNT(*) `+from+`;`, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if limit == 0 {
+		return nil, total, nil
+	}
+	roles, err := All[RoleRowST](fmt.Sprintf(`SELECT r.* %s
+		ORDER BY r.id ASC
+		LIMIT $%d OFFSET $%d;`, from, len(args)+1, len(args)+2),
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return roles, total, nil
+}
+
+type RoleMemberRowST struct {
+	RoleId   int32  `db:"role_id"`
+	UserId   int32  `db:"user_id"`
+	Username string `db:"username"`
+}
+
+func GetRolesMembers(roleIds []int32) ([]RoleMemberRowST, error) {
+	return All[RoleMemberRowST](`SELECT ur.role_id, u.id AS user_id, u.username
+		FROM user_roles ur
+		JOIN users u ON u.id = ur.user_id
+		WHERE ur.role_id = ANY($1)
+		ORDER BY u.id ASC;`,
+		pq.Array(roleIds))
+}
+
+type SaveSCIMUserST struct {
+	Username          string
+	ExternalId        *string
+	Active            bool
+	EncryptedPassword *string
+	// primary first
+	Emails       []string
+	PhoneNumbers []string
+	Info         UpdateUserInfoST
+}
+
+// scimUserStatusSQL sets the status from SCIM's active attribute in the active parameter, deactivating only touches
+// active users and activating only disabled ones so locked and pending users keep their status
+func scimUserStatusSQL(active string) string {
+	return fmt.Sprintf(`status_changed_at = (CASE WHEN (%[1]s AND status = 'disabled') OR (NOT %[1]s AND status = 'active') THEN CURRENT_TIMESTAMP ELSE status_changed_at END),
+			tokens_revoked_at = (CASE WHEN NOT %[1]s AND status = 'active' THEN CURRENT_TIMESTAMP ELSE tokens_revoked_at END),
+			status = (CASE WHEN %[1]s AND status = 'disabled' THEN 'active' WHEN NOT %[1]s AND status = 'active' THEN 'disabled' ELSE status END)::USER_STATUS`, active)
+}
+
+func CreateSCIMUser(applicationId int32, user SaveSCIMUserST) (UserRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (UserRowST, error) {
+		var result UserRowST
+		importUserRow := ImportUserST{
+			Username: user.Username,
+			Info:     user.Info,
+		}
+		if user.EncryptedPassword != nil {
+			importUserRow.EncryptedPassword = *user.EncryptedPassword
+		}
+		for _, email := range user.Emails {
+			importUserRow.Emails = append(importUserRow.Emails, ImportUserEmailST{Email: email})
+		}
+		for _, phoneNumber := range user.PhoneNumbers {
+			importUserRow.PhoneNumbers = append(importUserRow.PhoneNumbers, ImportUserPhoneNumberST{PhoneNumber: phoneNumber})
+		}
+		userId, err := importUser(tx, applicationId, importUserRow)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result, `UPDATE users SET external_id = $2, `+scimUserStatusSQL("$3")+` WHERE id = $1 RETURNING *;`,
+			userId, user.ExternalId, user.Active)
+		return result, err
+	})
+}
+
+// UpdateSCIMUser replaces a user with the given attributes, emails and phone numbers that are kept keep their
+// confirmation, user info that SCIM has no attribute for is left alone
+func UpdateSCIMUser(applicationId, id int32, user SaveSCIMUserST) (*UserRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (*UserRowST, error) {
+		var result UserRowST
+		err := tx.Get(&result, `UPDATE users SET
+			username = $3,
+			external_id = $4,
+			`+scimUserStatusSQL("$5")+`,
+			encrypted_password = COALESCE($6, encrypted_password),
+			password_set = password_set OR $6 IS NOT NULL,
+			email_id = NULL,
+			phone_number_id = NULL
+			WHERE application_id = $1 AND id = $2
+			RETURNING *;`,
+			applicationId, id, user.Username, user.ExternalId, user.Active, user.EncryptedPassword)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM emails WHERE user_id = $1 AND NOT (email = ANY($2));`, id, pq.Array(user.Emails)); err != nil {
+			return nil, err
+		}
+		for _, email := range user.Emails {
+			if _, err := tx.Exec(`INSERT INTO emails (application_id, user_id, email)
+				SELECT $1, $2, $3
+				WHERE NOT EXISTS(SELECT 1 FROM emails WHERE user_id = $2 AND email = $3);`,
+				applicationId, id, email); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM phone_numbers WHERE user_id = $1 AND NOT (phone_number = ANY($2));`, id, pq.Array(user.PhoneNumbers)); err != nil {
+			return nil, err
+		}
+		for _, phoneNumber := range user.PhoneNumbers {
+			if _, err := tx.Exec(`INSERT INTO phone_numbers (application_id, user_id, phone_number)
+				SELECT $1, $2, $3
+				WHERE NOT EXISTS(SELECT 1 FROM phone_numbers WHERE user_id = $2 AND phone_number = $3);`,
+				applicationId, id, phoneNumber); err != nil {
+				return nil, err
+			}
+		}
+		var primaryEmail, primaryPhoneNumber *string
+		if len(user.Emails) > 0 {
+			primaryEmail = &user.Emails[0]
+		}
+		if len(user.PhoneNumbers) > 0 {
+			primaryPhoneNumber = &user.PhoneNumbers[0]
+		}
+		err = tx.Get(&result, `UPDATE users SET
+			email_id = (SELECT id FROM emails WHERE user_id = $1 AND email = $2),
+			phone_number_id = (SELECT id FROM phone_numbers WHERE user_id = $1 AND phone_number = $3)
+			WHERE id = $1
+			RETURNING *;`,
+			id, primaryEmail, primaryPhoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		info := user.Info
+		if _, err := tx.Exec(`UPDATE user_infos SET
+			name = $2,
+			given_name = $3,
+			family_name = $4,
+			middle_name = $5,
+			nickname = $6,
+			profile = $7,
+			picture = $8,
+			zoneinfo = $9,
+			locale = $10,
+			street_address = $11,
+			locality = $12,
+			region = $13,
+			postal_code = $14,
+			country = $15
+			WHERE user_id = $1;`,
+			id, info.Name, info.GivenName, info.FamilyName, info.MiddleName, info.Nickname, info.Profile,
+			info.Picture, info.Zoneinfo, info.Locale, info.StreetAddress, info.Locality, info.Region,
+			info.PostalCode, info.Country); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	})
+}
+
+type SaveSCIMGroupST struct {
+	DisplayName string
+	ExternalId  *string
+	MemberIds   []int32
+}
+
+func CreateSCIMGroup(applicationId int32, group SaveSCIMGroupST) (RoleRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (RoleRowST, error) {
+		var result RoleRowST
+		err := tx.Get(&result, `INSERT INTO roles (application_id, description, uri, external_id)
+			VALUES ($1, $2, $2, $3)
+			RETURNING *;`,
+			applicationId, group.DisplayName, group.ExternalId)
+		if err != nil {
+			return result, err
+		}
+		return result, setRoleMembers(tx, applicationId, result.Id, group.MemberIds)
+	})
+}
+
+func UpdateSCIMGroup(applicationId, id int32, group SaveSCIMGroupST) (*RoleRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (*RoleRowST, error) {
+		var result RoleRowST
+		err := tx.Get(&result, `UPDATE roles SET
+			uri = $3,
+			external_id = $4
+			WHERE application_id = $1 AND id = $2
+			RETURNING *;`,
+			applicationId, id, group.DisplayName, group.ExternalId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &result, setRoleMembers(tx, applicationId, id, group.MemberIds)
+	})
+}
+
+// setRoleMembers replaces the users of a role, ids of users in other applications are ignored
+func setRoleMembers(tx *sqlx.Tx, applicationId, roleId int32, userIds []int32) error {
+	if _, err := tx.Exec(`DELETE FROM user_roles WHERE role_id = $1 AND NOT (user_id = ANY($2));`, roleId, pq.Array(userIds)); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, $2 FROM users u
+		WHERE u.application_id = $1 AND u.id = ANY($3)
+		ON CONFLICT (user_id, role_id) DO NOTHING;`,
+		applicationId, roleId, pq.Array(userIds))
+	return err
+}
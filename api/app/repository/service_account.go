@@ -9,6 +9,7 @@ import (
 
 type ServiceAccountRowST struct {
 	Id              int32     `db:"id"`
+	ApplicationId   int32     `db:"application_id"`
 	Name            string    `db:"name"`
 	Key             uuid.UUID `db:"key"`
 	EncryptedSecret string    `db:"encrypted_secret"`
@@ -4,49 +4,54 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type TenentRowST struct {
-	Id                            int32     `db:"id"`
-	ApplicationId                 int32     `db:"application_id"`
-	Description                   string    `db:"description"`
-	URI                           string    `db:"uri"`
-	AuthorizationWebsite          string    `db:"authorization_website"`
-	RegistrationWebsite           *string   `db:"registration_website"`
-	EmailEndpoint                 *string   `db:"email_endpoint"`
-	PhoneNumberEndpoint           *string   `db:"phone_number_endpoint"`
-	Algorithm                     string    `db:"algorithm"`
-	ClientId                      uuid.UUID `db:"client_id"`
-	ClientSecret                  string    `db:"client_secret"`
-	PublicKey                     *string   `db:"public_key"`
-	PrivateKey                    string    `db:"private_key"`
-	ExpiresInSeconds              int64     `db:"expires_in_seconds"`
-	RefreshExpiresInSeconds       int64     `db:"refresh_expires_in_seconds"`
-	PasswordResetExpiresInSeconds int64     `db:"password_reset_expires_in_seconds"`
-	UpdatedAt                     time.Time `db:"updated_at"`
-	CreatedAt                     time.Time `db:"created_at"`
+	Id                               int32          `db:"id"`
+	ApplicationId                    int32          `db:"application_id"`
+	Description                      string         `db:"description"`
+	URI                              string         `db:"uri"`
+	AuthorizationWebsite             string         `db:"authorization_website"`
+	RegistrationWebsite              *string        `db:"registration_website"`
+	EmailEndpoint                    *string        `db:"email_endpoint"`
+	PhoneNumberEndpoint              *string        `db:"phone_number_endpoint"`
+	Algorithm                        string         `db:"algorithm"`
+	ClientId                         uuid.UUID      `db:"client_id"`
+	ClientSecret                     string         `db:"client_secret"`
+	PublicKey                        *string        `db:"public_key"`
+	PrivateKey                       string         `db:"private_key"`
+	ExpiresInSeconds                 int64          `db:"expires_in_seconds"`
+	RefreshExpiresInSeconds          int64          `db:"refresh_expires_in_seconds"`
+	PasswordResetExpiresInSeconds    int64          `db:"password_reset_expires_in_seconds"`
+	PasswordlessEnabled              bool           `db:"passwordless_enabled"`
+	PasswordlessRegistration         bool           `db:"passwordless_registration_enabled"`
+	SAMLCertificate                  *string        `db:"saml_certificate"`
+	ThirdParty                       bool           `db:"third_party"`
+	RedirectURIs                     pq.StringArray `db:"redirect_uris"`
+	GrantTypes                       pq.StringArray `db:"grant_types"`
+	TokenEndpointAuthMethod          string         `db:"token_endpoint_auth_method"`
+	EncryptedRegistrationAccessToken *string        `db:"encrypted_registration_access_token"`
+	RequireConfirmedEmail            bool           `db:"require_confirmed_email"`
+	RequireConfirmedPhoneNumber      bool           `db:"require_confirmed_phone_number"`
+	DefaultPhoneRegion               *string        `db:"default_phone_region"`
+	FoldEmailAliases                 bool           `db:"fold_email_aliases"`
+	UpdatedAt                        time.Time      `db:"updated_at"`
+	CreatedAt                        time.Time      `db:"created_at"`
 }
 
-func GetTenents(applicationId int32, limit, offset *int) ([]TenentRowST, error) {
-	if limit == nil && offset == nil {
-		return All[TenentRowST](`SELECT at.* 
-			FROM tenents at 
-			WHERE at.application_id = $1 
-			ORDER BY at.updated_at DESC ;`, applicationId)
-	}
-	if limit == nil {
-		limit = new(int)
-		*limit = 10
-	}
-	if offset == nil {
-		offset = new(int)
-		*offset = 0
-	}
-	return All[TenentRowST](`SELECT at.* 
-		FROM tenents at 
-		WHERE at.application_id = $1 
-		ORDER BY at.updated_at DESC 
-		LIMIT $2 OFFSET $3;`, applicationId, limit, offset)
+var tenentsKeyset = KeysetST[TenentRowST]{
+	Name:       "updated_at",
+	Column:     "at.updated_at",
+	IdColumn:   "at.id",
+	Time:       true,
+	Descending: true,
+	Key:        func(row TenentRowST) interface{} { return row.UpdatedAt },
+	Id:         func(row TenentRowST) int32 { return row.Id },
+}
+
+func GetTenents(applicationId int32, page PageST) (*PageResultST[TenentRowST], error) {
+	return Paginate(`SELECT at.* FROM tenents at`, `at.application_id = $1`, []interface{}{applicationId}, tenentsKeyset, page)
 }
 
 func GetTenentById(id int32) (*TenentRowST, error) {
@@ -79,6 +84,13 @@ type CreateTenentST struct {
 	ExpiresInSeconds              *int64     `json:"expires_in_seconds"`
 	RefreshExpiresInSeconds       *int64     `json:"refresh_expires_in_seconds"`
 	PasswordResetExpiresInSeconds *int64     `json:"password_reset_expires_in_seconds"`
+	PasswordlessEnabled           *bool      `json:"passwordless_enabled"`
+	PasswordlessRegistration      *bool      `json:"passwordless_registration_enabled"`
+	ThirdParty                    *bool      `json:"third_party"`
+	RequireConfirmedEmail         *bool      `json:"require_confirmed_email"`
+	RequireConfirmedPhoneNumber   *bool      `json:"require_confirmed_phone_number"`
+	DefaultPhoneRegion            *string    `json:"default_phone_region"`
+	FoldEmailAliases              *bool      `json:"fold_email_aliases"`
 }
 
 func CreateTenent(applicationId int32, create CreateTenentST) (TenentRowST, error) {
@@ -102,6 +114,13 @@ func CreateTenent(applicationId int32, create CreateTenentST) (TenentRowST, erro
 		ExpiresInSeconds:              create.ExpiresInSeconds,
 		RefreshExpiresInSeconds:       create.RefreshExpiresInSeconds,
 		PasswordResetExpiresInSeconds: create.PasswordResetExpiresInSeconds,
+		PasswordlessEnabled:           create.PasswordlessEnabled,
+		PasswordlessRegistration:      create.PasswordlessRegistration,
+		ThirdParty:                    create.ThirdParty,
+		RequireConfirmedEmail:         create.RequireConfirmedEmail,
+		RequireConfirmedPhoneNumber:   create.RequireConfirmedPhoneNumber,
+		DefaultPhoneRegion:            create.DefaultPhoneRegion,
+		FoldEmailAliases:              create.FoldEmailAliases,
 	})
 	if updatedTenentApplication == nil {
 		return tenent, err
@@ -123,6 +142,13 @@ type UpdateTenentST struct {
 	ExpiresInSeconds              *int64     `json:"expires_in_seconds"`
 	RefreshExpiresInSeconds       *int64     `json:"refresh_expires_in_seconds"`
 	PasswordResetExpiresInSeconds *int64     `json:"password_reset_expires_in_seconds"`
+	PasswordlessEnabled           *bool      `json:"passwordless_enabled"`
+	PasswordlessRegistration      *bool      `json:"passwordless_registration_enabled"`
+	ThirdParty                    *bool      `json:"third_party"`
+	RequireConfirmedEmail         *bool      `json:"require_confirmed_email"`
+	RequireConfirmedPhoneNumber   *bool      `json:"require_confirmed_phone_number"`
+	DefaultPhoneRegion            *string    `json:"default_phone_region"`
+	FoldEmailAliases              *bool      `json:"fold_email_aliases"`
 }
 
 func UpdateTenent(id int32, update UpdateTenentST) (*TenentRowST, error) {
@@ -139,10 +165,17 @@ func UpdateTenent(id int32, update UpdateTenentST) (*TenentRowST, error) {
 		private_key = COALESCE($11, private_key),
 		expires_in_seconds = COALESCE($12, expires_in_seconds),
 		refresh_expires_in_seconds = COALESCE($13, refresh_expires_in_seconds),
-		password_reset_expires_in_seconds = COALESCE($14, password_reset_expires_in_seconds)
+		password_reset_expires_in_seconds = COALESCE($14, password_reset_expires_in_seconds),
+		passwordless_enabled = COALESCE($15, passwordless_enabled),
+		passwordless_registration_enabled = COALESCE($16, passwordless_registration_enabled),
+		third_party = COALESCE($17, third_party),
+		require_confirmed_email = COALESCE($18, require_confirmed_email),
+		require_confirmed_phone_number = COALESCE($19, require_confirmed_phone_number),
+		default_phone_region = COALESCE($20, default_phone_region),
+		fold_email_aliases = COALESCE($21, fold_email_aliases)
 		WHERE id = $1
 		RETURNING *;`,
-		id, update.Description, update.URI, update.AuthorizationWebsite, update.RegistrationWebsite, update.EmailEndpoint, update.PhoneNumberEndpoint, update.ClientId, update.Algorithm, update.PublicKey, update.PrivateKey, update.ExpiresInSeconds, update.RefreshExpiresInSeconds, update.PasswordResetExpiresInSeconds,
+		id, update.Description, update.URI, update.AuthorizationWebsite, update.RegistrationWebsite, update.EmailEndpoint, update.PhoneNumberEndpoint, update.ClientId, update.Algorithm, update.PublicKey, update.PrivateKey, update.ExpiresInSeconds, update.RefreshExpiresInSeconds, update.PasswordResetExpiresInSeconds, update.PasswordlessEnabled, update.PasswordlessRegistration, update.ThirdParty, update.RequireConfirmedEmail, update.RequireConfirmedPhoneNumber, update.DefaultPhoneRegion, update.FoldEmailAliases,
 	)
 }
 
@@ -155,6 +188,10 @@ func RegenerateTenentSecret(id int32) (*TenentRowST, error) {
 	)
 }
 
+func SetTenentSAMLCertificate(id int32, certificate string) (bool, error) {
+	return Execute(`UPDATE tenents SET saml_certificate = $2 WHERE id = $1;`, id, certificate)
+}
+
 func DeleteTenent(id int32) (bool, error) {
 	return Execute(`DELETE FROM tenents WHERE id = $1;`, id)
 }
@@ -20,7 +20,7 @@ func GetTOTPsByUserId(userId int32) ([]TOTPRowST, error) {
 	return All[TOTPRowST](
 		`SELECT utt.*, (um.id IS NOT NULL) as enabled
 		FROM totps utt
-		LEFT JOIN user_mfas um ON um.type = 'totp' AND um.id = utt.id
+		LEFT JOIN user_mfas um ON um.user_id = utt.user_id AND um.type = 'totp' AND um.id = utt.id
 		WHERE utt.user_id = $1;`,
 		userId)
 }
@@ -28,7 +28,7 @@ func GetTOTPsByUserId(userId int32) ([]TOTPRowST, error) {
 func GetTOTPsByUserIdAndTenentId(userId, tenentId int32) (*TOTPRowST, error) {
 	return GetOptional[TOTPRowST](`SELECT utt.*, (um.id IS NOT NULL) as enabled
 		FROM totps utt
-		LEFT JOIN user_mfas um ON um.type = 'totp' AND um.id = utt.id
+		LEFT JOIN user_mfas um ON um.user_id = utt.user_id AND um.type = 'totp' AND um.id = utt.id
 		WHERE utt.user_id = $1 AND utt.tenent_id = $2
 		LIMIT 1;`,
 		userId, tenentId)
@@ -38,7 +38,7 @@ func CreateTOTP(userId, tenentId int32) (TOTPRowST, error) {
 	return Get[TOTPRowST](`INSERT INTO totps (tenent_id, user_id, secret)
 		VALUES ($1, $2, $3)
 		RETURNING *;`,
-		userId, tenentId, gotp.RandomSecret(16))
+		tenentId, userId, gotp.RandomSecret(16))
 }
 
 func DeleteTOTP(userId, tenentId int32) (bool, error) {
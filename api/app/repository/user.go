@@ -1,99 +1,189 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/aicacia/auth/api/app/util"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type UserRowST struct {
-	Id                int32     `db:"id"`
-	ApplicationId     int32     `db:"application_id"`
-	EmailId           *int32    `db:"email_id"`
-	PhoneNumberId     *int32    `db:"phone_number_id"`
-	Username          string    `db:"username"`
-	EncryptedPassword string    `db:"encrypted_password"`
-	Key               []byte    `db:"key"`
-	UpdatedAt         time.Time `db:"updated_at"`
-	CreatedAt         time.Time `db:"created_at"`
+	Id                              int32      `db:"id"`
+	ApplicationId                   int32      `db:"application_id"`
+	EmailId                         *int32     `db:"email_id"`
+	PhoneNumberId                   *int32     `db:"phone_number_id"`
+	Username                        string     `db:"username"`
+	EncryptedPassword               string     `db:"encrypted_password"`
+	Key                             []byte     `db:"key"`
+	ExternalId                      *string    `db:"external_id"`
+	LDAPBacked                      bool       `db:"ldap_backed"`
+	LDAPDN                          *string    `db:"ldap_dn"`
+	PasswordSet                     bool       `db:"password_set"`
+	Status                          string     `db:"status"`
+	StatusReason                    *string    `db:"status_reason"`
+	StatusExpiresAt                 *time.Time `db:"status_expires_at"`
+	StatusChangedByUserId           *int32     `db:"status_changed_by_user_id"`
+	StatusChangedByServiceAccountId *int32     `db:"status_changed_by_service_account_id"`
+	StatusChangedAt                 *time.Time `db:"status_changed_at"`
+	TokensRevokedAt                 *time.Time `db:"tokens_revoked_at"`
+	UpdatedAt                       time.Time  `db:"updated_at"`
+	CreatedAt                       time.Time  `db:"created_at"`
 }
 
-func GetUsers(applicationId int32, limit, offset *int) ([]UserRowST, error) {
-	if limit == nil && offset == nil {
-		return All[UserRowST](`SELECT u.* 
-			FROM users u 
-			WHERE u.application_id = $1
-			ORDER BY u.updated_at DESC;`, applicationId)
-	}
-	if limit == nil {
-		limit = new(int)
-		*limit = 10
-	}
-	if offset == nil {
-		offset = new(int)
-		*offset = 0
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusLocked   = "locked"
+	UserStatusPending  = "pending"
+)
+
+var UserStatuses = []string{UserStatusActive, UserStatusDisabled, UserStatusLocked, UserStatusPending}
+
+// userActiveSQL is true for users that are active, including those whose status has expired
+const userActiveSQL = `(u.status = 'active' OR u.status_expires_at <= CURRENT_TIMESTAMP)`
+
+// CurrentStatus returns the user's status, a status that has expired is active again
+func (user *UserRowST) CurrentStatus() string {
+	if user.Status != UserStatusActive && user.StatusExpiresAt != nil && !time.Now().Before(*user.StatusExpiresAt) {
+		return UserStatusActive
 	}
-	return All[UserRowST](`SELECT u.* 
-		FROM users u 
-		WHERE u.application_id = $1
-		ORDER BY u.updated_at DESC 
-		LIMIT $2 OFFSET $3;`, applicationId, limit, offset)
+	return user.Status
+}
+
+func (user *UserRowST) IsActive() bool {
+	return user.CurrentStatus() == UserStatusActive
+}
+
+// TokenRevoked reports whether a token issued at issuedAtSeconds was revoked when the user was last deactivated
+func (user *UserRowST) TokenRevoked(issuedAtSeconds int64) bool {
+	return user.TokensRevokedAt != nil && issuedAtSeconds <= user.TokensRevokedAt.Unix()
+}
+
+type UserFilterST struct {
+	Username             *string
+	Email                *string
+	PhoneNumber          *string
+	EmailConfirmed       *bool
+	PhoneNumberConfirmed *bool
+	Role                 *string
+	MFAEnabled           *bool
+	Status               *string
+	CreatedAfter         *time.Time
+	CreatedBefore        *time.Time
+	UpdatedAfter         *time.Time
+	UpdatedBefore        *time.Time
+	Query                *string
+	Sort                 string
+	Descending           bool
 }
 
-func GetUsersEmails(applicationId int32, limit, offset *int) ([]EmailRowST, error) {
-	if limit == nil && offset == nil {
-		return All[EmailRowST](`SELECT e.*
-			FROM emails e
-			JOIN users u ON e.user_id = u.id
-			JOIN applications a ON a.id = u.application_id
-			WHERE a.id = $1;`, applicationId)
+var UserSorts = []string{"created_at", "updated_at", "username"}
+
+// userInfoNames is the expression the user_infos trigram index is built on, it must match the migration exactly
+const userInfoNames = `LOWER(COALESCE(ui.name, '') || ' ' || COALESCE(ui.given_name, '') || ' ' || COALESCE(ui.middle_name, '') || ' ' || COALESCE(ui.family_name, '') || ' ' || COALESCE(ui.nickname, ''))`
+
+// userFilterWhere builds the where clause for filter, $1 is always the application id
+func userFilterWhere(applicationId int32, filter *UserFilterST) (string, []interface{}) {
+	args := []interface{}{applicationId}
+	conditions := []string{"u.application_id = $1"}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
 	}
-	if limit == nil {
-		limit = new(int)
-		*limit = 10
+	if filter.Username != nil {
+		conditions = append(conditions, "LOWER(u.username) LIKE "+arg(escapeLike(strings.ToLower(*filter.Username))+"%"))
 	}
-	if offset == nil {
-		offset = new(int)
-		*offset = 0
+	if filter.Email != nil {
+		conditions = append(conditions, "u.id IN (SELECT e.user_id FROM emails e WHERE e.application_id = $1 AND LOWER(e.email) LIKE "+arg(escapeLike(strings.ToLower(*filter.Email))+"%")+")")
 	}
-	return All[EmailRowST](`SELECT e.* 
-		FROM emails e
-		WHERE e.user_id in (
-			SELECT u.id 
-			FROM users u
-			WHERE u.application_id = $1
-			ORDER BY u.updated_at DESC 
-			LIMIT $2 OFFSET $3
-		);`, applicationId, limit, offset)
+	if filter.PhoneNumber != nil {
+		conditions = append(conditions, "u.id IN (SELECT p.user_id FROM phone_numbers p WHERE p.application_id = $1 AND p.phone_number LIKE "+arg(escapeLike(*filter.PhoneNumber)+"%")+")")
+	}
+	if filter.EmailConfirmed != nil {
+		conditions = append(conditions, "COALESCE((SELECT e.confirmed FROM emails e WHERE e.id = u.email_id), false) = "+arg(*filter.EmailConfirmed))
+	}
+	if filter.PhoneNumberConfirmed != nil {
+		conditions = append(conditions, "COALESCE((SELECT p.confirmed FROM phone_numbers p WHERE p.id = u.phone_number_id), false) = "+arg(*filter.PhoneNumberConfirmed))
+	}
+	if filter.Role != nil {
+		conditions = append(conditions, "EXISTS(SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = u.id AND r.uri = "+arg(*filter.Role)+")")
+	}
+	if filter.MFAEnabled != nil {
+		conditions = append(conditions, "EXISTS(SELECT 1 FROM ("+mfaSelect+") mfas WHERE mfas.user_id = u.id AND mfas.enabled) = "+arg(*filter.MFAEnabled))
+	}
+	if filter.Status != nil {
+		if *filter.Status == UserStatusActive {
+			conditions = append(conditions, userActiveSQL)
+		} else {
+			conditions = append(conditions, "u.status = "+arg(*filter.Status)+" AND NOT "+userActiveSQL)
+		}
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "u.created_at >= "+arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "u.created_at < "+arg(*filter.CreatedBefore))
+	}
+	if filter.UpdatedAfter != nil {
+		conditions = append(conditions, "u.updated_at >= "+arg(*filter.UpdatedAfter))
+	}
+	if filter.UpdatedBefore != nil {
+		conditions = append(conditions, "u.updated_at < "+arg(*filter.UpdatedBefore))
+	}
+	if filter.Query != nil {
+		conditions = append(conditions, "u.id IN (SELECT ui.user_id FROM user_infos ui WHERE ui.application_id = $1 AND "+userInfoNames+" LIKE "+arg("%"+escapeLike(strings.ToLower(*filter.Query))+"%")+")")
+	}
+	return strings.Join(conditions, " AND "), args
 }
 
-func GetUsersPhoneNumbers(applicationId int32, limit, offset *int) ([]PhoneNumberRowST, error) {
-	if limit == nil && offset == nil {
-		return All[PhoneNumberRowST](`SELECT pn.*
-			FROM phone_numbers pn
-			JOIN users u ON pn.user_id = u.id
-			JOIN applications a ON a.id = u.application_id
-			WHERE a.id = $1;`, applicationId)
-	}
-	if limit == nil {
-		limit = new(int)
-		*limit = 10
+func userFilterKeyset(filter *UserFilterST) KeysetST[UserRowST] {
+	keyset := KeysetST[UserRowST]{
+		Name:       "updated_at",
+		Column:     "u.updated_at",
+		IdColumn:   "u.id",
+		Time:       true,
+		Descending: filter.Descending,
+		Key:        func(row UserRowST) interface{} { return row.UpdatedAt },
+		Id:         func(row UserRowST) int32 { return row.Id },
 	}
-	if offset == nil {
-		offset = new(int)
-		*offset = 0
+	switch filter.Sort {
+	case "created_at":
+		keyset.Name = "created_at"
+		keyset.Column = "u.created_at"
+		keyset.Key = func(row UserRowST) interface{} { return row.CreatedAt }
+	case "username":
+		keyset.Name = "username"
+		keyset.Column = "u.username"
+		keyset.Time = false
+		keyset.Key = func(row UserRowST) interface{} { return row.Username }
 	}
-	return All[PhoneNumberRowST](`SELECT pn.* 
-			FROM phone_numbers pn
-			WHERE pn.user_id in (
-				SELECT u.id 
-				FROM users u
-				WHERE u.application_id = $1
-				ORDER BY u.updated_at DESC 
-				LIMIT $2 OFFSET $3
-			);`, applicationId, limit, offset)
+	return keyset
+}
+
+func GetUsers(applicationId int32, filter UserFilterST, page PageST) (*PageResultST[UserRowST], error) {
+	where, args := userFilterWhere(applicationId, &filter)
+	return Paginate(`SELECT u.* FROM users u`, where, args, userFilterKeyset(&filter), page)
+}
+
+func GetUsersEmails(userIds []int32) ([]EmailRowST, error) {
+	return All[EmailRowST](`SELECT e.*
+		FROM emails e
+		WHERE e.user_id = ANY($1);`, pq.Array(userIds))
+}
+
+func GetUsersPhoneNumbers(userIds []int32) ([]PhoneNumberRowST, error) {
+	return All[PhoneNumberRowST](`SELECT pn.*
+		FROM phone_numbers pn
+		WHERE pn.user_id = ANY($1);`, pq.Array(userIds))
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
 }
 
 func GetUserById(applicationId, userId int32) (*UserRowST, error) {
@@ -104,24 +194,46 @@ func GetUserById(applicationId, userId int32) (*UserRowST, error) {
 		applicationId, userId)
 }
 
-func GetUserByUsernameOrEmail(applicationId int32, usernameOrEmail string) (*UserRowST, error) {
+// GetUserByUsernameOrEmail matches the username and the primary email by their canonical forms, a username match wins
+// over an email match of another user
+func GetUserByUsernameOrEmail(applicationId int32, usernameOrEmail string, foldEmailAliases bool) (*UserRowST, error) {
 	return GetOptional[UserRowST](`SELECT u.*
 		FROM users u
 		LEFT JOIN emails e ON e.id = u.email_id
-		WHERE u.application_id = $1 AND u.username = $2 OR e.email = $2
+		WHERE u.application_id = $1 AND (canonical_username(u.username) = canonical_username($2) OR `+emailMatch("e.email", "$2", foldEmailAliases)+`)
+		ORDER BY canonical_username(u.username) = canonical_username($2) DESC
 		LIMIT 1;`,
 		applicationId, usernameOrEmail)
 }
 
-func GetUserByEmail(applicationId int32, email string) (*UserRowST, error) {
+func GetUserByEmail(applicationId int32, email string, foldEmailAliases bool) (*UserRowST, error) {
 	return GetOptional[UserRowST](`SELECT u.*
 		FROM users u
 		LEFT JOIN emails e ON e.id = u.email_id
-		WHERE u.application_id = $1 AND e.email = $2
+		WHERE u.application_id = $1 AND `+emailMatch("e.email", "$2", foldEmailAliases)+`
 		LIMIT 1;`,
 		applicationId, email)
 }
 
+// GetUserByConfirmedEmail finds the user owning a confirmed email whether or not it is their primary email
+func GetUserByConfirmedEmail(applicationId int32, email string, foldEmailAliases bool) (*UserRowST, error) {
+	return GetOptional[UserRowST](`SELECT u.*
+		FROM users u
+		JOIN emails e ON e.user_id = u.id
+		WHERE u.application_id = $1 AND `+emailMatch("e.email", "$2", foldEmailAliases)+` AND e.confirmed
+		LIMIT 1;`,
+		applicationId, email)
+}
+
+// emailMatch compares an email column to an email argument by their canonical forms, or by their folded forms when
+// foldEmailAliases is set so gmail addresses differing only in dots and a +tag match
+func emailMatch(column, arg string, foldEmailAliases bool) string {
+	if foldEmailAliases {
+		return "folded_email(" + column + ") = folded_email(" + arg + ")"
+	}
+	return "canonical_email(" + column + ") = canonical_email(" + arg + ")"
+}
+
 func GetUserByPhoneNumber(applicationId int32, phoneNumber string) (*UserRowST, error) {
 	return GetOptional[UserRowST](`SELECT u.*
 		FROM users u
@@ -134,7 +246,7 @@ func GetUserByPhoneNumber(applicationId int32, phoneNumber string) (*UserRowST,
 func GetUserByUsername(applicationId int32, username string) (*UserRowST, error) {
 	return GetOptional[UserRowST](`SELECT u.*
 		FROM users u
-		WHERE u.application_id = $1 AND u.username = $2
+		WHERE u.application_id = $1 AND canonical_username(u.username) = canonical_username($2)
 		LIMIT 1;`,
 		applicationId, username)
 }
@@ -182,8 +294,8 @@ func CreateUserFromUsername(applicationId int32, username string) (UserAndUserIn
 		if err != nil {
 			return result, err
 		}
-		userRow := tx.QueryRowx(`INSERT INTO users (application_id, username, encrypted_password)
-			VALUES ($1, $2, $3)
+		userRow := tx.QueryRowx(`INSERT INTO users (application_id, username, encrypted_password, password_set)
+			VALUES ($1, $2, $3, false)
 			RETURNING *;`,
 			applicationId, username, encryptedPassword)
 		if userRow.Err() != nil {
@@ -202,22 +314,11 @@ func CreateUserFromUsername(applicationId int32, username string) (UserAndUserIn
 	})
 }
 
+// CreateUserFromEmail creates a user with email as its confirmed primary email, callers must have verified the email
+// is owned by the requester. An email that already belongs to a user, confirmed or not, is a duplicate key error
 func CreateUserFromEmail(applicationId int32, email string) (UserAndUserInfoST, error) {
 	return Transaction(func(tx *sqlx.Tx) (UserAndUserInfoST, error) {
 		var result UserAndUserInfoST
-		user, err := GetUserByEmail(applicationId, email)
-		if err != nil {
-			return result, err
-		}
-		if user != nil {
-			userInfo, err := GetUserInfoByUserId(user.Id)
-			if err != nil {
-				return result, err
-			}
-			result.User = *user
-			result.UserInfo = *userInfo
-			return result, nil
-		}
 		username := strings.Split(email, "@")[0]
 		for {
 			user, err := GetUserByUsername(applicationId, username)
@@ -241,8 +342,8 @@ func CreateUserFromEmail(applicationId int32, email string) (UserAndUserInfoST,
 		if err != nil {
 			return result, err
 		}
-		userRow := tx.QueryRowx(`INSERT INTO users (application_id, username, encrypted_password)
-			VALUES ($1, $2, $3)
+		userRow := tx.QueryRowx(`INSERT INTO users (application_id, username, encrypted_password, password_set)
+			VALUES ($1, $2, $3, false)
 			RETURNING *;`,
 			applicationId, username, encryptedPassword)
 		if userRow.Err() != nil {
@@ -252,6 +353,18 @@ func CreateUserFromEmail(applicationId int32, email string) (UserAndUserInfoST,
 		if err != nil {
 			return result, err
 		}
+		var emailId int32
+		err = tx.Get(&emailId, `INSERT INTO emails (application_id, user_id, email, confirmed)
+			VALUES ($1, $2, $3, true)
+			RETURNING id;`,
+			applicationId, result.User.Id, email)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result.User, `UPDATE users SET email_id = $2 WHERE id = $1 RETURNING *;`, result.User.Id, emailId)
+		if err != nil {
+			return result, err
+		}
 		userInfoRow := tx.QueryRowx(`INSERT INTO user_infos (application_id, user_id) VALUES ($1, $2) RETURNING *;`, applicationId, result.User.Id)
 		err = userInfoRow.StructScan(&result.UserInfo)
 		if err != nil {
@@ -266,11 +379,47 @@ func UpdateUserPassword(applicationId, id int32, password string) (*UserRowST, e
 	if err != nil {
 		return nil, err
 	}
-	return GetOptional[UserRowST](`UPDATE users
+	return Transaction(func(tx *sqlx.Tx) (*UserRowST, error) {
+		_, err := tx.Exec(`INSERT INTO password_histories (user_id, encrypted_password)
+			SELECT u.id, u.encrypted_password FROM users u WHERE u.application_id=$1 AND u.id=$2;`,
+			applicationId, id)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`DELETE FROM password_histories
+			WHERE user_id=$1 AND id NOT IN (
+				SELECT ph.id FROM password_histories ph WHERE ph.user_id=$1 ORDER BY ph.created_at DESC LIMIT $2
+			);`,
+			id, PasswordHistoryMax)
+		if err != nil {
+			return nil, err
+		}
+		var user UserRowST
+		err = tx.Get(&user, `UPDATE users
+			SET encrypted_password = $3, password_set = true
+			WHERE application_id=$1 AND id=$2
+			RETURNING *;`,
+			applicationId, id, encryptedPassword)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &user, nil
+	})
+}
+
+// RehashUserPassword stores a new hash of the user's current password, it is not recorded in the password history
+func RehashUserPassword(id int32, encryptedPassword, password string) (bool, error) {
+	rehashedPassword, err := util.EncryptPassword(password)
+	if err != nil {
+		return false, err
+	}
+	return Execute(`UPDATE users
 		SET encrypted_password = $3
-		WHERE application_id=$1 AND id=$2
-		RETURNING *;`,
-		applicationId, id, encryptedPassword)
+		WHERE id=$1 AND encrypted_password=$2;`,
+		id, encryptedPassword, rehashedPassword)
 }
 
 func UpdateUsername(applicationId, id int32, username string) (*UserRowST, error) {
@@ -281,6 +430,37 @@ func UpdateUsername(applicationId, id int32, username string) (*UserRowST, error
 		applicationId, id, username)
 }
 
+type UpdateUserStatusST struct {
+	Status                    string
+	Reason                    *string
+	ExpiresAt                 *time.Time
+	ChangedByUserId           *int32
+	ChangedByServiceAccountId *int32
+}
+
+// SetUserStatus changes the user's status, any other status than active revokes the tokens they were issued
+func SetUserStatus(applicationId, id int32, update UpdateUserStatusST) (*UserRowST, error) {
+	return GetOptional[UserRowST](`UPDATE users
+		SET status = $3,
+			status_reason = $4,
+			status_expires_at = $5,
+			status_changed_by_user_id = $6,
+			status_changed_by_service_account_id = $7,
+			status_changed_at = CURRENT_TIMESTAMP,
+			tokens_revoked_at = (CASE WHEN $3 = 'active' THEN tokens_revoked_at ELSE CURRENT_TIMESTAMP END)
+		WHERE application_id=$1 AND id=$2
+		RETURNING *;`,
+		applicationId, id, update.Status, update.Reason, update.ExpiresAt, update.ChangedByUserId, update.ChangedByServiceAccountId)
+}
+
+func SetUserLDAPBacked(applicationId, id int32, ldapBacked bool) (*UserRowST, error) {
+	return GetOptional[UserRowST](`UPDATE users
+		SET ldap_backed = $3
+		WHERE application_id=$1 AND id=$2
+		RETURNING *;`,
+		applicationId, id, ldapBacked)
+}
+
 func DeleteUserById(applicationId, id int32) (bool, error) {
 	return Execute(`DELETE FROM users WHERE application_id=$1 AND id=$2;`, applicationId, id)
 }
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type UserConsentRowST struct {
+	Id            int32          `db:"id"`
+	ApplicationId int32          `db:"application_id"`
+	UserId        int32          `db:"user_id"`
+	TenentId      int32          `db:"tenent_id"`
+	Scopes        pq.StringArray `db:"scopes"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
+
+// Covers reports whether the user approved every one of scopes
+func (consent *UserConsentRowST) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(consent.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func GetUserConsent(userId, tenentId int32) (*UserConsentRowST, error) {
+	return GetOptional[UserConsentRowST](`SELECT uc.*
+		FROM user_consents uc
+		WHERE uc.user_id = $1 AND uc.tenent_id = $2
+		LIMIT 1;`,
+		userId, tenentId)
+}
+
+type UserConsentWithTenentRowST struct {
+	UserConsentRowST
+	TenentDescription string    `db:"tenent_description"`
+	TenentURI         string    `db:"tenent_uri"`
+	TenentClientId    uuid.UUID `db:"tenent_client_id"`
+}
+
+func GetUserConsentsWithTenentByUserId(userId int32) ([]UserConsentWithTenentRowST, error) {
+	return All[UserConsentWithTenentRowST](`SELECT uc.*, t.description AS tenent_description, t.uri AS tenent_uri, t.client_id AS tenent_client_id
+		FROM user_consents uc
+		JOIN tenents t ON t.id = uc.tenent_id
+		WHERE uc.user_id = $1
+		ORDER BY uc.created_at;`,
+		userId)
+}
+
+// GrantUserConsent records the user approved scopes for the tenent, adding to any scopes they approved before
+func GrantUserConsent(applicationId, userId, tenentId int32, scopes []string) (UserConsentRowST, error) {
+	return Get[UserConsentRowST](`INSERT INTO user_consents (application_id, user_id, tenent_id, scopes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, tenent_id) DO UPDATE SET
+			scopes = ARRAY(SELECT DISTINCT unnest(user_consents.scopes || EXCLUDED.scopes))
+		RETURNING *;`,
+		applicationId, userId, tenentId, pq.StringArray(scopes))
+}
+
+// RevokeUserConsent deletes the user's consent, refresh tokens issued before a consent was created are not accepted so
+// the tenent's refresh tokens stop working
+func RevokeUserConsent(userId, id int32) (*UserConsentRowST, error) {
+	return GetOptional[UserConsentRowST](`DELETE FROM user_consents
+		WHERE user_id = $1 AND id = $2
+		RETURNING *;`,
+		userId, id)
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrLastCredential = errors.New("last credential")
+
+type UserIdentityRowST struct {
+	Id                 int32      `db:"id"`
+	ApplicationId      int32      `db:"application_id"`
+	UserId             int32      `db:"user_id"`
+	IdentityProviderId int32      `db:"identity_provider_id"`
+	Subject            string     `db:"subject"`
+	Email              *string    `db:"email"`
+	LastLoginAt        *time.Time `db:"last_login_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+	CreatedAt          time.Time  `db:"created_at"`
+}
+
+func GetUserIdentity(identityProviderId int32, subject string) (*UserIdentityRowST, error) {
+	return GetOptional[UserIdentityRowST](`SELECT ui.*
+		FROM user_identities ui
+		WHERE ui.identity_provider_id = $1 AND ui.subject = $2
+		LIMIT 1;`,
+		identityProviderId, subject)
+}
+
+type UserIdentityWithProviderRowST struct {
+	UserIdentityRowST
+	IdentityProviderName string `db:"identity_provider_name"`
+	IdentityProviderKind string `db:"identity_provider_kind"`
+}
+
+func GetUserIdentitiesWithProviderByUserId(userId int32) ([]UserIdentityWithProviderRowST, error) {
+	return All[UserIdentityWithProviderRowST](`SELECT ui.*, ip.name AS identity_provider_name, ip.kind AS identity_provider_kind
+		FROM user_identities ui
+		JOIN identity_providers ip ON ip.id = ui.identity_provider_id
+		WHERE ui.user_id = $1
+		ORDER BY ui.created_at;`,
+		userId)
+}
+
+func GetUserIdentitiesByUserId(userId int32) ([]UserIdentityRowST, error) {
+	return All[UserIdentityRowST](`SELECT ui.*
+		FROM user_identities ui
+		WHERE ui.user_id = $1
+		ORDER BY ui.created_at;`,
+		userId)
+}
+
+// UnlinkUserIdentity removes one of the user's identities unless the user would be left without a password, passkey or
+// identity to sign in with
+func UnlinkUserIdentity(userId, id int32) (bool, error) {
+	return Transaction(func(tx *sqlx.Tx) (bool, error) {
+		var user UserRowST
+		if err := tx.Get(&user, `SELECT u.* FROM users u WHERE u.id = $1 FOR UPDATE;`, userId); err != nil {
+			return false, err
+		}
+		var identities []int32
+		if err := tx.Select(&identities, `SELECT ui.id FROM user_identities ui WHERE ui.user_id = $1;`, userId); err != nil {
+			return false, err
+		}
+		if !slices.Contains(identities, id) {
+			return false, nil
+		}
+		var passKeys int
+		if err := tx.Get(&passKeys, `SELECT COUNT(*) FROM passkeys pk WHERE pk.user_id = $1;`, userId); err != nil {
+			return false, err
+		}
+		if !user.PasswordSet && !user.LDAPBacked && passKeys == 0 && len(identities) == 1 {
+			return false, ErrLastCredential
+		}
+		_, err := tx.Exec(`DELETE FROM user_identities WHERE id = $1;`, id)
+		return err == nil, err
+	})
+}
+
+// UpstreamUserST is what an upstream provider told us about the user signing in
+type UpstreamUserST struct {
+	Subject       string
+	Email         *string
+	EmailVerified bool
+	Username      *string
+	Name          *string
+	GivenName     *string
+	FamilyName    *string
+	Picture       *string
+}
+
+// VerifiedEmail returns the email only if the provider verified it
+func (upstream *UpstreamUserST) VerifiedEmail() *string {
+	if upstream.EmailVerified && upstream.Email != nil && *upstream.Email != "" {
+		return upstream.Email
+	}
+	return nil
+}
+
+// LinkUserIdentity links an upstream identity to a user and records the login
+func LinkUserIdentity(applicationId, userId, identityProviderId int32, upstream UpstreamUserST) (UserIdentityRowST, error) {
+	return Get[UserIdentityRowST](`INSERT INTO user_identities (application_id, user_id, identity_provider_id, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING *;`,
+		applicationId, userId, identityProviderId, upstream.Subject, upstream.Email)
+}
+
+func TouchUserIdentity(id int32, email *string) (bool, error) {
+	return Execute(`UPDATE user_identities SET
+		email = COALESCE($2, email),
+		last_login_at = NOW()
+		WHERE id = $1;`,
+		id, email)
+}
+
+// CreateUserFromIdentity creates a user with a random password for an upstream identity and links it, a verified email
+// becomes the confirmed primary email when no other user has it
+func CreateUserFromIdentity(applicationId, identityProviderId int32, upstream UpstreamUserST) (UserRowST, error) {
+	return Transaction(func(tx *sqlx.Tx) (UserRowST, error) {
+		var result UserRowST
+		username := ""
+		if upstream.Username != nil {
+			username = strings.TrimSpace(*upstream.Username)
+		}
+		if username == "" && upstream.Email != nil {
+			username = strings.Split(*upstream.Email, "@")[0]
+		}
+		if username == "" {
+			username = "user"
+		}
+		username, err := availableUsername(tx, applicationId, username)
+		if err != nil {
+			return result, err
+		}
+		password, err := util.GenerateRandomHex(32)
+		if err != nil {
+			return result, err
+		}
+		encryptedPassword, err := util.EncryptPassword(password)
+		if err != nil {
+			return result, err
+		}
+		err = tx.Get(&result, `INSERT INTO users (application_id, username, encrypted_password, password_set)
+			VALUES ($1, $2, $3, false)
+			RETURNING *;`,
+			applicationId, username, encryptedPassword)
+		if err != nil {
+			return result, err
+		}
+		if email := upstream.VerifiedEmail(); email != nil {
+			var emailIds []int32
+			err = tx.Select(&emailIds, `INSERT INTO emails (application_id, user_id, email, confirmed)
+				VALUES ($1, $2, $3, true)
+				ON CONFLICT (application_id, canonical_email(email)) DO NOTHING
+				RETURNING id;`,
+				applicationId, result.Id, *email)
+			if err != nil {
+				return result, err
+			}
+			if len(emailIds) > 0 {
+				err = tx.Get(&result, `UPDATE users SET email_id = $2 WHERE id = $1 RETURNING *;`, result.Id, emailIds[0])
+				if err != nil {
+					return result, err
+				}
+			}
+		}
+		_, err = tx.Exec(`INSERT INTO user_infos (application_id, user_id, name, given_name, family_name, picture)
+			VALUES ($1, $2, $3, $4, $5, $6);`,
+			applicationId, result.Id, upstream.Name, upstream.GivenName, upstream.FamilyName, upstream.Picture)
+		if err != nil {
+			return result, err
+		}
+		_, err = tx.Exec(`INSERT INTO user_identities (application_id, user_id, identity_provider_id, subject, email, last_login_at)
+			VALUES ($1, $2, $3, $4, $5, NOW());`,
+			applicationId, result.Id, identityProviderId, upstream.Subject, upstream.Email)
+		return result, err
+	})
+}
+
+// availableUsername appends random characters to username until no user of the application has it
+func availableUsername(tx *sqlx.Tx, applicationId int32, username string) (string, error) {
+	for {
+		var exists bool
+		err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM users WHERE application_id = $1 AND canonical_username(username) = canonical_username($2));`, applicationId, username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+		hex, err := util.GenerateRandomHex(2)
+		if err != nil {
+			return "", err
+		}
+		username += hex
+	}
+}
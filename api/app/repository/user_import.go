@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const ImportUsersBatchSize = 500
+
+var ErrImportUnknownRole = errors.New("unknown role")
+
+type ImportUserEmailST struct {
+	Email     string
+	Confirmed bool
+}
+
+type ImportUserPhoneNumberST struct {
+	PhoneNumber string
+	Confirmed   bool
+}
+
+type ImportUserST struct {
+	Username          string
+	EncryptedPassword string
+	Emails            []ImportUserEmailST
+	PhoneNumbers      []ImportUserPhoneNumberST
+	Roles             []string
+	Info              UpdateUserInfoST
+}
+
+type ImportUserResultST struct {
+	UserId int32
+	Error  error
+}
+
+// ImportUsers inserts users in one transaction, each user inside its own savepoint so a failing
+// user is reported in its result without aborting the others, the first email and phone number become primary
+func ImportUsers(applicationId int32, users []ImportUserST) ([]ImportUserResultST, error) {
+	return Transaction(func(tx *sqlx.Tx) ([]ImportUserResultST, error) {
+		results := make([]ImportUserResultST, len(users))
+		for i, user := range users {
+			if _, err := tx.Exec(`SAVEPOINT import_user;`); err != nil {
+				return nil, err
+			}
+			userId, err := importUser(tx, applicationId, user)
+			if err != nil {
+				if _, rollbackErr := tx.Exec(`ROLLBACK TO SAVEPOINT import_user;`); rollbackErr != nil {
+					return nil, errors.Join(err, rollbackErr)
+				}
+				results[i].Error = err
+				continue
+			}
+			if _, err := tx.Exec(`RELEASE SAVEPOINT import_user;`); err != nil {
+				return nil, err
+			}
+			results[i].UserId = userId
+		}
+		return results, nil
+	})
+}
+
+func importUser(tx *sqlx.Tx, applicationId int32, user ImportUserST) (int32, error) {
+	var userId int32
+	err := tx.Get(&userId, `INSERT INTO users (application_id, username, encrypted_password, password_set)
+		VALUES ($1, $2, $3, $3 <> '')
+		RETURNING id;`,
+		applicationId, user.Username, user.EncryptedPassword)
+	if err != nil {
+		return 0, err
+	}
+	for i, email := range user.Emails {
+		var emailId int32
+		err := tx.Get(&emailId, `INSERT INTO emails (application_id, user_id, email, confirmed)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id;`,
+			applicationId, userId, email.Email, email.Confirmed)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 {
+			if _, err := tx.Exec(`UPDATE users SET email_id = $2 WHERE id = $1;`, userId, emailId); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for i, phoneNumber := range user.PhoneNumbers {
+		var phoneNumberId int32
+		err := tx.Get(&phoneNumberId, `INSERT INTO phone_numbers (application_id, user_id, phone_number, confirmed)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id;`,
+			applicationId, userId, phoneNumber.PhoneNumber, phoneNumber.Confirmed)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 {
+			if _, err := tx.Exec(`UPDATE users SET phone_number_id = $2 WHERE id = $1;`, userId, phoneNumberId); err != nil {
+				return 0, err
+			}
+		}
+	}
+	info := user.Info
+	_, err = tx.Exec(`INSERT INTO user_infos (application_id, user_id, name, given_name, family_name, middle_name,
+			nickname, profile, picture, website, gender, birthdate, zoneinfo, locale, street_address, locality,
+			region, postal_code, country)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19);`,
+		applicationId, userId, info.Name, info.GivenName, info.FamilyName, info.MiddleName,
+		info.Nickname, info.Profile, info.Picture, info.Website, info.Gender, info.Birthdate, info.Zoneinfo,
+		info.Locale, info.StreetAddress, info.Locality, info.Region, info.PostalCode, info.Country)
+	if err != nil {
+		return 0, err
+	}
+	if len(user.Roles) > 0 {
+		result, err := tx.Exec(`INSERT INTO user_roles (user_id, role_id)
+			SELECT $1, r.id FROM roles r
+			WHERE r.application_id = $2 AND r.uri = ANY($3);`,
+			userId, applicationId, pq.Array(user.Roles))
+		if err != nil {
+			return 0, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if int(rows) != len(user.Roles) {
+			return 0, ErrImportUnknownRole
+		}
+	}
+	return userId, nil
+}
+
+type UserDetailsST struct {
+	User         UserRowST
+	Emails       []EmailRowST
+	PhoneNumbers []PhoneNumberRowST
+	Info         *UserInfoRowST
+	Roles        []RoleRowST
+}
+
+// GetExportUsers returns up to limit users with an id greater than afterId along with everything
+// needed to import them again, ordered by id so callers can page with the last id returned
+func GetExportUsers(applicationId, afterId int32, limit int) ([]UserDetailsST, error) {
+	userRows, err := All[UserRowST](`SELECT u.*
+		FROM users u
+		WHERE u.application_id = $1 AND u.id > $2
+		ORDER BY u.id ASC
+		LIMIT $3;`,
+		applicationId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return GetUsersDetails(userRows)
+}
+
+type userRoleRowST struct {
+	UserId int32 `db:"user_id"`
+	RoleRowST
+}
+
+// GetUsersDetails loads the emails, phone numbers, info and roles of users keeping their order
+func GetUsersDetails(userRows []UserRowST) ([]UserDetailsST, error) {
+	if len(userRows) == 0 {
+		return nil, nil
+	}
+	userIds := make([]int32, 0, len(userRows))
+	users := make([]UserDetailsST, len(userRows))
+	indexByUserId := make(map[int32]int, len(userRows))
+	for i, userRow := range userRows {
+		userIds = append(userIds, userRow.Id)
+		users[i].User = userRow
+		indexByUserId[userRow.Id] = i
+	}
+	emailRows, err := All[EmailRowST](`SELECT e.*
+		FROM emails e
+		WHERE e.user_id = ANY($1)
+		ORDER BY e.id ASC;`,
+		pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, emailRow := range emailRows {
+		user := &users[indexByUserId[emailRow.UserId]]
+		user.Emails = append(user.Emails, emailRow)
+	}
+	phoneNumberRows, err := All[PhoneNumberRowST](`SELECT p.*
+		FROM phone_numbers p
+		WHERE p.user_id = ANY($1)
+		ORDER BY p.id ASC;`,
+		pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, phoneNumberRow := range phoneNumberRows {
+		user := &users[indexByUserId[phoneNumberRow.UserId]]
+		user.PhoneNumbers = append(user.PhoneNumbers, phoneNumberRow)
+	}
+	userInfoRows, err := All[UserInfoRowST](`SELECT ui.*
+		FROM user_infos ui
+		WHERE ui.user_id = ANY($1);`,
+		pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	for i := range userInfoRows {
+		users[indexByUserId[userInfoRows[i].UserId]].Info = &userInfoRows[i]
+	}
+	roleRows, err := All[userRoleRowST](`SELECT ur.user_id, r.*
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = ANY($1)
+		ORDER BY r.uri ASC;`,
+		pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	for _, roleRow := range roleRows {
+		user := &users[indexByUserId[roleRow.UserId]]
+		user.Roles = append(user.Roles, roleRow.RoleRowST)
+	}
+	return users, nil
+}
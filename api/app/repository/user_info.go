@@ -56,8 +56,50 @@ type UpdateUserInfoST struct {
 	Country       *string    `json:"country"`
 }
 
-func UpdateUserInfoByUserId(userId int32, updates UpdateUserInfoST) (*UserInfoRowST, error) {
-	return GetOptional[UserInfoRowST](`UPDATE user_infos
+var UserInfoFields = []string{"name", "given_name", "family_name", "middle_name", "nickname", "profile", "picture", "website", "gender", "birthdate", "zoneinfo", "locale", "street_address", "locality", "region", "postal_code", "country"}
+
+// IsSet reports whether the update sets field, field is one of UserInfoFields
+func (updates *UpdateUserInfoST) IsSet(field string) bool {
+	switch field {
+	case "name":
+		return updates.Name != nil
+	case "given_name":
+		return updates.GivenName != nil
+	case "family_name":
+		return updates.FamilyName != nil
+	case "middle_name":
+		return updates.MiddleName != nil
+	case "nickname":
+		return updates.Nickname != nil
+	case "profile":
+		return updates.Profile != nil
+	case "picture":
+		return updates.Picture != nil
+	case "website":
+		return updates.Website != nil
+	case "gender":
+		return updates.Gender != nil
+	case "birthdate":
+		return updates.Birthdate != nil
+	case "zoneinfo":
+		return updates.Zoneinfo != nil
+	case "locale":
+		return updates.Locale != nil
+	case "street_address":
+		return updates.StreetAddress != nil
+	case "locality":
+		return updates.Locality != nil
+	case "region":
+		return updates.Region != nil
+	case "postal_code":
+		return updates.PostalCode != nil
+	case "country":
+		return updates.Country != nil
+	}
+	return false
+}
+
+const updateUserInfoSQL = `UPDATE user_infos
 		SET name = COALESCE($2, name),
 		  given_name = COALESCE($3, given_name),
 		  family_name = COALESCE($4, family_name),
@@ -76,7 +118,10 @@ func UpdateUserInfoByUserId(userId int32, updates UpdateUserInfoST) (*UserInfoRo
 		  postal_code = COALESCE($17, postal_code),
 		  country = COALESCE($18, country)
 		WHERE user_id = $1
-		RETURNING *;`,
+		RETURNING *;`
+
+func (updates *UpdateUserInfoST) args(userId int32) []interface{} {
+	return []interface{}{
 		userId,
 		updates.Name,
 		updates.GivenName,
@@ -95,5 +140,9 @@ func UpdateUserInfoByUserId(userId int32, updates UpdateUserInfoST) (*UserInfoRo
 		updates.Region,
 		updates.PostalCode,
 		updates.Country,
-	)
+	}
+}
+
+func UpdateUserInfoByUserId(userId int32, updates UpdateUserInfoST) (*UserInfoRowST, error) {
+	return GetOptional[UserInfoRowST](updateUserInfoSQL, updates.args(userId)...)
 }
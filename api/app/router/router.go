@@ -30,11 +30,60 @@ func InstallRouter(fiberApp *fiber.App) {
 
 	registration := root.Group("/registration")
 	registration.Use(middleware.TenentMiddleware())
+	registration.Get("", controller.GetRegistrationSchema)
 	registration.Post("", controller.PostRegistration)
 
+	register := root.Group("/register", middleware.ClientRegistrationMiddleware())
+	register.Post("", controller.PostRegisterClient)
+	register.Get("/:clientId", controller.GetRegisteredClient)
+	register.Put("/:clientId", controller.PutUpdateRegisteredClient)
+	register.Delete("/:clientId", controller.DeleteRegisteredClient)
+
+	passwordless := root.Group("/passwordless")
+	passwordless.Use(middleware.TenentMiddleware())
+	passwordless.Post("", controller.PostRequestPasswordless)
+
+	confirmation := root.Group("/confirmation")
+	confirmation.Use(middleware.TenentMiddleware())
+	confirmation.Post("", controller.PostConfirmation)
+
+	invitations := root.Group("/invitations")
+	invitations.Use(middleware.TenentMiddleware())
+	invitations.Post("/accept", controller.PostAcceptInvitation)
+
+	identityProviders := root.Group("/identity-providers")
+	identityProviders.Get("/callback", controller.GetIdentityProviderCallback)
+	identityProviders.Get("", middleware.TenentMiddleware(), controller.GetTenentIdentityProviders)
+	identityProviders.Post("/:id/authorize", middleware.TenentMiddleware(), controller.PostIdentityProviderAuthorize)
+
+	saml := root.Group("/saml")
+	saml.Post("/complete", middleware.AuthorizedMiddleware(), middleware.IsUserMiddleware(), middleware.NotImpersonatedMiddleware(), controller.PostCompleteSAMLRequest)
+	saml.Get("/:tenentClientId/metadata", controller.GetSAMLMetadata)
+	saml.Get("/:tenentClientId/sso", controller.SAMLSingleSignOn)
+	saml.Post("/:tenentClientId/sso", controller.SAMLSingleSignOn)
+
 	mfa := root.Group("/mfa")
 	mfa.Use(middleware.MFAAuthorizedMiddleware())
 	mfa.Post("", controller.PostValidateMFA)
+	mfa.Post("/resend", controller.PostResendMFA)
+	mfa.Post("/passkey/begin-login", controller.PostMFAPassKeyBeginLogin)
+
+	consent := root.Group("/consent")
+	consent.Use(middleware.ConsentAuthorizedMiddleware())
+	consent.Post("", controller.PostConsent)
+
+	verification := root.Group("/verification")
+	verification.Use(middleware.VerificationAuthorizedMiddleware())
+	verification.Get("", controller.GetCurrentUser)
+	verification.Post("", controller.PostVerification)
+	verification.Post("/emails", controller.PostCurrentUserCreateEmail)
+	verification.Patch("/emails/:id/send-confirmation", controller.PatchCurrentUserEmailSendConfirmation)
+	verification.Patch("/emails/:id/confirm", controller.PatchCurrentUserEmailConfirm)
+	verification.Patch("/emails/:id/set-primary", controller.PatchCurrentUserEmailSetPrimary)
+	verification.Post("/phone-numbers", controller.PostCurrentUserCreatePhoneNumber)
+	verification.Patch("/phone-numbers/:id/send-confirmation", controller.PatchCurrentUserPhoneNumberSendConfirmation)
+	verification.Patch("/phone-numbers/:id/confirm", controller.PatchCurrentUserPhoneNumberConfirm)
+	verification.Patch("/phone-numbers/:id/set-primary", controller.PatchCurrentUserPhoneNumberSetPrimary)
 
 	wellKnown := root.Group("/.well-known")
 	wellKnown.Use(middleware.TenentMiddleware())
@@ -43,38 +92,78 @@ func InstallRouter(fiberApp *fiber.App) {
 	user := root.Group("/user")
 	user.Use(middleware.AuthorizedMiddleware(), middleware.IsUserMiddleware())
 	user.Get("", controller.GetCurrentUser)
-	user.Patch("", controller.PatchUpdateCurrentUser)
-	user.Patch("/reset-password", controller.PatchResetPassword)
+	user.Patch("", middleware.NotImpersonatedMiddleware(), controller.PatchUpdateCurrentUser)
+	user.Patch("/reset-password", middleware.NotImpersonatedMiddleware(), controller.PatchResetPassword)
 
-	userEmails := user.Group("/emails")
+	userEmails := user.Group("/emails", middleware.NotImpersonatedMiddleware())
 	userEmails.Patch("/:id/send-confirmation", controller.PatchCurrentUserEmailSendConfirmation)
 	userEmails.Patch("/:id/confirm", controller.PatchCurrentUserEmailConfirm)
 	userEmails.Patch("/:id/set-primary", controller.PatchCurrentUserEmailSetPrimary)
 	userEmails.Post("", controller.PostCurrentUserCreateEmail)
 	userEmails.Delete("/:id", controller.DeleteCurrentUserEmail)
 
-	userPhoneNumbers := user.Group("/phone-numbers")
+	userPhoneNumbers := user.Group("/phone-numbers", middleware.NotImpersonatedMiddleware())
 	userPhoneNumbers.Patch("/:id/send-confirmation", controller.PatchCurrentUserPhoneNumberSendConfirmation)
 	userPhoneNumbers.Patch("/:id/confirm", controller.PatchCurrentUserPhoneNumberConfirm)
 	userPhoneNumbers.Patch("/:id/set-primary", controller.PatchCurrentUserPhoneNumberSetPrimary)
 	userPhoneNumbers.Post("", controller.PostCurrentUserCreatePhoneNumber)
 	userPhoneNumbers.Delete("/:id", controller.DeleteCurrentUserPhoneNumber)
 
-	userPassKeys := user.Group("/passkeys")
+	userPassKeys := user.Group("/passkeys", middleware.NotImpersonatedMiddleware())
 	userPassKeys.Post("/begin-registration", controller.PostPassKeyBeginRegistration)
 	userPassKeys.Patch("/finish-registration", controller.PostPassKeyFinishRegistration)
 
-	userTOTP := user.Group("/totp")
+	userIdentities := user.Group("/identities")
+	userIdentities.Get("", controller.GetCurrentUserIdentities)
+	userIdentities.Post("", middleware.NotImpersonatedMiddleware(), middleware.FreshAuthenticationMiddleware(), controller.PostCurrentUserLinkIdentity)
+	userIdentities.Delete("/:id", middleware.NotImpersonatedMiddleware(), controller.DeleteCurrentUserIdentity)
+
+	userConsents := user.Group("/consents")
+	userConsents.Get("", controller.GetCurrentUserConsents)
+	userConsents.Delete("/:id", middleware.NotImpersonatedMiddleware(), controller.DeleteCurrentUserConsent)
+
+	userTOTP := user.Group("/totp", middleware.NotImpersonatedMiddleware())
 	userTOTP.Get("", controller.GetCurrentUserTOTPs)
 	userTOTP.Post("/:tenentId", controller.PostCurrentUserCreateTOTP)
 	userTOTP.Delete("/:tenentId", controller.DeleteCurrentUserTOTP)
 	userTOTP.Patch("/:tenentId/enable", controller.PatchCurrentUserEnableTOTP)
 	userTOTP.Delete("/:tenentId/enable", controller.DeleteCurrentUserDisableTOTP)
 
+	userMFA := user.Group("/mfa", middleware.NotImpersonatedMiddleware())
+	userMFA.Get("", controller.GetCurrentUserMFAs)
+	userMFA.Patch("/email", controller.PatchCurrentUserEnableEmailMFA)
+	userMFA.Patch("/sms", controller.PatchCurrentUserEnableSMSMFA)
+	userMFA.Patch("/passkey", controller.PatchCurrentUserEnablePassKeyMFA)
+	userMFA.Patch("/:type/:id/preferred", controller.PatchCurrentUserSetPreferredMFA)
+	userMFA.Delete("/:type/:id", controller.DeleteCurrentUserMFA)
+	userMFA.Delete("", controller.DeleteCurrentUserDisableMFA)
+
+	userRecoveryCodes := user.Group("/recovery-codes", middleware.NotImpersonatedMiddleware())
+	userRecoveryCodes.Get("", controller.GetCurrentUserRecoveryCodes)
+	userRecoveryCodes.Post("", controller.PostCurrentUserRegenerateRecoveryCodes)
+
 	openid := user.Group("/info")
 	openid.Use(middleware.OpenIdMiddleware())
 	openid.Get("", controller.GetCurrentUserInfo)
-	openid.Patch("", controller.PatchCurrentUserInfo)
+	openid.Patch("", middleware.NotImpersonatedMiddleware(), controller.PatchCurrentUserInfo)
+
+	scimV2 := root.Group("/scim/v2", middleware.SCIMMiddleware(), middleware.AuthorizedMiddleware(), middleware.IsServiceAccountMiddleware())
+	scimV2.Get("/ServiceProviderConfig", controller.GetSCIMServiceProviderConfig)
+	scimV2.Get("/ResourceTypes", controller.GetSCIMResourceTypes)
+	scimV2.Get("/Schemas", controller.GetSCIMSchemas)
+	scimV2.Get("/Schemas/:id", controller.GetSCIMSchemaById)
+	scimV2.Get("/Users", controller.GetSCIMUsers)
+	scimV2.Post("/Users", controller.PostSCIMUser)
+	scimV2.Get("/Users/:id", controller.GetSCIMUserById)
+	scimV2.Put("/Users/:id", controller.PutSCIMUser)
+	scimV2.Patch("/Users/:id", controller.PatchSCIMUser)
+	scimV2.Delete("/Users/:id", controller.DeleteSCIMUser)
+	scimV2.Get("/Groups", controller.GetSCIMGroups)
+	scimV2.Post("/Groups", controller.PostSCIMGroup)
+	scimV2.Get("/Groups/:id", controller.GetSCIMGroupById)
+	scimV2.Put("/Groups/:id", controller.PutSCIMGroup)
+	scimV2.Patch("/Groups/:id", controller.PatchSCIMGroup)
+	scimV2.Delete("/Groups/:id", controller.DeleteSCIMGroup)
 
 	admin := root.Group("")
 	admin.Use(middleware.AuthorizedMiddleware(), middleware.AdminApplicationMiddleware())
@@ -85,6 +174,22 @@ func InstallRouter(fiberApp *fiber.App) {
 	applications.Post("", controller.PostCreateApplication)
 	applications.Patch("/:id", controller.PatchUpdateApplication)
 	applications.Delete("/:id", controller.DeleteApplication)
+	applications.Get("/:applicationId/password-policy", controller.GetApplicationPasswordPolicy)
+	applications.Patch("/:applicationId/password-policy", controller.PatchApplicationPasswordPolicy)
+	applications.Get("/:applicationId/ldap-connector", controller.GetLDAPConnector)
+	applications.Post("/:applicationId/ldap-connector", controller.PostCreateLDAPConnector)
+	applications.Patch("/:applicationId/ldap-connector", controller.PatchUpdateLDAPConnector)
+	applications.Delete("/:applicationId/ldap-connector", controller.DeleteLDAPConnector)
+
+	applications.Get("/:applicationId/initial-access-tokens", controller.GetInitialAccessTokens)
+	applications.Post("/:applicationId/initial-access-tokens", controller.PostCreateInitialAccessToken)
+	applications.Delete("/:applicationId/initial-access-tokens/:id", controller.DeleteInitialAccessToken)
+
+	applicationInvitations := applications.Group("/:applicationId/invitations")
+	applicationInvitations.Get("", controller.GetInvitations)
+	applicationInvitations.Post("", controller.PostCreateInvitation)
+	applicationInvitations.Post("/:id/resend", controller.PostResendInvitation)
+	applicationInvitations.Delete("/:id", controller.DeleteInvitation)
 
 	tenents := applications.Group("/:applicationId/tenents")
 	tenents.Get("", controller.GetTenents)
@@ -93,15 +198,43 @@ func InstallRouter(fiberApp *fiber.App) {
 	tenents.Post("", controller.PostCreateTenent)
 	tenents.Patch("/:id", controller.PatchUpdateTenent)
 	tenents.Delete("/:id", controller.DeleteTenent)
+	tenents.Get("/:id/password-policy", controller.GetTenentPasswordPolicy)
+	tenents.Patch("/:id/password-policy", controller.PatchTenentPasswordPolicy)
+	tenents.Delete("/:id/password-policy", controller.DeleteTenentPasswordPolicy)
+	tenents.Get("/:id/registration-schema", controller.GetTenentRegistrationSchema)
+	tenents.Patch("/:id/registration-schema", controller.PatchTenentRegistrationSchema)
+	tenents.Delete("/:id/registration-schema", controller.DeleteTenentRegistrationSchema)
+	tenents.Get("/:id/registration-invite-codes", controller.GetRegistrationInviteCodes)
+	tenents.Post("/:id/registration-invite-codes", controller.PostCreateRegistrationInviteCode)
+	tenents.Delete("/:id/registration-invite-codes/:inviteCodeId", controller.DeleteRegistrationInviteCode)
+
+	tenentIdentityProviders := tenents.Group("/:tenentId/identity-providers")
+	tenentIdentityProviders.Get("", controller.GetIdentityProviders)
+	tenentIdentityProviders.Get("/:id", controller.GetIdentityProviderById)
+	tenentIdentityProviders.Post("", controller.PostCreateIdentityProvider)
+	tenentIdentityProviders.Patch("/:id", controller.PatchUpdateIdentityProvider)
+	tenentIdentityProviders.Delete("/:id", controller.DeleteIdentityProvider)
+
+	tenentSAMLServiceProviders := tenents.Group("/:tenentId/saml-service-providers")
+	tenentSAMLServiceProviders.Get("", controller.GetSAMLServiceProviders)
+	tenentSAMLServiceProviders.Get("/:id", controller.GetSAMLServiceProviderById)
+	tenentSAMLServiceProviders.Post("", controller.PostCreateSAMLServiceProvider)
+	tenentSAMLServiceProviders.Patch("/:id", controller.PatchUpdateSAMLServiceProvider)
+	tenentSAMLServiceProviders.Delete("/:id", controller.DeleteSAMLServiceProvider)
 
 	users := applications.Group("/:applicationId/users")
 	users.Get("", controller.GetUsers)
+	users.Post("/import", controller.PostImportUsers)
+	users.Get("/export", controller.GetExportUsers)
 	users.Get("/:id", controller.GetUserById)
 	users.Patch("/:id", controller.PatchUpdateUserById)
 	users.Post("", controller.PostCreateUser)
 	users.Delete("/:id", controller.DeleteUserById)
 	users.Get("/:id/info", controller.GetUserInfo)
 	users.Patch("/:id/info", controller.PatchUserInfo)
+	users.Patch("/:id/ldap", controller.PatchUserLDAP)
+	users.Patch("/:id/status", controller.PatchUserStatus)
+	users.Post("/:id/impersonate", middleware.NotImpersonatedMiddleware(), controller.PostImpersonateUser)
 }
 
 func ErrorHandler(c *fiber.Ctx, err error) error {
@@ -0,0 +1,366 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	OperatorEqual          = "eq"
+	OperatorNotEqual       = "ne"
+	OperatorContains       = "co"
+	OperatorStartsWith     = "sw"
+	OperatorEndsWith       = "ew"
+	OperatorPresent        = "pr"
+	OperatorGreaterThan    = "gt"
+	OperatorGreaterOrEqual = "ge"
+	OperatorLessThan       = "lt"
+	OperatorLessOrEqual    = "le"
+	OperatorAnd            = "and"
+	OperatorOr             = "or"
+	OperatorNot            = "not"
+)
+
+var compareOperators = []string{
+	OperatorEqual, OperatorNotEqual, OperatorContains, OperatorStartsWith, OperatorEndsWith,
+	OperatorGreaterThan, OperatorGreaterOrEqual, OperatorLessThan, OperatorLessOrEqual,
+}
+
+// ExpressionST is a parsed filter from https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2,
+// exactly one of the groups of fields is set depending on Operator
+type ExpressionST struct {
+	Operator string
+	// attribute expressions, Value is a string, float64, bool or nil
+	Path  string
+	Value interface{}
+	// and, or
+	Left  *ExpressionST
+	Right *ExpressionST
+	// not and value paths like emails[type eq "work"]
+	Filter *ExpressionST
+}
+
+func (expression *ExpressionST) IsValuePath() bool {
+	return expression.Operator == "" && expression.Filter != nil
+}
+
+func ParseFilter(filter string) (*ExpressionST, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	parser := filterParser{tokens: tokens}
+	expression, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.done() {
+		return nil, NewError(ErrorTypeInvalidFilter, "unexpected %q", parser.peek().text)
+	}
+	return expression, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(filter string) ([]token, error) {
+	var tokens []token
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpenParen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenCloseParen, ")"})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{tokenOpenBracket, "["})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{tokenCloseBracket, "]"})
+			i++
+		case r == '"':
+			end := i + 1
+			for ; end < len(runes) && runes[end] != '"'; end++ {
+				if runes[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(runes) {
+				return nil, NewError(ErrorTypeInvalidFilter, "unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(string(runes[i:end+1])), &value); err != nil {
+				return nil, NewError(ErrorTypeInvalidFilter, "invalid string %s", string(runes[i:end+1]))
+			}
+			tokens = append(tokens, token{tokenString, value})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()[]"`, runes[end]); end++ {
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens   []token
+	position int
+}
+
+func (parser *filterParser) done() bool {
+	return parser.position >= len(parser.tokens)
+}
+
+func (parser *filterParser) peek() token {
+	if parser.done() {
+		return token{kind: -1}
+	}
+	return parser.tokens[parser.position]
+}
+
+func (parser *filterParser) next() token {
+	token := parser.peek()
+	parser.position++
+	return token
+}
+
+func (parser *filterParser) peekKeyword(keyword string) bool {
+	token := parser.peek()
+	return token.kind == tokenWord && strings.EqualFold(token.text, keyword)
+}
+
+func (parser *filterParser) expect(kind tokenKind, text string) error {
+	if token := parser.next(); token.kind != kind {
+		return NewError(ErrorTypeInvalidFilter, "expected %q", text)
+	}
+	return nil
+}
+
+func (parser *filterParser) parseOr() (*ExpressionST, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peekKeyword(OperatorOr) {
+		parser.next()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &ExpressionST{Operator: OperatorOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (parser *filterParser) parseAnd() (*ExpressionST, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peekKeyword(OperatorAnd) {
+		parser.next()
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &ExpressionST{Operator: OperatorAnd, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (parser *filterParser) parseUnary() (*ExpressionST, error) {
+	if parser.peekKeyword(OperatorNot) {
+		parser.next()
+		if err := parser.expect(tokenOpenParen, "("); err != nil {
+			return nil, err
+		}
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return &ExpressionST{Operator: OperatorNot, Filter: filter}, nil
+	}
+	if parser.peek().kind == tokenOpenParen {
+		parser.next()
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return filter, nil
+	}
+	path := parser.next()
+	if path.kind != tokenWord {
+		return nil, NewError(ErrorTypeInvalidFilter, "expected an attribute")
+	}
+	if parser.peek().kind == tokenOpenBracket {
+		parser.next()
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := parser.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		return &ExpressionST{Path: NormalizePath(path.text), Filter: filter}, nil
+	}
+	operator := parser.next()
+	if operator.kind != tokenWord {
+		return nil, NewError(ErrorTypeInvalidFilter, "expected an operator after %q", path.text)
+	}
+	expression := &ExpressionST{Operator: strings.ToLower(operator.text), Path: NormalizePath(path.text)}
+	if expression.Operator == OperatorPresent {
+		return expression, nil
+	}
+	if !slices.Contains(compareOperators, expression.Operator) {
+		return nil, NewError(ErrorTypeInvalidFilter, "unknown operator %q", operator.text)
+	}
+	value := parser.next()
+	switch {
+	case value.kind == tokenString:
+		expression.Value = value.text
+	case value.kind == tokenWord && value.text == "true":
+		expression.Value = true
+	case value.kind == tokenWord && value.text == "false":
+		expression.Value = false
+	case value.kind == tokenWord && value.text == "null":
+		expression.Value = nil
+	case value.kind == tokenWord:
+		number, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, NewError(ErrorTypeInvalidFilter, "invalid value %q", value.text)
+		}
+		expression.Value = number
+	default:
+		return nil, NewError(ErrorTypeInvalidFilter, "expected a value after %q", operator.text)
+	}
+	return expression, nil
+}
+
+// Matches evaluates the expression against one element of a multi-valued attribute, used by PATCH value paths
+func (expression *ExpressionST) Matches(element map[string]interface{}) bool {
+	switch expression.Operator {
+	case OperatorAnd:
+		return expression.Left.Matches(element) && expression.Right.Matches(element)
+	case OperatorOr:
+		return expression.Left.Matches(element) || expression.Right.Matches(element)
+	case OperatorNot:
+		return !expression.Filter.Matches(element)
+	}
+	value, ok := lookup(element, expression.Path)
+	if expression.Operator == OperatorPresent {
+		return ok && value != nil && value != ""
+	}
+	if !ok || value == nil {
+		if expression.Value == nil {
+			return expression.Operator == OperatorEqual
+		}
+		return expression.Operator == OperatorNotEqual
+	}
+	switch expected := expression.Value.(type) {
+	case string:
+		actual, ok := value.(string)
+		if !ok {
+			return false
+		}
+		actual, expected = strings.ToLower(actual), strings.ToLower(expected)
+		switch expression.Operator {
+		case OperatorEqual:
+			return actual == expected
+		case OperatorNotEqual:
+			return actual != expected
+		case OperatorContains:
+			return strings.Contains(actual, expected)
+		case OperatorStartsWith:
+			return strings.HasPrefix(actual, expected)
+		case OperatorEndsWith:
+			return strings.HasSuffix(actual, expected)
+		case OperatorGreaterThan:
+			return actual > expected
+		case OperatorGreaterOrEqual:
+			return actual >= expected
+		case OperatorLessThan:
+			return actual < expected
+		case OperatorLessOrEqual:
+			return actual <= expected
+		}
+	case bool:
+		actual, ok := value.(bool)
+		if !ok {
+			return false
+		}
+		switch expression.Operator {
+		case OperatorEqual:
+			return actual == expected
+		case OperatorNotEqual:
+			return actual != expected
+		}
+	case float64:
+		actual, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		switch expression.Operator {
+		case OperatorEqual:
+			return actual == expected
+		case OperatorNotEqual:
+			return actual != expected
+		case OperatorGreaterThan:
+			return actual > expected
+		case OperatorGreaterOrEqual:
+			return actual >= expected
+		case OperatorLessThan:
+			return actual < expected
+		case OperatorLessOrEqual:
+			return actual <= expected
+		}
+	case nil:
+		return expression.Operator == OperatorNotEqual
+	}
+	return false
+}
+
+func (expression *ExpressionST) String() string {
+	switch expression.Operator {
+	case OperatorAnd, OperatorOr:
+		return fmt.Sprintf("(%s %s %s)", expression.Left, expression.Operator, expression.Right)
+	case OperatorNot:
+		return fmt.Sprintf("not (%s)", expression.Filter)
+	case OperatorPresent:
+		return fmt.Sprintf("%s pr", expression.Path)
+	case "":
+		return fmt.Sprintf("%s[%s]", expression.Path, expression.Filter)
+	}
+	value, _ := json.Marshal(expression.Value)
+	return fmt.Sprintf("%s %s %s", expression.Path, expression.Operator, value)
+}
@@ -0,0 +1,247 @@
+package scim
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	PatchOpAdd     = "add"
+	PatchOpReplace = "replace"
+	PatchOpRemove  = "remove"
+)
+
+// PathST is a parsed PATCH path attrPath[valFilter].subAttr from
+// https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2, Schema is set for extension attributes
+type PathST struct {
+	Schema       string
+	Attribute    string
+	Filter       *ExpressionST
+	SubAttribute string
+}
+
+func ParsePath(path string) (*PathST, error) {
+	var parsed PathST
+	path = strings.TrimSpace(path)
+	if open := strings.Index(path, "["); open != -1 {
+		end := strings.LastIndex(path, "]")
+		if end < open {
+			return nil, NewError(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		filter, err := ParseFilter(path[open+1 : end])
+		if err != nil {
+			return nil, NewError(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		parsed.Filter = filter
+		rest := path[end+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return nil, NewError(ErrorTypeInvalidPath, "invalid path %q", path)
+			}
+			parsed.SubAttribute = strings.ToLower(rest[1:])
+		}
+		path = path[:open]
+	}
+	path = NormalizePath(path)
+	name, rest, nested := splitPath(path)
+	if strings.HasPrefix(name, "urn:") {
+		if !nested || rest == "" {
+			return nil, NewError(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		parsed.Schema = name
+		name, rest, nested = strings.Cut(rest, ".")
+	}
+	if name == "" {
+		return nil, NewError(ErrorTypeInvalidPath, "invalid path %q", path)
+	}
+	parsed.Attribute = name
+	if nested {
+		if parsed.Filter != nil || rest == "" || strings.Contains(rest, ".") {
+			return nil, NewError(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		parsed.SubAttribute = rest
+	}
+	return &parsed, nil
+}
+
+// ApplyPatch applies one PATCH operation to a resource decoded from json, the caller then saves the
+// whole resource, matching elements are created for add and replace on a value path without a match
+func ApplyPatch(resource map[string]interface{}, op, path string, value interface{}) error {
+	op = strings.ToLower(op)
+	switch op {
+	case PatchOpAdd, PatchOpReplace, PatchOpRemove:
+	default:
+		return NewError(ErrorTypeInvalidSyntax, "unknown op %q", op)
+	}
+	if strings.TrimSpace(path) == "" {
+		if op == PatchOpRemove {
+			return NewError(ErrorTypeNoTarget, "remove requires a path")
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return NewError(ErrorTypeInvalidValue, "value must be an object when there is no path")
+		}
+		for key, attributeValue := range object {
+			if strings.EqualFold(key, "schemas") {
+				continue
+			}
+			if err := ApplyPatch(resource, op, key, attributeValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	parsed, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	container := resource
+	if parsed.Schema != "" {
+		schemaKey, ok := findKey(resource, parsed.Schema)
+		child, isObject := resource[schemaKey].(map[string]interface{})
+		if !ok || !isObject {
+			if op == PatchOpRemove {
+				return nil
+			}
+			child = make(map[string]interface{})
+			resource[schemaKey] = child
+		}
+		container = child
+	}
+	key, _ := findKey(container, parsed.Attribute)
+	if parsed.Filter != nil {
+		container[key] = patchValuePath(container[key], op, parsed, value)
+		return nil
+	}
+	if parsed.SubAttribute != "" {
+		switch existing := container[key].(type) {
+		case []interface{}:
+			for _, element := range existing {
+				if object, ok := element.(map[string]interface{}); ok {
+					patchAttribute(object, op, parsed.SubAttribute, value)
+				}
+			}
+		case map[string]interface{}:
+			patchAttribute(existing, op, parsed.SubAttribute, value)
+		default:
+			if op != PatchOpRemove {
+				container[key] = map[string]interface{}{parsed.SubAttribute: value}
+			}
+		}
+		return nil
+	}
+	patchAttribute(container, op, key, value)
+	return nil
+}
+
+func patchAttribute(object map[string]interface{}, op, name string, value interface{}) {
+	key, _ := findKey(object, name)
+	existing, isArray := object[key].([]interface{})
+	values, valueIsArray := value.([]interface{})
+	switch op {
+	case PatchOpAdd:
+		if isArray && valueIsArray {
+			for _, v := range values {
+				if !containsElement(existing, v) {
+					existing = append(existing, v)
+				}
+			}
+			object[key] = existing
+		} else {
+			object[key] = value
+		}
+	case PatchOpReplace:
+		object[key] = value
+	case PatchOpRemove:
+		if isArray && valueIsArray {
+			remaining := make([]interface{}, 0, len(existing))
+			for _, element := range existing {
+				if !containsElement(values, element) {
+					remaining = append(remaining, element)
+				}
+			}
+			object[key] = remaining
+		} else {
+			delete(object, key)
+		}
+	}
+}
+
+func patchValuePath(existing interface{}, op string, path *PathST, value interface{}) interface{} {
+	elements, _ := existing.([]interface{})
+	result := make([]interface{}, 0, len(elements)+1)
+	matched := false
+	for _, element := range elements {
+		object, ok := element.(map[string]interface{})
+		if !ok || !path.Filter.Matches(object) {
+			result = append(result, element)
+			continue
+		}
+		matched = true
+		if op == PatchOpRemove && path.SubAttribute == "" {
+			continue
+		}
+		patchElement(object, op, path.SubAttribute, value)
+		result = append(result, object)
+	}
+	if !matched && op != PatchOpRemove {
+		object := filterEqualities(path.Filter)
+		patchElement(object, op, path.SubAttribute, value)
+		result = append(result, object)
+	}
+	return result
+}
+
+func patchElement(object map[string]interface{}, op, subAttribute string, value interface{}) {
+	if subAttribute != "" {
+		patchAttribute(object, op, subAttribute, value)
+		return
+	}
+	if values, ok := value.(map[string]interface{}); ok {
+		for key, v := range values {
+			patchAttribute(object, PatchOpReplace, key, v)
+		}
+	}
+}
+
+// filterEqualities builds the element a value path filter like type eq "work" describes
+func filterEqualities(expression *ExpressionST) map[string]interface{} {
+	object := make(map[string]interface{})
+	var collect func(expression *ExpressionST)
+	collect = func(expression *ExpressionST) {
+		switch expression.Operator {
+		case OperatorAnd:
+			collect(expression.Left)
+			collect(expression.Right)
+		case OperatorEqual:
+			if !strings.Contains(expression.Path, ".") {
+				object[expression.Path] = expression.Value
+			}
+		}
+	}
+	collect(expression)
+	return object
+}
+
+// containsElement compares elements of multi-valued attributes by their value sub attribute when they have one
+func containsElement(elements []interface{}, element interface{}) bool {
+	elementValue, hasValue := lookupValue(element)
+	for _, e := range elements {
+		if value, ok := lookupValue(e); ok && hasValue {
+			if reflect.DeepEqual(value, elementValue) {
+				return true
+			}
+		} else if reflect.DeepEqual(e, element) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupValue(element interface{}) (interface{}, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookup(object, "value")
+}
@@ -0,0 +1,111 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	ContentType = "application/scim+json"
+)
+
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeInvalidSyntax = "invalidSyntax"
+	ErrorTypeNoTarget      = "noTarget"
+	ErrorTypeUniqueness    = "uniqueness"
+	ErrorTypeMutability    = "mutability"
+	ErrorTypeTooMany       = "tooMany"
+)
+
+// ErrorST is a client error that is returned with its scimType, as a 400 unless Status is set
+type ErrorST struct {
+	Status int
+	Type   string
+	Detail string
+}
+
+func NewError(errorType, format string, args ...interface{}) *ErrorST {
+	return &ErrorST{
+		Type:   errorType,
+		Detail: fmt.Sprintf(format, args...),
+	}
+}
+
+func NewUniquenessError(format string, args ...interface{}) *ErrorST {
+	return &ErrorST{
+		Status: http.StatusConflict,
+		Type:   ErrorTypeUniqueness,
+		Detail: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *ErrorST) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Detail)
+}
+
+// NormalizePath lowercases an attribute path and removes the core schema prefix,
+// attributes of other schemas keep their urn followed by a colon
+func NormalizePath(path string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		schema = strings.ToLower(schema) + ":"
+		if strings.HasPrefix(path, schema) {
+			return path[len(schema):]
+		}
+	}
+	return path
+}
+
+// lookup finds a possibly dotted attribute path in a resource ignoring case
+func lookup(resource map[string]interface{}, path string) (interface{}, bool) {
+	name, rest, nested := splitPath(path)
+	key, ok := findKey(resource, name)
+	if !ok {
+		return nil, false
+	}
+	value := resource[key]
+	if !nested {
+		return value, true
+	}
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookup(child, rest)
+}
+
+// splitPath splits the first attribute off a path, attributes of extension schemas are nested
+// under their urn which itself contains dots
+func splitPath(path string) (string, string, bool) {
+	if strings.HasPrefix(path, "urn:") {
+		if i := strings.LastIndex(path, ":"); i > len("urn:") {
+			return path[:i], path[i+1:], true
+		}
+	}
+	return strings.Cut(path, ".")
+}
+
+func findKey(resource map[string]interface{}, name string) (string, bool) {
+	if _, ok := resource[name]; ok {
+		return name, true
+	}
+	for key := range resource {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return name, false
+}
@@ -0,0 +1,159 @@
+package scim
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	AttributeTypeString   = "string"
+	AttributeTypeBoolean  = "boolean"
+	AttributeTypeDateTime = "dateTime"
+)
+
+// SQLAttributeST maps a filterable attribute to sql, simple attributes set Column,
+// multi-valued attributes set Exists with a %s where the condition on its SubAttributes goes
+type SQLAttributeST struct {
+	Column        string
+	Type          string
+	CaseExact     bool
+	Exists        string
+	SubAttributes map[string]SQLAttributeST
+}
+
+// ToSQL translates a filter to a sql condition on the given attributes, values are appended to args
+// and referenced by their position so the condition can follow other parameters
+func ToSQL(expression *ExpressionST, attributes map[string]SQLAttributeST, args *[]interface{}) (string, error) {
+	switch expression.Operator {
+	case OperatorAnd, OperatorOr:
+		left, err := ToSQL(expression.Left, attributes, args)
+		if err != nil {
+			return "", err
+		}
+		right, err := ToSQL(expression.Right, attributes, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, strings.ToUpper(expression.Operator), right), nil
+	case OperatorNot:
+		filter, err := ToSQL(expression.Filter, attributes, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("NOT (%s)", filter), nil
+	}
+	if expression.IsValuePath() {
+		attribute, ok := attributes[expression.Path]
+		if !ok || attribute.Exists == "" {
+			return "", NewError(ErrorTypeInvalidFilter, "unsupported attribute %q", expression.Path)
+		}
+		filter, err := ToSQL(expression.Filter, attribute.SubAttributes, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("EXISTS(%s)", fmt.Sprintf(attribute.Exists, filter)), nil
+	}
+	if attribute, ok := attributes[expression.Path]; ok && attribute.Exists == "" {
+		return compareSQL(expression, attribute, args)
+	}
+	// emails eq "x" and emails.value eq "x" both filter on the value of any email
+	name, subName, nested := splitPath(expression.Path)
+	if !nested {
+		subName = "value"
+	}
+	attribute, ok := attributes[name]
+	if !ok || attribute.Exists == "" {
+		return "", NewError(ErrorTypeInvalidFilter, "unsupported attribute %q", expression.Path)
+	}
+	subAttribute, ok := attribute.SubAttributes[subName]
+	if !ok {
+		return "", NewError(ErrorTypeInvalidFilter, "unsupported attribute %q", expression.Path)
+	}
+	condition, err := compareSQL(expression, subAttribute, args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("EXISTS(%s)", fmt.Sprintf(attribute.Exists, condition)), nil
+}
+
+func compareSQL(expression *ExpressionST, attribute SQLAttributeST, args *[]interface{}) (string, error) {
+	column := attribute.Column
+	if expression.Operator == OperatorPresent {
+		if attribute.Type == AttributeTypeString {
+			return fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", column, column), nil
+		}
+		return fmt.Sprintf("%s IS NOT NULL", column), nil
+	}
+	if expression.Value == nil {
+		switch expression.Operator {
+		case OperatorEqual:
+			return fmt.Sprintf("%s IS NULL", column), nil
+		case OperatorNotEqual:
+			return fmt.Sprintf("%s IS NOT NULL", column), nil
+		default:
+			return "", NewError(ErrorTypeInvalidFilter, "null can only be compared with eq or ne")
+		}
+	}
+	switch attribute.Type {
+	case AttributeTypeBoolean:
+		if _, ok := expression.Value.(bool); !ok {
+			return "", NewError(ErrorTypeInvalidFilter, "%q is a boolean", expression.Path)
+		}
+		if expression.Operator != OperatorEqual && expression.Operator != OperatorNotEqual {
+			return "", NewError(ErrorTypeInvalidFilter, "%q can only be compared with eq or ne", expression.Path)
+		}
+	case AttributeTypeDateTime:
+		if _, ok := expression.Value.(string); !ok {
+			return "", NewError(ErrorTypeInvalidFilter, "%q is a dateTime", expression.Path)
+		}
+		switch expression.Operator {
+		case OperatorContains, OperatorStartsWith, OperatorEndsWith:
+			return "", NewError(ErrorTypeInvalidFilter, "%q can not be compared with %s", expression.Path, expression.Operator)
+		}
+	default:
+		value, ok := expression.Value.(string)
+		if !ok {
+			return "", NewError(ErrorTypeInvalidFilter, "%q is a string", expression.Path)
+		}
+		if !attribute.CaseExact {
+			column = fmt.Sprintf("LOWER(%s)", column)
+			value = strings.ToLower(value)
+		}
+		switch expression.Operator {
+		case OperatorContains:
+			*args = append(*args, "%"+escapeLike(value)+"%")
+			return fmt.Sprintf("%s LIKE $%d", column, len(*args)), nil
+		case OperatorStartsWith:
+			*args = append(*args, escapeLike(value)+"%")
+			return fmt.Sprintf("%s LIKE $%d", column, len(*args)), nil
+		case OperatorEndsWith:
+			*args = append(*args, "%"+escapeLike(value))
+			return fmt.Sprintf("%s LIKE $%d", column, len(*args)), nil
+		}
+		*args = append(*args, value)
+		return fmt.Sprintf("%s %s $%d", column, sqlOperator(expression.Operator), len(*args)), nil
+	}
+	*args = append(*args, expression.Value)
+	return fmt.Sprintf("%s %s $%d", column, sqlOperator(expression.Operator), len(*args)), nil
+}
+
+func sqlOperator(operator string) string {
+	switch operator {
+	case OperatorNotEqual:
+		return "IS DISTINCT FROM"
+	case OperatorGreaterThan:
+		return ">"
+	case OperatorGreaterOrEqual:
+		return ">="
+	case OperatorLessThan:
+		return "<"
+	case OperatorLessOrEqual:
+		return "<="
+	default:
+		return "="
+	}
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
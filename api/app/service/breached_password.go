@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsPasswordBreached looks the password's SHA-1 up in a local copy of the
+// Have I Been Pwned password list. path is either a directory of k-anonymity
+// range files, named by the first 5 hex characters of the hash and holding
+// SUFFIX:COUNT lines, or a single file of HASH:COUNT lines sorted by hash.
+func IsPasswordBreached(path, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return isPasswordHashInRangeFile(path, hash)
+	}
+	return isPasswordHashInSortedFile(path, info.Size(), hash)
+}
+
+// CheckBreachedPasswords makes sure the breached password list at path can be
+// read, an empty path has no list to check.
+func CheckBreachedPasswords(path string) error {
+	if path == "" {
+		return nil
+	}
+	_, err := IsPasswordBreached(path, "")
+	return err
+}
+
+func isPasswordHashInRangeFile(dir, hash string) (bool, error) {
+	prefix, suffix := hash[:5], hash[5:]
+	file, err := os.Open(filepath.Join(dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(dir, prefix+".txt"))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineHash, breached := parsePasswordHashLine(scanner.Text())
+		if breached && strings.EqualFold(lineHash, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// isPasswordHashInSortedFile binary searches the file by byte offset, reading
+// the first full line after each offset.
+func isPasswordHashInSortedFile(path string, size int64, hash string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	low, high := int64(0), size
+	for low < high {
+		mid := low + (high-low)/2
+		lineHash, breached, start, err := readPasswordHashLineAfter(file, mid)
+		if err != nil {
+			return false, err
+		}
+		if start < 0 {
+			high = mid
+			continue
+		}
+		switch compare := strings.Compare(strings.ToUpper(lineHash), hash); {
+		case compare == 0:
+			return breached, nil
+		case compare < 0:
+			low = start
+		default:
+			high = mid
+		}
+	}
+	// the first line is never read after an offset
+	lineHash, breached, _, err := readPasswordHashLineAfter(file, -1)
+	if err != nil {
+		return false, err
+	}
+	return breached && strings.EqualFold(lineHash, hash), nil
+}
+
+// readPasswordHashLineAfter returns the first line starting after offset and
+// where it starts, start is -1 when there is no such line.
+func readPasswordHashLineAfter(file *os.File, offset int64) (string, bool, int64, error) {
+	start := int64(0)
+	if offset >= 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return "", false, -1, err
+		}
+		reader := bufio.NewReader(file)
+		skipped, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return "", false, -1, nil
+		}
+		if err != nil {
+			return "", false, -1, err
+		}
+		start = offset + int64(len(skipped))
+	}
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return "", false, -1, err
+	}
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return "", false, -1, err
+	}
+	if len(line) == 0 {
+		return "", false, -1, nil
+	}
+	hash, breached := parsePasswordHashLine(string(bytes.TrimSpace(line)))
+	return hash, breached, start, nil
+}
+
+func parsePasswordHashLine(line string) (string, bool) {
+	hash, count, found := strings.Cut(strings.TrimSpace(line), ":")
+	if !found {
+		return hash, hash != ""
+	}
+	return hash, strings.TrimSpace(count) != "0"
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/go-expiringmap"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	googleIssuer                  = "https://accounts.google.com"
+	identityProviderCacheDuration = time.Hour
+	identityProviderMaxBodySize   = 1 << 20
+)
+
+var (
+	identityProviderClient = &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	identityProviderDiscoveries = expiringmap.New[string, IdentityProviderEndpointsST]()
+	identityProviderKeySets     = expiringmap.New[string, map[string]interface{}]()
+	githubEndpoints             = IdentityProviderEndpointsST{
+		AuthorizationEndpoint: "https://github.com/login/oauth/authorize",
+		TokenEndpoint:         "https://github.com/login/oauth/access_token",
+		UserinfoEndpoint:      "https://api.github.com/user",
+	}
+	identityProviderAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+type IdentityProviderEndpointsST struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// GetIdentityProviderEndpoints resolves a provider's endpoints from its preset or OpenID discovery, endpoints
+// configured on the provider take precedence
+func GetIdentityProviderEndpoints(provider *repository.IdentityProviderRowST) (IdentityProviderEndpointsST, error) {
+	var endpoints IdentityProviderEndpointsST
+	switch provider.Kind {
+	case repository.IdentityProviderKindGitHub:
+		endpoints = githubEndpoints
+	case repository.IdentityProviderKindGoogle:
+		issuer := googleIssuer
+		if provider.Issuer != nil && *provider.Issuer != "" {
+			issuer = *provider.Issuer
+		}
+		discovered, err := discoverIdentityProvider(issuer)
+		if err != nil {
+			return endpoints, err
+		}
+		endpoints = discovered
+	case repository.IdentityProviderKindOIDC:
+		if provider.Issuer != nil && *provider.Issuer != "" && (provider.AuthorizationEndpoint == nil || provider.TokenEndpoint == nil || provider.JWKSURI == nil) {
+			discovered, err := discoverIdentityProvider(*provider.Issuer)
+			if err != nil {
+				return endpoints, err
+			}
+			endpoints = discovered
+		}
+	default:
+		return endpoints, fmt.Errorf("unknown identity provider kind %s", provider.Kind)
+	}
+	overrideEndpoint(&endpoints.Issuer, provider.Issuer)
+	overrideEndpoint(&endpoints.AuthorizationEndpoint, provider.AuthorizationEndpoint)
+	overrideEndpoint(&endpoints.TokenEndpoint, provider.TokenEndpoint)
+	overrideEndpoint(&endpoints.UserinfoEndpoint, provider.UserinfoEndpoint)
+	overrideEndpoint(&endpoints.JWKSURI, provider.JWKSURI)
+	if endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" {
+		return endpoints, fmt.Errorf("identity provider %d has no authorization or token endpoint", provider.Id)
+	}
+	return endpoints, nil
+}
+
+func IdentityProviderScopes(provider *repository.IdentityProviderRowST) []string {
+	if len(provider.Scopes) > 0 {
+		return provider.Scopes
+	}
+	if provider.Kind == repository.IdentityProviderKindGitHub {
+		return []string{"read:user", "user:email"}
+	}
+	return []string{"openid", "email", "profile"}
+}
+
+// IdentityProviderAuthorizationURL builds the url the user is sent to, with PKCE and the nonce the id token must contain
+func IdentityProviderAuthorizationURL(provider *repository.IdentityProviderRowST, endpoints IdentityProviderEndpointsST, redirectURI, state, nonce, codeVerifier string) (string, error) {
+	authorizationURL, err := url.Parse(endpoints.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	codeChallenge := sha256.Sum256([]byte(codeVerifier))
+	query := authorizationURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.ClientId)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(IdentityProviderScopes(provider), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(codeChallenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authorizationURL.RawQuery = query.Encode()
+	return authorizationURL.String(), nil
+}
+
+type identityProviderTokenST struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type upstreamClaimsST struct {
+	jwt.RegisteredClaims
+	Nonce             string      `json:"nonce"`
+	Email             *string     `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	PreferredUsername *string     `json:"preferred_username"`
+	Name              *string     `json:"name"`
+	GivenName         *string     `json:"given_name"`
+	FamilyName        *string     `json:"family_name"`
+	Picture           *string     `json:"picture"`
+}
+
+func (claims *upstreamClaimsST) toUpstreamUser() repository.UpstreamUserST {
+	emailVerified := false
+	switch value := claims.EmailVerified.(type) {
+	case bool:
+		emailVerified = value
+	case string:
+		emailVerified, _ = strconv.ParseBool(value)
+	}
+	return repository.UpstreamUserST{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: emailVerified,
+		Username:      claims.PreferredUsername,
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Picture:       claims.Picture,
+	}
+}
+
+// ExchangeIdentityProviderCode redeems the code the provider redirected back with and returns who signed in,
+// OpenID providers are trusted through their verified id token and GitHub through its api
+func ExchangeIdentityProviderCode(provider *repository.IdentityProviderRowST, endpoints IdentityProviderEndpointsST, redirectURI, code, codeVerifier, nonce string) (repository.UpstreamUserST, error) {
+	var upstream repository.UpstreamUserST
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", provider.ClientId)
+	form.Set("client_secret", provider.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequest(http.MethodPost, endpoints.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return upstream, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	status, body, err := doIdentityProviderRequest(req)
+	if err != nil {
+		return upstream, err
+	}
+	var token identityProviderTokenST
+	if err := json.Unmarshal(body, &token); err != nil && status < 300 {
+		return upstream, err
+	}
+	if token.Error != "" {
+		return upstream, fmt.Errorf("token endpoint responded with %s: %s", token.Error, token.ErrorDescription)
+	}
+	if status < 200 || status >= 300 {
+		return upstream, fmt.Errorf("token endpoint responded with %d", status)
+	}
+	if provider.Kind == repository.IdentityProviderKindGitHub {
+		return getGitHubUser(endpoints, token.AccessToken)
+	}
+	if token.IdToken != "" {
+		claims, err := verifyIdentityProviderIdToken(provider, endpoints, token.IdToken, nonce)
+		if err != nil {
+			return upstream, err
+		}
+		upstream = claims.toUpstreamUser()
+	} else if endpoints.UserinfoEndpoint == "" {
+		return upstream, fmt.Errorf("identity provider %d returned no id token", provider.Id)
+	}
+	if endpoints.UserinfoEndpoint != "" && token.AccessToken != "" && (upstream.Subject == "" || upstream.Email == nil) {
+		var userinfo upstreamClaimsST
+		if err := getIdentityProviderJSON(endpoints.UserinfoEndpoint, token.AccessToken, &userinfo); err != nil {
+			return upstream, err
+		}
+		if upstream.Subject != "" && userinfo.Subject != upstream.Subject {
+			return upstream, fmt.Errorf("userinfo subject does not match the id token")
+		}
+		if upstream.Subject == "" {
+			upstream = userinfo.toUpstreamUser()
+		} else if userinfo.Email != nil {
+			fromUserinfo := userinfo.toUpstreamUser()
+			upstream.Email = fromUserinfo.Email
+			upstream.EmailVerified = fromUserinfo.EmailVerified
+		}
+	}
+	if upstream.Subject == "" {
+		return upstream, fmt.Errorf("identity provider %d returned no subject", provider.Id)
+	}
+	return upstream, nil
+}
+
+func verifyIdentityProviderIdToken(provider *repository.IdentityProviderRowST, endpoints IdentityProviderEndpointsST, idToken, nonce string) (*upstreamClaimsST, error) {
+	if endpoints.JWKSURI == "" {
+		return nil, fmt.Errorf("identity provider %d has no jwks uri", provider.Id)
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(identityProviderAlgorithms),
+		jwt.WithAudience(provider.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	}
+	if endpoints.Issuer != "" {
+		options = append(options, jwt.WithIssuer(endpoints.Issuer))
+	}
+	var claims upstreamClaimsST
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return getIdentityProviderKey(endpoints.JWKSURI, kid)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("invalid id token nonce")
+	}
+	return &claims, nil
+}
+
+type jsonWebKeyST struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// getIdentityProviderKey returns the signing key with kid, the key set is fetched again once when the kid is unknown
+// so rotated keys are picked up
+func getIdentityProviderKey(jwksURI, kid string) (interface{}, error) {
+	keys, cached := identityProviderKeySets.Get(jwksURI)
+	if !cached || !hasIdentityProviderKey(keys, kid) {
+		fetched, err := fetchIdentityProviderKeys(jwksURI)
+		if err != nil {
+			return nil, err
+		}
+		identityProviderKeySets.Set(jwksURI, fetched, time.Now().Add(identityProviderCacheDuration))
+		keys = fetched
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func hasIdentityProviderKey(keys map[string]interface{}, kid string) bool {
+	if kid == "" {
+		return len(keys) == 1
+	}
+	_, ok := keys[kid]
+	return ok
+}
+
+func fetchIdentityProviderKeys(jwksURI string) (map[string]interface{}, error) {
+	var keySet struct {
+		Keys []jsonWebKeyST `json:"keys"`
+	}
+	if err := getIdentityProviderJSON(jwksURI, "", &keySet); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (jwk *jsonWebKeyST) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("invalid ec key")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+func discoverIdentityProvider(issuer string) (IdentityProviderEndpointsST, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if endpoints, ok := identityProviderDiscoveries.Get(issuer); ok {
+		return endpoints, nil
+	}
+	var endpoints IdentityProviderEndpointsST
+	if err := getIdentityProviderJSON(issuer+"/.well-known/openid-configuration", "", &endpoints); err != nil {
+		return endpoints, err
+	}
+	if strings.TrimSuffix(endpoints.Issuer, "/") != issuer {
+		return endpoints, fmt.Errorf("discovered issuer %s does not match %s", endpoints.Issuer, issuer)
+	}
+	identityProviderDiscoveries.Set(issuer, endpoints, time.Now().Add(identityProviderCacheDuration))
+	return endpoints, nil
+}
+
+type githubUserST struct {
+	Id        int64   `json:"id"`
+	Login     string  `json:"login"`
+	Name      *string `json:"name"`
+	AvatarURL *string `json:"avatar_url"`
+}
+
+type githubEmailST struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func getGitHubUser(endpoints IdentityProviderEndpointsST, accessToken string) (repository.UpstreamUserST, error) {
+	var upstream repository.UpstreamUserST
+	var user githubUserST
+	if err := getIdentityProviderJSON(endpoints.UserinfoEndpoint, accessToken, &user); err != nil {
+		return upstream, err
+	}
+	if user.Id == 0 {
+		return upstream, fmt.Errorf("github returned no user id")
+	}
+	upstream.Subject = strconv.FormatInt(user.Id, 10)
+	upstream.Username = &user.Login
+	upstream.Name = user.Name
+	upstream.Picture = user.AvatarURL
+	var emails []githubEmailST
+	if err := getIdentityProviderJSON(strings.TrimSuffix(endpoints.UserinfoEndpoint, "/")+"/emails", accessToken, &emails); err != nil {
+		return upstream, err
+	}
+	for _, email := range emails {
+		if email.Primary {
+			upstream.Email = &email.Email
+			upstream.EmailVerified = email.Verified
+		}
+	}
+	return upstream, nil
+}
+
+func getIdentityProviderJSON(endpoint, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	status, body, err := doIdentityProviderRequest(req)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("%s responded with %d", req.URL.Redacted(), status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func doIdentityProviderRequest(req *http.Request) (int, []byte, error) {
+	res, err := identityProviderClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, identityProviderMaxBodySize))
+	if err != nil {
+		return 0, nil, err
+	}
+	return res.StatusCode, body, nil
+}
+
+func overrideEndpoint(endpoint *string, value *string) {
+	if value != nil && *value != "" {
+		*endpoint = *value
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const ldapTimeout = 10 * time.Second
+
+var (
+	ErrLDAPInvalidCredentials = errors.New("invalid ldap credentials")
+)
+
+// AuthenticateLDAP finds the user in the directory with the connector's service account, binds as them with password
+// and returns their attributes and groups
+func AuthenticateLDAP(connector *repository.LDAPConnectorRowST, username, password string) (*repository.LDAPUserST, error) {
+	// an empty password is an unauthenticated bind which most directories accept
+	if username == "" || password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+	conn, err := dialLDAP(connector)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := bindLDAPServiceAccount(conn, connector); err != nil {
+		return nil, err
+	}
+	filter := strings.ReplaceAll(connector.UserFilter, "{username}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		connector.UserSearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(ldapTimeout.Seconds()), false,
+		filter,
+		[]string{connector.UsernameAttribute, connector.EmailAttribute, connector.NameAttribute, connector.GivenNameAttribute, connector.FamilyNameAttribute},
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, err
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrLDAPInvalidCredentials
+	}
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, err
+	}
+	ldapUser := &repository.LDAPUserST{
+		DN:         entry.DN,
+		Username:   entry.GetAttributeValue(connector.UsernameAttribute),
+		Email:      ldapAttribute(entry, connector.EmailAttribute),
+		Name:       ldapAttribute(entry, connector.NameAttribute),
+		GivenName:  ldapAttribute(entry, connector.GivenNameAttribute),
+		FamilyName: ldapAttribute(entry, connector.FamilyNameAttribute),
+	}
+	if ldapUser.Username == "" {
+		ldapUser.Username = username
+	}
+	if connector.GroupSearchBase != nil && *connector.GroupSearchBase != "" {
+		if err := bindLDAPServiceAccount(conn, connector); err != nil {
+			return nil, err
+		}
+		filter := strings.ReplaceAll(connector.GroupFilter, "{dn}", ldap.EscapeFilter(entry.DN))
+		filter = strings.ReplaceAll(filter, "{username}", ldap.EscapeFilter(ldapUser.Username))
+		result, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+			*connector.GroupSearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(ldapTimeout.Seconds()), false,
+			filter,
+			[]string{connector.GroupNameAttribute},
+			nil,
+		), 500)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range result.Entries {
+			ldapUser.Groups = append(ldapUser.Groups, group.DN)
+			if name := group.GetAttributeValue(connector.GroupNameAttribute); name != "" {
+				ldapUser.Groups = append(ldapUser.Groups, name)
+			}
+		}
+	}
+	return ldapUser, nil
+}
+
+// LDAPRoles returns the role uris the user's groups map to and every role uri the connector maps
+func LDAPRoles(connector *repository.LDAPConnectorRowST, ldapUser *repository.LDAPUserST) ([]string, []string, error) {
+	groupRoles, err := connector.GroupRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	mappedRoles := make([]string, 0)
+	roles := make([]string, 0)
+	for group, groupRoleURIs := range groupRoles {
+		member := slices.ContainsFunc(ldapUser.Groups, func(userGroup string) bool {
+			return strings.EqualFold(userGroup, group)
+		})
+		for _, roleURI := range groupRoleURIs {
+			if !slices.Contains(mappedRoles, roleURI) {
+				mappedRoles = append(mappedRoles, roleURI)
+			}
+			if member && !slices.Contains(roles, roleURI) {
+				roles = append(roles, roleURI)
+			}
+		}
+	}
+	return mappedRoles, roles, nil
+}
+
+// LDAPTLSConfig returns the tls config for the connector's url, nil when it uses neither ldaps nor StartTLS
+func LDAPTLSConfig(connector *repository.LDAPConnectorRowST) (*tls.Config, error) {
+	ldapURL, err := url.Parse(connector.URL)
+	if err != nil {
+		return nil, err
+	}
+	if ldapURL.Scheme != "ldaps" && !connector.StartTLS {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         ldapURL.Hostname(),
+		InsecureSkipVerify: connector.TLSSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if connector.TLSRootCA != nil && *connector.TLSRootCA != "" {
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM([]byte(*connector.TLSRootCA)) {
+			return nil, fmt.Errorf("invalid tls root ca")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	return tlsConfig, nil
+}
+
+func dialLDAP(connector *repository.LDAPConnectorRowST) (*ldap.Conn, error) {
+	tlsConfig, err := LDAPTLSConfig(connector)
+	if err != nil {
+		return nil, err
+	}
+	options := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout})}
+	if tlsConfig != nil {
+		options = append(options, ldap.DialWithTLSConfig(tlsConfig))
+	}
+	conn, err := ldap.DialURL(connector.URL, options...)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeout)
+	if connector.StartTLS && !strings.HasPrefix(connector.URL, "ldaps:") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func bindLDAPServiceAccount(conn *ldap.Conn, connector *repository.LDAPConnectorRowST) error {
+	if connector.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	return conn.Bind(connector.BindDN, connector.BindPassword)
+}
+
+func ldapAttribute(entry *ldap.Entry, name string) *string {
+	value := strings.TrimSpace(entry.GetAttributeValue(name))
+	if value == "" {
+		return nil
+	}
+	return &value
+}
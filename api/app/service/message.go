@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+)
+
+const (
+	MessageKindMFACode           = "mfa-code"
+	MessageKindPasswordlessLogin = "passwordless-login"
+	MessageKindInvitation        = "invitation"
+	MessageKindConfirmation      = "confirmation"
+)
+
+// ErrNoMessageEndpoint is returned when the tenent has no endpoint configured for the message's channel
+var ErrNoMessageEndpoint = errors.New("no message endpoint")
+
+var messageClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+type MessageST struct {
+	Kind     string                 `json:"kind"`
+	To       string                 `json:"to"`
+	TenentId int32                  `json:"tenent_id"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// CanSendEmail reports whether the tenent has an endpoint to deliver emails to
+func CanSendEmail(tenent *repository.TenentRowST) bool {
+	return tenent.EmailEndpoint != nil && *tenent.EmailEndpoint != ""
+}
+
+// CanSendSMS reports whether the tenent has an endpoint to deliver text messages to
+func CanSendSMS(tenent *repository.TenentRowST) bool {
+	return tenent.PhoneNumberEndpoint != nil && *tenent.PhoneNumberEndpoint != ""
+}
+
+func SendEmail(tenent *repository.TenentRowST, to, kind string, data map[string]interface{}) error {
+	return sendMessage(tenent, tenent.EmailEndpoint, MessageST{
+		Kind:     kind,
+		To:       to,
+		TenentId: tenent.Id,
+		Data:     data,
+	})
+}
+
+func SendSMS(tenent *repository.TenentRowST, to, kind string, data map[string]interface{}) error {
+	return sendMessage(tenent, tenent.PhoneNumberEndpoint, MessageST{
+		Kind:     kind,
+		To:       to,
+		TenentId: tenent.Id,
+		Data:     data,
+	})
+}
+
+func sendMessage(tenent *repository.TenentRowST, endpoint *string, message MessageST) error {
+	if endpoint == nil || *endpoint == "" {
+		return ErrNoMessageEndpoint
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, *endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, []byte(tenent.ClientSecret))
+	mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", util.BytesToHex(mac.Sum(nil)))
+	res, err := messageClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("message endpoint responded with %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+)
+
+type PasswordViolationST struct {
+	Message    string
+	Parameters []interface{}
+}
+
+func newPasswordViolation(message string, parameters ...interface{}) PasswordViolationST {
+	return PasswordViolationST{
+		Message:    message,
+		Parameters: parameters,
+	}
+}
+
+// ValidatePassword checks password against policy, previousEncryptedPasswords
+// are the user's recent password hashes and breachedPath the local breached
+// password list, empty to skip that check
+func ValidatePassword(policy repository.PasswordPolicyRowST, username, password string, previousEncryptedPasswords []string, breachedPath string) ([]PasswordViolationST, error) {
+	violations := make([]PasswordViolationST, 0)
+	length := utf8.RuneCountInString(password)
+	if length < int(policy.MinLength) {
+		violations = append(violations, newPasswordViolation("minLength", policy.MinLength))
+	}
+	if policy.MaxLength > 0 && length > int(policy.MaxLength) {
+		violations = append(violations, newPasswordViolation("maxLength", policy.MaxLength))
+	}
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireLowercase && !hasLower {
+		violations = append(violations, newPasswordViolation("lowercase"))
+	}
+	if policy.RequireUppercase && !hasUpper {
+		violations = append(violations, newPasswordViolation("uppercase"))
+	}
+	if policy.RequireDigit && !hasDigit {
+		violations = append(violations, newPasswordViolation("digit"))
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		violations = append(violations, newPasswordViolation("symbol"))
+	}
+	if policy.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, newPasswordViolation("containsUsername"))
+	}
+	for _, encryptedPassword := range previousEncryptedPasswords {
+		reused, err := util.VerifyPassword(password, encryptedPassword)
+		if err != nil {
+			return nil, err
+		}
+		if reused {
+			violations = append(violations, newPasswordViolation("reused", policy.HistoryCount))
+			break
+		}
+	}
+	if policy.CheckBreached && breachedPath != "" {
+		breached, err := IsPasswordBreached(breachedPath, password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			violations = append(violations, newPasswordViolation("breached"))
+		}
+	}
+	return violations, nil
+}
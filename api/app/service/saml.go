@@ -0,0 +1,465 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/crewjam/saml"
+	xrv "github.com/mattermost/xml-roundtrip-validator"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	samlCertificateValidFor     = 10 * 365 * 24 * time.Hour
+	samlCertificateRenewBefore  = 30 * 24 * time.Hour
+	samlAttributeNameFormatURI  = "urn:oasis:names:tc:SAML:2.0:attrname-format:uri"
+	samlAttributeNameFormatBase = "urn:oasis:names:tc:SAML:2.0:attrname-format:basic"
+)
+
+var (
+	ErrSAMLSymmetricKey = errors.New("saml requires an asymmetric tenent algorithm")
+	samlNameIdFormats   = map[string]string{
+		repository.SAMLNameIdFormatPersistent:  "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent",
+		repository.SAMLNameIdFormatEmail:       "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress",
+		repository.SAMLNameIdFormatUnspecified: "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified",
+		repository.SAMLNameIdFormatTransient:   "urn:oasis:names:tc:SAML:2.0:nameid-format:transient",
+	}
+	// SAMLAttributeSources are the user fields service provider attributes can be mapped from
+	SAMLAttributeSources = []string{
+		"id", "username", "email", "phone_number", "roles", "name", "given_name", "family_name", "middle_name",
+		"nickname", "profile", "picture", "website", "gender", "birthdate", "zoneinfo", "locale", "street_address",
+		"locality", "region", "postal_code", "country",
+	}
+)
+
+// SAMLUserST is everything an assertion can say about the signed in user
+type SAMLUserST struct {
+	User        *repository.UserRowST
+	Email       *string
+	PhoneNumber *string
+	Info        *repository.UserInfoRowST
+	Roles       []string
+}
+
+// SAMLIdentityProviderST is a tenent's SAML identity provider, it remembers the service provider the last request
+// was validated against
+type SAMLIdentityProviderST struct {
+	saml.IdentityProvider
+	serviceProviders *samlServiceProviders
+}
+
+func (idp *SAMLIdentityProviderST) ServiceProvider() *repository.SAMLServiceProviderRowST {
+	return idp.serviceProviders.found
+}
+
+type samlServiceProviders struct {
+	tenentId int32
+	found    *repository.SAMLServiceProviderRowST
+}
+
+func (serviceProviders *samlServiceProviders) GetServiceProvider(r *http.Request, serviceProviderID string) (*saml.EntityDescriptor, error) {
+	serviceProvider, err := repository.GetSAMLServiceProviderByEntityId(serviceProviders.tenentId, serviceProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if serviceProvider == nil || !serviceProvider.Enabled {
+		return nil, os.ErrNotExist
+	}
+	serviceProviders.found = serviceProvider
+	return SAMLServiceProviderMetadata(serviceProvider)
+}
+
+// GetSAMLIdentityProvider builds the tenent's identity provider, assertions are signed with the tenent's private key
+// and a self-signed certificate for it which is created when missing, expiring or issued for a previous key
+func GetSAMLIdentityProvider(tenent *repository.TenentRowST) (*SAMLIdentityProviderST, error) {
+	key, signatureMethod, err := samlSigningKey(tenent)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := samlCertificate(tenent, key)
+	if err != nil {
+		return nil, err
+	}
+	metadataURL, err := url.Parse(SAMLURL(tenent, "metadata"))
+	if err != nil {
+		return nil, err
+	}
+	ssoURL, err := url.Parse(SAMLURL(tenent, "sso"))
+	if err != nil {
+		return nil, err
+	}
+	var signer crypto.Signer = key
+	if ecdsaKey, ok := key.(*ecdsa.PrivateKey); ok {
+		signer = &samlECDSASigner{ecdsaKey}
+	}
+	serviceProviders := &samlServiceProviders{tenentId: tenent.Id}
+	return &SAMLIdentityProviderST{
+		IdentityProvider: saml.IdentityProvider{
+			Signer:                  signer,
+			Certificate:             certificate,
+			MetadataURL:             *metadataURL,
+			SSOURL:                  *ssoURL,
+			ServiceProviderProvider: serviceProviders,
+			SignatureMethod:         signatureMethod,
+		},
+		serviceProviders: serviceProviders,
+	}, nil
+}
+
+func SAMLURL(tenent *repository.TenentRowST, path string) string {
+	return strings.TrimSuffix(config.Get().URL, "/") + "/saml/" + tenent.ClientId.String() + "/" + path
+}
+
+// MetadataXML returns the identity provider's metadata advertising every name id format service providers can use
+func (idp *SAMLIdentityProviderST) MetadataXML() ([]byte, error) {
+	metadata := idp.Metadata()
+	nameIdFormats := make([]saml.NameIDFormat, 0, len(samlNameIdFormats))
+	for _, format := range []string{repository.SAMLNameIdFormatPersistent, repository.SAMLNameIdFormatEmail, repository.SAMLNameIdFormatUnspecified, repository.SAMLNameIdFormatTransient} {
+		nameIdFormats = append(nameIdFormats, saml.NameIDFormat(samlNameIdFormats[format]))
+	}
+	metadata.IDPSSODescriptors[0].NameIDFormats = nameIdFormats
+	buf, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
+
+// ParseAuthnRequest reads an AuthnRequest sent with the HTTP-Redirect or HTTP-POST binding and validates it against
+// the registered service provider
+func (idp *SAMLIdentityProviderST) ParseAuthnRequest(r *http.Request) (*saml.IdpAuthnRequest, error) {
+	req, err := saml.NewIdpAuthnRequest(&idp.IdentityProvider, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// RestoreAuthnRequest validates a stored AuthnRequest again as of when it was received, the user may have taken
+// longer to sign in than requests are valid for
+func (idp *SAMLIdentityProviderST) RestoreAuthnRequest(r *http.Request, request *repository.SAMLRequestRowST) (*saml.IdpAuthnRequest, error) {
+	req := &saml.IdpAuthnRequest{
+		IDP:           &idp.IdentityProvider,
+		HTTPRequest:   r,
+		RequestBuffer: []byte(request.Request),
+		RelayState:    request.RelayState,
+		Now:           request.CreatedAt,
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if idp.ServiceProvider().Id != request.SAMLServiceProviderId {
+		return nil, fmt.Errorf("service provider %s changed", req.Request.Issuer.Value)
+	}
+	req.Now = saml.TimeNow()
+	return req, nil
+}
+
+// MakeSAMLResponse signs an assertion for the user with the service provider's name id format and attribute mapping
+// and returns the form the browser posts to the service provider
+func MakeSAMLResponse(req *saml.IdpAuthnRequest, serviceProvider *repository.SAMLServiceProviderRowST, user SAMLUserST) (saml.IdpAuthnRequestForm, error) {
+	var form saml.IdpAuthnRequestForm
+	session, err := samlSession(serviceProvider, user)
+	if err != nil {
+		return form, err
+	}
+	if err := (saml.DefaultAssertionMaker{}).MakeAssertion(req, session); err != nil {
+		return form, err
+	}
+	return req.PostBinding()
+}
+
+// SAMLServiceProviderMetadata parses the service provider's metadata or builds it from its acs url
+func SAMLServiceProviderMetadata(serviceProvider *repository.SAMLServiceProviderRowST) (*saml.EntityDescriptor, error) {
+	if serviceProvider.Metadata != nil && *serviceProvider.Metadata != "" {
+		if err := xrv.Validate(strings.NewReader(*serviceProvider.Metadata)); err != nil {
+			return nil, err
+		}
+		var metadata saml.EntityDescriptor
+		if err := xml.Unmarshal([]byte(*serviceProvider.Metadata), &metadata); err != nil {
+			return nil, err
+		}
+		if len(metadata.SPSSODescriptors) == 0 {
+			return nil, fmt.Errorf("metadata for %s has no SPSSODescriptor", serviceProvider.EntityId)
+		}
+		return &metadata, nil
+	}
+	if serviceProvider.ACSURL != nil && *serviceProvider.ACSURL != "" {
+		isDefault := true
+		return &saml.EntityDescriptor{
+			EntityID: serviceProvider.EntityId,
+			SPSSODescriptors: []saml.SPSSODescriptor{{
+				AssertionConsumerServices: []saml.IndexedEndpoint{{
+					Binding:   saml.HTTPPostBinding,
+					Location:  *serviceProvider.ACSURL,
+					Index:     0,
+					IsDefault: &isDefault,
+				}},
+			}},
+		}, nil
+	}
+	return nil, fmt.Errorf("service provider %s has neither metadata nor an acs url", serviceProvider.EntityId)
+}
+
+func samlSession(serviceProvider *repository.SAMLServiceProviderRowST, user SAMLUserST) (*saml.Session, error) {
+	index, err := util.GenerateRandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	session := &saml.Session{
+		ID:           index,
+		CreateTime:   saml.TimeNow(),
+		Index:        index,
+		NameIDFormat: samlNameIdFormats[serviceProvider.NameIdFormat],
+		UserName:     user.User.Username,
+		Groups:       user.Roles,
+	}
+	switch serviceProvider.NameIdFormat {
+	case repository.SAMLNameIdFormatEmail:
+		if user.Email == nil {
+			return nil, fmt.Errorf("user %d has no email for %s", user.User.Id, serviceProvider.EntityId)
+		}
+		session.NameID = *user.Email
+	case repository.SAMLNameIdFormatUnspecified:
+		session.NameID = user.User.Username
+	case repository.SAMLNameIdFormatTransient:
+		session.NameID, err = util.GenerateRandomHex(20)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		session.NameID = strconv.Itoa(int(user.User.Id))
+	}
+	if user.Email != nil {
+		session.UserEmail = *user.Email
+	}
+	if user.Info != nil {
+		session.UserCommonName = stringValue(user.Info.Name)
+		session.UserGivenName = stringValue(user.Info.GivenName)
+		session.UserSurname = stringValue(user.Info.FamilyName)
+	}
+	attributes, err := serviceProvider.Attributes()
+	if err != nil {
+		return nil, err
+	}
+	for name, source := range attributes {
+		values := samlAttributeValues(user, source)
+		if len(values) == 0 {
+			continue
+		}
+		nameFormat := samlAttributeNameFormatBase
+		if strings.HasPrefix(name, "urn:") {
+			nameFormat = samlAttributeNameFormatURI
+		}
+		attribute := saml.Attribute{
+			Name:       name,
+			NameFormat: nameFormat,
+		}
+		for _, value := range values {
+			attribute.Values = append(attribute.Values, saml.AttributeValue{
+				Type:  "xs:string",
+				Value: value,
+			})
+		}
+		session.CustomAttributes = append(session.CustomAttributes, attribute)
+	}
+	return session, nil
+}
+
+func samlAttributeValues(user SAMLUserST, source string) []string {
+	var value *string
+	switch source {
+	case "id":
+		return []string{strconv.Itoa(int(user.User.Id))}
+	case "username":
+		return []string{user.User.Username}
+	case "roles":
+		return user.Roles
+	case "email":
+		value = user.Email
+	case "phone_number":
+		value = user.PhoneNumber
+	}
+	if info := user.Info; info != nil {
+		switch source {
+		case "name":
+			value = info.Name
+		case "given_name":
+			value = info.GivenName
+		case "family_name":
+			value = info.FamilyName
+		case "middle_name":
+			value = info.MiddleName
+		case "nickname":
+			value = info.Nickname
+		case "profile":
+			value = info.Profile
+		case "picture":
+			value = info.Picture
+		case "website":
+			value = info.Website
+		case "gender":
+			value = info.Gender
+		case "birthdate":
+			if info.Birthdate != nil {
+				return []string{info.Birthdate.Format(time.DateOnly)}
+			}
+		case "zoneinfo":
+			value = info.Zoneinfo
+		case "locale":
+			value = info.Locale
+		case "street_address":
+			value = info.StreetAddress
+		case "locality":
+			value = info.Locality
+		case "region":
+			value = info.Region
+		case "postal_code":
+			value = info.PostalCode
+		case "country":
+			value = info.Country
+		}
+	}
+	if value == nil || *value == "" {
+		return nil
+	}
+	return []string{*value}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func samlSigningKey(tenent *repository.TenentRowST) (crypto.Signer, string, error) {
+	if strings.HasPrefix(tenent.Algorithm, "HS") {
+		return nil, "", ErrSAMLSymmetricKey
+	}
+	block, _ := pem.Decode([]byte(tenent.PrivateKey))
+	if block == nil {
+		return nil, "", fmt.Errorf("invalid private key for tenent %d", tenent.Id)
+	}
+	var key interface{}
+	var err error
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return nil, "", fmt.Errorf("invalid private key for tenent %d: %w", tenent.Id, err)
+			}
+		}
+	}
+	hash := crypto.SHA256
+	if strings.HasSuffix(tenent.Algorithm, "384") {
+		hash = crypto.SHA384
+	} else if strings.HasSuffix(tenent.Algorithm, "512") {
+		hash = crypto.SHA512
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, map[crypto.Hash]string{
+			crypto.SHA256: dsig.RSASHA256SignatureMethod,
+			crypto.SHA384: dsig.RSASHA384SignatureMethod,
+			crypto.SHA512: dsig.RSASHA512SignatureMethod,
+		}[hash], nil
+	case *ecdsa.PrivateKey:
+		return key, map[crypto.Hash]string{
+			crypto.SHA256: dsig.ECDSASHA256SignatureMethod,
+			crypto.SHA384: dsig.ECDSASHA384SignatureMethod,
+			crypto.SHA512: dsig.ECDSASHA512SignatureMethod,
+		}[hash], nil
+	default:
+		return nil, "", fmt.Errorf("unsupported private key %T for tenent %d", key, tenent.Id)
+	}
+}
+
+func samlCertificate(tenent *repository.TenentRowST, key crypto.Signer) (*x509.Certificate, error) {
+	if tenent.SAMLCertificate != nil {
+		if block, _ := pem.Decode([]byte(*tenent.SAMLCertificate)); block != nil {
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err == nil && certificate.NotAfter.After(time.Now().Add(samlCertificateRenewBefore)) {
+				if publicKey, ok := certificate.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok && publicKey.Equal(key.Public()) {
+					return certificate, nil
+				}
+			}
+		}
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: tenent.URI},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(samlCertificateValidFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	encoded := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	if _, err := repository.SetTenentSAMLCertificate(tenent.Id, encoded); err != nil {
+		return nil, err
+	}
+	tenent.SAMLCertificate = &encoded
+	return certificate, nil
+}
+
+// samlECDSASigner signs with the fixed width r || s encoding XML signatures use instead of ASN.1
+type samlECDSASigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (signer *samlECDSASigner) Public() crypto.PublicKey {
+	return signer.key.Public()
+}
+
+func (signer *samlECDSASigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	der, err := signer.key.Sign(random, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	var signature struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &signature); err != nil {
+		return nil, err
+	}
+	size := (signer.key.Curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	signature.R.FillBytes(raw[:size])
+	signature.S.FillBytes(raw[size:])
+	return raw, nil
+}
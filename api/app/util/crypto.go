@@ -2,20 +2,75 @@ package util
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"fmt"
-	"runtime"
+	"sync/atomic"
+	"time"
 
 	"github.com/alexedwards/argon2id"
 )
 
-var argon2Params = &argon2id.Params{
-	Memory:      128 * 1024,
-	Iterations:  10,
-	Parallelism: uint8(runtime.NumCPU()),
+// DefaultArgon2Params are used until the argon2 config is loaded, they match the migration defaults
+var DefaultArgon2Params = argon2id.Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
 	SaltLength:  16,
 	KeyLength:   32,
 }
 
+var argon2Params atomic.Pointer[argon2id.Params]
+
+func init() {
+	params := DefaultArgon2Params
+	argon2Params.Store(&params)
+}
+
+func GetArgon2Params() argon2id.Params {
+	return *argon2Params.Load()
+}
+
+func SetArgon2Params(params argon2id.Params) error {
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 || params.SaltLength < 8 || params.KeyLength < 16 {
+		return fmt.Errorf("invalid argon2 params %+v", params)
+	}
+	argon2Params.Store(&params)
+	return nil
+}
+
+// CalibrateArgon2 keeps memory and parallelism and raises the iterations until
+// hashing takes at least target, halving memory first if a single iteration is
+// already too slow
+func CalibrateArgon2(target time.Duration, memory uint32, parallelism uint8) (argon2id.Params, time.Duration, error) {
+	params := DefaultArgon2Params
+	params.Memory = memory
+	params.Parallelism = parallelism
+	params.Iterations = 1
+	for {
+		elapsed, err := timeArgon2(&params)
+		if err != nil {
+			return params, 0, err
+		}
+		if elapsed >= target {
+			if params.Iterations > 1 {
+				return params, elapsed, nil
+			}
+			if params.Memory/2 < 19*1024 {
+				return params, elapsed, nil
+			}
+			params.Memory /= 2
+			continue
+		}
+		params.Iterations++
+	}
+}
+
+func timeArgon2(params *argon2id.Params) (time.Duration, error) {
+	start := time.Now()
+	_, err := argon2id.CreateHash("calibrate", params)
+	return time.Since(start), err
+}
+
 func GenerateRandomBytes(length int) ([]byte, error) {
 	bytes := make([]byte, length)
 	_, err := rand.Read(bytes)
@@ -33,14 +88,61 @@ func GenerateRandomHex(length int) (string, error) {
 	return BytesToHex(bytes), nil
 }
 
+func GenerateRandomDigits(length int) (string, error) {
+	digits := make([]byte, 0, length)
+	for len(digits) < length {
+		bytes, err := GenerateRandomBytes(length)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range bytes {
+			// reject bytes that would bias the modulo
+			if b < 250 && len(digits) < length {
+				digits = append(digits, '0'+b%10)
+			}
+		}
+	}
+	return string(digits), nil
+}
+
 func BytesToHex(bytes []byte) string {
 	return fmt.Sprintf("%x", bytes)
 }
 
 func EncryptPassword(password string) (string, error) {
-	return argon2id.CreateHash(password, argon2Params)
+	return argon2id.CreateHash(password, argon2Params.Load())
+}
+
+// PasswordNeedsRehash reports whether the hash was created with parameters other than the current ones
+func PasswordNeedsRehash(encryptedPassword string) bool {
+	params, _, _, err := argon2id.DecodeHash(encryptedPassword)
+	if err != nil {
+		return true
+	}
+	current := argon2Params.Load()
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		params.SaltLength != current.SaltLength ||
+		params.KeyLength != current.KeyLength
 }
 
+// NoPassword is stored as the hash of users without a password, no password verifies against it
+const NoPassword = ""
+
+// VerifyPassword checks the password against any supported hash format, see GetPasswordHashFormat
 func VerifyPassword(password, encryptedPassword string) (bool, error) {
-	return argon2id.ComparePasswordAndHash(password, encryptedPassword)
+	if encryptedPassword == NoPassword {
+		return false, nil
+	}
+	format, err := GetPasswordHashFormat(encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	return format.Verify(password, encryptedPassword)
+}
+
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return BytesToHex(hash[:])
 }
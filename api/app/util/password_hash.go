@@ -0,0 +1,465 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	PasswordHashArgon2id       = "argon2id"
+	PasswordHashBcrypt         = "bcrypt"
+	PasswordHashScrypt         = "scrypt"
+	PasswordHashPBKDF2SHA256   = "pbkdf2-sha256"
+	PasswordHashDjangoPBKDF2   = "django-pbkdf2-sha256"
+	PasswordHashDjangoScrypt   = "django-scrypt"
+	PasswordHashFirebaseScrypt = "firebase-scrypt"
+	PasswordHashSHA256Crypt    = "sha256-crypt"
+	PasswordHashSHA512Crypt    = "sha512-crypt"
+)
+
+// bounds on the cost parameters of imported hashes, hashes above them would let
+// a single sign in use gigabytes of memory or minutes of cpu
+const (
+	passwordHashMaxScryptLogN   = 20
+	passwordHashMaxScryptRP     = 64
+	passwordHashMaxScryptMemory = 1 << 30
+	passwordHashMaxArgon2Memory = 1 << 20
+	passwordHashMaxArgon2Time   = 64
+	passwordHashMaxBcryptCost   = 16
+	passwordHashMaxIterations   = 10_000_000
+	passwordHashMinKeyLength    = 16
+	passwordHashMaxKeyLength    = 64
+)
+
+const firebaseScryptKeyLength = 32
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+var ErrPasswordHashParams = errors.New("password hash parameters out of range")
+
+type PasswordHashFormatST struct {
+	Name    string
+	Matches func(encryptedPassword string) bool
+	// Check parses the hash and its parameters without verifying a password
+	Check  func(encryptedPassword string) error
+	Verify func(password, encryptedPassword string) (bool, error)
+}
+
+// passwordHashFormats are checked in order, argon2id is what EncryptPassword
+// creates, the rest are accepted from imported users and upgraded on login
+var passwordHashFormats = []PasswordHashFormatST{
+	{
+		Name:    PasswordHashArgon2id,
+		Matches: hasPrefix("$argon2id$"),
+		Check:   checkArgon2id,
+		Verify:  verifyArgon2id,
+	},
+	{
+		Name:    PasswordHashBcrypt,
+		Matches: hasPrefix("$2a$", "$2b$", "$2y$"),
+		Check:   checkBcrypt,
+		Verify:  verifyBcrypt,
+	},
+	{
+		Name:    PasswordHashScrypt,
+		Matches: hasPrefix("$scrypt$"),
+		Check:   checkWith(parseScrypt),
+		Verify:  verifyScrypt,
+	},
+	{
+		Name:    PasswordHashPBKDF2SHA256,
+		Matches: hasPrefix("$pbkdf2-sha256$"),
+		Check:   checkWith(parsePBKDF2SHA256),
+		Verify:  verifyPBKDF2,
+	},
+	{
+		Name:    PasswordHashDjangoPBKDF2,
+		Matches: hasPrefix("pbkdf2_sha256$"),
+		Check:   checkWith(parseDjangoPBKDF2SHA256),
+		Verify:  verifyDjangoPBKDF2,
+	},
+	{
+		Name:    PasswordHashDjangoScrypt,
+		Matches: hasPrefix("scrypt$"),
+		Check:   checkWith(parseDjangoScrypt),
+		Verify:  verifyDjangoScrypt,
+	},
+	{
+		Name:    PasswordHashFirebaseScrypt,
+		Matches: hasPrefix("$firebase-scrypt$"),
+		Check:   checkWith(parseFirebaseScrypt),
+		Verify:  verifyFirebaseScrypt,
+	},
+	{
+		Name:    PasswordHashSHA256Crypt,
+		Matches: hasPrefix("$5$"),
+		Check:   checkSHACrypt,
+		Verify:  verifySHACrypt,
+	},
+	{
+		Name:    PasswordHashSHA512Crypt,
+		Matches: hasPrefix("$6$"),
+		Check:   checkSHACrypt,
+		Verify:  verifySHACrypt,
+	},
+}
+
+// RegisterPasswordHashFormat adds a format checked before the built in ones
+func RegisterPasswordHashFormat(format PasswordHashFormatST) {
+	passwordHashFormats = append([]PasswordHashFormatST{format}, passwordHashFormats...)
+}
+
+func GetPasswordHashFormat(encryptedPassword string) (*PasswordHashFormatST, error) {
+	for i := range passwordHashFormats {
+		if passwordHashFormats[i].Matches(encryptedPassword) {
+			return &passwordHashFormats[i], nil
+		}
+	}
+	return nil, ErrUnknownPasswordHash
+}
+
+// CheckPasswordHash parses the hash with its format and makes sure its
+// parameters are within the bounds verifying it is allowed to take
+func CheckPasswordHash(encryptedPassword string) error {
+	format, err := GetPasswordHashFormat(encryptedPassword)
+	if err != nil {
+		return err
+	}
+	if format.Check == nil {
+		return nil
+	}
+	return format.Check(encryptedPassword)
+}
+
+func hasPrefix(prefixes ...string) func(string) bool {
+	return func(encryptedPassword string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(encryptedPassword, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func checkWith[T any](parse func(encryptedPassword string) (T, error)) func(string) error {
+	return func(encryptedPassword string) error {
+		_, err := parse(encryptedPassword)
+		return err
+	}
+}
+
+func checkKeyLength(key []byte) error {
+	if len(key) < passwordHashMinKeyLength || len(key) > passwordHashMaxKeyLength {
+		return ErrPasswordHashParams
+	}
+	return nil
+}
+
+func checkArgon2id(encryptedPassword string) error {
+	params, salt, key, err := argon2id.DecodeHash(encryptedPassword)
+	if err != nil {
+		return err
+	}
+	if params.Memory > passwordHashMaxArgon2Memory || params.Iterations > passwordHashMaxArgon2Time || params.Parallelism < 1 || len(salt) == 0 {
+		return ErrPasswordHashParams
+	}
+	// the key length is only bounded below, EncryptPassword uses the configured length
+	if len(key) < passwordHashMinKeyLength {
+		return ErrPasswordHashParams
+	}
+	return nil
+}
+
+func verifyArgon2id(password, encryptedPassword string) (bool, error) {
+	if err := checkArgon2id(encryptedPassword); err != nil {
+		return false, err
+	}
+	return argon2id.ComparePasswordAndHash(password, encryptedPassword)
+}
+
+func checkBcrypt(encryptedPassword string) error {
+	cost, err := bcrypt.Cost([]byte(encryptedPassword))
+	if err != nil {
+		return err
+	}
+	if cost > passwordHashMaxBcryptCost {
+		return ErrPasswordHashParams
+	}
+	return nil
+}
+
+func verifyBcrypt(password, encryptedPassword string) (bool, error) {
+	if err := checkBcrypt(encryptedPassword); err != nil {
+		return false, err
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encryptedPassword), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+type scryptHashST struct {
+	N, R, P int
+	Salt    []byte
+	Hash    []byte
+}
+
+func checkScryptParams(N, r, p int) error {
+	if N < 2 || N > 1<<passwordHashMaxScryptLogN || N&(N-1) != 0 {
+		return ErrPasswordHashParams
+	}
+	if r < 1 || p < 1 || r > passwordHashMaxScryptRP || p > passwordHashMaxScryptRP || r*p > passwordHashMaxScryptRP {
+		return ErrPasswordHashParams
+	}
+	if 128*r*N > passwordHashMaxScryptMemory {
+		return ErrPasswordHashParams
+	}
+	return nil
+}
+
+func (hash *scryptHashST) verify(password string) (bool, error) {
+	key, err := scrypt.Key([]byte(password), hash.Salt, hash.N, hash.R, hash.P, len(hash.Hash))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, hash.Hash) == 1, nil
+}
+
+// parseScrypt reads the passlib format $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<hash>
+func parseScrypt(encryptedPassword string) (*scryptHashST, error) {
+	parts := strings.Split(encryptedPassword, "$")
+	if len(parts) != 5 {
+		return nil, ErrUnknownPasswordHash
+	}
+	var ln, r, p int
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &ln, &r, &p); err != nil {
+		return nil, err
+	}
+	if ln < 1 || ln > passwordHashMaxScryptLogN {
+		return nil, ErrPasswordHashParams
+	}
+	if err := checkScryptParams(1<<ln, r, p); err != nil {
+		return nil, err
+	}
+	salt, err := decodeBase64(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	hash, err := decodeBase64(parts[4])
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyLength(hash); err != nil {
+		return nil, err
+	}
+	return &scryptHashST{N: 1 << ln, R: r, P: p, Salt: salt, Hash: hash}, nil
+}
+
+func verifyScrypt(password, encryptedPassword string) (bool, error) {
+	hash, err := parseScrypt(encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	return hash.verify(password)
+}
+
+// parseDjangoScrypt reads django's scrypt$<salt>$<N>$<r>$<p>$<hash>
+func parseDjangoScrypt(encryptedPassword string) (*scryptHashST, error) {
+	parts := strings.Split(encryptedPassword, "$")
+	if len(parts) != 6 {
+		return nil, ErrUnknownPasswordHash
+	}
+	params := make([]int, 3)
+	for i := range params {
+		value, err := strconv.Atoi(parts[2+i])
+		if err != nil {
+			return nil, err
+		}
+		params[i] = value
+	}
+	if err := checkScryptParams(params[0], params[1], params[2]); err != nil {
+		return nil, err
+	}
+	hash, err := decodeBase64(parts[5])
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyLength(hash); err != nil {
+		return nil, err
+	}
+	return &scryptHashST{N: params[0], R: params[1], P: params[2], Salt: []byte(parts[1]), Hash: hash}, nil
+}
+
+func verifyDjangoScrypt(password, encryptedPassword string) (bool, error) {
+	hash, err := parseDjangoScrypt(encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	return hash.verify(password)
+}
+
+type pbkdf2HashST struct {
+	Iterations int
+	Salt       []byte
+	Hash       []byte
+}
+
+func newPBKDF2Hash(iterations string, salt, hash []byte) (*pbkdf2HashST, error) {
+	parsed, err := strconv.Atoi(iterations)
+	if err != nil {
+		return nil, err
+	}
+	if parsed < 1 || parsed > passwordHashMaxIterations {
+		return nil, ErrPasswordHashParams
+	}
+	if err := checkKeyLength(hash); err != nil {
+		return nil, err
+	}
+	return &pbkdf2HashST{Iterations: parsed, Salt: salt, Hash: hash}, nil
+}
+
+func (hash *pbkdf2HashST) verify(password string) bool {
+	key := pbkdf2.Key([]byte(password), hash.Salt, hash.Iterations, len(hash.Hash), sha256.New)
+	return subtle.ConstantTimeCompare(key, hash.Hash) == 1
+}
+
+// parsePBKDF2SHA256 reads the passlib format $pbkdf2-sha256$<rounds>$<salt>$<hash>
+func parsePBKDF2SHA256(encryptedPassword string) (*pbkdf2HashST, error) {
+	parts := strings.Split(encryptedPassword, "$")
+	if len(parts) != 5 {
+		return nil, ErrUnknownPasswordHash
+	}
+	salt, err := decodeBase64(strings.ReplaceAll(parts[3], ".", "+"))
+	if err != nil {
+		return nil, err
+	}
+	hash, err := decodeBase64(strings.ReplaceAll(parts[4], ".", "+"))
+	if err != nil {
+		return nil, err
+	}
+	return newPBKDF2Hash(parts[2], salt, hash)
+}
+
+// parseDjangoPBKDF2SHA256 reads django's pbkdf2_sha256$<iterations>$<salt>$<hash>
+func parseDjangoPBKDF2SHA256(encryptedPassword string) (*pbkdf2HashST, error) {
+	parts := strings.Split(encryptedPassword, "$")
+	if len(parts) != 4 {
+		return nil, ErrUnknownPasswordHash
+	}
+	hash, err := decodeBase64(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	return newPBKDF2Hash(parts[1], []byte(parts[2]), hash)
+}
+
+func verifyPBKDF2(password, encryptedPassword string) (bool, error) {
+	hash, err := parsePBKDF2SHA256(encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	return hash.verify(password), nil
+}
+
+func verifyDjangoPBKDF2(password, encryptedPassword string) (bool, error) {
+	hash, err := parseDjangoPBKDF2SHA256(encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	return hash.verify(password), nil
+}
+
+type firebaseScryptHashST struct {
+	scryptHashST
+	SignerKey []byte
+}
+
+// parseFirebaseScrypt reads firebase's modified scrypt exported as
+// $firebase-scrypt$rounds=<rounds>,mem=<mem cost>$<salt separator>$<signer key>$<salt>$<hash>
+// with the project's hash config, all values standard base64
+func parseFirebaseScrypt(encryptedPassword string) (*firebaseScryptHashST, error) {
+	parts := strings.Split(encryptedPassword, "$")
+	if len(parts) != 7 {
+		return nil, ErrUnknownPasswordHash
+	}
+	var rounds, memCost int
+	if _, err := fmt.Sscanf(parts[2], "rounds=%d,mem=%d", &rounds, &memCost); err != nil {
+		return nil, err
+	}
+	if memCost < 1 || memCost > passwordHashMaxScryptLogN {
+		return nil, ErrPasswordHashParams
+	}
+	if err := checkScryptParams(1<<memCost, rounds, 1); err != nil {
+		return nil, err
+	}
+	decoded := make([][]byte, 4)
+	for i := range decoded {
+		value, err := decodeBase64(parts[3+i])
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = value
+	}
+	// the hash is the encrypted signer key, so it is as long as the key
+	if len(decoded[3]) < passwordHashMinKeyLength || len(decoded[1]) != len(decoded[3]) {
+		return nil, ErrPasswordHashParams
+	}
+	return &firebaseScryptHashST{
+		scryptHashST: scryptHashST{
+			N:    1 << memCost,
+			R:    rounds,
+			P:    1,
+			Salt: append(decoded[2], decoded[0]...),
+			Hash: decoded[3],
+		},
+		SignerKey: decoded[1],
+	}, nil
+}
+
+func verifyFirebaseScrypt(password, encryptedPassword string) (bool, error) {
+	hash, err := parseFirebaseScrypt(encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	key, err := scrypt.Key([]byte(password), hash.Salt, hash.N, hash.R, hash.P, firebaseScryptKeyLength)
+	if err != nil {
+		return false, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return false, err
+	}
+	signed := make([]byte, len(hash.SignerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(signed, hash.SignerKey)
+	return subtle.ConstantTimeCompare(signed, hash.Hash) == 1, nil
+}
+
+func checkSHACrypt(encryptedPassword string) error {
+	_, _, _, err := shaCryptRounds(encryptedPassword[3:])
+	return err
+}
+
+func verifySHACrypt(password, encryptedPassword string) (bool, error) {
+	expected, err := shaCrypt(password, encryptedPassword)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(encryptedPassword)) == 1, nil
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+}
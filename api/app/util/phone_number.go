@@ -0,0 +1,49 @@
+package util
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+)
+
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// IsPhoneRegion reports whether region can be used as a default region for NormalizePhoneNumber
+func IsPhoneRegion(region string) bool {
+	_, ok := libphonenumber.GetSupportedRegions()[region]
+	return ok
+}
+
+// NormalizePhoneNumber returns the E.164 form of the phone number. Numbers starting with + are international, other
+// numbers are read as dialed from defaultRegion, without a default region only international numbers are accepted.
+// Numbers that aren't valid in their region's numbering plan are invalid
+func NormalizePhoneNumber(phoneNumber, defaultRegion string) (string, error) {
+	number, err := libphonenumber.Parse(strings.TrimSpace(phoneNumber), strings.ToUpper(defaultRegion))
+	if err != nil || !libphonenumber.IsValidNumber(number) {
+		return "", ErrInvalidPhoneNumber
+	}
+	return libphonenumber.Format(number, libphonenumber.E164), nil
+}
+
+// NormalizePhoneNumberPrefix returns the start of E.164 phone numbers a search for prefix matches. Complete numbers are
+// normalized like NormalizePhoneNumber, otherwise formatting is dropped, a leading 00 is read as + and the + is added
+// when it is missing. An empty string is returned when prefix has no digits
+func NormalizePhoneNumberPrefix(prefix string) string {
+	if phoneNumber, err := NormalizePhoneNumber(prefix, ""); err == nil {
+		return phoneNumber
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, prefix)
+	if !strings.HasPrefix(strings.TrimSpace(prefix), "+") {
+		digits = strings.TrimPrefix(digits, "00")
+	}
+	if digits == "" {
+		return ""
+	}
+	return "+" + digits
+}
@@ -0,0 +1,167 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+const (
+	shaCryptAlphabet      = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	shaCryptRoundsDefault = 5000
+	shaCryptRoundsMin     = 1000
+	shaCryptSaltMax       = 16
+)
+
+var sha256CryptOrder = [][3]int{
+	{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+	{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29},
+}
+
+var sha512CryptOrder = [][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+	{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+	{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+	{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+	{62, 20, 41},
+}
+
+// shaCrypt implements the SHA-256 ($5$) and SHA-512 ($6$) crypt schemes
+// from https://www.akkadia.org/drepper/SHA-crypt.txt, setting is a full
+// hash or just its $id$[rounds=N$]salt prefix
+func shaCrypt(password, setting string) (string, error) {
+	var newHash func() hash.Hash
+	var prefix string
+	switch {
+	case strings.HasPrefix(setting, "$5$"):
+		newHash, prefix = sha256.New, "$5$"
+	case strings.HasPrefix(setting, "$6$"):
+		newHash, prefix = sha512.New, "$6$"
+	default:
+		return "", ErrUnknownPasswordHash
+	}
+	rounds, customRounds, rest, err := shaCryptRounds(setting[len(prefix):])
+	if err != nil {
+		return "", err
+	}
+	salt, _, _ := strings.Cut(rest, "$")
+	if len(salt) > shaCryptSaltMax {
+		salt = salt[:shaCryptSaltMax]
+	}
+	p, s := []byte(password), []byte(salt)
+
+	b := newHash()
+	b.Write(p)
+	b.Write(s)
+	b.Write(p)
+	digestB := b.Sum(nil)
+	size := len(digestB)
+
+	a := newHash()
+	a.Write(p)
+	a.Write(s)
+	a.Write(repeatBytes(digestB, len(p)))
+	for i := len(p); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			a.Write(digestB)
+		} else {
+			a.Write(p)
+		}
+	}
+	digestA := a.Sum(nil)
+
+	dp := newHash()
+	for range p {
+		dp.Write(p)
+	}
+	pBytes := repeatBytes(dp.Sum(nil), len(p))
+
+	ds := newHash()
+	for i := 0; i < 16+int(digestA[0]); i++ {
+		ds.Write(s)
+	}
+	sBytes := repeatBytes(ds.Sum(nil), len(s))
+
+	digestC := digestA
+	for i := 0; i < rounds; i++ {
+		c := newHash()
+		if i%2 == 1 {
+			c.Write(pBytes)
+		} else {
+			c.Write(digestC)
+		}
+		if i%3 != 0 {
+			c.Write(sBytes)
+		}
+		if i%7 != 0 {
+			c.Write(pBytes)
+		}
+		if i%2 == 1 {
+			c.Write(digestC)
+		} else {
+			c.Write(pBytes)
+		}
+		digestC = c.Sum(nil)
+	}
+
+	var result strings.Builder
+	result.WriteString(prefix)
+	if customRounds {
+		result.WriteString("rounds=")
+		result.WriteString(strconv.Itoa(rounds))
+		result.WriteByte('$')
+	}
+	result.WriteString(salt)
+	result.WriteByte('$')
+	if size == sha256.Size {
+		for _, order := range sha256CryptOrder {
+			writeShaCrypt24Bit(&result, digestC[order[0]], digestC[order[1]], digestC[order[2]], 4)
+		}
+		writeShaCrypt24Bit(&result, 0, digestC[31], digestC[30], 3)
+	} else {
+		for _, order := range sha512CryptOrder {
+			writeShaCrypt24Bit(&result, digestC[order[0]], digestC[order[1]], digestC[order[2]], 4)
+		}
+		writeShaCrypt24Bit(&result, 0, 0, digestC[63], 2)
+	}
+	return result.String(), nil
+}
+
+// shaCryptRounds reads the optional rounds=N$ of a setting without its $id$,
+// rounds above passwordHashMaxIterations are rejected rather than clamped to
+// the spec's maximum which would take minutes to hash
+func shaCryptRounds(rest string) (int, bool, string, error) {
+	if !strings.HasPrefix(rest, "rounds=") {
+		return shaCryptRoundsDefault, false, rest, nil
+	}
+	value, remaining, found := strings.Cut(rest[len("rounds="):], "$")
+	if !found {
+		return 0, false, "", ErrUnknownPasswordHash
+	}
+	rounds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false, "", err
+	}
+	if rounds > passwordHashMaxIterations {
+		return 0, false, "", ErrPasswordHashParams
+	}
+	return max(rounds, shaCryptRoundsMin), true, remaining, nil
+}
+
+func repeatBytes(bytes []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result) < length {
+		result = append(result, bytes[:min(len(bytes), length-len(result))]...)
+	}
+	return result
+}
+
+func writeShaCrypt24Bit(result *strings.Builder, b2, b1, b0 byte, n int) {
+	w := uint32(b2)<<16 | uint32(b1)<<8 | uint32(b0)
+	for ; n > 0; n-- {
+		result.WriteByte(shaCryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+}
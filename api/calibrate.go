@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/joho/godotenv"
+)
+
+// calibrateArgon2 picks argon2 parameters that take at least the target time
+// on this machine, run it on the hardware the api is deployed to
+//
+//	auth-api calibrate-argon2 -target 250ms -memory 65536 -parallelism 2 [-save]
+func calibrateArgon2(args []string) int {
+	flags := flag.NewFlagSet("calibrate-argon2", flag.ExitOnError)
+	target := flags.Duration("target", 250*time.Millisecond, "target time to hash a password")
+	memory := flags.Uint("memory", uint(util.DefaultArgon2Params.Memory), "memory in KiB")
+	parallelism := flags.Uint("parallelism", uint(util.DefaultArgon2Params.Parallelism), "number of threads")
+	save := flags.Bool("save", false, "save the parameters to the configs table of DATABASE_URL")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *parallelism < 1 || *parallelism > 255 {
+		fmt.Fprintln(os.Stderr, "parallelism must be between 1 and 255")
+		return 2
+	}
+	params, elapsed, err := util.CalibrateArgon2(*target, uint32(*memory), uint8(*parallelism))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to calibrate argon2:", err)
+		return 1
+	}
+	configs := [][2]string{
+		{"argon2.memory", fmt.Sprint(params.Memory)},
+		{"argon2.iterations", fmt.Sprint(params.Iterations)},
+		{"argon2.parallelism", fmt.Sprint(params.Parallelism)},
+		{"argon2.salt_length", fmt.Sprint(params.SaltLength)},
+		{"argon2.key_length", fmt.Sprint(params.KeyLength)},
+	}
+	fmt.Printf("-- m=%d,t=%d,p=%d hashes in %s\n", params.Memory, params.Iterations, params.Parallelism, elapsed)
+	for _, config := range configs {
+		fmt.Printf("UPDATE \"configs\" SET \"value\" = '%s' WHERE \"key\" = '%s';\n", config[1], config[0])
+	}
+	if !*save {
+		return 0
+	}
+	env := os.Getenv("APP_ENV")
+	envs := []string{".env"}
+	if env != "" {
+		envs = append([]string{".env." + env}, envs...)
+	}
+	if err := godotenv.Load(envs...); err != nil {
+		slog.Error("error loading .env file", "error", err)
+	}
+	if err := repository.InitDB(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to database:", err)
+		return 1
+	}
+	defer repository.CloseDB()
+	for _, config := range configs {
+		if _, err := repository.UpsertConfig(config[0], config[1]); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to save config:", err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -87,6 +87,11 @@ const docTemplate = `{
                 "summary": "Get applications",
                 "operationId": "applications",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "name": "cursor",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "name": "limit",
@@ -96,6 +101,15 @@ const docTemplate = `{
                         "type": "integer",
                         "name": "offset",
                         "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "name": "total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -193,7 +207,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/applications/{applicationId}/tenents": {
+        "/applications/{applicationId}/initial-access-tokens": {
             "get": {
                 "security": [
                     {
@@ -207,10 +221,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tenent"
+                    "client-registration"
                 ],
-                "summary": "Get application tenents",
-                "operationId": "tenents",
+                "summary": "Get application initial access tokens",
+                "operationId": "initial-access-tokens",
                 "parameters": [
                     {
                         "type": "integer",
@@ -218,23 +232,16 @@ const docTemplate = `{
                         "name": "applicationId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "offset",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Pagination-Tenent"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/InitialAccessToken"
+                            }
                         }
                     },
                     "400": {
@@ -269,6 +276,7 @@ const docTemplate = `{
                         "Authorization": []
                     }
                 ],
+                "description": "Clients register themselves with the returned token, it is only returned once",
                 "consumes": [
                     "application/json"
                 ],
@@ -276,10 +284,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tenent"
+                    "client-registration"
                 ],
-                "summary": "Create application tenent",
-                "operationId": "create-tenent",
+                "summary": "Create application initial access token",
+                "operationId": "create-initial-access-token",
                 "parameters": [
                     {
                         "type": "integer",
@@ -289,12 +297,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "create application",
-                        "name": "tenent",
+                        "description": "create initial access token",
+                        "name": "initialAccessToken",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/CreateTenent"
+                            "$ref": "#/definitions/CreateInitialAccessToken"
                         }
                     }
                 ],
@@ -302,7 +310,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/Tenent"
+                            "$ref": "#/definitions/CreatedInitialAccessToken"
                         }
                     },
                     "400": {
@@ -332,13 +340,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/applications/{applicationId}/tenents/{id}": {
-            "get": {
+        "/applications/{applicationId}/initial-access-tokens/{id}": {
+            "delete": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Clients already registered with it are kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -346,10 +355,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tenent"
+                    "client-registration"
                 ],
-                "summary": "Get application tenent by id",
-                "operationId": "tenent",
+                "summary": "Delete application initial access token",
+                "operationId": "delete-initial-access-token",
                 "parameters": [
                     {
                         "type": "integer",
@@ -360,18 +369,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "application tenent id",
+                        "description": "initial access token id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/Tenent"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -391,6 +397,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -398,13 +410,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/applications/{applicationId}/invitations": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Invitations that were neither accepted nor revoked, newest first. Expired invitations are included so they can be resent",
                 "consumes": [
                     "application/json"
                 ],
@@ -412,10 +427,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tenent"
+                    "invitation"
                 ],
-                "summary": "Delete application tenent",
-                "operationId": "delete-tenent",
+                "summary": "Get outstanding invitations",
+                "operationId": "invitations",
                 "parameters": [
                     {
                         "type": "integer",
@@ -424,17 +439,37 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "name": "cursor",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "application tenent id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "name": "total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Pagination-Invitation"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -462,12 +497,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Creates a pending user with the email and sends it a single use link to the tenent's authorization website, the user can't sign in until it accepts the invitation and sets its password",
                 "consumes": [
                     "application/json"
                 ],
@@ -475,10 +511,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tenent"
+                    "invitation"
                 ],
-                "summary": "Update application tenent",
-                "operationId": "update-tenent",
+                "summary": "Invite a user",
+                "operationId": "create-invitation",
                 "parameters": [
                     {
                         "type": "integer",
@@ -488,27 +524,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "application tenent id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "update application",
-                        "name": "tenent",
+                        "description": "create invitation",
+                        "name": "createInvitation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/UpdateTenent"
+                            "$ref": "#/definitions/CreateInvitation"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/Tenent"
+                            "$ref": "#/definitions/Invitation"
                         }
                     },
                     "400": {
@@ -538,13 +567,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/applications/{applicationId}/tenents/{id}/private-key": {
-            "get": {
+        "/applications/{applicationId}/invitations/{id}": {
+            "delete": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "The invitation's links stop working and its user is deleted",
                 "consumes": [
                     "application/json"
                 ],
@@ -552,10 +582,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tenent"
+                    "invitation"
                 ],
-                "summary": "Get application tenent by id",
-                "operationId": "tenent-private-key",
+                "summary": "Revoke an invitation",
+                "operationId": "delete-invitation",
                 "parameters": [
                     {
                         "type": "integer",
@@ -566,18 +596,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "application tenent id",
+                        "description": "invitation id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "string"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -597,6 +624,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -606,13 +639,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/applications/{applicationId}/users": {
-            "get": {
+        "/applications/{applicationId}/invitations/{id}/resend": {
+            "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Sends a new link and restarts the invitation's expiry, links sent before stop working",
                 "consumes": [
                     "application/json"
                 ],
@@ -620,10 +654,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "invitation"
                 ],
-                "summary": "Get users",
-                "operationId": "users",
+                "summary": "Resend an invitation",
+                "operationId": "resend-invitation",
                 "parameters": [
                     {
                         "type": "integer",
@@ -634,20 +668,17 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "invitation id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Pagination-User"
+                            "$ref": "#/definitions/Invitation"
                         }
                     },
                     "400": {
@@ -668,6 +699,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -675,8 +712,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/applications/{applicationId}/ldap-connector": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -689,10 +728,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "ldap"
                 ],
-                "summary": "Create user",
-                "operationId": "create-user",
+                "summary": "Get application LDAP connector",
+                "operationId": "ldap-connector",
                 "parameters": [
                     {
                         "type": "integer",
@@ -700,22 +739,13 @@ const docTemplate = `{
                         "name": "applicationId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "create user",
-                        "name": "createUser",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/CreateUser"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "$ref": "#/definitions/LDAPConnector"
                         }
                     },
                     "400": {
@@ -736,6 +766,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -743,15 +779,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/applications/{applicationId}/users/{id}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Password logins for users who are not local or are flagged as ldap backed are checked against the directory, {username} in user_filter is replaced with the login and {dn} and {username} in group_filter with the user's, group_role_mapping maps group names or dns to role uris",
                 "consumes": [
                     "application/json"
                 ],
@@ -759,10 +794,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "ldap"
                 ],
-                "summary": "Get user by id",
-                "operationId": "user",
+                "summary": "Create application LDAP connector",
+                "operationId": "create-ldap-connector",
                 "parameters": [
                     {
                         "type": "integer",
@@ -772,18 +807,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "create ldap connector",
+                        "name": "ldapConnector",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateLDAPConnector"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "$ref": "#/definitions/LDAPConnector"
                         }
                     },
                     "400": {
@@ -818,6 +855,7 @@ const docTemplate = `{
                         "Authorization": []
                     }
                 ],
+                "description": "Users provisioned from the directory are kept but can no longer sign in with a password until they reset it",
                 "consumes": [
                     "application/json"
                 ],
@@ -825,10 +863,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "ldap"
                 ],
-                "summary": "Delets a user by id",
-                "operationId": "delete-user",
+                "summary": "Delete application LDAP connector",
+                "operationId": "delete-ldap-connector",
                 "parameters": [
                     {
                         "type": "integer",
@@ -836,13 +874,6 @@ const docTemplate = `{
                         "name": "applicationId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -867,6 +898,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -888,10 +925,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "ldap"
                 ],
-                "summary": "Updates a user's username",
-                "operationId": "update-user",
+                "summary": "Update application LDAP connector",
+                "operationId": "update-ldap-connector",
                 "parameters": [
                     {
                         "type": "integer",
@@ -901,19 +938,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "update user",
-                        "name": "updateUser",
+                        "description": "update ldap connector",
+                        "name": "ldapConnector",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/UpdateUser"
+                            "$ref": "#/definitions/UpdateLDAPConnector"
                         }
                     }
                 ],
@@ -921,7 +951,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/User"
+                            "$ref": "#/definitions/LDAPConnector"
                         }
                     },
                     "400": {
@@ -942,6 +972,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -951,13 +987,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/applications/{applicationId}/users/{id}/info": {
+        "/applications/{applicationId}/password-policy": {
             "get": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Returns the default policy when the application has none",
                 "consumes": [
                     "application/json"
                 ],
@@ -965,10 +1002,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "password-policy"
                 ],
-                "summary": "Get user info",
-                "operationId": "user-info",
+                "summary": "Get application password policy",
+                "operationId": "application-password-policy",
                 "parameters": [
                     {
                         "type": "integer",
@@ -976,20 +1013,13 @@ const docTemplate = `{
                         "name": "applicationId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/UserInfo"
+                            "$ref": "#/definitions/PasswordPolicy"
                         }
                     },
                     "400": {
@@ -1031,10 +1061,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "password-policy"
                 ],
-                "summary": "Updates the user's info",
-                "operationId": "update-user-info",
+                "summary": "Update application password policy",
+                "operationId": "update-application-password-policy",
                 "parameters": [
                     {
                         "type": "integer",
@@ -1044,19 +1074,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "User info updates",
-                        "name": "userinfoUpdates",
+                        "description": "update password policy",
+                        "name": "updatePasswordPolicy",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/UpdateUserInfoRequest"
+                            "$ref": "#/definitions/UpdatePasswordPolicy"
                         }
                     }
                 ],
@@ -1064,7 +1087,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/UserInfo"
+                            "$ref": "#/definitions/PasswordPolicy"
                         }
                     },
                     "400": {
@@ -1094,7 +1117,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/applications/{id}": {
+        "/applications/{applicationId}/tenents": {
             "get": {
                 "security": [
                     {
@@ -1108,24 +1131,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "application"
+                    "tenent"
                 ],
-                "summary": "Get application by id",
-                "operationId": "application",
+                "summary": "Get application tenents",
+                "operationId": "tenents",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "application id",
-                        "name": "id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "name": "total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Application"
+                            "$ref": "#/definitions/Pagination-Tenent"
                         }
                     },
                     "400": {
@@ -1154,7 +1201,7 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "Authorization": []
@@ -1167,22 +1214,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "application"
+                    "tenent"
                 ],
-                "summary": "Delete application",
-                "operationId": "delete-application",
+                "summary": "Create application tenent",
+                "operationId": "create-tenent",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "application id",
-                        "name": "id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "create application",
+                        "name": "tenent",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateTenent"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Tenent"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1209,8 +1268,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/applications/{applicationId}/tenents/{id}": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -1223,33 +1284,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "application"
+                    "tenent"
                 ],
-                "summary": "Update application",
-                "operationId": "update-application",
+                "summary": "Get application tenent by id",
+                "operationId": "tenent",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "application id",
-                        "name": "id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "update application",
-                        "name": "application",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/UpdateApplication"
-                        }
+                        "type": "integer",
+                        "description": "application tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Application"
+                            "$ref": "#/definitions/Tenent"
                         }
                     },
                     "400": {
@@ -1277,10 +1336,13 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/health": {
-            "get": {
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -1288,34 +1350,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "app"
+                    "tenent"
+                ],
+                "summary": "Delete application tenent",
+                "operationId": "delete-tenent",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get Health Check",
-                "operationId": "healthCheck",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/Health"
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/Health"
+                            "$ref": "#/definitions/Errors"
                         }
                     }
                 }
-            }
-        },
-        "/mfa": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
-                "description": "Multi-factor authentication",
                 "consumes": [
                     "application/json"
                 ],
@@ -1323,18 +1413,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "token"
+                    "tenent"
                 ],
-                "summary": "Multi-factor authentication",
-                "operationId": "validate-mfa",
+                "summary": "Update application tenent",
+                "operationId": "update-tenent",
                 "parameters": [
                     {
-                        "description": "mfa validation",
-                        "name": "mfa",
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update application",
+                        "name": "tenent",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.ValidateMFAST"
+                            "$ref": "#/definitions/UpdateTenent"
                         }
                     }
                 ],
@@ -1342,7 +1446,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Token"
+                            "$ref": "#/definitions/Tenent"
                         }
                     },
                     "400": {
@@ -1372,8 +1476,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/password-reset": {
-            "post": {
+        "/applications/{applicationId}/tenents/{id}/password-policy": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Falls back to the application's policy and then the default policy",
                 "consumes": [
                     "application/json"
                 ],
@@ -1381,26 +1491,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "password-reset"
+                    "password-policy"
                 ],
-                "summary": "Request Password Reset",
-                "operationId": "password-reset",
+                "summary": "Get the password policy used by a tenent",
+                "operationId": "tenent-password-policy",
                 "parameters": [
                     {
-                        "description": "request password reset body",
-                        "name": "passwordReset",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.PasswordResetST"
-                        }
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Token"
+                            "$ref": "#/definitions/PasswordPolicy"
                         }
                     },
                     "400": {
@@ -1415,8 +1530,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/Errors"
                         }
@@ -1428,10 +1543,13 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/password-reset/request": {
-            "post": {
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -1439,19 +1557,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "password-reset"
+                    "password-policy"
                 ],
-                "summary": "Request Password Reset",
-                "operationId": "request-password-reset",
+                "summary": "Delete a tenent's password policy so it uses the application's policy",
+                "operationId": "delete-tenent-password-policy",
                 "parameters": [
                     {
-                        "description": "request password reset body",
-                        "name": "requestPasswordReset",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.RequestPasswordResetST"
-                        }
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1470,6 +1593,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1483,15 +1612,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/register": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
-                        "TenentId": []
+                        "Authorization": []
                     }
                 ],
+                "description": "Creates a tenent specific policy from the policy it currently uses when it has none",
                 "consumes": [
                     "application/json"
                 ],
@@ -1499,26 +1627,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "registration"
+                    "password-policy"
                 ],
-                "summary": "Registration as a new user",
-                "operationId": "register-user",
+                "summary": "Update a tenent's password policy",
+                "operationId": "update-tenent-password-policy",
                 "parameters": [
                     {
-                        "description": "token request body",
-                        "name": "registrationRequest",
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update password policy",
+                        "name": "updatePasswordPolicy",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/RegistrationRequest"
+                            "$ref": "#/definitions/UpdatePasswordPolicy"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Token"
+                            "$ref": "#/definitions/PasswordPolicy"
                         }
                     },
                     "400": {
@@ -1533,6 +1675,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1548,11 +1696,11 @@ const docTemplate = `{
                 }
             }
         },
-        "/token": {
-            "post": {
+        "/applications/{applicationId}/tenents/{id}/private-key": {
+            "get": {
                 "security": [
                     {
-                        "TenentId": []
+                        "Authorization": []
                     }
                 ],
                 "consumes": [
@@ -1562,26 +1710,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "token"
+                    "tenent"
                 ],
-                "summary": "Create JWT Token",
-                "operationId": "create-token",
+                "summary": "Get application tenent by id",
+                "operationId": "tenent-private-key",
                 "parameters": [
                     {
-                        "description": "token request body",
-                        "name": "tokenRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/TokenRequest"
-                        }
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "application tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Token"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -1596,8 +1749,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/Errors"
                         }
@@ -1611,7 +1764,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
+        "/applications/{applicationId}/tenents/{id}/registration-invite-codes": {
             "get": {
                 "security": [
                     {
@@ -1625,15 +1778,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "registration"
+                ],
+                "summary": "Get a tenent's registration invite codes",
+                "operationId": "registration-invite-codes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get current user",
-                "operationId": "current-user",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/UserWithPermissions"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/RegistrationInviteCode"
+                            }
                         }
                     },
                     "400": {
@@ -1662,12 +1834,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Users register with the returned code while the tenent's registration mode is invite_code, it is only returned once",
                 "consumes": [
                     "application/json"
                 ],
@@ -1675,78 +1848,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "registration"
                 ],
-                "summary": "Updates current user's username",
-                "operationId": "update-username",
+                "summary": "Create a registration invite code",
+                "operationId": "create-registration-invite-code",
                 "parameters": [
                     {
-                        "description": "update user",
-                        "name": "updateUser",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/UpdateUser"
-                        }
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/Errors"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/Errors"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/Errors"
-                        }
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/Errors"
-                        }
-                    }
-                }
-            }
-        },
-        "/user/emails": {
-            "post": {
-                "security": [
                     {
-                        "Authorization": []
-                    }
-                ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "current-user"
-                ],
-                "summary": "Create user email",
-                "operationId": "create-email",
-                "parameters": [
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
-                        "description": "update email",
-                        "name": "createEmail",
+                        "description": "create registration invite code",
+                        "name": "inviteCode",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/CreateEmail"
+                            "$ref": "#/definitions/CreateRegistrationInviteCode"
                         }
                     }
                 ],
@@ -1754,7 +1881,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/Email"
+                            "$ref": "#/definitions/CreatedRegistrationInviteCode"
                         }
                     },
                     "400": {
@@ -1775,6 +1902,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1784,13 +1917,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/emails/{id}": {
+        "/applications/{applicationId}/tenents/{id}/registration-invite-codes/{inviteCodeId}": {
             "delete": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Users already registered with it are kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -1798,17 +1932,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "registration"
                 ],
-                "summary": "Delete user email",
-                "operationId": "delete-email",
+                "summary": "Delete a registration invite code",
+                "operationId": "delete-registration-invite-code",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "email id",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "registration invite code id",
+                        "name": "inviteCodeId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1833,6 +1981,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1842,13 +1996,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/emails/{id}/confirm": {
-            "patch": {
+        "/applications/{applicationId}/tenents/{id}/registration-schema": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Returns the default schema when the tenent has none",
                 "consumes": [
                     "application/json"
                 ],
@@ -1856,33 +2011,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "registration"
                 ],
-                "summary": "Confirm email with token",
-                "operationId": "confirm-email",
+                "summary": "Get a tenent's registration schema",
+                "operationId": "tenent-registration-schema",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "email id",
-                        "name": "id",
+                        "description": "application id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "email confirmation",
-                        "name": "confirmEmail",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ConfirmEmail"
-                        }
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Email"
+                            "$ref": "#/definitions/RegistrationSchema"
                         }
                     },
                     "400": {
@@ -1910,10 +2063,8 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/emails/{id}/send-confirmation": {
-            "patch": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Authorization": []
@@ -1926,14 +2077,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "registration"
                 ],
-                "summary": "Send confirmation token to user email",
-                "operationId": "send-confirmation-to-email",
+                "summary": "Delete a tenent's registration schema so it uses the default schema",
+                "operationId": "delete-tenent-registration-schema",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "email id",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1961,6 +2119,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1968,15 +2132,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/emails/{id}/set-primary": {
+            },
             "patch": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Creates the tenent's schema from the default schema when it has none. required_info lists user info fields, address fields are named street_address, locality, region, postal_code and country",
                 "consumes": [
                     "application/json"
                 ],
@@ -1984,22 +2147,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "registration"
                 ],
-                "summary": "Set a confirmed email to primary",
-                "operationId": "set-primary-email",
+                "summary": "Update a tenent's registration schema",
+                "operationId": "update-tenent-registration-schema",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "email id",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "update registration schema",
+                        "name": "updateRegistrationSchema",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateRegistrationSchema"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RegistrationSchema"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2019,6 +2201,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2028,7 +2216,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/info": {
+        "/applications/{applicationId}/tenents/{tenentId}/identity-providers": {
             "get": {
                 "security": [
                     {
@@ -2042,15 +2230,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "identity-provider"
+                ],
+                "summary": "Get tenent identity providers",
+                "operationId": "identity-providers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get user info",
-                "operationId": "current-user-info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/UserInfo"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/IdentityProvider"
+                            }
                         }
                     },
                     "400": {
@@ -2071,6 +2278,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2079,12 +2292,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "github and google providers only need a client id and secret, oidc providers need an issuer supporting discovery or their endpoints",
                 "consumes": [
                     "application/json"
                 ],
@@ -2092,26 +2306,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "identity-provider"
                 ],
-                "summary": "Updates the user's info",
-                "operationId": "update-current-user-info",
+                "summary": "Create tenent identity provider",
+                "operationId": "create-identity-provider",
                 "parameters": [
                     {
-                        "description": "User info updates",
-                        "name": "userinfoUpdates",
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "create identity provider",
+                        "name": "identityProvider",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/UpdateUserInfoRequest"
+                            "$ref": "#/definitions/CreateIdentityProvider"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/UserInfo"
+                            "$ref": "#/definitions/IdentityProvider"
                         }
                     },
                     "400": {
@@ -2132,6 +2360,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2141,8 +2375,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/passkeys/begin-login": {
-            "post": {
+        "/applications/{applicationId}/tenents/{tenentId}/identity-providers/{id}": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -2155,15 +2389,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "passkey"
+                    "identity-provider"
+                ],
+                "summary": "Get tenent identity provider by id",
+                "operationId": "identity-provider",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "identity provider id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Begin logining with a passkey",
-                "operationId": "passkey-begin-login",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/protocol.PublicKeyCredentialRequestOptions"
+                            "$ref": "#/definitions/IdentityProvider"
                         }
                     },
                     "400": {
@@ -2178,6 +2435,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -2191,15 +2454,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/passkeys/begin-registration": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Also removes the identities users linked with it",
                 "consumes": [
                     "application/json"
                 ],
@@ -2207,16 +2469,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "passkey"
+                    "identity-provider"
+                ],
+                "summary": "Delete tenent identity provider",
+                "operationId": "delete-identity-provider",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "identity provider id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Begin registering a new passkey",
-                "operationId": "passkey-begin-registration",
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/protocol.PublicKeyCredentialCreationOptions"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2230,6 +2512,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -2243,10 +2531,8 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/passkeys/finish-login": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "Authorization": []
@@ -2259,13 +2545,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "passkey"
+                    "identity-provider"
+                ],
+                "summary": "Update tenent identity provider",
+                "operationId": "update-identity-provider",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "identity provider id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update identity provider",
+                        "name": "identityProvider",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateIdentityProvider"
+                        }
+                    }
                 ],
-                "summary": "Finish logining with a passkey",
-                "operationId": "passkey-finish-login",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/IdentityProvider"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2279,6 +2600,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -2294,8 +2621,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/passkeys/finish-registration": {
-            "post": {
+        "/applications/{applicationId}/tenents/{tenentId}/saml-service-providers": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -2308,13 +2635,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "passkey"
+                    "saml"
+                ],
+                "summary": "Get tenent SAML service providers",
+                "operationId": "saml-service-providers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Finish registering a new passkey",
-                "operationId": "passkey-finish-registration",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/SAMLServiceProvider"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2328,28 +2677,33 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/Errors"
                         }
                     },
-                    "500": {
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/Errors"
                         }
                     }
                 }
-            }
-        },
-        "/user/phone-numbers": {
+            },
             "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Service providers need their metadata or an acs url accepting the HTTP-POST binding, attribute_mapping maps SAML attribute names to id, username, email, phone_number, roles or a user info field",
                 "consumes": [
                     "application/json"
                 ],
@@ -2357,18 +2711,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "saml"
                 ],
-                "summary": "Create user phone number",
-                "operationId": "create-phone-number",
+                "summary": "Register a tenent SAML service provider",
+                "operationId": "create-saml-service-provider",
                 "parameters": [
                     {
-                        "description": "update phone_number",
-                        "name": "createPhoneNumber",
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "create saml service provider",
+                        "name": "samlServiceProvider",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/CreatePhoneNumber"
+                            "$ref": "#/definitions/CreateSAMLServiceProvider"
                         }
                     }
                 ],
@@ -2376,7 +2744,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/PhoneNumber"
+                            "$ref": "#/definitions/SAMLServiceProvider"
                         }
                     },
                     "400": {
@@ -2397,6 +2765,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2406,8 +2780,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/phone-numbers/{id}": {
-            "delete": {
+        "/applications/{applicationId}/tenents/{tenentId}/saml-service-providers/{id}": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -2420,22 +2794,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "saml"
                 ],
-                "summary": "Delete user phone number",
-                "operationId": "delete-phone-number",
+                "summary": "Get tenent SAML service provider by id",
+                "operationId": "saml-service-provider",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "phone_number id",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "saml service provider id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SAMLServiceProvider"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2455,6 +2846,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2462,10 +2859,8 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/phone-numbers/{id}/confirm": {
-            "patch": {
+            },
+            "delete": {
                 "security": [
                     {
                         "Authorization": []
@@ -2478,34 +2873,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "saml"
                 ],
-                "summary": "Confirm phone_number with token",
-                "operationId": "confirm-phone-number",
+                "summary": "Delete tenent SAML service provider",
+                "operationId": "delete-saml-service-provider",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "phone_number id",
-                        "name": "id",
+                        "description": "application id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "phone_number confirmation",
-                        "name": "confirmPhoneNumber",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ConfirmPhoneNumber"
-                        }
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "saml service provider id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/PhoneNumber"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2525,6 +2922,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2532,9 +2935,7 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/phone-numbers/{id}/send-confirmation": {
+            },
             "patch": {
                 "security": [
                     {
@@ -2548,22 +2949,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "saml"
                 ],
-                "summary": "Send confirmation token to user phone_number",
-                "operationId": "send-confirmation-to-phone-number",
+                "summary": "Update tenent SAML service provider",
+                "operationId": "update-saml-service-provider",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "phone_number id",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "saml service provider id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "update saml service provider",
+                        "name": "samlServiceProvider",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateSAMLServiceProvider"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SAMLServiceProvider"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2583,6 +3010,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/Errors"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2592,8 +3025,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/phone-numbers/{id}/set-primary": {
-            "patch": {
+        "/applications/{applicationId}/users": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -2606,22 +3039,143 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
                 ],
-                "summary": "Set a confirmed phone to primary",
-                "operationId": "set-primary-phone-number",
+                "summary": "Get users",
+                "operationId": "users",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "email id",
-                        "name": "id",
+                        "description": "application id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "name": "email_confirmed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "name": "mfa_enabled",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "phone_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "name": "phone_number_confirmed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "username"
+                        ],
+                        "type": "string",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "disabled",
+                            "locked",
+                            "pending"
+                        ],
+                        "type": "string",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "estimated"
+                        ],
+                        "type": "string",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "username",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Pagination-User"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2648,10 +3202,8 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/reset-password": {
-            "patch": {
+            },
+            "post": {
                 "security": [
                     {
                         "Authorization": []
@@ -2664,24 +3216,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
                 ],
-                "summary": "Resets a user's password",
-                "operationId": "reset-password",
+                "summary": "Create user",
+                "operationId": "create-user",
                 "parameters": [
                     {
-                        "description": "reset user's password",
-                        "name": "resetPassword",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ResetPassword"
-                        }
-                    }
-                ],
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "create user",
+                        "name": "createUser",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateUser"
+                        }
+                    }
+                ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2710,32 +3272,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/totp": {
+        "/applications/{applicationId}/users/export": {
             "get": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Streams every user of the application as csv or json lines in the format the import accepts",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
+                ],
+                "summary": "Export users",
+                "operationId": "export-users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "name": "includePasswordHashes",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get user TOTPs",
-                "operationId": "totps",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/TOTP"
-                            }
+                            "$ref": "#/definitions/ExportUser"
                         }
                     },
                     "400": {
@@ -2765,38 +3349,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/totp/{tenentId}": {
+        "/applications/{applicationId}/users/import": {
             "post": {
                 "security": [
                     {
                         "Authorization": []
                     }
                 ],
+                "description": "Imports users from csv or json lines, valid users are inserted in batches and every invalid line is reported with its errors, at most user.import_max_rows lines per request",
                 "consumes": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
                 ],
-                "summary": "Create user TOTP",
-                "operationId": "create-totp",
+                "summary": "Import users",
+                "operationId": "import-users",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tenent id",
-                        "name": "tenentId",
+                        "description": "application id",
+                        "name": "applicationId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "DefaultPhoneRegion is the region phone numbers without a calling code are read as, without one they must be international",
+                        "name": "defaultPhoneRegion",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "one user per line, or csv with a header row",
+                        "name": "users",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ImportUser"
+                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/TOTPWithSecret"
+                            "$ref": "#/definitions/ImportUsersResult"
                         }
                     },
                     "400": {
@@ -2824,8 +3434,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/applications/{applicationId}/users/{id}": {
+            "get": {
                 "security": [
                     {
                         "Authorization": []
@@ -2838,22 +3450,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
                 ],
-                "summary": "Delete user TOTP",
-                "operationId": "delete-totp",
+                "summary": "Get user by id",
+                "operationId": "user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tenent id",
-                        "name": "tenentId",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2880,9 +3502,7 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/user/totp/{tenentId}/enable": {
+            },
             "delete": {
                 "security": [
                     {
@@ -2896,25 +3516,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
                 ],
-                "summary": "Disables user TOTP",
-                "operationId": "disalbe-totp",
+                "summary": "Delets a user by id",
+                "operationId": "delete-user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tenent id",
-                        "name": "tenentId",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/TOTPWithSecret"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -2955,24 +3579,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "current-user"
+                    "user"
                 ],
-                "summary": "Enables user TOTP",
-                "operationId": "enable-totp",
+                "summary": "Updates a user's username",
+                "operationId": "update-user",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "tenent id",
-                        "name": "tenentId",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "update user",
+                        "name": "updateUser",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateUser"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/TOTPWithSecret"
+                            "$ref": "#/definitions/User"
                         }
                     },
                     "400": {
@@ -3002,8 +3642,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/version": {
-            "get": {
+        "/applications/{applicationId}/users/{id}/impersonate": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Issues a short lived access token for the user with an act claim identifying the caller. It can't be refreshed, can't change the user's password, MFA, emails, phone numbers or identities and every request made with it is audit logged",
                 "consumes": [
                     "application/json"
                 ],
@@ -3011,450 +3657,7637 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "app"
+                    "user"
+                ],
+                "summary": "Impersonate a user",
+                "operationId": "impersonate-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "impersonate user",
+                        "name": "impersonateUser",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ImpersonateUser"
+                        }
+                    }
                 ],
-                "summary": "Get Version",
-                "operationId": "version",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/Version"
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "Application": {
-            "type": "object",
-            "required": [
-                "created_at",
-                "description",
-                "id",
-                "updated_at",
-                "uri"
-            ],
-            "properties": {
-                "created_at": {
-                    "type": "string",
-                    "format": "date-time"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "updated_at": {
-                    "type": "string",
+        },
+        "/applications/{applicationId}/users/{id}/info": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user info",
+                "operationId": "user-info",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Updates the user's info",
+                "operationId": "update-user-info",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User info updates",
+                        "name": "userinfoUpdates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateUserInfoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{applicationId}/users/{id}/ldap": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Password logins for ldap backed users are checked against the application's directory instead of their local password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ldap"
+                ],
+                "summary": "Flag a user as ldap backed",
+                "operationId": "update-user-ldap",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update user ldap",
+                        "name": "updateUserLDAP",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateUserLDAP"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{applicationId}/users/{id}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Users that are not active can't sign in and the tokens they were issued are revoked, a status with an expiry becomes active again once it passes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Changes a user's status",
+                "operationId": "update-user-status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "applicationId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update user status",
+                        "name": "updateUserStatus",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateUserStatus"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/applications/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "application"
+                ],
+                "summary": "Get application by id",
+                "operationId": "application",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Application"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "application"
+                ],
+                "summary": "Delete application",
+                "operationId": "delete-application",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "application"
+                ],
+                "summary": "Update application",
+                "operationId": "update-application",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "application id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update application",
+                        "name": "application",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateApplication"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Application"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/confirmation": {
+            "post": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "description": "The token is the confirmation_token of the link sent with a confirmation code, it works once and stops working when a new code is sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "confirmation"
+                ],
+                "summary": "Confirm an email or phone number with the link sent to it",
+                "operationId": "confirm",
+                "parameters": [
+                    {
+                        "description": "confirmation",
+                        "name": "confirmation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/Confirmation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/consent": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Third party tenents get a consent token until the user approves the requested scopes, approving them records the consent and returns the tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Approve a third party tenent's scopes",
+                "operationId": "consent",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app"
+                ],
+                "summary": "Get Health Check",
+                "operationId": "healthCheck",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Health"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/identity-providers": {
+            "get": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "identity-provider"
+                ],
+                "summary": "List the identity providers users can sign in with",
+                "operationId": "tenent-identity-providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/PublicIdentityProvider"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/identity-providers/callback": {
+            "get": {
+                "description": "Finds the user linked to the upstream identity, links a user with the same confirmed email when the provider allows it or creates one, then redirects to the tenent's authorization website with an identity_provider_code or an identity_provider_error. Logins started from /user/identities redirect with identity_provider_linked instead of a code",
+                "tags": [
+                    "identity-provider"
+                ],
+                "summary": "Identity provider redirect back",
+                "operationId": "identity-provider-callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "error",
+                        "name": "error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "See Other"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/identity-providers/{id}/authorize": {
+            "post": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "description": "Returns the url to send the user to, the provider redirects back to the callback which redirects to the tenent's authorization website with an identity_provider_code to exchange with the identity-provider grant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "identity-provider"
+                ],
+                "summary": "Start signing in with an identity provider",
+                "operationId": "identity-provider-authorize",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "identity provider id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/IdentityProviderAuthorization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/invitations/accept": {
+            "post": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "description": "Sets the invited user's password, and optionally its username and info, activates it and signs it in. Each invitation link can only be used once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invitation"
+                ],
+                "summary": "Accept an invitation",
+                "operationId": "accept-invitation",
+                "parameters": [
+                    {
+                        "description": "accept invitation",
+                        "name": "acceptInvitation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/AcceptInvitation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Multi-factor authentication",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Multi-factor authentication",
+                "operationId": "validate-mfa",
+                "parameters": [
+                    {
+                        "description": "mfa validation",
+                        "name": "mfa",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ValidateMFAST"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa/passkey/begin-login": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Returns the assertion options to sign, the signed credential is sent to /mfa with type passkey",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Begin passkey multi-factor authentication",
+                "operationId": "mfa-passkey-begin-login",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/protocol.PublicKeyCredentialRequestOptions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa/resend": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Resends the one-time code for email and sms multi-factor authentication, defaults to the preferred method",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Resend multi-factor authentication code",
+                "operationId": "resend-mfa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "mfa type, email or sms",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/password-reset": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "password-reset"
+                ],
+                "summary": "Request Password Reset",
+                "operationId": "password-reset",
+                "parameters": [
+                    {
+                        "description": "request password reset body",
+                        "name": "passwordReset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PasswordResetST"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/password-reset/request": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "password-reset"
+                ],
+                "summary": "Request Password Reset",
+                "operationId": "request-password-reset",
+                "parameters": [
+                    {
+                        "description": "request password reset body",
+                        "name": "requestPasswordReset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RequestPasswordResetST"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/passwordless": {
+            "post": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "description": "Sends a one-time code and a login link to the email, always responds with 204 whether or not the email is known",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Request a passwordless login",
+                "operationId": "request-passwordless",
+                "parameters": [
+                    {
+                        "description": "request passwordless body",
+                        "name": "requestPasswordless",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RequestPasswordless"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Creates a third party tenent for the client, authorized with an initial access token as the bearer token. grant_types defaults to passwordless, identity-provider and refresh-token and token_endpoint_auth_method to client_secret_basic",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client-registration"
+                ],
+                "summary": "Register a client",
+                "operationId": "register-client",
+                "parameters": [
+                    {
+                        "description": "client metadata",
+                        "name": "client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistration"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    }
+                }
+            }
+        },
+        "/register/{clientId}": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Authorized with the client's registration access token as the bearer token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client-registration"
+                ],
+                "summary": "Read a client's registration",
+                "operationId": "registered-client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "clientId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistration"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Authorized with the client's registration access token as the bearer token, metadata left out is reset to its default",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client-registration"
+                ],
+                "summary": "Replace a client's registration",
+                "operationId": "update-registered-client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "clientId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "client metadata",
+                        "name": "client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistration"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Authorized with the client's registration access token as the bearer token, deletes the client's tenent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client-registration"
+                ],
+                "summary": "Delete a client's registration",
+                "operationId": "delete-registered-client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "clientId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ClientRegistrationError"
+                        }
+                    }
+                }
+            }
+        },
+        "/registration": {
+            "get": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "registration"
+                ],
+                "summary": "Get what registering with the tenent requires",
+                "operationId": "registration-schema",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RegistrationSchema"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "description": "The tenent's registration schema decides which of email, phone number and info are required, which email domains are allowed and whether an invite code is needed. The user, its email, phone number and info are created together or not at all",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "registration"
+                ],
+                "summary": "Registration as a new user",
+                "operationId": "register-user",
+                "parameters": [
+                    {
+                        "description": "token request body",
+                        "name": "registrationRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/saml/complete": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Returns the signed response the authorization website posts to the service provider's url as SAMLResponse and RelayState form fields",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "saml"
+                ],
+                "summary": "Complete a SAML request for the current user",
+                "operationId": "saml-complete",
+                "parameters": [
+                    {
+                        "description": "saml request to complete",
+                        "name": "completeSAMLRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CompleteSAMLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SAMLResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/saml/{tenentClientId}/metadata": {
+            "get": {
+                "description": "Assertions are signed with the tenent's private key, tenents using an HS algorithm can not be SAML identity providers",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "saml"
+                ],
+                "summary": "SAML identity provider metadata",
+                "operationId": "saml-metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenent client id",
+                        "name": "tenentClientId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/saml/{tenentClientId}/sso": {
+            "get": {
+                "description": "Accepts an AuthnRequest with the HTTP-Redirect or HTTP-POST binding from a registered service provider and redirects to the tenent's authorization website with a saml_request, after signing the user in it completes the request with POST /saml/complete",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "tags": [
+                    "saml"
+                ],
+                "summary": "SAML single sign on",
+                "operationId": "saml-sso",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenent client id",
+                        "name": "tenentClientId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "saml request",
+                        "name": "SAMLRequest",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "relay state",
+                        "name": "RelayState",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "See Other"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Accepts an AuthnRequest with the HTTP-Redirect or HTTP-POST binding from a registered service provider and redirects to the tenent's authorization website with a saml_request, after signing the user in it completes the request with POST /saml/complete",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "tags": [
+                    "saml"
+                ],
+                "summary": "SAML single sign on",
+                "operationId": "saml-sso",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenent client id",
+                        "name": "tenentClientId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "saml request",
+                        "name": "SAMLRequest",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "relay state",
+                        "name": "RelayState",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "See Other"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Groups": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "List SCIM groups",
+                "operationId": "scim-groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "filter, for example displayName eq \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1 based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max results",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "members to skip loading members",
+                        "name": "excludedAttributes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMListResponse-SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/scim+json"
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Create SCIM group",
+                "operationId": "scim-create-group",
+                "parameters": [
+                    {
+                        "description": "group",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SCIMGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Groups/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Get SCIM group",
+                "operationId": "scim-group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "members to skip loading members",
+                        "name": "excludedAttributes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMGroup"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/scim+json"
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Replace SCIM group",
+                "operationId": "scim-replace-group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "group",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SCIMGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Delete SCIM group",
+                "operationId": "scim-delete-group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/scim+json"
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Patch SCIM group",
+                "operationId": "scim-patch-group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "patch operations",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SCIMPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ResourceTypes": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "SCIM resource types",
+                "operationId": "scim-resource-types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMListResponse-SCIMResourceType"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Schemas": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "SCIM schemas",
+                "operationId": "scim-schemas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMListResponse-SCIMSchema"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Schemas/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "SCIM schema by id",
+                "operationId": "scim-schema",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "schema urn",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMSchema"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ServiceProviderConfig": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "SCIM service provider config",
+                "operationId": "scim-service-provider-config",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMServiceProviderConfig"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "List SCIM users",
+                "operationId": "scim-users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "filter, for example userName eq \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1 based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max results",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMListResponse-SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/scim+json"
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Create SCIM user",
+                "operationId": "scim-create-user",
+                "parameters": [
+                    {
+                        "description": "user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SCIMUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Get SCIM user",
+                "operationId": "scim-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMUser"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/scim+json"
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Replace SCIM user",
+                "operationId": "scim-replace-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SCIMUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Delete SCIM user",
+                "operationId": "scim-delete-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/scim+json"
+                ],
+                "produces": [
+                    "application/scim+json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "Patch SCIM user",
+                "operationId": "scim-patch-user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "patch operations",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SCIMPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/SCIMError"
+                        }
+                    }
+                }
+            }
+        },
+        "/token": {
+            "post": {
+                "security": [
+                    {
+                        "TenentId": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Create JWT Token",
+                "operationId": "create-token",
+                "parameters": [
+                    {
+                        "description": "token request body",
+                        "name": "tokenRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Get current user",
+                "operationId": "current-user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserWithPermissions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Updates current user's username",
+                "operationId": "update-username",
+                "parameters": [
+                    {
+                        "description": "update user",
+                        "name": "updateUser",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/consents": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "List the third party tenents the current user approved",
+                "operationId": "current-user-consents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/UserConsent"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/consents/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "The tenent's refresh tokens for the user stop working and the user is asked to approve its scopes again on their next sign in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Revoke a third party tenent's consent",
+                "operationId": "current-user-revoke-consent",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "consent id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/emails": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Create user email",
+                "operationId": "create-email",
+                "parameters": [
+                    {
+                        "description": "update email",
+                        "name": "createEmail",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateEmail"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Email"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/emails/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Delete user email",
+                "operationId": "delete-email",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "email id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/emails/{id}/confirm": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "The token is the numeric code sent to the email. A wrong code is a 400 and can be retried, an expired code or one with too many wrong attempts is a 410 and a new code has to be sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Confirm email with code",
+                "operationId": "confirm-email",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "email id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "email confirmation",
+                        "name": "confirmEmail",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ConfirmEmail"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Email"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/emails/{id}/send-confirmation": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Codes sent before stop working, sending is throttled per email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Send a confirmation code and link to user email",
+                "operationId": "send-confirmation-to-email",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "email id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/emails/{id}/set-primary": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Set a confirmed email to primary",
+                "operationId": "set-primary-email",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "email id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/identities": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "List the upstream identities linked to the current user",
+                "operationId": "current-user-identities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/UserIdentity"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Requires the user to have signed in recently. Returns the url to send the user to, after signing in with the provider they are redirected to the tenent's authorization website with identity_provider_linked set to the provider's id or an identity_provider_error",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Start linking an upstream identity to the current user",
+                "operationId": "current-user-link-identity",
+                "parameters": [
+                    {
+                        "description": "identity provider to link",
+                        "name": "linkIdentity",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LinkUserIdentity"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/IdentityProviderAuthorization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/identities/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Refused with credentials last when the user would have no password, passkey or other identity left to sign in with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Unlink an upstream identity from the current user",
+                "operationId": "current-user-unlink-identity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user identity id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/info": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Get user info",
+                "operationId": "current-user-info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Updates the user's info",
+                "operationId": "update-current-user-info",
+                "parameters": [
+                    {
+                        "description": "User info updates",
+                        "name": "userinfoUpdates",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateUserInfoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Get user's enrolled multi-factor authentication methods",
+                "operationId": "current-user-mfas",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/MFA"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Disables user's multi-factor authentication, removing every enrolled method",
+                "operationId": "disable-mfa",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/email": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Enables email multi-factor authentication using the confirmed primary email",
+                "operationId": "enable-email-mfa",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/MFA"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/passkey": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Enables passkey multi-factor authentication using the user's registered passkeys",
+                "operationId": "enable-passkey-mfa",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/MFA"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/sms": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Enables sms multi-factor authentication using the confirmed primary phone number",
+                "operationId": "enable-sms-mfa",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/MFA"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/{type}/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Removes one of the user's multi-factor authentication methods",
+                "operationId": "delete-mfa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "mfa type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "mfa id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/mfa/{type}/{id}/preferred": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Sets the multi-factor authentication method used by default",
+                "operationId": "set-preferred-mfa",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "mfa type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "mfa id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/MFA"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/passkeys/begin-login": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkey"
+                ],
+                "summary": "Begin logining with a passkey",
+                "operationId": "passkey-begin-login",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/protocol.PublicKeyCredentialRequestOptions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/passkeys/begin-registration": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkey"
+                ],
+                "summary": "Begin registering a new passkey",
+                "operationId": "passkey-begin-registration",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/protocol.PublicKeyCredentialCreationOptions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/passkeys/finish-login": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkey"
+                ],
+                "summary": "Finish logining with a passkey",
+                "operationId": "passkey-finish-login",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/passkeys/finish-registration": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkey"
+                ],
+                "summary": "Finish registering a new passkey",
+                "operationId": "passkey-finish-registration",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/phone-numbers": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Create user phone number",
+                "operationId": "create-phone-number",
+                "parameters": [
+                    {
+                        "description": "update phone_number",
+                        "name": "createPhoneNumber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreatePhoneNumber"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/PhoneNumber"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/phone-numbers/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Delete user phone number",
+                "operationId": "delete-phone-number",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "phone_number id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/phone-numbers/{id}/confirm": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "The token is the numeric code sent to the phone number. A wrong code is a 400 and can be retried, an expired code or one with too many wrong attempts is a 410 and a new code has to be sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Confirm phone_number with code",
+                "operationId": "confirm-phone-number",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "phone_number id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "phone_number confirmation",
+                        "name": "confirmPhoneNumber",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ConfirmPhoneNumber"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/PhoneNumber"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/phone-numbers/{id}/send-confirmation": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Codes sent before stop working, sending is throttled per phone number",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Send a confirmation code and link to user phone_number",
+                "operationId": "send-confirmation-to-phone-number",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "phone_number id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/phone-numbers/{id}/set-primary": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Set a confirmed phone to primary",
+                "operationId": "set-primary-phone-number",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "email id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/recovery-codes": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Get the number of unused recovery codes",
+                "operationId": "recovery-codes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RecoveryCodesStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Regenerate recovery codes, invalidating any existing codes",
+                "operationId": "regenerate-recovery-codes",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/reset-password": {
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Resets a user's password",
+                "operationId": "reset-password",
+                "parameters": [
+                    {
+                        "description": "reset user's password",
+                        "name": "resetPassword",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ResetPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/totp": {
+            "get": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Get user TOTPs",
+                "operationId": "totps",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/TOTP"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/totp/{tenentId}": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Create user TOTP",
+                "operationId": "create-totp",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/TOTPWithSecret"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Delete user TOTP",
+                "operationId": "delete-totp",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/totp/{tenentId}/enable": {
+            "delete": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Disables user TOTP",
+                "operationId": "disalbe-totp",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/TOTPWithSecret"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "current-user"
+                ],
+                "summary": "Enables user TOTP",
+                "operationId": "enable-totp",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "tenent id",
+                        "name": "tenentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/TOTPWithSecret"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/verification": {
+            "post": {
+                "security": [
+                    {
+                        "Authorization": []
+                    }
+                ],
+                "description": "Tenents requiring a confirmed primary email or phone number issue a verification token to users that haven't confirmed it, the token can only be used to add, confirm and set primary emails and phone numbers under /verification. Once they are confirmed this returns the tokens",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "token"
+                ],
+                "summary": "Finish signing in after confirming the required email or phone number",
+                "operationId": "verification",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/Errors"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app"
+                ],
+                "summary": "Get Version",
+                "operationId": "version",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Version"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "AcceptInvitation": {
+            "type": "object",
+            "required": [
+                "password",
+                "password_confirmation",
+                "token"
+            ],
+            "properties": {
+                "info": {
+                    "$ref": "#/definitions/UpdateUserInfoRequest"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "password_confirmation": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "Application": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "description",
+                "id",
+                "updated_at",
+                "uri"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "uri": {
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "ClientRegistration": {
+            "type": "object",
+            "required": [
+                "client_id",
+                "client_id_issued_at",
+                "client_name",
+                "client_secret",
+                "client_secret_expires_at",
+                "grant_types",
+                "redirect_uris",
+                "registration_client_uri",
+                "token_endpoint_auth_method"
+            ],
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_id_issued_at": {
+                    "type": "integer"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "client_secret_expires_at": {
+                    "type": "integer"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "registration_access_token": {
+                    "type": "string"
+                },
+                "registration_client_uri": {
+                    "type": "string"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
+                }
+            }
+        },
+        "ClientRegistrationError": {
+            "type": "object",
+            "required": [
+                "error"
+            ],
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "error_description": {
+                    "type": "string"
+                }
+            }
+        },
+        "ClientRegistrationRequest": {
+            "type": "object",
+            "required": [
+                "redirect_uris"
+            ],
+            "properties": {
+                "client_name": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string",
+                    "enum": [
+                        "none",
+                        "client_secret_basic",
+                        "client_secret_post"
+                    ]
+                }
+            }
+        },
+        "CompleteSAMLRequest": {
+            "type": "object",
+            "required": [
+                "saml_request"
+            ],
+            "properties": {
+                "saml_request": {
+                    "type": "string"
+                }
+            }
+        },
+        "ConfirmEmail": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "ConfirmPhoneNumber": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "Confirmation": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateApplication": {
+            "type": "object",
+            "required": [
+                "description",
+                "uri"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateEmail": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateIdentityProvider": {
+            "type": "object",
+            "required": [
+                "client_id",
+                "client_secret",
+                "kind",
+                "name"
+            ],
+            "properties": {
+                "authorization_endpoint": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "oidc",
+                        "github",
+                        "google"
+                    ]
+                },
+                "link_by_email": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "registration_enabled": {
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint": {
+                    "type": "string"
+                },
+                "userinfo_endpoint": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateInitialAccessToken": {
+            "type": "object",
+            "required": [
+                "description"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "expires_in_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "CreateInvitation": {
+            "type": "object",
+            "required": [
+                "email",
+                "tenent_id"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateLDAPConnector": {
+            "type": "object",
+            "required": [
+                "url",
+                "user_search_base"
+            ],
+            "properties": {
+                "bind_dn": {
+                    "type": "string"
+                },
+                "bind_password": {
+                    "type": "string"
+                },
+                "email_attribute": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "family_name_attribute": {
+                    "type": "string"
+                },
+                "given_name_attribute": {
+                    "type": "string"
+                },
+                "group_filter": {
+                    "type": "string"
+                },
+                "group_name_attribute": {
+                    "type": "string"
+                },
+                "group_role_mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "group_search_base": {
+                    "type": "string"
+                },
+                "name_attribute": {
+                    "type": "string"
+                },
+                "registration_enabled": {
+                    "type": "boolean"
+                },
+                "start_tls": {
+                    "type": "boolean"
+                },
+                "tls_root_ca": {
+                    "type": "string"
+                },
+                "tls_skip_verify": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_filter": {
+                    "type": "string"
+                },
+                "user_search_base": {
+                    "type": "string"
+                },
+                "username_attribute": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreatePhoneNumber": {
+            "type": "object",
+            "required": [
+                "phone_number"
+            ],
+            "properties": {
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateRegistrationInviteCode": {
+            "type": "object",
+            "required": [
+                "description"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "expires_in_seconds": {
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "CreateSAMLServiceProvider": {
+            "type": "object",
+            "required": [
+                "entity_id",
+                "name"
+            ],
+            "properties": {
+                "acs_url": {
+                    "type": "string"
+                },
+                "attribute_mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "name_id_format": {
+                    "type": "string",
+                    "enum": [
+                        "persistent",
+                        "email",
+                        "unspecified",
+                        "transient"
+                    ]
+                }
+            }
+        },
+        "CreateTenent": {
+            "type": "object",
+            "required": [
+                "description",
+                "uri"
+            ],
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "authorization_website": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "default_phone_region": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "email_endpoint": {
+                    "type": "string"
+                },
+                "expires_in_seconds": {
+                    "type": "integer"
+                },
+                "fold_email_aliases": {
+                    "type": "boolean"
+                },
+                "password_reset_expires_in_seconds": {
+                    "type": "integer"
+                },
+                "passwordless_enabled": {
+                    "type": "boolean"
+                },
+                "passwordless_registration_enabled": {
+                    "type": "boolean"
+                },
+                "phone_number_endpoint": {
+                    "type": "string"
+                },
+                "private_key": {
+                    "type": "string"
+                },
+                "public_key": {
+                    "type": "string"
+                },
+                "refresh_expires_in_seconds": {
+                    "type": "integer"
+                },
+                "registration_website": {
+                    "type": "string"
+                },
+                "require_confirmed_email": {
+                    "type": "boolean"
+                },
+                "require_confirmed_phone_number": {
+                    "type": "boolean"
+                },
+                "third_party": {
+                    "type": "boolean"
+                },
+                "uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreateUser": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "CreatedInitialAccessToken": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "created_at",
+                "description",
+                "id",
+                "token",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "CreatedRegistrationInviteCode": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "code",
+                "created_at",
+                "description",
+                "id",
+                "tenent_id",
+                "updated_at",
+                "uses"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "type": "integer"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Email": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "confirmed",
+                "created_at",
+                "email",
+                "id",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "ErrorMessage": {
+            "type": "object",
+            "required": [
+                "error",
+                "parameters"
+            ],
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "parameters": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "Errors": {
+            "type": "object",
+            "required": [
+                "errors"
+            ],
+            "properties": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "ExportUser": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "updated_at",
+                "username"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportUserEmail"
+                    }
+                },
+                "encrypted_password": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "info": {
+                    "$ref": "#/definitions/repository.UpdateUserInfoST"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "phone_numbers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportUserPhoneNumber"
+                    }
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "Health": {
+            "type": "object",
+            "required": [
+                "date",
+                "db"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "db": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "IdentityProvider": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "client_id",
+                "created_at",
+                "enabled",
+                "id",
+                "kind",
+                "link_by_email",
+                "name",
+                "registration_enabled",
+                "scopes",
+                "tenent_id",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "authorization_endpoint": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "oidc",
+                        "github",
+                        "google"
+                    ]
+                },
+                "link_by_email": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "registration_enabled": {
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "token_endpoint": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "userinfo_endpoint": {
+                    "type": "string"
+                }
+            }
+        },
+        "IdentityProviderAuthorization": {
+            "type": "object",
+            "required": [
+                "authorization_url"
+            ],
+            "properties": {
+                "authorization_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "ImpersonateUser": {
+            "type": "object",
+            "required": [
+                "tenent_id"
+            ],
+            "properties": {
+                "scope": {
+                    "type": "string"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ImportUser": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportUserEmail"
+                    }
+                },
+                "encrypted_password": {
+                    "type": "string"
+                },
+                "info": {
+                    "$ref": "#/definitions/repository.UpdateUserInfoST"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "phone_numbers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportUserPhoneNumber"
+                    }
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "ImportUserEmail": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "ImportUserError": {
+            "type": "object",
+            "required": [
+                "errors",
+                "line"
+            ],
+            "properties": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/ErrorMessage"
+                        }
+                    }
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "ImportUserPhoneNumber": {
+            "type": "object",
+            "required": [
+                "phone_number"
+            ],
+            "properties": {
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "ImportUsersResult": {
+            "type": "object",
+            "required": [
+                "errors",
+                "failed",
+                "imported"
+            ],
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ImportUserError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                }
+            }
+        },
+        "InitialAccessToken": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "created_at",
+                "description",
+                "id",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "Invitation": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "created_at",
+                "email",
+                "expired",
+                "expires_at",
+                "id",
+                "tenent_id",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invited_by_service_account_id": {
+                    "type": "integer"
+                },
+                "invited_by_user_id": {
+                    "type": "integer"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "LDAPConnector": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "bind_dn",
+                "created_at",
+                "email_attribute",
+                "enabled",
+                "family_name_attribute",
+                "given_name_attribute",
+                "group_filter",
+                "group_name_attribute",
+                "group_role_mapping",
+                "id",
+                "name_attribute",
+                "registration_enabled",
+                "start_tls",
+                "tls_skip_verify",
+                "updated_at",
+                "url",
+                "user_filter",
+                "user_search_base",
+                "username_attribute"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "bind_dn": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "email_attribute": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "family_name_attribute": {
+                    "type": "string"
+                },
+                "given_name_attribute": {
+                    "type": "string"
+                },
+                "group_filter": {
+                    "type": "string"
+                },
+                "group_name_attribute": {
+                    "type": "string"
+                },
+                "group_role_mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "group_search_base": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name_attribute": {
+                    "type": "string"
+                },
+                "registration_enabled": {
+                    "type": "boolean"
+                },
+                "start_tls": {
+                    "type": "boolean"
+                },
+                "tls_root_ca": {
+                    "type": "string"
+                },
+                "tls_skip_verify": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_filter": {
+                    "type": "string"
+                },
+                "user_search_base": {
+                    "type": "string"
+                },
+                "username_attribute": {
+                    "type": "string"
+                }
+            }
+        },
+        "LinkUserIdentity": {
+            "type": "object",
+            "required": [
+                "identity_provider_id"
+            ],
+            "properties": {
+                "identity_provider_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "MFA": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "enabled",
+                "id",
+                "preferred",
+                "type",
+                "updated_at"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "preferred": {
+                    "type": "boolean"
+                },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "OpenIDConfiguration": {
+            "type": "object",
+            "required": [
+                "claims_supported",
+                "code_challenge_methods_supported",
+                "grant_types_supported",
+                "id_token_signing_alg_values_supported",
+                "issuer",
+                "require_confirmed_email",
+                "require_confirmed_phone_number",
+                "response_types_supported",
+                "scopes_supported",
+                "subject_types_supported",
+                "token_endpoint",
+                "token_endpoint_auth_methods_supported",
+                "userinfo_endpoint"
+            ],
+            "properties": {
+                "authorization_endpoint": {
+                    "type": "string"
+                },
+                "claims_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code_challenge_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "grant_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id_token_signing_alg_values_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "registration_endpoint": {
+                    "type": "string"
+                },
+                "require_confirmed_email": {
+                    "type": "boolean"
+                },
+                "require_confirmed_phone_number": {
+                    "type": "boolean"
+                },
+                "response_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subject_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint": {
+                    "type": "string"
+                },
+                "token_endpoint_auth_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userinfo_endpoint": {
+                    "type": "string"
+                }
+            }
+        },
+        "Pagination-Application": {
+            "type": "object",
+            "required": [
+                "has_more",
+                "items"
+            ],
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Application"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_estimated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "Pagination-Invitation": {
+            "type": "object",
+            "required": [
+                "has_more",
+                "items"
+            ],
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Invitation"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_estimated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "Pagination-Tenent": {
+            "type": "object",
+            "required": [
+                "has_more",
+                "items"
+            ],
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Tenent"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_estimated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "Pagination-User": {
+            "type": "object",
+            "required": [
+                "has_more",
+                "items"
+            ],
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/User"
+                    }
+                },
+                "next": {
+                    "type": "string"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_estimated": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "PasswordPolicy": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "check_breached",
+                "created_at",
+                "disallow_username",
+                "history_count",
+                "max_length",
+                "min_length",
+                "require_digit",
+                "require_lowercase",
+                "require_symbol",
+                "require_uppercase",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "check_breached": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string",
                     "format": "date-time"
                 },
-                "uri": {
+                "disallow_username": {
+                    "type": "boolean"
+                },
+                "history_count": {
+                    "type": "integer"
+                },
+                "max_length": {
+                    "type": "integer"
+                },
+                "min_length": {
+                    "type": "integer"
+                },
+                "require_digit": {
+                    "type": "boolean"
+                },
+                "require_lowercase": {
+                    "type": "boolean"
+                },
+                "require_symbol": {
+                    "type": "boolean"
+                },
+                "require_uppercase": {
+                    "type": "boolean"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "PhoneNumber": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "confirmed",
+                "created_at",
+                "id",
+                "phone_number",
+                "updated_at"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "PublicIdentityProvider": {
+            "type": "object",
+            "required": [
+                "id",
+                "kind",
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "oidc",
+                        "github",
+                        "google"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "RecoveryCodes": {
+            "type": "object",
+            "required": [
+                "codes"
+            ],
+            "properties": {
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "RecoveryCodesStatus": {
+            "type": "object",
+            "required": [
+                "remaining"
+            ],
+            "properties": {
+                "remaining": {
+                    "type": "integer"
+                }
+            }
+        },
+        "RegistrationInviteCode": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "created_at",
+                "description",
+                "id",
+                "tenent_id",
+                "updated_at",
+                "uses"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "type": "integer"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "RegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "info": {
+                    "$ref": "#/definitions/UpdateUserInfoRequest"
+                },
+                "invite_code": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "password_confirmation": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "RegistrationSchema": {
+            "type": "object",
+            "required": [
+                "allowed_email_domains",
+                "application_id",
+                "blocked_email_domains",
+                "created_at",
+                "mode",
+                "require_email",
+                "require_phone_number",
+                "required_info",
+                "tenent_id",
+                "updated_at"
+            ],
+            "properties": {
+                "allowed_email_domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "application_id": {
+                    "type": "integer"
+                },
+                "blocked_email_domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "invite_code",
+                        "closed"
+                    ]
+                },
+                "require_email": {
+                    "type": "boolean"
+                },
+                "require_phone_number": {
+                    "type": "boolean"
+                },
+                "required_info": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "RequestPasswordless": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "ResetPassword": {
+            "type": "object",
+            "required": [
+                "password",
+                "password_confirmation"
+            ],
+            "properties": {
+                "password": {
                     "type": "string"
                 },
-                "website": {
+                "password_confirmation": {
                     "type": "string"
                 }
             }
         },
-        "ConfirmEmail": {
+        "SAMLResponse": {
             "type": "object",
             "required": [
-                "token"
+                "relay_state",
+                "saml_response",
+                "url"
             ],
             "properties": {
-                "token": {
+                "relay_state": {
+                    "type": "string"
+                },
+                "saml_response": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "ConfirmPhoneNumber": {
+        "SAMLServiceProvider": {
             "type": "object",
             "required": [
-                "token"
+                "application_id",
+                "attribute_mapping",
+                "created_at",
+                "enabled",
+                "entity_id",
+                "id",
+                "name",
+                "name_id_format",
+                "tenent_id",
+                "updated_at"
             ],
             "properties": {
-                "token": {
+                "acs_url": {
+                    "type": "string"
+                },
+                "application_id": {
+                    "type": "integer"
+                },
+                "attribute_mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "metadata": {
                     "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "name_id_format": {
+                    "type": "string",
+                    "enum": [
+                        "persistent",
+                        "email",
+                        "unspecified",
+                        "transient"
+                    ]
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
                 }
             }
         },
-        "CreateApplication": {
+        "SCIMAddress": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "formatted": {
+                    "type": "string"
+                },
+                "locality": {
+                    "type": "string"
+                },
+                "postalCode": {
+                    "type": "string"
+                },
+                "primary": {
+                    "type": "boolean"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "streetAddress": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "SCIMAuthenticationScheme": {
             "type": "object",
             "required": [
                 "description",
-                "uri"
+                "name",
+                "type"
             ],
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "uri": {
+                "name": {
+                    "type": "string"
+                },
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "CreateEmail": {
+        "SCIMBulkSupported": {
             "type": "object",
             "required": [
-                "email"
+                "maxOperations",
+                "maxPayloadSize",
+                "supported"
             ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "maxOperations": {
+                    "type": "integer"
+                },
+                "maxPayloadSize": {
+                    "type": "integer"
+                },
+                "supported": {
+                    "type": "boolean"
                 }
             }
         },
-        "CreatePhoneNumber": {
+        "SCIMError": {
             "type": "object",
             "required": [
-                "phone_number"
+                "schemas",
+                "status"
             ],
             "properties": {
-                "phone_number": {
+                "detail": {
+                    "type": "string"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scimType": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "CreateTenent": {
+        "SCIMFilterSupported": {
             "type": "object",
             "required": [
-                "description",
-                "uri"
+                "maxResults",
+                "supported"
             ],
             "properties": {
-                "algorithm": {
-                    "type": "string"
+                "maxResults": {
+                    "type": "integer"
                 },
-                "authorization_website": {
+                "supported": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "SCIMGroup": {
+            "type": "object",
+            "required": [
+                "displayName",
+                "schemas"
+            ],
+            "properties": {
+                "displayName": {
                     "type": "string"
                 },
-                "client_id": {
+                "externalId": {
                     "type": "string"
                 },
-                "description": {
+                "id": {
                     "type": "string"
                 },
-                "email_endpoint": {
-                    "type": "string"
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMReference"
+                    }
                 },
-                "expires_in_seconds": {
-                    "type": "integer"
+                "meta": {
+                    "$ref": "#/definitions/SCIMMeta"
                 },
-                "password_reset_expires_in_seconds": {
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "SCIMListResponse-SCIMGroup": {
+            "type": "object",
+            "required": [
+                "Resources",
+                "itemsPerPage",
+                "schemas",
+                "startIndex",
+                "totalResults"
+            ],
+            "properties": {
+                "Resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMGroup"
+                    }
+                },
+                "itemsPerPage": {
                     "type": "integer"
                 },
-                "phone_number_endpoint": {
-                    "type": "string"
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "private_key": {
-                    "type": "string"
+                "startIndex": {
+                    "type": "integer"
                 },
-                "public_key": {
-                    "type": "string"
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "SCIMListResponse-SCIMResourceType": {
+            "type": "object",
+            "required": [
+                "Resources",
+                "itemsPerPage",
+                "schemas",
+                "startIndex",
+                "totalResults"
+            ],
+            "properties": {
+                "Resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMResourceType"
+                    }
                 },
-                "refresh_expires_in_seconds": {
+                "itemsPerPage": {
                     "type": "integer"
                 },
-                "registration_website": {
-                    "type": "string"
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "uri": {
-                    "type": "string"
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
                 }
             }
         },
-        "CreateUser": {
+        "SCIMListResponse-SCIMSchema": {
             "type": "object",
             "required": [
-                "username"
+                "Resources",
+                "itemsPerPage",
+                "schemas",
+                "startIndex",
+                "totalResults"
             ],
             "properties": {
-                "username": {
-                    "type": "string"
+                "Resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMSchema"
+                    }
+                },
+                "itemsPerPage": {
+                    "type": "integer"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
                 }
             }
         },
-        "Email": {
+        "SCIMListResponse-SCIMUser": {
             "type": "object",
             "required": [
-                "application_id",
-                "confirmed",
-                "created_at",
-                "email",
-                "id",
-                "updated_at"
+                "Resources",
+                "itemsPerPage",
+                "schemas",
+                "startIndex",
+                "totalResults"
             ],
             "properties": {
-                "application_id": {
+                "Resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMUser"
+                    }
+                },
+                "itemsPerPage": {
                     "type": "integer"
                 },
-                "confirmed": {
-                    "type": "boolean"
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "created_at": {
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "SCIMMeta": {
+            "type": "object",
+            "required": [
+                "resourceType"
+            ],
+            "properties": {
+                "created": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "lastModified": {
                     "type": "string",
                     "format": "date-time"
                 },
-                "email": {
+                "location": {
+                    "type": "string"
+                },
+                "resourceType": {
+                    "type": "string"
+                }
+            }
+        },
+        "SCIMMultiValued": {
+            "type": "object",
+            "required": [
+                "value"
+            ],
+            "properties": {
+                "display": {
+                    "type": "string"
+                },
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "SCIMName": {
+            "type": "object",
+            "properties": {
+                "familyName": {
+                    "type": "string"
+                },
+                "formatted": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
+                "givenName": {
+                    "type": "string"
                 },
-                "updated_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "middleName": {
+                    "type": "string"
                 }
             }
         },
-        "ErrorMessage": {
+        "SCIMPatch": {
             "type": "object",
             "required": [
-                "error",
-                "parameters"
+                "Operations",
+                "schemas"
             ],
             "properties": {
-                "error": {
-                    "type": "string"
+                "Operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMPatchOperation"
+                    }
                 },
-                "parameters": {
+                "schemas": {
                     "type": "array",
-                    "items": {}
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "Errors": {
+        "SCIMPatchOperation": {
             "type": "object",
             "required": [
-                "errors"
+                "op"
             ],
             "properties": {
-                "errors": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "array",
-                        "items": {
-                            "$ref": "#/definitions/ErrorMessage"
-                        }
-                    }
+                "op": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "object"
                 }
             }
         },
-        "Health": {
+        "SCIMReference": {
             "type": "object",
             "required": [
-                "date",
-                "db"
+                "value"
             ],
             "properties": {
-                "date": {
-                    "type": "string",
-                    "format": "date-time"
+                "$ref": {
+                    "type": "string"
                 },
-                "db": {
-                    "type": "boolean"
+                "display": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
                 }
             }
         },
-        "OpenIDConfiguration": {
+        "SCIMResourceType": {
             "type": "object",
             "required": [
-                "claims_supported",
-                "code_challenge_methods_supported",
-                "grant_types_supported",
-                "id_token_signing_alg_values_supported",
-                "issuer",
-                "response_types_supported",
-                "scopes_supported",
-                "subject_types_supported",
-                "token_endpoint",
-                "token_endpoint_auth_methods_supported",
-                "userinfo_endpoint"
+                "description",
+                "endpoint",
+                "id",
+                "meta",
+                "name",
+                "schema",
+                "schemas"
             ],
             "properties": {
-                "authorization_endpoint": {
+                "description": {
                     "type": "string"
                 },
-                "claims_supported": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "endpoint": {
+                    "type": "string"
                 },
-                "code_challenge_methods_supported": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "id": {
+                    "type": "string"
                 },
-                "grant_types_supported": {
+                "meta": {
+                    "$ref": "#/definitions/SCIMMeta"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "schemas": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "id_token_signing_alg_values_supported": {
+                }
+            }
+        },
+        "SCIMSchema": {
+            "type": "object",
+            "required": [
+                "attributes",
+                "description",
+                "id",
+                "meta",
+                "name",
+                "schemas"
+            ],
+            "properties": {
+                "attributes": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/SCIMSchemaAttribute"
                     }
                 },
-                "issuer": {
+                "description": {
                     "type": "string"
                 },
-                "jwks_uri": {
+                "id": {
                     "type": "string"
                 },
-                "registration_endpoint": {
+                "meta": {
+                    "$ref": "#/definitions/SCIMMeta"
+                },
+                "name": {
                     "type": "string"
                 },
-                "response_types_supported": {
+                "schemas": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                }
+            }
+        },
+        "SCIMSchemaAttribute": {
+            "type": "object",
+            "required": [
+                "mutability",
+                "name",
+                "returned",
+                "type",
+                "uniqueness"
+            ],
+            "properties": {
+                "caseExact": {
+                    "type": "boolean"
                 },
-                "scopes_supported": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "multiValued": {
+                    "type": "boolean"
                 },
-                "subject_types_supported": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "mutability": {
+                    "type": "string"
                 },
-                "token_endpoint": {
+                "name": {
                     "type": "string"
                 },
-                "token_endpoint_auth_methods_supported": {
+                "required": {
+                    "type": "boolean"
+                },
+                "returned": {
+                    "type": "string"
+                },
+                "subAttributes": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/SCIMSchemaAttribute"
                     }
                 },
-                "userinfo_endpoint": {
+                "type": {
+                    "type": "string"
+                },
+                "uniqueness": {
                     "type": "string"
                 }
             }
         },
-        "Pagination-Application": {
+        "SCIMServiceProviderConfig": {
             "type": "object",
             "required": [
-                "has_more",
-                "items"
+                "authenticationSchemes",
+                "bulk",
+                "changePassword",
+                "etag",
+                "filter",
+                "meta",
+                "patch",
+                "schemas",
+                "sort"
             ],
             "properties": {
-                "has_more": {
-                    "type": "boolean"
+                "authenticationSchemes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMAuthenticationScheme"
+                    }
                 },
-                "items": {
+                "bulk": {
+                    "$ref": "#/definitions/SCIMBulkSupported"
+                },
+                "changePassword": {
+                    "$ref": "#/definitions/SCIMSupported"
+                },
+                "etag": {
+                    "$ref": "#/definitions/SCIMSupported"
+                },
+                "filter": {
+                    "$ref": "#/definitions/SCIMFilterSupported"
+                },
+                "meta": {
+                    "$ref": "#/definitions/SCIMMeta"
+                },
+                "patch": {
+                    "$ref": "#/definitions/SCIMSupported"
+                },
+                "schemas": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/Application"
+                        "type": "string"
                     }
+                },
+                "sort": {
+                    "$ref": "#/definitions/SCIMSupported"
                 }
             }
         },
-        "Pagination-Tenent": {
+        "SCIMSupported": {
             "type": "object",
             "required": [
-                "has_more",
-                "items"
+                "supported"
             ],
             "properties": {
-                "has_more": {
+                "supported": {
                     "type": "boolean"
-                },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/Tenent"
-                    }
                 }
             }
         },
-        "Pagination-User": {
+        "SCIMUser": {
             "type": "object",
             "required": [
-                "has_more",
-                "items"
+                "schemas",
+                "userName"
             ],
             "properties": {
-                "has_more": {
+                "active": {
                     "type": "boolean"
                 },
-                "items": {
+                "addresses": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/User"
+                        "$ref": "#/definitions/SCIMAddress"
                     }
-                }
-            }
-        },
-        "PhoneNumber": {
-            "type": "object",
-            "required": [
-                "application_id",
-                "confirmed",
-                "created_at",
-                "id",
-                "phone_number",
-                "updated_at"
-            ],
-            "properties": {
-                "application_id": {
-                    "type": "integer"
                 },
-                "confirmed": {
-                    "type": "boolean"
+                "displayName": {
+                    "type": "string"
                 },
-                "created_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMMultiValued"
+                    }
+                },
+                "externalId": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMReference"
+                    }
                 },
                 "id": {
-                    "type": "integer"
+                    "type": "string"
                 },
-                "phone_number": {
+                "locale": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string",
-                    "format": "date-time"
-                }
-            }
-        },
-        "RegistrationRequest": {
-            "type": "object",
-            "properties": {
-                "password": {
+                "meta": {
+                    "$ref": "#/definitions/SCIMMeta"
+                },
+                "name": {
+                    "$ref": "#/definitions/SCIMName"
+                },
+                "nickName": {
                     "type": "string"
                 },
-                "password_confirmation": {
+                "password": {
                     "type": "string"
                 },
-                "username": {
+                "phoneNumbers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMMultiValued"
+                    }
+                },
+                "photos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SCIMMultiValued"
+                    }
+                },
+                "profileUrl": {
                     "type": "string"
-                }
-            }
-        },
-        "ResetPassword": {
-            "type": "object",
-            "required": [
-                "password",
-                "password_confirmation"
-            ],
-            "properties": {
-                "password": {
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "timezone": {
                     "type": "string"
                 },
-                "password_confirmation": {
+                "userName": {
                     "type": "string"
                 }
             }
@@ -3514,6 +11347,12 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "secret": {
                     "type": "string"
                 },
@@ -3539,9 +11378,19 @@ const docTemplate = `{
                 "created_at",
                 "description",
                 "expires_in_seconds",
+                "fold_email_aliases",
+                "grant_types",
                 "id",
                 "password_reset_expires_in_seconds",
+                "passwordless_enabled",
+                "passwordless_registration_enabled",
+                "redirect_uris",
                 "refresh_expires_in_seconds",
+                "registered",
+                "require_confirmed_email",
+                "require_confirmed_phone_number",
+                "third_party",
+                "token_endpoint_auth_method",
                 "updated_at",
                 "uri"
             ],
@@ -3562,27 +11411,66 @@ const docTemplate = `{
                     "type": "string",
                     "format": "date-time"
                 },
+                "default_phone_region": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
                 "expires_in_seconds": {
                     "type": "integer"
                 },
+                "fold_email_aliases": {
+                    "type": "boolean"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
                 "password_reset_expires_in_seconds": {
                     "type": "integer"
                 },
+                "passwordless_enabled": {
+                    "type": "boolean"
+                },
+                "passwordless_registration_enabled": {
+                    "type": "boolean"
+                },
                 "public_key": {
                     "type": "string"
                 },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "refresh_expires_in_seconds": {
                     "type": "integer"
                 },
+                "registered": {
+                    "type": "boolean"
+                },
                 "registration_website": {
                     "type": "string"
                 },
+                "require_confirmed_email": {
+                    "type": "boolean"
+                },
+                "require_confirmed_phone_number": {
+                    "type": "boolean"
+                },
+                "third_party": {
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string",
                     "format": "date-time"
@@ -3616,90 +11504,331 @@ const docTemplate = `{
                 "issued_token_type": {
                     "type": "string"
                 },
+                "mfa_methods": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "refresh_token": {
                     "type": "string"
                 },
                 "refresh_token_expires_in": {
                     "type": "integer"
                 },
-                "scope": {
+                "scope": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "verification_required": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "email",
+                            "phone_number"
+                        ]
+                    }
+                }
+            }
+        },
+        "TokenRequest": {
+            "type": "object",
+            "required": [
+                "grant_type"
+            ],
+            "properties": {
+                "actor_token": {
+                    "type": "string"
+                },
+                "actor_token_type": {
+                    "type": "string"
+                },
+                "assertion": {
+                    "type": "string"
+                },
+                "audience": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "code_verifier": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "grant_type": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "requested_token_type": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "subject_token": {
+                    "type": "string"
+                },
+                "subject_token_type": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "UpdateApplication": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "UpdateIdentityProvider": {
+            "type": "object",
+            "properties": {
+                "authorization_endpoint": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "link_by_email": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "registration_enabled": {
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint": {
+                    "type": "string"
+                },
+                "userinfo_endpoint": {
+                    "type": "string"
+                }
+            }
+        },
+        "UpdateLDAPConnector": {
+            "type": "object",
+            "properties": {
+                "bind_dn": {
+                    "type": "string"
+                },
+                "bind_password": {
+                    "type": "string"
+                },
+                "email_attribute": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "family_name_attribute": {
+                    "type": "string"
+                },
+                "given_name_attribute": {
+                    "type": "string"
+                },
+                "group_filter": {
+                    "type": "string"
+                },
+                "group_name_attribute": {
+                    "type": "string"
+                },
+                "group_role_mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "group_search_base": {
+                    "type": "string"
+                },
+                "name_attribute": {
+                    "type": "string"
+                },
+                "registration_enabled": {
+                    "type": "boolean"
+                },
+                "start_tls": {
+                    "type": "boolean"
+                },
+                "tls_root_ca": {
+                    "type": "string"
+                },
+                "tls_skip_verify": {
+                    "type": "boolean"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_filter": {
+                    "type": "string"
+                },
+                "user_search_base": {
+                    "type": "string"
+                },
+                "username_attribute": {
+                    "type": "string"
+                }
+            }
+        },
+        "UpdatePasswordPolicy": {
+            "type": "object",
+            "properties": {
+                "check_breached": {
+                    "type": "boolean"
+                },
+                "disallow_username": {
+                    "type": "boolean"
+                },
+                "history_count": {
+                    "type": "integer"
+                },
+                "max_length": {
+                    "type": "integer"
+                },
+                "min_length": {
+                    "type": "integer"
+                },
+                "require_digit": {
+                    "type": "boolean"
+                },
+                "require_lowercase": {
+                    "type": "boolean"
+                },
+                "require_symbol": {
+                    "type": "boolean"
+                },
+                "require_uppercase": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "UpdateRegistrationSchema": {
+            "type": "object",
+            "properties": {
+                "allowed_email_domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "blocked_email_domains": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "invite_code",
+                        "closed"
+                    ]
+                },
+                "require_email": {
+                    "type": "boolean"
+                },
+                "require_phone_number": {
+                    "type": "boolean"
+                },
+                "required_info": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "token_type": {
-                    "type": "string"
                 }
             }
         },
-        "TokenRequest": {
+        "UpdateSAMLServiceProvider": {
             "type": "object",
-            "required": [
-                "grant_type"
-            ],
             "properties": {
-                "actor_token": {
-                    "type": "string"
-                },
-                "actor_token_type": {
-                    "type": "string"
-                },
-                "assertion": {
-                    "type": "string"
-                },
-                "audience": {
-                    "type": "string"
-                },
-                "code": {
-                    "type": "string"
-                },
-                "code_verifier": {
-                    "type": "string"
-                },
-                "grant_type": {
-                    "type": "string"
-                },
-                "key": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                },
-                "refresh_token": {
-                    "type": "string"
-                },
-                "requested_token_type": {
+                "acs_url": {
                     "type": "string"
                 },
-                "resource": {
-                    "type": "string"
-                },
-                "scope": {
-                    "type": "string"
+                "attribute_mapping": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "secret": {
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "subject_token": {
+                "entity_id": {
                     "type": "string"
                 },
-                "subject_token_type": {
+                "metadata": {
                     "type": "string"
                 },
-                "username": {
-                    "type": "string"
-                }
-            }
-        },
-        "UpdateApplication": {
-            "type": "object",
-            "properties": {
-                "description": {
+                "name": {
                     "type": "string"
                 },
-                "uri": {
-                    "type": "string"
+                "name_id_format": {
+                    "type": "string",
+                    "enum": [
+                        "persistent",
+                        "email",
+                        "unspecified",
+                        "transient"
+                    ]
                 }
             }
         },
@@ -3715,6 +11844,9 @@ const docTemplate = `{
                 "client_id": {
                     "type": "string"
                 },
+                "default_phone_region": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -3724,9 +11856,18 @@ const docTemplate = `{
                 "expires_in_seconds": {
                     "type": "integer"
                 },
+                "fold_email_aliases": {
+                    "type": "boolean"
+                },
                 "password_reset_expires_in_seconds": {
                     "type": "integer"
                 },
+                "passwordless_enabled": {
+                    "type": "boolean"
+                },
+                "passwordless_registration_enabled": {
+                    "type": "boolean"
+                },
                 "phone_number_endpoint": {
                     "type": "string"
                 },
@@ -3742,6 +11883,15 @@ const docTemplate = `{
                 "registration_website": {
                     "type": "string"
                 },
+                "require_confirmed_email": {
+                    "type": "boolean"
+                },
+                "require_confirmed_phone_number": {
+                    "type": "boolean"
+                },
+                "third_party": {
+                    "type": "boolean"
+                },
                 "uri": {
                     "type": "string"
                 }
@@ -3803,6 +11953,41 @@ const docTemplate = `{
                 }
             }
         },
+        "UpdateUserLDAP": {
+            "type": "object",
+            "required": [
+                "ldap_backed"
+            ],
+            "properties": {
+                "ldap_backed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "UpdateUserStatus": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "disabled",
+                        "locked",
+                        "pending"
+                    ]
+                }
+            }
+        },
         "User": {
             "type": "object",
             "required": [
@@ -3810,7 +11995,9 @@ const docTemplate = `{
                 "created_at",
                 "emails",
                 "id",
+                "ldap_backed",
                 "phone_numbers",
+                "status",
                 "updated_at",
                 "username"
             ],
@@ -3834,6 +12021,9 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "ldap_backed": {
+                    "type": "boolean"
+                },
                 "phone_number": {
                     "$ref": "#/definitions/PhoneNumber"
                 },
@@ -3843,6 +12033,22 @@ const docTemplate = `{
                         "$ref": "#/definitions/PhoneNumber"
                     }
                 },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "disabled",
+                        "locked",
+                        "pending"
+                    ]
+                },
+                "status_expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string",
                     "format": "date-time"
@@ -3852,6 +12058,99 @@ const docTemplate = `{
                 }
             }
         },
+        "UserConsent": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "scopes",
+                "tenent_client_id",
+                "tenent_description",
+                "tenent_id",
+                "tenent_uri",
+                "updated_at"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenent_client_id": {
+                    "type": "string"
+                },
+                "tenent_description": {
+                    "type": "string"
+                },
+                "tenent_id": {
+                    "type": "integer"
+                },
+                "tenent_uri": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "UserIdentity": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "identity_provider_id",
+                "identity_provider_kind",
+                "identity_provider_name",
+                "subject",
+                "updated_at"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "identity_provider_id": {
+                    "type": "integer"
+                },
+                "identity_provider_kind": {
+                    "type": "string",
+                    "enum": [
+                        "oidc",
+                        "github",
+                        "google"
+                    ]
+                },
+                "identity_provider_name": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
         "UserInfo": {
             "type": "object",
             "required": [
@@ -3945,8 +12244,10 @@ const docTemplate = `{
                 "created_at",
                 "emails",
                 "id",
+                "ldap_backed",
                 "permissions",
                 "phone_numbers",
+                "status",
                 "updated_at",
                 "username"
             ],
@@ -3970,6 +12271,9 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "ldap_backed": {
+                    "type": "boolean"
+                },
                 "permissions": {
                     "type": "object",
                     "additionalProperties": {
@@ -3988,6 +12292,22 @@ const docTemplate = `{
                         "$ref": "#/definitions/PhoneNumber"
                     }
                 },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "disabled",
+                        "locked",
+                        "pending"
+                    ]
+                },
+                "status_expires_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string",
                     "format": "date-time"
@@ -4048,12 +12368,15 @@ const docTemplate = `{
         },
         "model.ValidateMFAST": {
             "type": "object",
-            "required": [
-                "code"
-            ],
             "properties": {
                 "code": {
                     "type": "string"
+                },
+                "credential": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
@@ -4320,6 +12643,62 @@ const docTemplate = `{
                 "VerificationDiscouraged"
             ]
         },
+        "repository.UpdateUserInfoST": {
+            "type": "object",
+            "properties": {
+                "birthdate": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "family_name": {
+                    "type": "string"
+                },
+                "gender": {
+                    "type": "string"
+                },
+                "given_name": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "locality": {
+                    "type": "string"
+                },
+                "middle_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "picture": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "street_address": {
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                },
+                "zoneinfo": {
+                    "type": "string"
+                }
+            }
+        },
         "webauthncose.COSEAlgorithmIdentifier": {
             "type": "integer",
             "enum": [
@@ -37,6 +37,9 @@ var (
 // @in header
 // @name X-Timezone
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "calibrate-argon2" {
+		os.Exit(calibrateArgon2(os.Args[2:]))
+	}
 	defer func() {
 		if err := recover(); err != nil {
 			slog.Error("application panic", "error", err)
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/alexedwards/argon2id"
+)
+
+// staleArgon2Params are cheaper than any parameters the api is configured with
+var staleArgon2Params = argon2id.Params{
+	Memory:      8 * 1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func encryptedPassword(t *testing.T, user *TestUserST) string {
+	t.Helper()
+	row, err := repository.GetUserById(user.User.ApplicationId, user.User.Id)
+	if err != nil || row == nil {
+		t.Fatalf("could not get user: %s\n", err)
+	}
+	return row.EncryptedPassword
+}
+
+// staleUser gives the user a hash of their password made with staleArgon2Params
+func staleUser(t *testing.T, user *TestUserST) string {
+	t.Helper()
+	hash, err := argon2id.CreateHash(user.Password, &staleArgon2Params)
+	if err != nil {
+		t.Fatalf("could not hash password: %s\n", err)
+	}
+	if _, err := repository.Execute(`UPDATE users SET encrypted_password=$2 WHERE id=$1;`, user.User.Id, hash); err != nil {
+		t.Fatalf("could not set password hash: %s\n", err)
+	}
+	return hash
+}
+
+func TestArgon2Rehash(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	stale := staleUser(t, user)
+
+	tenent.BearerToken(t, user)
+	rehashed := encryptedPassword(t, user)
+	if rehashed == stale {
+		t.Fatalf("expected signing in to rehash the password\n")
+	}
+	params, _, _, err := argon2id.DecodeHash(rehashed)
+	if err != nil {
+		t.Fatalf("could not decode password hash: %s\n", err)
+	}
+	if *params == staleArgon2Params || util.PasswordNeedsRehash(rehashed) {
+		t.Fatalf("expected the password to be rehashed with the current parameters, got %+v\n", *params)
+	}
+	tenent.BearerToken(t, user)
+}
+
+func TestArgon2RehashRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	stale := staleUser(t, user)
+
+	if _, response := tenent.PasswordToken(t, &TestUserST{User: user.User, Password: "wrong-" + user.Password}); response.Status != http.StatusUnauthorized || !response.HasError("password", "invalid") {
+		t.Fatalf("expected wrong password to be rejected, got %s\n", response)
+	}
+	if encryptedPassword(t, user) != stale {
+		t.Fatalf("expected a rejected sign in to keep the password hash\n")
+	}
+}
+
+func TestCalibrateArgon2(t *testing.T) {
+	memory, parallelism := uint32(19*1024), uint8(1)
+	params, elapsed, err := util.CalibrateArgon2(time.Millisecond, memory, parallelism)
+	if err != nil {
+		t.Fatalf("could not calibrate argon2: %s\n", err)
+	}
+	if elapsed < time.Millisecond || params.Iterations != 1 || params.Memory != memory || params.Parallelism != parallelism {
+		t.Fatalf("expected one iteration with the given memory and parallelism, got %+v in %s\n", params, elapsed)
+	}
+
+	target := 3 * elapsed
+	params, elapsed, err = util.CalibrateArgon2(target, memory, parallelism)
+	if err != nil {
+		t.Fatalf("could not calibrate argon2: %s\n", err)
+	}
+	if elapsed < target || params.Iterations < 2 || params.Memory != memory || params.Parallelism != parallelism {
+		t.Fatalf("expected more iterations to reach %s, got %+v in %s\n", target, params, elapsed)
+	}
+	hash, err := argon2id.CreateHash("password", &params)
+	if err != nil {
+		t.Fatalf("could not hash with the calibrated parameters: %s\n", err)
+	}
+	if ok, err := argon2id.ComparePasswordAndHash("password", hash); err != nil || !ok {
+		t.Fatalf("expected the calibrated parameters to verify, got %v %v\n", ok, err)
+	}
+}
@@ -0,0 +1,201 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+func createInitialAccessToken(t *testing.T, applicationId int32) string {
+	t.Helper()
+	_, token, err := repository.CreateInitialAccessToken(applicationId, repository.CreateInitialAccessTokenST{Description: "Test"})
+	if err != nil {
+		t.Fatalf("could not create initial access token: %s\n", err)
+	}
+	return token
+}
+
+func registerClient(t *testing.T, token string, request model.ClientRegistrationRequestST) (model.ClientRegistrationST, ApiResponseST) {
+	t.Helper()
+	var registration model.ClientRegistrationST
+	response := ApiRequest(t, http.MethodPost, "/register", Bearer(token), request, &registration)
+	return registration, response
+}
+
+// registrationError is the RFC 7591 error code of a failed client registration request
+func registrationError(t *testing.T, response ApiResponseST) string {
+	t.Helper()
+	var registrationError model.ClientRegistrationErrorST
+	if err := json.Unmarshal(response.Body, &registrationError); err != nil {
+		t.Fatalf("could not decode client registration error: %s\n", err)
+	}
+	return registrationError.Error
+}
+
+// registeredClient is the tenent of a registered client
+func registeredClient(tenent *TestTenentST, registration model.ClientRegistrationST) *TestTenentST {
+	client := &TestTenentST{Application: tenent.Application}
+	client.Tenent.ClientId = registration.ClientId
+	return client
+}
+
+// basicClientToken requests a token authenticating the client with client_secret_basic
+func basicClientToken(t *testing.T, client *TestTenentST, clientSecret string, request model.TokenRequestST) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	headers := client.Headers()
+	headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(client.Tenent.ClientId.String()+":"+clientSecret))
+	var token model.TokenST
+	response := ApiRequest(t, http.MethodPost, "/token", headers, request, &token)
+	return token, response
+}
+
+func passwordTokenRequest(user *TestUserST) model.TokenRequestST {
+	return model.TokenRequestST{
+		GrantType: model.PasswordGrantType,
+		Username:  user.User.Username,
+		Password:  user.Password,
+	}
+}
+
+func TestClientRegistration(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+
+	registration, response := registerClient(t, createInitialAccessToken(t, tenent.Application.Id), model.ClientRegistrationRequestST{
+		ClientName:   "Client",
+		RedirectURIs: []string{"https://client.example.com/callback"},
+		GrantTypes:   []string{model.PasswordGrantType, model.RefreshTokenGrantType},
+	})
+	if response.Status != http.StatusCreated || registration.RegistrationAccessToken == "" {
+		t.Fatalf("could not register client: %s\n", response)
+	}
+	if registration.TokenEndpointAuthMethod != model.TokenEndpointAuthMethodClientSecretBasic {
+		t.Fatalf("expected client_secret_basic by default, got %s\n", registration.TokenEndpointAuthMethod)
+	}
+	client := registeredClient(tenent, registration)
+	if _, response := client.PasswordToken(t, user); response.Status != http.StatusUnauthorized || !response.HasError("client", "invalid") {
+		t.Fatalf("expected registered client to authenticate, got %s\n", response)
+	}
+	if _, response := basicClientToken(t, client, "wrong-secret", passwordTokenRequest(user)); response.Status != http.StatusUnauthorized || !response.HasError("client", "invalid") {
+		t.Fatalf("expected a wrong client secret to be rejected, got %s\n", response)
+	}
+	if _, response := basicClientToken(t, client, registration.ClientSecret, passwordTokenRequest(user)); response.Status != http.StatusOK {
+		t.Fatalf("expected registered client to sign users in, got %s\n", response)
+	}
+
+	path := "/register/" + registration.ClientId.String()
+	var read model.ClientRegistrationST
+	if response := ApiRequest(t, http.MethodGet, path, Bearer(registration.RegistrationAccessToken), nil, &read); response.Status != http.StatusOK || read.ClientName != "Client" {
+		t.Fatalf("could not read client registration: %s\n", response)
+	}
+	var updated model.ClientRegistrationST
+	if response := ApiRequest(t, http.MethodPut, path, Bearer(registration.RegistrationAccessToken), model.ClientRegistrationRequestST{
+		ClientName:   "Renamed",
+		RedirectURIs: []string{"https://client.example.com/callback"},
+	}, &updated); response.Status != http.StatusOK || updated.ClientName != "Renamed" {
+		t.Fatalf("could not update client registration: %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodDelete, path, Bearer(registration.RegistrationAccessToken), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not delete client registration: %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodGet, path, Bearer(registration.RegistrationAccessToken), nil, nil); response.Status != http.StatusUnauthorized || registrationError(t, response) != "invalid_token" {
+		t.Fatalf("expected deleted client to be gone, got %s\n", response)
+	}
+}
+
+func TestClientRegistrationRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	request := model.ClientRegistrationRequestST{
+		RedirectURIs: []string{"https://client.example.com/callback"},
+	}
+
+	if _, response := registerClient(t, "invalid", request); response.Status != http.StatusUnauthorized || registrationError(t, response) != "invalid_token" {
+		t.Fatalf("expected unknown initial access token to be rejected, got %s\n", response)
+	}
+	expired := createInitialAccessToken(t, tenent.Application.Id)
+	if _, err := repository.Execute(`UPDATE initial_access_tokens SET expires_at = NOW() WHERE application_id = $1;`, tenent.Application.Id); err != nil {
+		t.Fatalf("could not expire initial access token: %s\n", err)
+	}
+	if _, response := registerClient(t, expired, request); response.Status != http.StatusUnauthorized || registrationError(t, response) != "invalid_token" {
+		t.Fatalf("expected expired initial access token to be rejected, got %s\n", response)
+	}
+
+	token := createInitialAccessToken(t, tenent.Application.Id)
+	if _, response := registerClient(t, token, model.ClientRegistrationRequestST{
+		RedirectURIs: []string{"http://client.example.com/callback"},
+	}); response.Status != http.StatusBadRequest || registrationError(t, response) != "invalid_redirect_uri" {
+		t.Fatalf("expected plain http redirect uri to be rejected, got %s\n", response)
+	}
+	for _, method := range []string{"client_secret_jwt", "private_key_jwt", "unknown"} {
+		if _, response := registerClient(t, token, model.ClientRegistrationRequestST{
+			RedirectURIs:            []string{"https://client.example.com/callback"},
+			TokenEndpointAuthMethod: method,
+		}); response.Status != http.StatusBadRequest || registrationError(t, response) != "invalid_client_metadata" {
+			t.Fatalf("expected token_endpoint_auth_method %s to be rejected, got %s\n", method, response)
+		}
+	}
+	registration, response := registerClient(t, token, model.ClientRegistrationRequestST{
+		RedirectURIs: []string{"https://client.example.com/callback"},
+	})
+	if response.Status != http.StatusCreated {
+		t.Fatalf("could not register client: %s\n", response)
+	}
+	if !slices.Equal(registration.GrantTypes, []string{model.PasswordlessGrantType, model.IdentityProviderGrantType, model.RefreshTokenGrantType}) {
+		t.Fatalf("expected the consent based grant types by default, got %v\n", registration.GrantTypes)
+	}
+	client := registeredClient(tenent, registration)
+	if _, response := basicClientToken(t, client, registration.ClientSecret, passwordTokenRequest(CreateTestUser(t, tenent.Application.Id))); response.Status != http.StatusBadRequest || !response.HasError("grant_type", "invalid") {
+		t.Fatalf("expected grant type the client didn't register to be rejected, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodGet, "/register/"+registration.ClientId.String(), Bearer(token), nil, nil); response.Status != http.StatusUnauthorized || registrationError(t, response) != "invalid_token" {
+		t.Fatalf("expected the initial access token to not read the registration, got %s\n", response)
+	}
+}
+
+func TestClientRegistrationClientSecretPost(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	registration, response := registerClient(t, createInitialAccessToken(t, tenent.Application.Id), model.ClientRegistrationRequestST{
+		RedirectURIs:            []string{"https://client.example.com/callback"},
+		GrantTypes:              []string{model.PasswordGrantType},
+		TokenEndpointAuthMethod: model.TokenEndpointAuthMethodClientSecretPost,
+	})
+	if response.Status != http.StatusCreated {
+		t.Fatalf("could not register client: %s\n", response)
+	}
+	client := registeredClient(tenent, registration)
+
+	if _, response := basicClientToken(t, client, registration.ClientSecret, passwordTokenRequest(user)); response.Status != http.StatusUnauthorized || !response.HasError("client", "invalid") {
+		t.Fatalf("expected client_secret_post client to be rejected with basic credentials, got %s\n", response)
+	}
+	request := passwordTokenRequest(user)
+	request.ClientId = registration.ClientId.String()
+	request.ClientSecret = "wrong-secret"
+	if _, response := client.Token(t, request); response.Status != http.StatusUnauthorized || !response.HasError("client", "invalid") {
+		t.Fatalf("expected a wrong client secret to be rejected, got %s\n", response)
+	}
+	request.ClientSecret = registration.ClientSecret
+	if _, response := client.Token(t, request); response.Status != http.StatusOK {
+		t.Fatalf("expected client_secret_post client to sign users in, got %s\n", response)
+	}
+}
+
+func TestClientRegistrationAuthMethodsSupported(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	var configuration model.OpenIDConfigurationST
+	if response := ApiRequest(t, http.MethodGet, "/.well-known/openid-configuration", tenent.Headers(), nil, &configuration); response.Status != http.StatusOK {
+		t.Fatalf("could not get openid configuration: %s\n", response)
+	}
+	if !slices.Equal(configuration.TokenEndpointAuthMethodsSupported, model.TokenEndpointAuthMethods) {
+		t.Fatalf("expected only the enforced token endpoint auth methods, got %v\n", configuration.TokenEndpointAuthMethodsSupported)
+	}
+	// tenents created by admins don't authenticate at the token endpoint
+	if _, response := tenent.PasswordToken(t, CreateTestUser(t, tenent.Application.Id)); response.Status != http.StatusOK {
+		t.Fatalf("expected a tenent without client authentication to sign users in, got %s\n", response)
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/google/uuid"
+)
+
+// addEmail adds an email to the signed in user and returns it and the confirmation sent to it
+func addEmail(t *testing.T, tenent *TestTenentST, bearer string) (model.EmailST, service.MessageST) {
+	t.Helper()
+	var email model.EmailST
+	if response := ApiRequest(t, http.MethodPost, "/user/emails", Bearer(bearer), model.CreateEmailST{Email: "user-" + uuid.NewString() + "@example.com"}, &email); response.Status != http.StatusCreated {
+		t.Fatalf("could not add email: %s\n", response)
+	}
+	return email, tenent.Messages.Next(t, service.MessageKindConfirmation, email.Email)
+}
+
+func confirmEmail(t *testing.T, bearer string, email model.EmailST, code string) ApiResponseST {
+	t.Helper()
+	return ApiRequest(t, http.MethodPatch, fmt.Sprintf("/user/emails/%d/confirm", email.Id), Bearer(bearer), model.ConfirmEmailST{Token: code}, nil)
+}
+
+func confirmLink(t *testing.T, tenent *TestTenentST, token string) ApiResponseST {
+	t.Helper()
+	return ApiRequest(t, http.MethodPost, "/confirmation", tenent.Headers(), model.ConfirmationST{Token: token}, nil)
+}
+
+func emailConfirmed(t *testing.T, userId int32, email model.EmailST) bool {
+	t.Helper()
+	row, err := repository.GetEmailById(userId, email.Id)
+	if err != nil || row == nil {
+		t.Fatalf("could not get email: %s\n", err)
+	}
+	return row.Confirmed
+}
+
+func TestConfirmationCode(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user).AccessToken
+
+	email, message := addEmail(t, tenent, bearer)
+	if response := confirmEmail(t, bearer, email, Data(t, message, "code")); response.Status != http.StatusOK || !emailConfirmed(t, user.User.Id, email) {
+		t.Fatalf("expected the code to confirm the email, got %s\n", response)
+	}
+	if response := confirmLink(t, tenent, Data(t, message, "token")); response.Status != http.StatusGone || !response.HasError("token", "expired") {
+		t.Fatalf("expected the link of a used code to be rejected, got %s\n", response)
+	}
+
+	var phoneNumber model.PhoneNumberST
+	if response := ApiRequest(t, http.MethodPost, "/user/phone-numbers", Bearer(bearer), model.CreatePhoneNumberST{PhoneNumber: "+14155552671"}, &phoneNumber); response.Status != http.StatusCreated {
+		t.Fatalf("could not add phone number: %s\n", response)
+	}
+	message = tenent.Messages.Next(t, service.MessageKindConfirmation, phoneNumber.PhoneNumber)
+	var confirmed model.PhoneNumberST
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/user/phone-numbers/%d/confirm", phoneNumber.Id), Bearer(bearer), model.ConfirmPhoneNumberST{Token: Data(t, message, "code")}, &confirmed); response.Status != http.StatusOK || !confirmed.Confirmed {
+		t.Fatalf("expected the code to confirm the phone number, got %s\n", response)
+	}
+}
+
+func TestConfirmationLink(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user).AccessToken
+
+	email, message := addEmail(t, tenent, bearer)
+	token := Data(t, message, "token")
+	if response := confirmLink(t, tenent, token); response.Status != http.StatusNoContent || !emailConfirmed(t, user.User.Id, email) {
+		t.Fatalf("expected the link to confirm the email, got %s\n", response)
+	}
+	if response := confirmLink(t, tenent, token); response.Status != http.StatusGone || !response.HasError("token", "expired") {
+		t.Fatalf("expected the used link to be rejected, got %s\n", response)
+	}
+}
+
+func TestConfirmationCodeRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user).AccessToken
+
+	email, message := addEmail(t, tenent, bearer)
+	code := Data(t, message, "code")
+	for range config.Get().Confirmation.CodeMaxAttempts {
+		if response := confirmEmail(t, bearer, email, wrongCode(code)); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+			t.Fatalf("expected wrong code to be rejected, got %s\n", response)
+		}
+	}
+	if response := confirmEmail(t, bearer, email, code); response.Status != http.StatusGone || !response.HasError("token", "exceeded") {
+		t.Fatalf("expected the code to stop working after too many attempts, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/user/emails/%d/send-confirmation", email.Id), Bearer(bearer), nil, nil); response.Status != http.StatusTooManyRequests || !response.HasError("confirmation", "rateLimited") {
+		t.Fatalf("expected resending right away to be rate limited, got %s\n", response)
+	}
+
+	expired, message := addEmail(t, tenent, bearer)
+	if _, err := repository.Execute(`UPDATE confirmation_codes SET expires_at = NOW() WHERE email_id=$1;`, expired.Id); err != nil {
+		t.Fatalf("could not expire confirmation code: %s\n", err)
+	}
+	if response := confirmEmail(t, bearer, expired, Data(t, message, "code")); response.Status != http.StatusGone || !response.HasError("token", "expired") {
+		t.Fatalf("expected expired code to be rejected, got %s\n", response)
+	}
+	if response := confirmLink(t, tenent, Data(t, message, "token")); response.Status != http.StatusGone || !response.HasError("token", "expired") {
+		t.Fatalf("expected the link of an expired code to be rejected, got %s\n", response)
+	}
+	if response := confirmLink(t, tenent, "invalid"); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+		t.Fatalf("expected invalid link token to be rejected, got %s\n", response)
+	}
+	if emailConfirmed(t, user.User.Id, email) || emailConfirmed(t, user.User.Id, expired) {
+		t.Fatalf("expected the emails to stay unconfirmed\n")
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+func createThirdPartyTenent(t *testing.T) *TestTenentST {
+	t.Helper()
+	thirdParty := true
+	return CreateTestTenent(t, repository.CreateTenentST{ThirdParty: &thirdParty})
+}
+
+// consent approves the scopes of the consent token
+func consent(t *testing.T, token model.TokenST) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	var result model.TokenST
+	response := ApiRequest(t, http.MethodPost, "/consent", Bearer(token.AccessToken), nil, &result)
+	return result, response
+}
+
+func TestConsent(t *testing.T) {
+	tenent := createThirdPartyTenent(t)
+	user := CreateTestUser(t, tenent.Application.Id)
+
+	token, response := tenent.PasswordToken(t, user)
+	if response.Status != http.StatusOK || token.TokenType != jwt.ConsentTokenType || token.RefreshToken != nil {
+		t.Fatalf("expected a consent token, got %s %s\n", response, token.TokenType)
+	}
+	bearer, response := consent(t, token)
+	if response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType || bearer.RefreshToken == nil {
+		t.Fatalf("expected consent to issue tokens, got %s\n", response)
+	}
+	var consents []model.UserConsentST
+	if response := ApiRequest(t, http.MethodGet, "/user/consents", Bearer(bearer.AccessToken), nil, &consents); response.Status != http.StatusOK || len(consents) != 1 || consents[0].TenentId != tenent.Tenent.Id {
+		t.Fatalf("expected the consent to be recorded, got %s %v\n", response, consents)
+	}
+	tenent.BearerToken(t, user)
+}
+
+func TestConsentRejected(t *testing.T) {
+	tenent := createThirdPartyTenent(t)
+	user := CreateTestUser(t, tenent.Application.Id)
+
+	token, response := tenent.PasswordToken(t, user)
+	if response.Status != http.StatusOK || token.TokenType != jwt.ConsentTokenType {
+		t.Fatalf("expected a consent token, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodGet, "/user", Bearer(token.AccessToken), nil, nil); response.Status != http.StatusUnauthorized || !response.HasError("authorization", "invalid") {
+		t.Fatalf("expected consent token to not authorize the api, got %s\n", response)
+	}
+	bearer, response := consent(t, token)
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected consent to issue tokens, got %s\n", response)
+	}
+	if _, response := consent(t, bearer); response.Status != http.StatusUnauthorized || !response.HasError("authorization", "invalid") {
+		t.Fatalf("expected a bearer token to not be a consent token, got %s\n", response)
+	}
+}
+
+func TestConsentRevoked(t *testing.T) {
+	tenent := createThirdPartyTenent(t)
+	user := CreateTestUser(t, tenent.Application.Id)
+	token, _ := tenent.PasswordToken(t, user)
+	bearer, response := consent(t, token)
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected consent to issue tokens, got %s\n", response)
+	}
+
+	var consents []model.UserConsentST
+	if response := ApiRequest(t, http.MethodGet, "/user/consents", Bearer(bearer.AccessToken), nil, &consents); response.Status != http.StatusOK || len(consents) != 1 {
+		t.Fatalf("expected the consent to be recorded, got %s %v\n", response, consents)
+	}
+	if response := ApiRequest(t, http.MethodDelete, fmt.Sprintf("/user/consents/%d", consents[0].Id), Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not revoke consent: %s\n", response)
+	}
+	if _, response := tenent.Token(t, model.TokenRequestST{
+		GrantType:    model.RefreshTokenGrantType,
+		RefreshToken: *bearer.RefreshToken,
+	}); response.Status != http.StatusUnauthorized || !response.HasError("refresh_token", "invalid") {
+		t.Fatalf("expected refresh token to be rejected after consent was revoked, got %s\n", response)
+	}
+	if token, response := tenent.PasswordToken(t, user); response.Status != http.StatusOK || token.TokenType != jwt.ConsentTokenType {
+		t.Fatalf("expected consent to be asked for again, got %s %s\n", response, token.TokenType)
+	}
+}
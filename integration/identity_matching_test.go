@@ -0,0 +1,126 @@
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// setPrimaryEmail gives the user email as their confirmed primary email
+func setPrimaryEmail(t *testing.T, user *TestUserST, email string) {
+	t.Helper()
+	row, err := repository.CreateEmail(user.User.ApplicationId, user.User.Id, email)
+	if err != nil {
+		t.Fatalf("could not create email: %s\n", err)
+	}
+	if _, err := repository.Execute(`UPDATE emails SET confirmed=true WHERE id=$1;`, row.Id); err != nil {
+		t.Fatalf("could not confirm email: %s\n", err)
+	}
+	if _, err := repository.SetPrimaryEmail(user.User.Id, row.Id); err != nil {
+		t.Fatalf("could not set primary email: %s\n", err)
+	}
+}
+
+func signInAs(t *testing.T, tenent *TestTenentST, user *TestUserST, username string) ApiResponseST {
+	t.Helper()
+	_, response := tenent.Token(t, model.TokenRequestST{
+		GrantType: model.PasswordGrantType,
+		Username:  username,
+		Password:  user.Password,
+	})
+	return response
+}
+
+func TestIdentityMatchingUsername(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	id := uuid.NewString()
+	password := "password-" + uuid.NewString()
+	// fullwidth letters and the fi ligature are the same characters as their ascii forms under NFKC
+	result, err := repository.CreateUserWithPassword(tenent.Application.Id, "Ｂｏｂ-ﬁ-"+id, password)
+	if err != nil {
+		t.Fatalf("could not create user: %s\n", err)
+	}
+	user := &TestUserST{User: result.User, Password: password}
+	if user.User.Username != "Ｂｏｂ-ﬁ-"+id {
+		t.Fatalf("expected the username to be stored as entered, got %s\n", user.User.Username)
+	}
+
+	for _, username := range []string{"Ｂｏｂ-ﬁ-" + id, "bob-fi-" + id, "BOB-FI-" + strings.ToUpper(id), "  bob-fi-" + id + " "} {
+		if response := signInAs(t, tenent, user, username); response.Status != http.StatusOK {
+			t.Fatalf("expected %q to sign in, got %s\n", username, response)
+		}
+	}
+	if response := signInAs(t, tenent, user, "bob-f-"+id); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected another username not to sign in, got %s\n", response)
+	}
+
+	for _, username := range []string{"bob-fi-" + id, "BOB-ＦＩ-" + id} {
+		if _, err := repository.CreateUserWithPassword(tenent.Application.Id, username, password); err == nil || !repository.IsDuplicateKeyError(err) {
+			t.Fatalf("expected %q to be a duplicate username, got %v\n", username, err)
+		}
+	}
+	other := CreateTestTenent(t, repository.CreateTenentST{})
+	if _, err := repository.CreateUserWithPassword(other.Application.Id, "bob-fi-"+id, password); err != nil {
+		t.Fatalf("expected usernames to only be unique per application, got %s\n", err)
+	}
+}
+
+func TestIdentityMatchingEmail(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	local := "Mixed.Case-" + uuid.NewString()
+	setPrimaryEmail(t, user, local+"@Example.com")
+
+	for _, email := range []string{local + "@Example.com", strings.ToLower(local) + "@example.com", strings.ToUpper(local) + "@EXAMPLE.COM"} {
+		if response := signInAs(t, tenent, user, email); response.Status != http.StatusOK {
+			t.Fatalf("expected %q to sign in, got %s\n", email, response)
+		}
+	}
+
+	other := CreateTestUser(t, tenent.Application.Id)
+	if _, err := repository.CreateEmail(tenent.Application.Id, other.User.Id, " "+strings.ToLower(local)+"@example.com"); err == nil || !repository.IsDuplicateKeyError(err) {
+		t.Fatalf("expected an email differing in case to be a duplicate, got %v\n", err)
+	}
+	bearer := tenent.BearerToken(t, other)
+	if response := ApiRequest(t, http.MethodPost, "/user/emails", Bearer(bearer.AccessToken), model.CreateEmailST{Email: strings.ToUpper(local) + "@example.com"}, nil); response.Status != http.StatusBadRequest || !response.HasError("email", "duplicate") {
+		t.Fatalf("expected adding an email differing in case to be rejected, got %s\n", response)
+	}
+}
+
+func TestIdentityMatchingFoldEmailAliases(t *testing.T) {
+	fold := true
+	tenent := CreateTestTenent(t, repository.CreateTenentST{FoldEmailAliases: &fold})
+	user := CreateTestUser(t, tenent.Application.Id)
+	local := strings.ReplaceAll(uuid.NewString(), "-", "")
+	setPrimaryEmail(t, user, "jo.hn"+local+"@gmail.com")
+
+	for _, email := range []string{"john" + local + "@gmail.com", "j.o.h.n" + local + "+work@gmail.com", "JOHN" + local + "@googlemail.com"} {
+		if response := signInAs(t, tenent, user, email); response.Status != http.StatusOK {
+			t.Fatalf("expected the alias %q to sign in, got %s\n", email, response)
+		}
+	}
+	if response := signInAs(t, tenent, user, "john"+local+"@example.com"); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected another domain not to be folded, got %s\n", response)
+	}
+	other := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, other)
+	if response := ApiRequest(t, http.MethodPost, "/user/emails", Bearer(bearer.AccessToken), model.CreateEmailST{Email: "john" + local + "+other@gmail.com"}, nil); response.Status != http.StatusBadRequest || !response.HasError("email", "duplicate") {
+		t.Fatalf("expected adding an alias of a taken email to be rejected, got %s\n", response)
+	}
+
+	unfolded := CreateTestTenent(t, repository.CreateTenentST{})
+	unfoldedUser := CreateTestUser(t, unfolded.Application.Id)
+	setPrimaryEmail(t, unfoldedUser, "jo.hn"+local+"@gmail.com")
+	if response := signInAs(t, unfolded, unfoldedUser, "john"+local+"+work@gmail.com"); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected aliases not to sign in without fold_email_aliases, got %s\n", response)
+	}
+	unfoldedOther := CreateTestUser(t, unfolded.Application.Id)
+	bearer = unfolded.BearerToken(t, unfoldedOther)
+	if response := ApiRequest(t, http.MethodPost, "/user/emails", Bearer(bearer.AccessToken), model.CreateEmailST{Email: "john" + local + "+other@gmail.com"}, nil); response.Status != http.StatusCreated {
+		t.Fatalf("expected an alias to be a different email without fold_email_aliases, got %s\n", response)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// identityProviderSignIn starts signing in with the provider and returns the query the api redirected back with
+func identityProviderSignIn(t *testing.T, tenent *TestTenentST, provider repository.IdentityProviderRowST) url.Values {
+	t.Helper()
+	var authorization model.IdentityProviderAuthorizationST
+	if response := ApiRequest(t, http.MethodPost, fmt.Sprintf("/identity-providers/%d/authorize", provider.Id), tenent.Headers(), nil, &authorization); response.Status != http.StatusOK {
+		t.Fatalf("could not start identity provider sign in: %s\n", response)
+	}
+	return FollowIdentityProvider(t, authorization.AuthorizationURL)
+}
+
+func identityProviderToken(t *testing.T, tenent *TestTenentST, code string) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	return tenent.Token(t, model.TokenRequestST{
+		GrantType: model.IdentityProviderGrantType,
+		Code:      code,
+	})
+}
+
+func TestIdentityProviderSignIn(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{})
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString()})
+
+	result := identityProviderSignIn(t, tenent, provider)
+	token, response := identityProviderToken(t, tenent, result.Get("identity_provider_code"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected identity provider code to sign in, got %s %v\n", response, result)
+	}
+	created := Claims(t, token).Subject
+
+	result = identityProviderSignIn(t, tenent, provider)
+	token, response = identityProviderToken(t, tenent, result.Get("identity_provider_code"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected identity provider code to sign in, got %s %v\n", response, result)
+	}
+	if subject := Claims(t, token).Subject; subject != created {
+		t.Fatalf("expected the linked user %d to sign in again, got %d\n", created, subject)
+	}
+}
+
+func TestIdentityProviderLinkByEmail(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	linkByEmail := true
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{LinkByEmail: &linkByEmail})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := user.ConfirmedEmail(t)
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString(), Email: email.Email, EmailVerified: true})
+
+	result := identityProviderSignIn(t, tenent, provider)
+	token, response := identityProviderToken(t, tenent, result.Get("identity_provider_code"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected identity provider code to sign in, got %s %v\n", response, result)
+	}
+	if subject := Claims(t, token).Subject; subject != user.User.Id {
+		t.Fatalf("expected user %d with the verified email to sign in, got %d\n", user.User.Id, subject)
+	}
+}
+
+func TestIdentityProviderRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	registration := false
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{RegistrationEnabled: &registration})
+
+	oidc.SignInAs(OIDCUserST{})
+	if result := identityProviderSignIn(t, tenent, provider); result.Get("identity_provider_error") != "access_denied" {
+		t.Fatalf("expected denied sign in to be rejected, got %v\n", result)
+	}
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString()})
+	if result := identityProviderSignIn(t, tenent, provider); result.Get("identity_provider_error") != "registration_disabled" {
+		t.Fatalf("expected unknown identity to be rejected without registration, got %v\n", result)
+	}
+	if _, response := identityProviderToken(t, tenent, "invalid"); response.Status != http.StatusUnauthorized || !response.HasError("code", "invalid") {
+		t.Fatalf("expected unknown identity provider code to be rejected, got %s\n", response)
+	}
+}
+
+func TestIdentityProviderCodeReused(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{})
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString()})
+
+	code := identityProviderSignIn(t, tenent, provider).Get("identity_provider_code")
+	if _, response := identityProviderToken(t, tenent, code); response.Status != http.StatusOK {
+		t.Fatalf("expected identity provider code to sign in, got %s\n", response)
+	}
+	if _, response := identityProviderToken(t, tenent, code); response.Status != http.StatusUnauthorized || !response.HasError("code", "invalid") {
+		t.Fatalf("expected used identity provider code to be rejected, got %s\n", response)
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+func impersonate(t *testing.T, admin string, tenent *TestTenentST, user *TestUserST) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	var token model.TokenST
+	response := ApiRequest(t, http.MethodPost, fmt.Sprintf("/applications/%d/users/%d/impersonate", user.User.ApplicationId, user.User.Id), Bearer(admin), model.ImpersonateUserST{TenentId: tenent.Tenent.Id}, &token)
+	return token, response
+}
+
+func auditActions(t *testing.T, userId int32) []string {
+	t.Helper()
+	logs, err := repository.All[repository.AuditLogRowST](`SELECT * FROM audit_logs WHERE user_id=$1 ORDER BY id;`, userId)
+	if err != nil {
+		t.Fatalf("could not get audit logs: %s\n", err)
+	}
+	actions := make([]string, 0, len(logs))
+	for _, log := range logs {
+		actions = append(actions, log.Action)
+	}
+	return actions
+}
+
+func TestImpersonation(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	admin := AdminToken(t)
+
+	token, response := impersonate(t, admin.AccessToken, tenent, user)
+	if response.Status != http.StatusOK || token.RefreshToken != nil {
+		t.Fatalf("expected an impersonation token without a refresh token, got %s\n", response)
+	}
+	claims := Claims(t, token)
+	adminClaims := Claims(t, admin)
+	if claims.Subject != user.User.Id || claims.Actor == nil || claims.Actor.Subject != adminClaims.Subject || claims.Actor.ClientId != AdminClientId {
+		t.Fatalf("expected the token to be for the user acting as the admin, got %+v\n", claims)
+	}
+	var current model.UserST
+	if response := ApiRequest(t, http.MethodGet, "/user", Bearer(token.AccessToken), nil, &current); response.Status != http.StatusOK || current.Id != user.User.Id {
+		t.Fatalf("expected the impersonation token to read the user, got %s\n", response)
+	}
+	if actions := auditActions(t, user.User.Id); !slices.Contains(actions, repository.AuditActionImpersonationStarted) || !slices.Contains(actions, repository.AuditActionImpersonationUsed) {
+		t.Fatalf("expected impersonation to be audit logged, got %v\n", actions)
+	}
+}
+
+func TestImpersonationRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	admin := AdminToken(t)
+	token, response := impersonate(t, admin.AccessToken, tenent, user)
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not impersonate user: %s\n", response)
+	}
+
+	if response := ApiRequest(t, http.MethodPatch, "/user", Bearer(token.AccessToken), model.UpdateUserST{Username: "renamed"}, nil); response.Status != http.StatusForbidden || !response.HasError("authorization", "impersonated") {
+		t.Fatalf("expected the impersonation token to not change the user, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodPost, "/user/emails", Bearer(token.AccessToken), model.CreateEmailST{Email: "impersonator@example.com"}, nil); response.Status != http.StatusForbidden || !response.HasError("authorization", "impersonated") {
+		t.Fatalf("expected the impersonation token to not add emails, got %s\n", response)
+	}
+
+	thirdParty := true
+	thirdPartyTenent, err := repository.CreateTenent(tenent.Application.Id, repository.CreateTenentST{Description: "Third party", URI: "third-party", ThirdParty: &thirdParty})
+	if err != nil {
+		t.Fatalf("could not create tenent: %s\n", err)
+	}
+	if _, response := impersonate(t, admin.AccessToken, &TestTenentST{Tenent: thirdPartyTenent}, user); response.Status != http.StatusBadRequest || !response.HasError("tenent_id", "invalid") {
+		t.Fatalf("expected third party tenents to be rejected, got %s\n", response)
+	}
+	adminTenent, err := repository.GetTenentByClientId(AdminClientId)
+	if err != nil || adminTenent == nil {
+		t.Fatalf("could not get admin tenent: %s\n", err)
+	}
+	adminUser, err := repository.GetUserById(adminTenent.ApplicationId, Claims(t, admin).Subject)
+	if err != nil || adminUser == nil {
+		t.Fatalf("could not get admin: %s\n", err)
+	}
+	if _, response := impersonate(t, admin.AccessToken, &TestTenentST{Tenent: *adminTenent}, &TestUserST{User: *adminUser}); response.Status != http.StatusBadRequest || !response.HasError("applicationId", "invalid") {
+		t.Fatalf("expected admins to not be impersonated, got %s\n", response)
+	}
+}
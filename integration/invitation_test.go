@@ -0,0 +1,111 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/google/uuid"
+)
+
+// invite invites a new user to the tenent and returns the invitation and the token emailed to it
+func invite(t *testing.T, admin string, tenent *TestTenentST) (model.InvitationST, string) {
+	t.Helper()
+	username := "user-" + uuid.NewString()
+	var invitation model.InvitationST
+	if response := ApiRequest(t, http.MethodPost, fmt.Sprintf("/applications/%d/invitations", tenent.Application.Id), Bearer(admin), model.CreateInvitationST{
+		TenentId: tenent.Tenent.Id,
+		Email:    username + "@example.com",
+		Username: &username,
+	}, &invitation); response.Status != http.StatusCreated {
+		t.Fatalf("could not invite user: %s\n", response)
+	}
+	message := tenent.Messages.Next(t, service.MessageKindInvitation, invitation.Email)
+	return invitation, Data(t, message, "token")
+}
+
+func acceptInvitation(t *testing.T, tenent *TestTenentST, token, password string) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	var result model.TokenST
+	response := ApiRequest(t, http.MethodPost, "/invitations/accept", tenent.Headers(), model.AcceptInvitationST{
+		Token:                token,
+		Password:             password,
+		PasswordConfirmation: password,
+	}, &result)
+	return result, response
+}
+
+func TestInvitation(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	invitation, token := invite(t, AdminToken(t).AccessToken, tenent)
+	user, err := repository.GetUserById(tenent.Application.Id, *invitation.UserId)
+	if err != nil || user == nil || user.Status != repository.UserStatusPending {
+		t.Fatalf("expected a pending user, got %+v %v\n", user, err)
+	}
+
+	password := "password-" + uuid.NewString()
+	bearer, response := acceptInvitation(t, tenent, token, password)
+	if response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType {
+		t.Fatalf("expected accepting the invitation to sign in, got %s\n", response)
+	}
+	if claims := Claims(t, bearer); claims.Subject != user.Id {
+		t.Fatalf("expected token for user %d, got %d\n", user.Id, claims.Subject)
+	}
+	tenent.BearerToken(t, &TestUserST{User: *user, Password: password})
+	if _, response := acceptInvitation(t, tenent, token, password); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+		t.Fatalf("expected accepted invitation to be rejected, got %s\n", response)
+	}
+}
+
+func TestInvitationResent(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	admin := AdminToken(t).AccessToken
+	invitation, token := invite(t, admin, tenent)
+
+	if response := ApiRequest(t, http.MethodPost, fmt.Sprintf("/applications/%d/invitations/%d/resend", tenent.Application.Id, invitation.Id), Bearer(admin), nil, nil); response.Status != http.StatusOK {
+		t.Fatalf("could not resend invitation: %s\n", response)
+	}
+	resent := Data(t, tenent.Messages.Next(t, service.MessageKindInvitation, invitation.Email), "token")
+	password := "password-" + uuid.NewString()
+	if _, response := acceptInvitation(t, tenent, token, password); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+		t.Fatalf("expected the link sent before to be rejected, got %s\n", response)
+	}
+	if _, response := acceptInvitation(t, tenent, resent, password); response.Status != http.StatusOK {
+		t.Fatalf("expected the resent link to accept the invitation, got %s\n", response)
+	}
+}
+
+func TestInvitationRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	admin := AdminToken(t).AccessToken
+	password := "password-" + uuid.NewString()
+
+	_, token := invite(t, admin, tenent)
+	if response := ApiRequest(t, http.MethodPost, "/invitations/accept", tenent.Headers(), model.AcceptInvitationST{
+		Token:                token,
+		Password:             password,
+		PasswordConfirmation: "other-" + password,
+	}, nil); response.Status != http.StatusBadRequest || !response.HasError("password_confirmation", "mismatch") {
+		t.Fatalf("expected mismatched passwords to be rejected, got %s\n", response)
+	}
+
+	revoked, token := invite(t, admin, tenent)
+	if response := ApiRequest(t, http.MethodDelete, fmt.Sprintf("/applications/%d/invitations/%d", tenent.Application.Id, revoked.Id), Bearer(admin), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not revoke invitation: %s\n", response)
+	}
+	if _, response := acceptInvitation(t, tenent, token, password); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+		t.Fatalf("expected revoked invitation to be rejected, got %s\n", response)
+	}
+
+	expired, token := invite(t, admin, tenent)
+	if _, err := repository.Execute(`UPDATE invitations SET expires_at = NOW() WHERE id=$1;`, expired.Id); err != nil {
+		t.Fatalf("could not expire invitation: %s\n", err)
+	}
+	if _, response := acceptInvitation(t, tenent, token, password); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+		t.Fatalf("expected expired invitation to be rejected, got %s\n", response)
+	}
+}
@@ -0,0 +1,210 @@
+package test
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPEntryST is an entry in the directory, entries with a password can bind
+type LDAPEntryST struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// LDAPServerST is a directory answering simple binds and searches with and, or, not, equality and presence filters
+type LDAPServerST struct {
+	URL      string
+	listener net.Listener
+	mutex    sync.Mutex
+	entries  []LDAPEntryST
+}
+
+func NewLDAPServer(t *testing.T, entries ...LDAPEntryST) *LDAPServerST {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %s\n", err)
+	}
+	server := &LDAPServerST{
+		URL:      "ldap://" + listener.Addr().String(),
+		listener: listener,
+		entries:  entries,
+	}
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	go server.serve()
+	return server
+}
+
+// Put adds the entry or replaces the entry with the same dn
+func (server *LDAPServerST) Put(entry LDAPEntryST) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for i := range server.entries {
+		if strings.EqualFold(server.entries[i].DN, entry.DN) {
+			server.entries[i] = entry
+			return
+		}
+	}
+	server.entries = append(server.entries, entry)
+}
+
+func (server *LDAPServerST) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *LDAPServerST) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageId, _ := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		var responses []*ber.Packet
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			responses = append(responses, ldapResult(ldap.ApplicationBindResponse, server.bind(request)))
+		case ldap.ApplicationSearchRequest:
+			responses = server.search(request)
+		case ldap.ApplicationUnbindRequest:
+			return
+		default:
+			responses = append(responses, ldapResult(ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError))
+		}
+		for _, response := range responses {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "MessageID"))
+			envelope.AppendChild(response)
+			if _, err := conn.Write(envelope.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (server *LDAPServerST) bind(request *ber.Packet) uint16 {
+	if len(request.Children) < 3 {
+		return ldap.LDAPResultProtocolError
+	}
+	dn := request.Children[1].Data.String()
+	password := request.Children[2].Data.String()
+	if dn == "" && password == "" {
+		return ldap.LDAPResultSuccess
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, entry := range server.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return ldap.LDAPResultSuccess
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+func (server *LDAPServerST) search(request *ber.Packet) []*ber.Packet {
+	if len(request.Children) < 8 {
+		return []*ber.Packet{ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)}
+	}
+	baseDN := strings.ToLower(request.Children[0].Data.String())
+	filter := request.Children[6]
+	var attributes []string
+	for _, attribute := range request.Children[7].Children {
+		attributes = append(attributes, attribute.Data.String())
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	var responses []*ber.Packet
+	for _, entry := range server.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), baseDN) || !ldapFilterMatches(filter, entry) {
+			continue
+		}
+		result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+		result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+		resultAttributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+		for _, name := range attributes {
+			values, ok := ldapAttributeValues(entry, name)
+			if !ok {
+				continue
+			}
+			attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+			attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+			set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+			for _, value := range values {
+				set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+			}
+			attribute.AppendChild(set)
+			resultAttributes.AppendChild(attribute)
+		}
+		result.AppendChild(resultAttributes)
+		responses = append(responses, result)
+	}
+	return append(responses, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+}
+
+func ldapFilterMatches(filter *ber.Packet, entry LDAPEntryST) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !ldapFilterMatches(child, entry) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if ldapFilterMatches(child, entry) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(filter.Children) == 1 && !ldapFilterMatches(filter.Children[0], entry)
+	case ldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		values, _ := ldapAttributeValues(entry, filter.Children[0].Data.String())
+		for _, value := range values {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		_, ok := ldapAttributeValues(entry, filter.Data.String())
+		return ok
+	}
+	return false
+}
+
+func ldapAttributeValues(entry LDAPEntryST, name string) ([]string, bool) {
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+func ldapResult(tag ber.Tag, resultCode uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(resultCode), "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return result
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	ldapBaseDN        = "dc=example,dc=com"
+	ldapAdminDN       = "cn=admin," + ldapBaseDN
+	ldapAdminPassword = "admin-password"
+	ldapPeopleDN      = "ou=people," + ldapBaseDN
+	ldapGroupsDN      = "ou=groups," + ldapBaseDN
+)
+
+type testLDAPST struct {
+	Tenent *TestTenentST
+	Server *LDAPServerST
+	// Username and Password of the person in the directory, they are in the admins group
+	Username string
+	Password string
+	DN       string
+}
+
+// createTestLDAP creates a tenent whose application checks passwords against a directory mapping the admins group to
+// the ldap-admin role and the users group to the ldap-user role
+func createTestLDAP(t *testing.T, registration bool) *testLDAPST {
+	t.Helper()
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	username := "user-" + uuid.NewString()
+	testLDAP := &testLDAPST{
+		Tenent:   tenent,
+		Username: username,
+		Password: "password-" + uuid.NewString(),
+		DN:       "uid=" + username + "," + ldapPeopleDN,
+	}
+	testLDAP.Server = NewLDAPServer(t,
+		LDAPEntryST{DN: ldapAdminDN, Password: ldapAdminPassword},
+		LDAPEntryST{
+			DN:       testLDAP.DN,
+			Password: testLDAP.Password,
+			Attributes: map[string][]string{
+				"objectClass": {"person"},
+				"uid":         {username},
+				"mail":        {username + "@example.com"},
+				"cn":          {"Test User"},
+				"givenName":   {"Test"},
+				"sn":          {"User"},
+			},
+		},
+	)
+	testLDAP.SetGroup("admins", testLDAP.DN)
+	testLDAP.SetGroup("users")
+	for _, role := range []string{"ldap-admin", "ldap-user"} {
+		if _, err := repository.Execute(`INSERT INTO roles (application_id, description, uri) VALUES ($1, $2, $2);`, tenent.Application.Id, role); err != nil {
+			t.Fatalf("could not create role: %s\n", err)
+		}
+	}
+	bindDN := ldapAdminDN
+	bindPassword := ldapAdminPassword
+	groupSearchBase := ldapGroupsDN
+	if _, err := repository.CreateLDAPConnector(tenent.Application.Id, repository.CreateLDAPConnectorST{
+		URL:             testLDAP.Server.URL,
+		BindDN:          &bindDN,
+		BindPassword:    &bindPassword,
+		UserSearchBase:  ldapPeopleDN,
+		GroupSearchBase: &groupSearchBase,
+		GroupRoleMapping: map[string][]string{
+			"admins": {"ldap-admin"},
+			"users":  {"ldap-user"},
+		},
+		RegistrationEnabled: &registration,
+	}); err != nil {
+		t.Fatalf("could not create ldap connector: %s\n", err)
+	}
+	return testLDAP
+}
+
+// SetGroup replaces the group's members
+func (testLDAP *testLDAPST) SetGroup(name string, members ...string) {
+	testLDAP.Server.Put(LDAPEntryST{
+		DN: "cn=" + name + "," + ldapGroupsDN,
+		Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"cn":          {name},
+			"member":      members,
+		},
+	})
+}
+
+func (testLDAP *testLDAPST) Token(t *testing.T, password string) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	return testLDAP.Tenent.Token(t, model.TokenRequestST{
+		GrantType: model.PasswordGrantType,
+		Username:  testLDAP.Username,
+		Password:  password,
+	})
+}
+
+func userRoleURIs(t *testing.T, userId int32) []string {
+	t.Helper()
+	roles, err := repository.GetUserRoles(userId)
+	if err != nil {
+		t.Fatalf("could not get user roles: %s\n", err)
+	}
+	uris := make([]string, 0, len(roles))
+	for _, role := range roles {
+		uris = append(uris, role.URI)
+	}
+	return uris
+}
+
+func TestLDAPSignIn(t *testing.T) {
+	testLDAP := createTestLDAP(t, true)
+
+	token, response := testLDAP.Token(t, testLDAP.Password)
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected directory password to sign in, got %s\n", response)
+	}
+	userId := Claims(t, token).Subject
+	user, err := repository.GetUserById(testLDAP.Tenent.Application.Id, userId)
+	if err != nil || user == nil {
+		t.Fatalf("could not get user: %s\n", err)
+	}
+	if user.Username != testLDAP.Username || !user.LDAPBacked || user.LDAPDN == nil || *user.LDAPDN != testLDAP.DN {
+		t.Fatalf("expected the user to be provisioned from the directory, got %+v\n", user)
+	}
+
+	token, response = testLDAP.Token(t, testLDAP.Password)
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected directory password to sign in again, got %s\n", response)
+	}
+	if subject := Claims(t, token).Subject; subject != userId {
+		t.Fatalf("expected the provisioned user %d to sign in again, got %d\n", userId, subject)
+	}
+}
+
+func TestLDAPGroupMapping(t *testing.T) {
+	testLDAP := createTestLDAP(t, true)
+
+	token, response := testLDAP.Token(t, testLDAP.Password)
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected directory password to sign in, got %s\n", response)
+	}
+	userId := Claims(t, token).Subject
+	if roles := userRoleURIs(t, userId); !slices.Equal(roles, []string{"ldap-admin"}) {
+		t.Fatalf("expected the admins group to map to ldap-admin, got %v\n", roles)
+	}
+
+	testLDAP.SetGroup("admins")
+	testLDAP.SetGroup("users", testLDAP.DN)
+	if _, response := testLDAP.Token(t, testLDAP.Password); response.Status != http.StatusOK {
+		t.Fatalf("expected directory password to sign in, got %s\n", response)
+	}
+	if roles := userRoleURIs(t, userId); !slices.Equal(roles, []string{"ldap-user"}) {
+		t.Fatalf("expected roles to follow the user's groups, got %v\n", roles)
+	}
+}
+
+func TestLDAPRejected(t *testing.T) {
+	testLDAP := createTestLDAP(t, true)
+	if _, response := testLDAP.Token(t, "wrong-password"); response.Status != http.StatusUnauthorized || !response.HasError("password", "invalid") {
+		t.Fatalf("expected wrong directory password to be rejected, got %s\n", response)
+	}
+	if user, err := repository.GetUserByLDAPDN(testLDAP.Tenent.Application.Id, testLDAP.DN); err != nil || user != nil {
+		t.Fatalf("expected no user to be provisioned, got %v %v\n", user, err)
+	}
+	if _, response := testLDAP.Tenent.Token(t, model.TokenRequestST{
+		GrantType: model.PasswordGrantType,
+		Username:  "unknown-" + uuid.NewString(),
+		Password:  testLDAP.Password,
+	}); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected unknown directory user to be rejected, got %s\n", response)
+	}
+}
+
+func TestLDAPRegistrationDisabled(t *testing.T) {
+	testLDAP := createTestLDAP(t, false)
+	if _, response := testLDAP.Token(t, testLDAP.Password); response.Status != http.StatusUnauthorized || !response.HasError("username", "invalid") {
+		t.Fatalf("expected directory user to not be provisioned, got %s\n", response)
+	}
+	user, err := repository.GetUserByLDAPDN(testLDAP.Tenent.Application.Id, testLDAP.DN)
+	if err != nil || user != nil {
+		t.Fatalf("expected no user to be provisioned, got %v %v\n", user, err)
+	}
+}
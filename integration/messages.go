@@ -0,0 +1,86 @@
+package test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aicacia/auth/api/app/service"
+)
+
+// MessageServerST receives the emails and text messages a tenent sends
+type MessageServerST struct {
+	Server *httptest.Server
+	// Secret is the tenent's client secret the messages are signed with
+	Secret   string
+	messages chan service.MessageST
+}
+
+func NewMessageServer(t *testing.T) *MessageServerST {
+	messageServer := &MessageServerST{
+		messages: make(chan service.MessageST, 64),
+	}
+	messageServer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte(messageServer.Secret))
+		mac.Write(body)
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(r.Header.Get("X-Signature"))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var message service.MessageST
+		if err := json.Unmarshal(body, &message); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		messageServer.messages <- message
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(messageServer.Server.Close)
+	return messageServer
+}
+
+// Next waits for the next message, which must be of kind and sent to to
+func (messageServer *MessageServerST) Next(t *testing.T, kind, to string) service.MessageST {
+	t.Helper()
+	select {
+	case message := <-messageServer.messages:
+		if message.Kind != kind || message.To != to {
+			t.Fatalf("expected %s message to %s, got %s message to %s\n", kind, to, message.Kind, message.To)
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s message sent to %s\n", kind, to)
+	}
+	return service.MessageST{}
+}
+
+// None fails if a message was sent
+func (messageServer *MessageServerST) None(t *testing.T) {
+	t.Helper()
+	select {
+	case message := <-messageServer.messages:
+		t.Fatalf("expected no message, got %s message to %s\n", message.Kind, message.To)
+	case <-time.After(time.Second):
+	}
+}
+
+// Data returns the message's data value for key as a string
+func Data(t *testing.T, message service.MessageST, key string) string {
+	t.Helper()
+	value, ok := message.Data[key].(string)
+	if !ok {
+		t.Fatalf("%s message has no %s\n", message.Kind, key)
+	}
+	return value
+}
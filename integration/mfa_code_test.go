@@ -0,0 +1,117 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+)
+
+// enableTestEmailMFA enables email MFA for the user through the api
+func enableTestEmailMFA(t *testing.T, tenent *TestTenentST, user *TestUserST) repository.EmailRowST {
+	t.Helper()
+	email := user.ConfirmedEmail(t)
+	bearer := tenent.BearerToken(t, user)
+	var mfa model.MFAST
+	if response := ApiRequest(t, http.MethodPatch, "/user/mfa/email", Bearer(bearer.AccessToken), nil, &mfa); response.Status != http.StatusOK {
+		t.Fatalf("could not enable email mfa: %s\n", response)
+	}
+	return email
+}
+
+// wrongCode changes the first digit of code
+func wrongCode(code string) string {
+	return fmt.Sprintf("%d%s", (code[0]-'0'+1)%10, code[1:])
+}
+
+func TestEmailMFACode(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := enableTestEmailMFA(t, tenent, user)
+
+	token := mfaToken(t, tenent, user)
+	code := Data(t, tenent.Messages.Next(t, service.MessageKindMFACode, email.Email), "code")
+	bearer, response := validateMFA(t, token, repository.MFATypeEmail, code)
+	if response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType {
+		t.Fatalf("expected email code to sign in, got %s\n", response)
+	}
+}
+
+func TestSMSMFACode(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	phoneNumber := user.ConfirmedPhoneNumber(t, "+14155552671")
+	bearer := tenent.BearerToken(t, user)
+	if response := ApiRequest(t, http.MethodPatch, "/user/mfa/sms", Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusOK {
+		t.Fatalf("could not enable sms mfa: %s\n", response)
+	}
+
+	token := mfaToken(t, tenent, user)
+	code := Data(t, tenent.Messages.Next(t, service.MessageKindMFACode, phoneNumber.PhoneNumber), "code")
+	bearer, response := validateMFA(t, token, repository.MFATypeSMS, code)
+	if response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType {
+		t.Fatalf("expected sms code to sign in, got %s\n", response)
+	}
+}
+
+func TestMFACodeRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := enableTestEmailMFA(t, tenent, user)
+
+	token := mfaToken(t, tenent, user)
+	code := Data(t, tenent.Messages.Next(t, service.MessageKindMFACode, email.Email), "code")
+	for range config.Get().MFA.CodeMaxAttempts {
+		if _, response := validateMFA(t, token, repository.MFATypeEmail, wrongCode(code)); response.Status != http.StatusForbidden || !response.HasError("mfa", "invalid") {
+			t.Fatalf("expected wrong code to be rejected, got %s\n", response)
+		}
+	}
+	if _, response := validateMFA(t, token, repository.MFATypeEmail, code); response.Status != http.StatusTooManyRequests || !response.HasError("mfa", "exceeded") {
+		t.Fatalf("expected code to be rejected after too many attempts, got %s\n", response)
+	}
+}
+
+func TestMFACodeExpired(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := enableTestEmailMFA(t, tenent, user)
+
+	token := mfaToken(t, tenent, user)
+	code := Data(t, tenent.Messages.Next(t, service.MessageKindMFACode, email.Email), "code")
+	if _, err := repository.Execute(`UPDATE mfa_codes SET expires_at=NOW() WHERE user_id=$1;`, user.User.Id); err != nil {
+		t.Fatalf("could not expire mfa code: %s\n", err)
+	}
+	if _, response := validateMFA(t, token, repository.MFATypeEmail, code); response.Status != http.StatusGone || !response.HasError("mfa", "expired") {
+		t.Fatalf("expected expired code to be rejected, got %s\n", response)
+	}
+}
+
+func TestMFACodeWithoutEndpoint(t *testing.T) {
+	noEndpoint := ""
+	tenent := CreateTestTenent(t, repository.CreateTenentST{
+		EmailEndpoint: &noEndpoint,
+	})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := user.ConfirmedEmail(t)
+	bearer := tenent.BearerToken(t, user)
+	if response := ApiRequest(t, http.MethodPatch, "/user/mfa/email", Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusServiceUnavailable || !response.HasError("mfa", "unavailable") {
+		t.Fatalf("expected email mfa to be unavailable without an endpoint, got %s\n", response)
+	}
+
+	if _, err := repository.UpsertMFA(user.User.Id, email.Id, repository.MFATypeEmail); err != nil {
+		t.Fatalf("could not enable email mfa: %s\n", err)
+	}
+	token := mfaToken(t, tenent, user)
+	if token.TokenType != jwt.MFATokenType+":"+repository.MFATypeEmail {
+		t.Fatalf("expected an email mfa token when the code can't be sent, got %s\n", token.TokenType)
+	}
+	if response := ApiRequest(t, http.MethodPost, "/mfa/resend", Bearer(token.AccessToken), nil, nil); response.Status != http.StatusServiceUnavailable || !response.HasError("mfa", "unavailable") {
+		t.Fatalf("expected resend to report the mfa code can't be sent, got %s\n", response)
+	}
+	tenent.Messages.None(t)
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+	"github.com/xlzd/gotp"
+)
+
+func TestMFAMethods(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := enableTestEmailMFA(t, tenent, user)
+	totp := enableTestTOTP(t, tenent, user)
+
+	token := mfaToken(t, tenent, user)
+	if len(token.MFAMethods) != 3 || token.MFAMethods[0] != repository.MFATypeEmail || token.MFAMethods[1] != repository.MFATypeTOTP || token.MFAMethods[2] != repository.MFATypeRecoveryCode {
+		t.Fatalf("expected email, totp then recovery codes, got %v\n", token.MFAMethods)
+	}
+	tenent.Messages.Next(t, service.MessageKindMFACode, email.Email)
+	bearer, response := validateMFA(t, token, repository.MFATypeTOTP, gotp.NewDefaultTOTP(totp.Secret).Now())
+	if response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType {
+		t.Fatalf("expected totp to sign in, got %s\n", response)
+	}
+
+	var mfa model.MFAST
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/user/mfa/%s/%d/preferred", repository.MFATypeTOTP, totp.Id), Bearer(bearer.AccessToken), nil, &mfa); response.Status != http.StatusOK {
+		t.Fatalf("could not set preferred mfa: %s\n", response)
+	}
+	token = mfaToken(t, tenent, user)
+	if token.MFAMethods[0] != repository.MFATypeTOTP {
+		t.Fatalf("expected totp to be preferred, got %v\n", token.MFAMethods)
+	}
+	tenent.Messages.None(t)
+}
+
+func TestMFAMethodsRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	totp := enableTestTOTP(t, tenent, user)
+
+	token := mfaToken(t, tenent, user)
+	if _, response := validateMFA(t, token, repository.MFATypeSMS, "000000"); response.Status != http.StatusForbidden || !response.HasError("mfa", "disabled") {
+		t.Fatalf("expected a method the user didn't enroll to be rejected, got %s\n", response)
+	}
+	bearer, response := validateMFA(t, token, repository.MFATypeTOTP, gotp.NewDefaultTOTP(totp.Secret).Now())
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected totp to sign in, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/user/mfa/%s/%d/preferred", repository.MFATypeSMS, totp.Id), Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusNotFound || !response.HasError("mfa", "notFound") {
+		t.Fatalf("expected a method the user didn't enroll to not be found, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/user/mfa/unknown/%d/preferred", totp.Id), Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusBadRequest || !response.HasError("type", "invalid") {
+		t.Fatalf("expected an unknown mfa type to be rejected, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodDelete, fmt.Sprintf("/user/mfa/unknown/%d", totp.Id), Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusBadRequest || !response.HasError("type", "invalid") {
+		t.Fatalf("expected an unknown mfa type to be rejected, got %s\n", response)
+	}
+}
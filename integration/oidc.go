@@ -0,0 +1,225 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const oidcProviderKeyId = "test"
+
+// OIDCUserST is who signs in at the provider, users without a subject deny access
+type OIDCUserST struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type oidcCodeST struct {
+	user          OIDCUserST
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// OIDCProviderST is an OpenID provider supporting discovery, the authorization code flow with PKCE and id tokens
+type OIDCProviderST struct {
+	Server       *httptest.Server
+	ClientId     string
+	ClientSecret string
+	key          *rsa.PrivateKey
+	mutex        sync.Mutex
+	user         OIDCUserST
+	codes        map[string]oidcCodeST
+}
+
+func NewOIDCProvider(t *testing.T) *OIDCProviderST {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate key: %s\n", err)
+	}
+	provider := &OIDCProviderST{
+		ClientId:     uuid.NewString(),
+		ClientSecret: uuid.NewString(),
+		key:          key,
+		codes:        make(map[string]oidcCodeST),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("GET /authorize", provider.authorize)
+	mux.HandleFunc("POST /token", provider.token)
+	mux.HandleFunc("GET /jwks", provider.jwks)
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Server.Close)
+	return provider
+}
+
+// SignInAs sets who signs in at the provider next
+func (provider *OIDCProviderST) SignInAs(user OIDCUserST) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	provider.user = user
+}
+
+// Create adds the provider to the tenent, create's issuer and client are always the provider's
+func (provider *OIDCProviderST) Create(t *testing.T, tenent *TestTenentST, create repository.CreateIdentityProviderST) repository.IdentityProviderRowST {
+	t.Helper()
+	if create.Name == "" {
+		create.Name = "Test"
+	}
+	create.Kind = repository.IdentityProviderKindOIDC
+	create.Issuer = &provider.Server.URL
+	create.ClientId = provider.ClientId
+	create.ClientSecret = provider.ClientSecret
+	row, err := repository.CreateIdentityProvider(tenent.Application.Id, tenent.Tenent.Id, create)
+	if err != nil {
+		t.Fatalf("could not create identity provider: %s\n", err)
+	}
+	return row
+}
+
+func (provider *OIDCProviderST) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 provider.Server.URL,
+		"authorization_endpoint": provider.Server.URL + "/authorize",
+		"token_endpoint":         provider.Server.URL + "/token",
+		"jwks_uri":               provider.Server.URL + "/jwks",
+	})
+}
+
+func (provider *OIDCProviderST) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURL, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("client_id") != provider.ClientId || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	result := redirectURL.Query()
+	result.Set("state", query.Get("state"))
+	if provider.user.Subject == "" {
+		result.Set("error", "access_denied")
+	} else {
+		code, err := util.GenerateRandomHex(16)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		provider.codes[code] = oidcCodeST{
+			user:          provider.user,
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+			redirectURI:   redirectURL.String(),
+		}
+		result.Set("code", code)
+	}
+	redirectURL.RawQuery = result.Encode()
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+func (provider *OIDCProviderST) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	provider.mutex.Lock()
+	code, ok := provider.codes[r.PostForm.Get("code")]
+	delete(provider.codes, r.PostForm.Get("code"))
+	provider.mutex.Unlock()
+	codeChallenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok ||
+		r.PostForm.Get("client_id") != provider.ClientId ||
+		r.PostForm.Get("client_secret") != provider.ClientSecret ||
+		r.PostForm.Get("redirect_uri") != code.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(codeChallenge[:]) != code.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	now := time.Now()
+	idToken := gojwt.NewWithClaims(gojwt.SigningMethodRS256, gojwt.MapClaims{
+		"iss":            provider.Server.URL,
+		"aud":            provider.ClientId,
+		"sub":            code.user.Subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.user.Email,
+		"email_verified": code.user.EmailVerified,
+	})
+	idToken.Header["kid"] = oidcProviderKeyId
+	signed, err := idToken.SignedString(provider.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": uuid.NewString(),
+		"token_type":   "Bearer",
+		"id_token":     signed,
+	})
+}
+
+func (provider *OIDCProviderST) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": oidcProviderKeyId,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(provider.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(provider.key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// FollowIdentityProvider signs in at the provider with the authorization url and follows its redirect back through the
+// api's callback, returning the query the api redirects to the tenent's authorization website with
+func FollowIdentityProvider(t *testing.T, authorizationURL string) url.Values {
+	t.Helper()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	callbackURL := followRedirect(t, client, authorizationURL, http.StatusFound)
+	authorizationWebsiteURL, err := url.Parse(followRedirect(t, client, callbackURL, http.StatusSeeOther))
+	if err != nil {
+		t.Fatalf("could not parse redirect: %s\n", err)
+	}
+	return authorizationWebsiteURL.Query()
+}
+
+func followRedirect(t *testing.T, client *http.Client, from string, status int) string {
+	t.Helper()
+	res, err := client.Get(from)
+	if err != nil {
+		t.Fatalf("could not get %s: %s\n", from, err)
+	}
+	res.Body.Close()
+	if res.StatusCode != status {
+		t.Fatalf("expected %s to redirect with %d, got %d\n", from, status, res.StatusCode)
+	}
+	return res.Header.Get("Location")
+}
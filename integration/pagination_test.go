@@ -0,0 +1,146 @@
+package test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// createPaginationUsers creates users in the application whose usernames sort in the order they are returned
+func createPaginationUsers(t *testing.T, applicationId int32, count int) []*TestUserST {
+	t.Helper()
+	suffix := uuid.NewString()
+	users := make([]*TestUserST, 0, count)
+	for i := range count {
+		users = append(users, createSearchUser(t, applicationId, string(rune('b'+i))+"-"+suffix))
+	}
+	return users
+}
+
+// pageUsers lists a page of users by username and expects exactly users
+func pageUsers(t *testing.T, applicationId int32, query url.Values, users ...*TestUserST) model.PaginationST[model.UserST] {
+	t.Helper()
+	query.Set("sort", "username")
+	if !query.Has("order") {
+		query.Set("order", "asc")
+	}
+	return expectUsers(t, applicationId, query, users...)
+}
+
+func TestPaginationCursors(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	users := createPaginationUsers(t, tenent.Application.Id, 5)
+
+	first := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}}, users[0], users[1])
+	if first.Next == nil || first.Prev != nil || !first.HasMore {
+		t.Fatalf("expected the first page to only have a next cursor, got %+v\n", first)
+	}
+	second := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "cursor": {*first.Next}}, users[2], users[3])
+	if second.Next == nil || second.Prev == nil || !second.HasMore {
+		t.Fatalf("expected the second page to have both cursors, got %+v\n", second)
+	}
+	last := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "cursor": {*second.Next}}, users[4])
+	if last.Next != nil || last.Prev == nil || last.HasMore {
+		t.Fatalf("expected the last page to only have a prev cursor, got %+v\n", last)
+	}
+
+	back := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "cursor": {*last.Prev}}, users[2], users[3])
+	if back.Next == nil || back.Prev == nil {
+		t.Fatalf("expected going back to the second page to have both cursors, got %+v\n", back)
+	}
+	back = pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "cursor": {*back.Prev}}, users[0], users[1])
+	if back.Next == nil || back.Prev != nil {
+		t.Fatalf("expected going back to the first page to only have a next cursor, got %+v\n", back)
+	}
+
+	descending := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "order": {"desc"}}, users[4], users[3])
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "order": {"desc"}, "cursor": {*descending.Next}}, users[2], users[1])
+}
+
+func TestPaginationCursorRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	users := createPaginationUsers(t, tenent.Application.Id, 3)
+	first := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"1"}}, users[0])
+	if first.Next == nil {
+		t.Fatalf("expected a next cursor, got %+v\n", first)
+	}
+
+	for _, query := range []url.Values{
+		{"sort": {"username"}, "order": {"desc"}, "cursor": {*first.Next}},
+		{"sort": {"created_at"}, "order": {"asc"}, "cursor": {*first.Next}},
+		{"cursor": {*first.Next}},
+		{"sort": {"username"}, "order": {"asc"}, "cursor": {"not-a-cursor"}},
+	} {
+		if _, response := searchUsers(t, tenent.Application.Id, query); response.Status != http.StatusBadRequest || !response.HasError("cursor", "invalid") {
+			t.Fatalf("expected cursor to be rejected with %s, got %s\n", query.Encode(), response)
+		}
+	}
+}
+
+func TestPaginationOffsetAndCursor(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	users := createPaginationUsers(t, tenent.Application.Id, 4)
+
+	first := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}}, users[0], users[1])
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "offset": {"2"}}, users[2], users[3])
+
+	// a user sorting first shifts offsets but not cursors
+	inserted := createSearchUser(t, tenent.Application.Id, "a-"+uuid.NewString())
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "offset": {"2"}}, users[1], users[2])
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "cursor": {*first.Next}}, users[2], users[3])
+	// the offset is ignored with a cursor
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "offset": {"3"}, "cursor": {*first.Next}}, users[2], users[3])
+
+	page := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "offset": {"1"}}, users[0], users[1])
+	if page.Prev == nil {
+		t.Fatalf("expected a page after an offset to have a prev cursor, got %+v\n", page)
+	}
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"2"}, "cursor": {*page.Prev}}, inserted)
+}
+
+func TestPaginationTotal(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	users := createPaginationUsers(t, tenent.Application.Id, 3)
+
+	page := pageUsers(t, tenent.Application.Id, url.Values{"limit": {"1"}}, users[0])
+	if page.Total != nil || page.TotalEstimated {
+		t.Fatalf("expected no total unless asked, got %+v\n", page)
+	}
+	page = pageUsers(t, tenent.Application.Id, url.Values{"limit": {"1"}, "total": {"exact"}}, users[0])
+	if page.Total == nil || *page.Total != 3 || page.TotalEstimated {
+		t.Fatalf("expected an exact total of 3, got %+v\n", page)
+	}
+	page = pageUsers(t, tenent.Application.Id, url.Values{"limit": {"1"}, "total": {"exact"}, "username": {"c"}}, users[1])
+	if page.Total == nil || *page.Total != 1 {
+		t.Fatalf("expected the total to count the filtered users, got %+v\n", page)
+	}
+	page = pageUsers(t, tenent.Application.Id, url.Values{"limit": {"1"}, "total": {"estimated"}}, users[0])
+	if page.Total == nil || *page.Total < 0 || !page.TotalEstimated {
+		t.Fatalf("expected an estimated total, got %+v\n", page)
+	}
+	if _, response := searchUsers(t, tenent.Application.Id, url.Values{"total": {"all"}}); response.Status != http.StatusBadRequest || !response.HasError("total", "invalid") {
+		t.Fatalf("expected an unknown total to be rejected, got %s\n", response)
+	}
+}
+
+func TestPaginationLimits(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	users := createPaginationUsers(t, tenent.Application.Id, 4)
+	SetConfig(t, "pagination.default_limit", 2)
+	SetConfig(t, "pagination.max_limit", 3)
+
+	pageUsers(t, tenent.Application.Id, url.Values{}, users[0], users[1])
+	pageUsers(t, tenent.Application.Id, url.Values{"limit": {"100"}}, users[0], users[1], users[2])
+	for _, query := range []url.Values{{"limit": {"0"}}, {"limit": {"-1"}}} {
+		if _, response := searchUsers(t, tenent.Application.Id, query); response.Status != http.StatusBadRequest || !response.HasError("limit", "invalid") {
+			t.Fatalf("expected limit to be rejected with %s, got %s\n", query.Encode(), response)
+		}
+	}
+	if _, response := searchUsers(t, tenent.Application.Id, url.Values{"offset": {"-1"}}); response.Status != http.StatusBadRequest || !response.HasError("offset", "invalid") {
+		t.Fatalf("expected a negative offset to be rejected, got %s\n", response)
+	}
+}
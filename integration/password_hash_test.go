@@ -0,0 +1,77 @@
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setEncryptedPassword(t *testing.T, user *TestUserST, hash string) {
+	t.Helper()
+	if _, err := repository.Execute(`UPDATE users SET encrypted_password=$2 WHERE id=$1;`, user.User.Id, hash); err != nil {
+		t.Fatalf("could not set password hash: %s\n", err)
+	}
+}
+
+// importedUser gives the user a bcrypt hash of their password, like users imported from another system
+func importedUser(t *testing.T, user *TestUserST) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("could not hash password: %s\n", err)
+	}
+	setEncryptedPassword(t, user, string(hash))
+	return string(hash)
+}
+
+func TestPasswordHashRehash(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	importedUser(t, user)
+
+	tenent.BearerToken(t, user)
+	rehashed := encryptedPassword(t, user)
+	if !strings.HasPrefix(rehashed, "$argon2id$") || util.PasswordNeedsRehash(rehashed) {
+		t.Fatalf("expected signing in to upgrade the password hash, got %s\n", rehashed)
+	}
+	tenent.BearerToken(t, user)
+}
+
+func TestPasswordHashRehashRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	hash := importedUser(t, user)
+
+	if _, response := tenent.PasswordToken(t, &TestUserST{User: user.User, Password: "wrong-" + user.Password}); response.Status != http.StatusUnauthorized || !response.HasError("password", "invalid") {
+		t.Fatalf("expected wrong password to be rejected, got %s\n", response)
+	}
+	if encryptedPassword(t, user) != hash {
+		t.Fatalf("expected a rejected sign in to keep the password hash\n")
+	}
+}
+
+func TestPasswordHashParamsOutOfRange(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	for _, hash := range []string{
+		"$scrypt$ln=30,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		"$pbkdf2-sha256$100000000$c2FsdHNhbHRzYWx0c2FsdA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		"$5$rounds=999999999$saltstring$gpdvZHQ.qundoWJjAZwNzbBrhvpB73knRq.7HdH6Y9.",
+	} {
+		if util.CheckPasswordHash(hash) == nil {
+			t.Fatalf("expected %s to be out of range\n", hash)
+		}
+		user := CreateTestUser(t, tenent.Application.Id)
+		setEncryptedPassword(t, user, hash)
+
+		if _, response := tenent.PasswordToken(t, user); response.Status != http.StatusUnauthorized || !response.HasError("password", "invalid") {
+			t.Fatalf("expected %s to be rejected, got %s\n", hash, response)
+		}
+		if encryptedPassword(t, user) != hash {
+			t.Fatalf("expected a rejected sign in to keep the password hash\n")
+		}
+	}
+}
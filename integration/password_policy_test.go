@@ -0,0 +1,115 @@
+package test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// setTestPasswordPolicy sets the application's password policy through the api
+func setTestPasswordPolicy(t *testing.T, tenent *TestTenentST, policy repository.UpsertPasswordPolicyST) ApiResponseST {
+	t.Helper()
+	return ApiRequest(t, http.MethodPatch, fmt.Sprintf("/applications/%d/password-policy", tenent.Application.Id), Bearer(AdminToken(t).AccessToken), model.UpdatePasswordPolicyST{UpsertPasswordPolicyST: policy}, nil)
+}
+
+func resetPassword(t *testing.T, bearer model.TokenST, password string) ApiResponseST {
+	t.Helper()
+	return ApiRequest(t, http.MethodPatch, "/user/reset-password", Bearer(bearer.AccessToken), model.ResetPasswordST{
+		Password:             password,
+		PasswordConfirmation: password,
+	}, nil)
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	minLength, requireDigit, disallowUsername := int32(12), true, true
+	if response := setTestPasswordPolicy(t, tenent, repository.UpsertPasswordPolicyST{
+		MinLength:        &minLength,
+		RequireDigit:     &requireDigit,
+		DisallowUsername: &disallowUsername,
+	}); response.Status != http.StatusOK {
+		t.Fatalf("could not set password policy: %s\n", response)
+	}
+	bearer := tenent.BearerToken(t, user)
+
+	response := resetPassword(t, bearer, "short")
+	if response.Status != http.StatusBadRequest || !response.HasError("password", "minLength") || !response.HasError("password", "digit") {
+		t.Fatalf("expected a short password without a digit to be rejected, got %s\n", response)
+	}
+	if response := resetPassword(t, bearer, user.User.Username+"1"); response.Status != http.StatusBadRequest || !response.HasError("password", "containsUsername") {
+		t.Fatalf("expected a password containing the username to be rejected, got %s\n", response)
+	}
+	if response := resetPassword(t, bearer, "correct horse battery 9"); response.Status != http.StatusNoContent {
+		t.Fatalf("expected a password following the policy to be accepted, got %s\n", response)
+	}
+}
+
+func TestPasswordPolicyHistory(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	historyCount := int32(2)
+	if response := setTestPasswordPolicy(t, tenent, repository.UpsertPasswordPolicyST{HistoryCount: &historyCount}); response.Status != http.StatusOK {
+		t.Fatalf("could not set password policy: %s\n", response)
+	}
+	bearer := tenent.BearerToken(t, user)
+
+	if response := resetPassword(t, bearer, user.Password); response.Status != http.StatusBadRequest || !response.HasError("password", "reused") {
+		t.Fatalf("expected the current password to be rejected, got %s\n", response)
+	}
+	second := "password-" + uuid.NewString()
+	if response := resetPassword(t, bearer, second); response.Status != http.StatusNoContent {
+		t.Fatalf("could not change password: %s\n", response)
+	}
+	if response := resetPassword(t, bearer, user.Password); response.Status != http.StatusBadRequest || !response.HasError("password", "reused") {
+		t.Fatalf("expected the previous password to be rejected, got %s\n", response)
+	}
+	if response := resetPassword(t, bearer, "password-"+uuid.NewString()); response.Status != http.StatusNoContent {
+		t.Fatalf("could not change password: %s\n", response)
+	}
+	if response := resetPassword(t, bearer, user.Password); response.Status != http.StatusNoContent {
+		t.Fatalf("expected a password older than the history to be accepted, got %s\n", response)
+	}
+	historyCount = repository.PasswordHistoryMax + 1
+	if response := setTestPasswordPolicy(t, tenent, repository.UpsertPasswordPolicyST{HistoryCount: &historyCount}); response.Status != http.StatusBadRequest || !response.HasError("history_count", "invalid") {
+		t.Fatalf("expected a history count over the maximum to be rejected, got %s\n", response)
+	}
+}
+
+func TestPasswordPolicyBreached(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	checkBreached := true
+	SetConfig(t, "password.breached_path", "")
+	if response := setTestPasswordPolicy(t, tenent, repository.UpsertPasswordPolicyST{CheckBreached: &checkBreached}); response.Status != http.StatusBadRequest || !response.HasError("check_breached", "unavailable") {
+		t.Fatalf("expected checking breached passwords without a list to be rejected, got %s\n", response)
+	}
+
+	breached := "breached-" + uuid.NewString()
+	sum := sha1.Sum([]byte(breached))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]), []byte(hash[5:]+":3\n"), 0644); err != nil {
+		t.Fatalf("could not write breached passwords: %s\n", err)
+	}
+	SetConfig(t, "password.breached_path", dir)
+	if response := setTestPasswordPolicy(t, tenent, repository.UpsertPasswordPolicyST{CheckBreached: &checkBreached}); response.Status != http.StatusOK {
+		t.Fatalf("could not set password policy: %s\n", response)
+	}
+	bearer := tenent.BearerToken(t, user)
+	if response := resetPassword(t, bearer, breached); response.Status != http.StatusBadRequest || !response.HasError("password", "breached") {
+		t.Fatalf("expected a breached password to be rejected, got %s\n", response)
+	}
+	if response := resetPassword(t, bearer, "password-"+uuid.NewString()); response.Status != http.StatusNoContent {
+		t.Fatalf("expected a password that wasn't breached to be accepted, got %s\n", response)
+	}
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+)
+
+func createPasswordlessTenent(t *testing.T, registration bool) *TestTenentST {
+	t.Helper()
+	enabled := true
+	return CreateTestTenent(t, repository.CreateTenentST{
+		PasswordlessEnabled:      &enabled,
+		PasswordlessRegistration: &registration,
+	})
+}
+
+// requestPasswordless asks for a passwordless login and returns the email the tenent sent
+func requestPasswordless(t *testing.T, tenent *TestTenentST, email string) service.MessageST {
+	t.Helper()
+	if response := ApiRequest(t, http.MethodPost, "/passwordless", tenent.Headers(), model.RequestPasswordlessST{Email: email}, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not request passwordless login: %s\n", response)
+	}
+	return tenent.Messages.Next(t, service.MessageKindPasswordlessLogin, email)
+}
+
+func passwordlessToken(t *testing.T, tenent *TestTenentST, email, code string) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	return tenent.Token(t, model.TokenRequestST{
+		GrantType: model.PasswordlessGrantType,
+		Email:     email,
+		Code:      code,
+	})
+}
+
+func TestPasswordless(t *testing.T) {
+	tenent := createPasswordlessTenent(t, false)
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := user.ConfirmedEmail(t)
+
+	message := requestPasswordless(t, tenent, email.Email)
+	token, response := passwordlessToken(t, tenent, email.Email, Data(t, message, "code"))
+	if response.Status != http.StatusOK || token.TokenType != jwt.BearerTokenType {
+		t.Fatalf("expected passwordless code to sign in, got %s\n", response)
+	}
+	if claims := Claims(t, token); claims.Subject != user.User.Id {
+		t.Fatalf("expected token for user %d, got %d\n", user.User.Id, claims.Subject)
+	}
+	if _, response := passwordlessToken(t, tenent, email.Email, Data(t, message, "code")); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected used passwordless code to be rejected, got %s\n", response)
+	}
+}
+
+func TestPasswordlessLink(t *testing.T) {
+	tenent := createPasswordlessTenent(t, false)
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := user.ConfirmedEmail(t)
+
+	message := requestPasswordless(t, tenent, email.Email)
+	if _, response := passwordlessToken(t, tenent, email.Email, Data(t, message, "token")); response.Status != http.StatusOK {
+		t.Fatalf("expected passwordless link token to sign in, got %s\n", response)
+	}
+}
+
+func TestPasswordlessConfirmedSecondaryEmail(t *testing.T) {
+	tenent := createPasswordlessTenent(t, false)
+	user := CreateTestUser(t, tenent.Application.Id)
+	user.ConfirmedEmail(t)
+	secondary, err := repository.CreateEmail(tenent.Application.Id, user.User.Id, user.User.Username+"@secondary.example.com")
+	if err != nil {
+		t.Fatalf("could not create email: %s\n", err)
+	}
+	if _, err := repository.Execute(`UPDATE emails SET confirmed=true WHERE id=$1;`, secondary.Id); err != nil {
+		t.Fatalf("could not confirm email: %s\n", err)
+	}
+
+	message := requestPasswordless(t, tenent, secondary.Email)
+	token, response := passwordlessToken(t, tenent, secondary.Email, Data(t, message, "code"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected confirmed secondary email to sign in, got %s\n", response)
+	}
+	if claims := Claims(t, token); claims.Subject != user.User.Id {
+		t.Fatalf("expected token for user %d, got %d\n", user.User.Id, claims.Subject)
+	}
+}
+
+func TestPasswordlessRejected(t *testing.T) {
+	tenent := createPasswordlessTenent(t, false)
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := user.ConfirmedEmail(t)
+
+	code := Data(t, requestPasswordless(t, tenent, email.Email), "code")
+	for range config.Get().Passwordless.CodeMaxAttempts {
+		if _, response := passwordlessToken(t, tenent, email.Email, wrongCode(code)); response.Status != http.StatusUnauthorized || !response.HasError("code", "invalid") {
+			t.Fatalf("expected wrong passwordless code to be rejected, got %s\n", response)
+		}
+	}
+	if _, response := passwordlessToken(t, tenent, email.Email, code); response.Status != http.StatusUnauthorized || !response.HasError("code", "invalid") {
+		t.Fatalf("expected passwordless code to be rejected after too many attempts, got %s\n", response)
+	}
+}
+
+func TestPasswordlessUnknownEmail(t *testing.T) {
+	tenent := createPasswordlessTenent(t, false)
+	if response := ApiRequest(t, http.MethodPost, "/passwordless", tenent.Headers(), model.RequestPasswordlessST{Email: "unknown@example.com"}, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("expected unknown email to look like any other, got %s\n", response)
+	}
+	tenent.Messages.None(t)
+}
+
+func TestPasswordlessRegistrationEmailTaken(t *testing.T) {
+	tenent := createPasswordlessTenent(t, true)
+	user := CreateTestUser(t, tenent.Application.Id)
+	user.ConfirmedEmail(t)
+	unconfirmed, err := repository.CreateEmail(tenent.Application.Id, user.User.Id, user.User.Username+"@unconfirmed.example.com")
+	if err != nil {
+		t.Fatalf("could not create email: %s\n", err)
+	}
+
+	message := requestPasswordless(t, tenent, unconfirmed.Email)
+	if _, response := passwordlessToken(t, tenent, unconfirmed.Email, Data(t, message, "code")); response.Status != http.StatusConflict || !response.HasError("email", "taken") {
+		t.Fatalf("expected another user's unconfirmed email to be taken, got %s\n", response)
+	}
+}
+
+func TestPasswordlessUnconfirmedPrimaryEmail(t *testing.T) {
+	for _, registration := range []bool{false, true} {
+		tenent := createPasswordlessTenent(t, registration)
+		user := CreateTestUser(t, tenent.Application.Id)
+		unconfirmed, err := repository.CreateEmail(tenent.Application.Id, user.User.Id, user.User.Username+"@example.com")
+		if err != nil {
+			t.Fatalf("could not create email: %s\n", err)
+		}
+		if _, err := repository.SetPrimaryEmail(user.User.Id, unconfirmed.Id); err != nil {
+			t.Fatalf("could not set primary email: %s\n", err)
+		}
+
+		if registration {
+			message := requestPasswordless(t, tenent, unconfirmed.Email)
+			if _, response := passwordlessToken(t, tenent, unconfirmed.Email, Data(t, message, "code")); response.Status != http.StatusConflict || !response.HasError("email", "taken") {
+				t.Fatalf("expected an unconfirmed primary email to be taken, got %s\n", response)
+			}
+		} else {
+			if response := ApiRequest(t, http.MethodPost, "/passwordless", tenent.Headers(), model.RequestPasswordlessST{Email: unconfirmed.Email}, nil); response.Status != http.StatusNoContent {
+				t.Fatalf("expected an unconfirmed email to look like any other, got %s\n", response)
+			}
+			tenent.Messages.None(t)
+		}
+		email, err := repository.GetUserPrimaryEmail(user.User.Id)
+		if err != nil || email == nil || email.Confirmed {
+			t.Fatalf("expected the email to stay unconfirmed\n")
+		}
+	}
+}
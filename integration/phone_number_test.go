@@ -0,0 +1,136 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+func createPhoneRegionTenent(t *testing.T, region string) *TestTenentST {
+	t.Helper()
+	return CreateTestTenent(t, repository.CreateTenentST{DefaultPhoneRegion: &region})
+}
+
+func addPhoneNumber(t *testing.T, tenent *TestTenentST, user *TestUserST, phoneNumber string) (model.PhoneNumberST, ApiResponseST) {
+	t.Helper()
+	bearer := tenent.BearerToken(t, user)
+	var created model.PhoneNumberST
+	response := ApiRequest(t, http.MethodPost, "/user/phone-numbers", Bearer(bearer.AccessToken), model.CreatePhoneNumberST{PhoneNumber: phoneNumber}, &created)
+	return created, response
+}
+
+func TestPhoneNumberNormalization(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+
+	for _, phoneNumber := range []string{"+1 (201) 555-0123", "+44 7400 123456", " +4474 0012 3457 "} {
+		user := CreateTestUser(t, tenent.Application.Id)
+		created, response := addPhoneNumber(t, tenent, user, phoneNumber)
+		if response.Status != http.StatusCreated {
+			t.Fatalf("could not add %q: %s\n", phoneNumber, response)
+		}
+		expected := strings.Map(func(r rune) rune {
+			if r == '+' || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, phoneNumber)
+		if created.PhoneNumber != expected {
+			t.Fatalf("expected %q to be stored as %s, got %s\n", phoneNumber, expected, created.PhoneNumber)
+		}
+	}
+
+	user := CreateTestUser(t, tenent.Application.Id)
+	if _, response := addPhoneNumber(t, tenent, user, "+12015550124"); response.Status != http.StatusCreated {
+		t.Fatalf("could not add phone number: %s\n", response)
+	}
+	other := CreateTestUser(t, tenent.Application.Id)
+	if _, response := addPhoneNumber(t, tenent, other, "+1 201-555-0124"); response.Status == http.StatusCreated {
+		t.Fatalf("expected a number already taken in another format to be rejected, got %s\n", response)
+	}
+}
+
+func TestPhoneNumberDefaultRegion(t *testing.T) {
+	tenent := createPhoneRegionTenent(t, "GB")
+
+	for phoneNumber, expected := range map[string]string{
+		"07400 123456":      "+447400123456",
+		"0044 7400 123457":  "+447400123457",
+		"+1 (201) 555-0125": "+12015550125",
+	} {
+		created, response := addPhoneNumber(t, tenent, CreateTestUser(t, tenent.Application.Id), phoneNumber)
+		if response.Status != http.StatusCreated || created.PhoneNumber != expected {
+			t.Fatalf("expected %q to be read as a number from gb %s, got %s %s\n", phoneNumber, expected, response, created.PhoneNumber)
+		}
+	}
+
+	noRegion := CreateTestTenent(t, repository.CreateTenentST{})
+	if _, response := addPhoneNumber(t, noRegion, CreateTestUser(t, noRegion.Application.Id), "07400 123458"); response.Status != http.StatusBadRequest || !response.HasError("phoneNumber", "invalid") {
+		t.Fatalf("expected a national number to be rejected without a default region, got %s\n", response)
+	}
+
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/applications/%d/tenents/%d", tenent.Application.Id, tenent.Tenent.Id), Bearer(AdminToken(t).AccessToken), map[string]string{"default_phone_region": "XX"}, nil); response.Status != http.StatusBadRequest || !response.HasError("default_phone_region", "invalid") {
+		t.Fatalf("expected an unknown region to be rejected, got %s\n", response)
+	}
+}
+
+func TestPhoneNumberRejected(t *testing.T) {
+	tenent := createPhoneRegionTenent(t, "US")
+	user := CreateTestUser(t, tenent.Application.Id)
+
+	for _, phoneNumber := range []string{"+1 555 000 1234", "+44 7400 12", "not a number", "12"} {
+		if _, response := addPhoneNumber(t, tenent, user, phoneNumber); response.Status != http.StatusBadRequest || !response.HasError("phoneNumber", "invalid") {
+			t.Fatalf("expected %q to be rejected, got %s\n", phoneNumber, response)
+		}
+	}
+}
+
+func TestPhoneNumberImport(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	national, international := "phone-"+uuid.NewString(), "phone-"+uuid.NewString()
+	body := fmt.Sprintf("{\"username\":%q,\"phone_numbers\":[{\"phone_number\":\"07400 123459\"}]}\n{\"username\":%q,\"phone_numbers\":[{\"phone_number\":\"+44 7400 123460\"}]}\n", national, international)
+
+	result, response := importUsers(t, tenent.Application.Id, "application/x-ndjson", body)
+	if response.Status != http.StatusOK || result.Imported != 1 || !hasLineError(result, 1, "phone_numbers", "invalid") {
+		t.Fatalf("expected the national number to be rejected without a default region, got %s %+v\n", response, result)
+	}
+	// the international user was already imported
+	result, response = importUsersWithQuery(t, tenent.Application.Id, "default_phone_region=GB", "application/x-ndjson", body)
+	if response.Status != http.StatusOK || result.Imported != 1 || !hasLineError(result, 2, "username", "duplicate") {
+		t.Fatalf("expected the national number to be imported with a default region, got %s %+v\n", response, result)
+	}
+	for username, expected := range map[string]string{national: "+447400123459", international: "+447400123460"} {
+		user := getImportedUser(t, tenent.Application.Id, username)
+		if user == nil || user.PhoneNumberId == nil {
+			t.Fatalf("expected %s to be imported with a phone number\n", username)
+		}
+		phoneNumbers, err := repository.GetPhoneNumbersByUserId(user.Id)
+		if err != nil || len(phoneNumbers) != 1 || phoneNumbers[0].PhoneNumber != expected {
+			t.Fatalf("expected %s to have %s, got %+v %v\n", username, expected, phoneNumbers, err)
+		}
+	}
+	if _, response := importUsersWithQuery(t, tenent.Application.Id, "default_phone_region=XX", "application/x-ndjson", body); response.Status != http.StatusBadRequest || !response.HasError("default_phone_region", "invalid") {
+		t.Fatalf("expected an unknown region to be rejected, got %s\n", response)
+	}
+}
+
+func TestPhoneNumberSearch(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	user.ConfirmedPhoneNumber(t, "+12015550126")
+	other := CreateTestUser(t, tenent.Application.Id)
+	other.ConfirmedPhoneNumber(t, "+447400123461")
+
+	for _, phoneNumber := range []string{"+1 (201) 555-0126", "+1 201 555", "001 201", "1201555", "+1-201"} {
+		expectUsers(t, tenent.Application.Id, url.Values{"phone_number": {phoneNumber}}, user)
+	}
+	expectUsers(t, tenent.Application.Id, url.Values{"phone_number": {"0044 7400"}}, other)
+	if _, response := searchUsers(t, tenent.Application.Id, url.Values{"phone_number": {"phone"}}); response.Status != http.StatusBadRequest || !response.HasError("phone_number", "invalid") {
+		t.Fatalf("expected a phone number filter without digits to be rejected, got %s\n", response)
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+// enableTestTOTP enrolls the user in TOTP for the tenent
+func enableTestTOTP(t *testing.T, tenent *TestTenentST, user *TestUserST) repository.TOTPRowST {
+	t.Helper()
+	totp, err := repository.CreateTOTP(user.User.Id, tenent.Tenent.Id)
+	if err != nil {
+		t.Fatalf("could not create totp: %s\n", err)
+	}
+	if _, err := repository.UpsertMFA(user.User.Id, totp.Id, repository.MFATypeTOTP); err != nil {
+		t.Fatalf("could not enable totp: %s\n", err)
+	}
+	return totp
+}
+
+// mfaToken signs the user in with their password and expects to be asked for a second factor
+func mfaToken(t *testing.T, tenent *TestTenentST, user *TestUserST) model.TokenST {
+	t.Helper()
+	token, response := tenent.PasswordToken(t, user)
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not sign in: %s\n", response)
+	}
+	claims, err := jwt.ParseClaimsFromTokenNoValidation(token.AccessToken)
+	if err != nil || claims.Type != jwt.MFATokenType {
+		t.Fatalf("expected an mfa token, got %s\n", token.TokenType)
+	}
+	return token
+}
+
+func validateMFA(t *testing.T, token model.TokenST, mfaType, code string) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	var result model.TokenST
+	response := ApiRequest(t, http.MethodPost, "/mfa", Bearer(token.AccessToken), model.ValidateMFAST{
+		Type: &mfaType,
+		Code: code,
+	}, &result)
+	return result, response
+}
+
+func TestRecoveryCode(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	enableTestTOTP(t, tenent, user)
+	codes, err := repository.CreateRecoveryCodes(user.User.Id)
+	if err != nil {
+		t.Fatalf("could not create recovery codes: %s\n", err)
+	}
+
+	bearer, response := validateMFA(t, mfaToken(t, tenent, user), repository.MFATypeRecoveryCode, codes[0])
+	if response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType {
+		t.Fatalf("expected recovery code to sign in, got %s\n", response)
+	}
+	remaining, err := repository.GetRecoveryCodesRemaining(user.User.Id)
+	if err != nil || remaining != repository.RecoveryCodeCount-1 {
+		t.Fatalf("expected %d recovery codes remaining, got %d\n", repository.RecoveryCodeCount-1, remaining)
+	}
+}
+
+func TestRecoveryCodeRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	enableTestTOTP(t, tenent, user)
+	codes, err := repository.CreateRecoveryCodes(user.User.Id)
+	if err != nil {
+		t.Fatalf("could not create recovery codes: %s\n", err)
+	}
+
+	if _, response := validateMFA(t, mfaToken(t, tenent, user), repository.MFATypeRecoveryCode, "00000-00000"); response.Status != http.StatusForbidden || !response.HasError("mfa", "invalid") {
+		t.Fatalf("expected unknown recovery code to be rejected, got %s\n", response)
+	}
+	if _, response := validateMFA(t, mfaToken(t, tenent, user), repository.MFATypeRecoveryCode, codes[0]); response.Status != http.StatusOK {
+		t.Fatalf("expected recovery code to sign in, got %s\n", response)
+	}
+	if _, response := validateMFA(t, mfaToken(t, tenent, user), repository.MFATypeRecoveryCode, codes[0]); response.Status != http.StatusForbidden || !response.HasError("mfa", "invalid") {
+		t.Fatalf("expected used recovery code to be rejected, got %s\n", response)
+	}
+}
+
+func TestRecoveryCodesDeletedWithLastMFA(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	totp := enableTestTOTP(t, tenent, user)
+	codes, err := repository.CreateRecoveryCodes(user.User.Id)
+	if err != nil {
+		t.Fatalf("could not create recovery codes: %s\n", err)
+	}
+	bearer, response := validateMFA(t, mfaToken(t, tenent, user), repository.MFATypeRecoveryCode, codes[0])
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected recovery code to sign in, got %s\n", response)
+	}
+
+	if response := ApiRequest(t, http.MethodDelete, fmt.Sprintf("/user/totp/%d/enable", tenent.Tenent.Id), Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusOK {
+		t.Fatalf("could not disable mfa: %s\n", response)
+	}
+	remaining, err := repository.GetRecoveryCodesRemaining(user.User.Id)
+	if err != nil || remaining != 0 {
+		t.Fatalf("expected recovery codes to be deleted with the last mfa method, %d remaining\n", remaining)
+	}
+	if _, err := repository.UpsertMFA(user.User.Id, totp.Id, repository.MFATypeTOTP); err != nil {
+		t.Fatalf("could not enable totp: %s\n", err)
+	}
+	if _, response := validateMFA(t, mfaToken(t, tenent, user), repository.MFATypeRecoveryCode, codes[1]); response.Status != http.StatusForbidden {
+		t.Fatalf("expected recovery code from before mfa was disabled to be rejected, got %s\n", response)
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// createRegistrationTenent creates a tenent users can register with in the registration mode
+func createRegistrationTenent(t *testing.T, admin, mode string) *TestTenentST {
+	t.Helper()
+	registrationWebsite := BaseUrl + "/signup"
+	tenent := CreateTestTenent(t, repository.CreateTenentST{RegistrationWebsite: &registrationWebsite})
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/applications/%d/tenents/%d/registration-schema", tenent.Application.Id, tenent.Tenent.Id), Bearer(admin), model.UpdateRegistrationSchemaST{
+		UpsertRegistrationSchemaST: repository.UpsertRegistrationSchemaST{Mode: &mode},
+	}, nil); response.Status != http.StatusOK {
+		t.Fatalf("could not update registration schema: %s\n", response)
+	}
+	return tenent
+}
+
+func createRegistrationInviteCode(t *testing.T, admin string, tenent *TestTenentST, maxUses *int32) model.CreatedRegistrationInviteCodeST {
+	t.Helper()
+	var inviteCode model.CreatedRegistrationInviteCodeST
+	if response := ApiRequest(t, http.MethodPost, fmt.Sprintf("/applications/%d/tenents/%d/registration-invite-codes", tenent.Application.Id, tenent.Tenent.Id), Bearer(admin), model.CreateRegistrationInviteCodeST{
+		CreateRegistrationInviteCodeST: repository.CreateRegistrationInviteCodeST{Description: "Test", MaxUses: maxUses},
+	}, &inviteCode); response.Status != http.StatusCreated {
+		t.Fatalf("could not create registration invite code: %s\n", response)
+	}
+	return inviteCode
+}
+
+func register(t *testing.T, tenent *TestTenentST, inviteCode *string) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	password := "password-" + uuid.NewString()
+	var token model.TokenST
+	response := ApiRequest(t, http.MethodPost, "/registration", tenent.Headers(), model.RegistrationRequestST{
+		Username:             "user-" + uuid.NewString(),
+		Password:             password,
+		PasswordConfirmation: password,
+		InviteCode:           inviteCode,
+	}, &token)
+	return token, response
+}
+
+func TestRegistrationInviteCode(t *testing.T) {
+	admin := AdminToken(t).AccessToken
+	tenent := createRegistrationTenent(t, admin, repository.RegistrationModeInviteCode)
+	maxUses := int32(2)
+	inviteCode := createRegistrationInviteCode(t, admin, tenent, &maxUses)
+
+	for range maxUses {
+		if token, response := register(t, tenent, &inviteCode.Code); response.Status != http.StatusOK || token.TokenType != jwt.BearerTokenType {
+			t.Fatalf("expected the invite code to register users, got %s\n", response)
+		}
+	}
+	var inviteCodes []model.RegistrationInviteCodeST
+	if response := ApiRequest(t, http.MethodGet, fmt.Sprintf("/applications/%d/tenents/%d/registration-invite-codes", tenent.Application.Id, tenent.Tenent.Id), Bearer(admin), nil, &inviteCodes); response.Status != http.StatusOK || len(inviteCodes) != 1 || inviteCodes[0].Uses != maxUses {
+		t.Fatalf("expected the invite code's uses to be counted, got %s %v\n", response, inviteCodes)
+	}
+	if _, response := register(t, tenent, &inviteCode.Code); response.Status != http.StatusBadRequest || !response.HasError("invite_code", "invalid") {
+		t.Fatalf("expected used up invite code to be rejected, got %s\n", response)
+	}
+}
+
+func TestRegistrationInviteCodeRejected(t *testing.T) {
+	admin := AdminToken(t).AccessToken
+	tenent := createRegistrationTenent(t, admin, repository.RegistrationModeInviteCode)
+
+	if _, response := register(t, tenent, nil); response.Status != http.StatusBadRequest || !response.HasError("invite_code", "required") {
+		t.Fatalf("expected registration without an invite code to be rejected, got %s\n", response)
+	}
+	unknown := "unknown"
+	if _, response := register(t, tenent, &unknown); response.Status != http.StatusBadRequest || !response.HasError("invite_code", "invalid") {
+		t.Fatalf("expected unknown invite code to be rejected, got %s\n", response)
+	}
+	expired := createRegistrationInviteCode(t, admin, tenent, nil)
+	if _, err := repository.Execute(`UPDATE registration_invite_codes SET expires_at = NOW() WHERE id=$1;`, expired.Id); err != nil {
+		t.Fatalf("could not expire registration invite code: %s\n", err)
+	}
+	if _, response := register(t, tenent, &expired.Code); response.Status != http.StatusBadRequest || !response.HasError("invite_code", "invalid") {
+		t.Fatalf("expected expired invite code to be rejected, got %s\n", response)
+	}
+	deleted := createRegistrationInviteCode(t, admin, tenent, nil)
+	if response := ApiRequest(t, http.MethodDelete, fmt.Sprintf("/applications/%d/tenents/%d/registration-invite-codes/%d", tenent.Application.Id, tenent.Tenent.Id, deleted.Id), Bearer(admin), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not delete registration invite code: %s\n", response)
+	}
+	if _, response := register(t, tenent, &deleted.Code); response.Status != http.StatusBadRequest || !response.HasError("invite_code", "invalid") {
+		t.Fatalf("expected deleted invite code to be rejected, got %s\n", response)
+	}
+
+	closed := createRegistrationTenent(t, admin, repository.RegistrationModeClosed)
+	if _, response := register(t, closed, nil); response.Status != http.StatusForbidden || !response.HasError("signup", "disabled") {
+		t.Fatalf("expected closed registration to be rejected, got %s\n", response)
+	}
+}
@@ -0,0 +1,187 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+)
+
+// createSAMLTenent creates a tenent signing with an RSA key, SAML assertions can't be signed with an HS secret
+func createSAMLTenent(t *testing.T) *TestTenentST {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate key: %s\n", err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("could not marshal public key: %s\n", err)
+	}
+	algorithm := "RS256"
+	privateKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+	return CreateTestTenent(t, repository.CreateTenentST{
+		Algorithm:  &algorithm,
+		PrivateKey: &privateKeyPEM,
+		PublicKey:  &publicKeyPEM,
+	})
+}
+
+func getSAMLMetadata(t *testing.T, tenent *TestTenentST) (*saml.EntityDescriptor, ApiResponseST) {
+	t.Helper()
+	res, err := http.Get(ApiUrl + "/saml/" + tenent.Tenent.ClientId.String() + "/metadata")
+	if err != nil {
+		t.Fatalf("could not get saml metadata: %s\n", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("could not read saml metadata: %s\n", err)
+	}
+	response := ApiResponseST{Status: res.StatusCode, Body: body}
+	if res.StatusCode != http.StatusOK {
+		return nil, response
+	}
+	metadata, err := samlsp.ParseMetadata(body)
+	if err != nil {
+		t.Fatalf("could not parse saml metadata: %s\n", err)
+	}
+	return metadata, response
+}
+
+// newSAMLServiceProvider returns a service provider using the tenent as its identity provider, register registers
+// it with the tenent
+func newSAMLServiceProvider(t *testing.T, tenent *TestTenentST, register bool) *saml.ServiceProvider {
+	t.Helper()
+	metadata, response := getSAMLMetadata(t, tenent)
+	if metadata == nil {
+		t.Fatalf("could not get saml metadata: %s\n", response)
+	}
+	entityId := "https://sp.example.com/" + tenent.Tenent.ClientId.String()
+	acsURL, _ := url.Parse(entityId + "/acs")
+	if register {
+		acs := acsURL.String()
+		if _, err := repository.CreateSAMLServiceProvider(tenent.Application.Id, tenent.Tenent.Id, repository.CreateSAMLServiceProviderST{
+			Name:     "Service provider",
+			EntityId: entityId,
+			ACSURL:   &acs,
+		}); err != nil {
+			t.Fatalf("could not create saml service provider: %s\n", err)
+		}
+	}
+	return &saml.ServiceProvider{
+		EntityID:          entityId,
+		AcsURL:            *acsURL,
+		IDPMetadata:       metadata,
+		AuthnNameIDFormat: saml.PersistentNameIDFormat,
+	}
+}
+
+// samlSingleSignOn sends an AuthnRequest with the HTTP-Redirect binding and returns its id and the response
+func samlSingleSignOn(t *testing.T, serviceProvider *saml.ServiceProvider) (string, *http.Response) {
+	t.Helper()
+	request, err := serviceProvider.MakeAuthenticationRequest(serviceProvider.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		t.Fatalf("could not make authn request: %s\n", err)
+	}
+	redirectURL, err := request.Redirect("relay-state", serviceProvider)
+	if err != nil {
+		t.Fatalf("could not make authn request url: %s\n", err)
+	}
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Get(redirectURL.String())
+	if err != nil {
+		t.Fatalf("could not send authn request: %s\n", err)
+	}
+	res.Body.Close()
+	return request.ID, res
+}
+
+// samlRequest sends an AuthnRequest and returns its id and the saml_request the api redirected to the tenent's
+// authorization website with
+func samlRequest(t *testing.T, serviceProvider *saml.ServiceProvider) (string, string) {
+	t.Helper()
+	requestId, res := samlSingleSignOn(t, serviceProvider)
+	if res.StatusCode != http.StatusSeeOther {
+		t.Fatalf("expected authn request to redirect, got %d\n", res.StatusCode)
+	}
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("could not parse redirect: %s\n", err)
+	}
+	return requestId, location.Query().Get("saml_request")
+}
+
+func completeSAMLRequest(t *testing.T, bearer, request string) (model.SAMLResponseST, ApiResponseST) {
+	t.Helper()
+	var result model.SAMLResponseST
+	response := ApiRequest(t, http.MethodPost, "/saml/complete", Bearer(bearer), model.CompleteSAMLRequestST{SAMLRequest: request}, &result)
+	return result, response
+}
+
+func TestSAML(t *testing.T) {
+	tenent := createSAMLTenent(t)
+	serviceProvider := newSAMLServiceProvider(t, tenent, true)
+	user := CreateTestUser(t, tenent.Application.Id)
+
+	requestId, request := samlRequest(t, serviceProvider)
+	result, response := completeSAMLRequest(t, tenent.BearerToken(t, user).AccessToken, request)
+	if response.Status != http.StatusOK || result.URL != serviceProvider.AcsURL.String() || result.RelayState != "relay-state" {
+		t.Fatalf("expected a saml response for the service provider, got %s %+v\n", response, result)
+	}
+	responseXML, err := base64.StdEncoding.DecodeString(result.SAMLResponse)
+	if err != nil {
+		t.Fatalf("could not decode saml response: %s\n", err)
+	}
+	assertion, err := serviceProvider.ParseXMLResponse(responseXML, []string{requestId})
+	if err != nil {
+		if invalid, ok := err.(*saml.InvalidResponseError); ok {
+			err = invalid.PrivateErr
+		}
+		t.Fatalf("expected the service provider to accept the assertion: %s\n", err)
+	}
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		t.Fatalf("expected the assertion to name the user, got %+v\n", assertion.Subject)
+	}
+}
+
+func TestSAMLRejected(t *testing.T) {
+	tenent := createSAMLTenent(t)
+	serviceProvider := newSAMLServiceProvider(t, tenent, true)
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user).AccessToken
+
+	_, request := samlRequest(t, serviceProvider)
+	if _, response := completeSAMLRequest(t, bearer, request); response.Status != http.StatusOK {
+		t.Fatalf("could not complete saml request: %s\n", response)
+	}
+	if _, response := completeSAMLRequest(t, bearer, request); response.Status != http.StatusBadRequest || !response.HasError("saml_request", "invalid") {
+		t.Fatalf("expected completed saml request to be rejected, got %s\n", response)
+	}
+	if _, response := completeSAMLRequest(t, bearer, "invalid"); response.Status != http.StatusBadRequest || !response.HasError("saml_request", "invalid") {
+		t.Fatalf("expected unknown saml request to be rejected, got %s\n", response)
+	}
+
+	unregistered := newSAMLServiceProvider(t, createSAMLTenent(t), false)
+	if _, res := samlSingleSignOn(t, unregistered); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected authn request of an unregistered service provider to be rejected, got %d\n", res.StatusCode)
+	}
+	if _, response := getSAMLMetadata(t, CreateTestTenent(t, repository.CreateTenentST{})); response.Status != http.StatusBadRequest {
+		t.Fatalf("expected tenents signing with a secret to not be identity providers, got %d\n", response.Status)
+	}
+}
@@ -0,0 +1,231 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/scim"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/google/uuid"
+)
+
+// createTestServiceAccount creates a service account in the application with read and write on its users and roles
+func createTestServiceAccount(t *testing.T, applicationId int32) (repository.ServiceAccountRowST, string) {
+	t.Helper()
+	secret := uuid.NewString()
+	encryptedSecret, err := util.EncryptPassword(secret)
+	if err != nil {
+		t.Fatalf("could not hash secret: %s\n", err)
+	}
+	serviceAccount, err := repository.Get[repository.ServiceAccountRowST](`INSERT INTO service_accounts (application_id, name, encrypted_secret) VALUES ($1, $2, $3) RETURNING *;`, applicationId, "scim", encryptedSecret)
+	if err != nil {
+		t.Fatalf("could not create service account: %s\n", err)
+	}
+	if _, err := repository.Execute(`WITH new_role AS (
+			INSERT INTO roles (application_id, description, uri) VALUES ($1, 'SCIM', 'scim-' || gen_random_uuid()) RETURNING id
+		), new_resources AS (
+			INSERT INTO resources (application_id, description, uri, actions) VALUES
+				($1, 'Users', 'users', ARRAY['read', 'write']),
+				($1, 'Roles', 'roles', ARRAY['read', 'write'])
+			RETURNING id
+		), new_permissions AS (
+			INSERT INTO role_resource_permissions (role_id, resource_id, actions)
+			SELECT new_role.id, new_resources.id, ARRAY['read', 'write'] FROM new_role, new_resources
+		)
+		INSERT INTO service_account_roles (service_account_id, role_id) SELECT $2, id FROM new_role;`, applicationId, serviceAccount.Id); err != nil {
+		t.Fatalf("could not give service account permissions: %s\n", err)
+	}
+	return serviceAccount, secret
+}
+
+// scimToken signs in a new service account of the tenent's application
+func scimToken(t *testing.T, tenent *TestTenentST) string {
+	t.Helper()
+	serviceAccount, secret := createTestServiceAccount(t, tenent.Application.Id)
+	token, response := tenent.Token(t, model.TokenRequestST{
+		GrantType: model.ServieAccountGrantType,
+		Key:       serviceAccount.Key.String(),
+		Secret:    secret,
+	})
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not sign in service account: %s\n", response)
+	}
+	return token.AccessToken
+}
+
+func scimPatch(operations ...model.SCIMPatchOperationST) model.SCIMPatchST {
+	return model.SCIMPatchST{Schemas: []string{scim.SchemaPatchOp}, Operations: operations}
+}
+
+func scimPath(path string) *string {
+	return &path
+}
+
+func TestSCIMUsers(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	token := scimToken(t, tenent)
+	username, password, externalId := "scim-"+uuid.NewString(), "password-"+uuid.NewString(), uuid.NewString()
+	givenName := "Barbara"
+
+	var user model.SCIMUserST
+	if response := ApiRequest(t, http.MethodPost, "/scim/v2/Users", Bearer(token), model.SCIMUserST{
+		Schemas:    []string{scim.SchemaUser},
+		UserName:   username,
+		ExternalId: &externalId,
+		Password:   &password,
+		Name:       &model.SCIMNameST{GivenName: &givenName},
+		Emails: []model.SCIMMultiValuedST{
+			{Value: username + "@example.com", Primary: true},
+			{Value: "other-" + username + "@example.com"},
+		},
+	}, &user); response.Status != http.StatusCreated {
+		t.Fatalf("could not create scim user: %s\n", response)
+	}
+	if user.Id == "" || user.UserName != username || user.Active == nil || !*user.Active || len(user.Emails) != 2 || user.Emails[0].Value != username+"@example.com" || !user.Emails[0].Primary {
+		t.Fatalf("expected the created user back, got %+v\n", user)
+	}
+	if response := ApiRequest(t, http.MethodPost, "/scim/v2/Users", Bearer(token), model.SCIMUserST{
+		Schemas:  []string{scim.SchemaUser},
+		UserName: username,
+	}, nil); response.Status != http.StatusConflict {
+		t.Fatalf("expected a taken userName to conflict, got %s\n", response)
+	}
+
+	var list model.SCIMListResponseST[model.SCIMUserST]
+	filter := url.QueryEscape(fmt.Sprintf(`userName eq "%s" and externalId eq "%s"`, username, externalId))
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Users?filter="+filter, Bearer(token), nil, &list); response.Status != http.StatusOK {
+		t.Fatalf("could not list scim users: %s\n", response)
+	}
+	if list.TotalResults != 1 || len(list.Resources) != 1 || list.Resources[0].Id != user.Id || list.Schemas[0] != scim.SchemaListResponse {
+		t.Fatalf("expected the filter to find the user, got %+v\n", list)
+	}
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Users?filter="+url.QueryEscape(`userName eq`), Bearer(token), nil, nil); response.Status != http.StatusBadRequest {
+		t.Fatalf("expected an invalid filter to be rejected, got %s\n", response)
+	}
+
+	id, err := strconv.Atoi(user.Id)
+	if err != nil {
+		t.Fatalf("invalid scim user id %s\n", user.Id)
+	}
+	userRow, err := repository.GetUserById(tenent.Application.Id, int32(id))
+	if err != nil || userRow == nil {
+		t.Fatalf("could not get user: %s\n", err)
+	}
+	testUser := &TestUserST{User: *userRow, Password: password}
+	tenent.BearerToken(t, testUser)
+
+	if response := ApiRequest(t, http.MethodPatch, "/scim/v2/Users/"+user.Id, Bearer(token), scimPatch(model.SCIMPatchOperationST{
+		Op:    "replace",
+		Path:  scimPath("active"),
+		Value: []byte(`false`),
+	}), &user); response.Status != http.StatusOK || user.Active == nil || *user.Active {
+		t.Fatalf("expected patching active to disable the user, got %s %+v\n", response, user)
+	}
+	if _, response := tenent.PasswordToken(t, testUser); response.Status != http.StatusUnauthorized || !response.HasError("user", "inactive") {
+		t.Fatalf("expected an inactive user not to sign in, got %s\n", response)
+	}
+
+	displayName := "Barbara Jensen"
+	if response := ApiRequest(t, http.MethodPut, "/scim/v2/Users/"+user.Id, Bearer(token), model.SCIMUserST{
+		Schemas:     []string{scim.SchemaUser},
+		UserName:    username,
+		DisplayName: &displayName,
+		Emails:      []model.SCIMMultiValuedST{{Value: username + "@example.com", Primary: true}},
+	}, &user); response.Status != http.StatusOK {
+		t.Fatalf("could not replace scim user: %s\n", response)
+	}
+	if user.DisplayName == nil || *user.DisplayName != displayName || len(user.Emails) != 1 || user.Active == nil || !*user.Active {
+		t.Fatalf("expected the user to be replaced, got %+v\n", user)
+	}
+	tenent.BearerToken(t, testUser)
+
+	if response := ApiRequest(t, http.MethodDelete, "/scim/v2/Users/"+user.Id, Bearer(token), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not delete scim user: %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Users/"+user.Id, Bearer(token), nil, nil); response.Status != http.StatusNotFound {
+		t.Fatalf("expected a deleted user to be gone, got %s\n", response)
+	}
+}
+
+func TestSCIMGroups(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	token := scimToken(t, tenent)
+	user := CreateTestUser(t, tenent.Application.Id)
+	member := strconv.Itoa(int(user.User.Id))
+	displayName := "group-" + uuid.NewString()
+
+	var group model.SCIMGroupST
+	if response := ApiRequest(t, http.MethodPost, "/scim/v2/Groups", Bearer(token), model.SCIMGroupST{
+		Schemas:     []string{scim.SchemaGroup},
+		DisplayName: displayName,
+		Members:     []model.SCIMReferenceST{{Value: member}},
+	}, &group); response.Status != http.StatusCreated {
+		t.Fatalf("could not create scim group: %s\n", response)
+	}
+	if group.Id == "" || group.DisplayName != displayName || len(group.Members) != 1 || group.Members[0].Value != member {
+		t.Fatalf("expected the created group with its member, got %+v\n", group)
+	}
+
+	var list model.SCIMListResponseST[model.SCIMGroupST]
+	filter := url.QueryEscape(fmt.Sprintf(`displayName eq "%s"`, displayName))
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Groups?excludedAttributes=members&filter="+filter, Bearer(token), nil, &list); response.Status != http.StatusOK {
+		t.Fatalf("could not list scim groups: %s\n", response)
+	}
+	if list.TotalResults != 1 || len(list.Resources) != 1 || list.Resources[0].Id != group.Id || len(list.Resources[0].Members) != 0 {
+		t.Fatalf("expected the filter to find the group without its members, got %+v\n", list)
+	}
+
+	var scimUser model.SCIMUserST
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Users/"+member, Bearer(token), nil, &scimUser); response.Status != http.StatusOK {
+		t.Fatalf("could not get scim user: %s\n", response)
+	}
+	if len(scimUser.Groups) != 1 || scimUser.Groups[0].Value != group.Id {
+		t.Fatalf("expected the user to list its group, got %+v\n", scimUser.Groups)
+	}
+
+	if response := ApiRequest(t, http.MethodPatch, "/scim/v2/Groups/"+group.Id, Bearer(token), scimPatch(model.SCIMPatchOperationST{
+		Op:   "remove",
+		Path: scimPath(fmt.Sprintf(`members[value eq "%s"]`, member)),
+	}), &group); response.Status != http.StatusOK || len(group.Members) != 0 {
+		t.Fatalf("expected the member to be removed, got %s %+v\n", response, group)
+	}
+	if response := ApiRequest(t, http.MethodPatch, "/scim/v2/Groups/"+group.Id, Bearer(token), scimPatch(model.SCIMPatchOperationST{
+		Op:    "add",
+		Path:  scimPath("members"),
+		Value: []byte(`[{"value":"not a user"}]`),
+	}), nil); response.Status != http.StatusBadRequest {
+		t.Fatalf("expected an invalid member to be rejected, got %s\n", response)
+	}
+
+	if response := ApiRequest(t, http.MethodDelete, "/scim/v2/Groups/"+group.Id, Bearer(token), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not delete scim group: %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Groups/"+group.Id, Bearer(token), nil, nil); response.Status != http.StatusNotFound {
+		t.Fatalf("expected a deleted group to be gone, got %s\n", response)
+	}
+}
+
+func TestSCIMRequiresServiceAccount(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+
+	bearer := tenent.BearerToken(t, user)
+	if response := ApiRequest(t, http.MethodGet, "/scim/v2/Users", Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusForbidden {
+		t.Fatalf("expected a user's token to be rejected, got %s\n", response)
+	}
+
+	other := CreateTestTenent(t, repository.CreateTenentST{})
+	serviceAccount, secret := createTestServiceAccount(t, other.Application.Id)
+	if _, response := tenent.Token(t, model.TokenRequestST{
+		GrantType: model.ServieAccountGrantType,
+		Key:       serviceAccount.Key.String(),
+		Secret:    secret,
+	}); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected another application's service account to be rejected, got %s\n", response)
+	}
+}
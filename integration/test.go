@@ -1,10 +1,20 @@
 package test
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
+	"testing"
+	"time"
 
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
 	"github.com/aicacia/auth/api/app/repository"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
@@ -15,6 +25,7 @@ var Playwright *playwright.Playwright
 var Browser playwright.Browser
 var Page playwright.Page
 var BaseUrl = "http://localhost:5173"
+var ApiUrl = "http://localhost:3000"
 
 func SetupTest() {
 	err := godotenv.Load("../.env", "../.env.test")
@@ -26,6 +37,10 @@ func SetupTest() {
 	if err != nil {
 		log.Fatalf("could not init db: %s\n", err)
 	}
+	err = config.InitConfig()
+	if err != nil {
+		log.Fatalf("could not init config: %s\n", err)
+	}
 
 	InitTestUser()
 
@@ -49,6 +64,9 @@ func SetupTest() {
 }
 
 func TeardownTest() {
+	if err := config.CloseConfigListener(); err != nil {
+		log.Fatalf("could not close config listener: %s\n", err)
+	}
 	if err := repository.CloseDB(); err != nil {
 		log.Fatalf("could not close db: %s\n", err)
 	}
@@ -95,3 +113,283 @@ func Signin() {
 		log.Fatalf("could not click submit: %s\n", err)
 	}
 }
+
+// ApiResponseST is the status of an api response and its errors when it failed, Body is the body of failed responses
+type ApiResponseST struct {
+	Status int
+	Errors *model.ErrorST
+	Body   []byte
+}
+
+func (response ApiResponseST) HasError(name, message string) bool {
+	if response.Errors == nil {
+		return false
+	}
+	for _, errorMessage := range response.Errors.Errors[name] {
+		if errorMessage.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func (response ApiResponseST) String() string {
+	if response.Errors == nil {
+		return fmt.Sprintf("%d", response.Status)
+	}
+	return fmt.Sprintf("%d %s", response.Status, response.Errors.Error())
+}
+
+// ApiRequest sends body as json to the api, successful responses are decoded into result when it isn't nil
+func ApiRequest(t *testing.T, method, path string, headers map[string]string, body, result interface{}) ApiResponseST {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatalf("could not encode body: %s\n", err)
+		}
+	}
+	req, err := http.NewRequest(method, ApiUrl+path, &reader)
+	if err != nil {
+		t.Fatalf("could not create request: %s\n", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("could not send request: %s\n", err)
+	}
+	defer res.Body.Close()
+	response := ApiResponseST{Status: res.StatusCode}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		if result != nil {
+			if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+				t.Fatalf("could not decode response: %s\n", err)
+			}
+		}
+	} else {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("could not read response: %s\n", err)
+		}
+		response.Body = body
+		response.Errors = model.NewError(res.StatusCode)
+		if err := json.Unmarshal(body, response.Errors); err != nil {
+			response.Errors = nil
+		}
+	}
+	return response
+}
+
+func Bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// TestTenentST is a tenent of its own application, every user and message of a test stays in it
+type TestTenentST struct {
+	Application repository.ApplicationRowST
+	Tenent      repository.TenentRowST
+	Messages    *MessageServerST
+}
+
+// CreateTestTenent creates an application with a tenent sending its emails and text messages to Messages, unless
+// create sets the endpoints. The application and everything in it is deleted when the test finishes
+func CreateTestTenent(t *testing.T, create repository.CreateTenentST) *TestTenentST {
+	t.Helper()
+	application, err := repository.CreateApplication(repository.CreateApplicationST{
+		Description: "Test",
+		URI:         "test-" + uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatalf("could not create application: %s\n", err)
+	}
+	t.Cleanup(func() {
+		if _, err := repository.DeleteApplication(application.Id); err != nil {
+			slog.Error("could not delete application", "error", err)
+		}
+	})
+	messages := NewMessageServer(t)
+	if create.Description == "" {
+		create.Description = "Test"
+	}
+	if create.URI == "" {
+		create.URI = "test"
+	}
+	if create.AuthorizationWebsite == "" {
+		create.AuthorizationWebsite = BaseUrl + "/signin"
+	}
+	if create.EmailEndpoint == nil {
+		create.EmailEndpoint = &messages.Server.URL
+	}
+	if create.PhoneNumberEndpoint == nil {
+		create.PhoneNumberEndpoint = &messages.Server.URL
+	}
+	tenent, err := repository.CreateTenent(application.Id, create)
+	if err != nil {
+		t.Fatalf("could not create tenent: %s\n", err)
+	}
+	messages.Secret = tenent.ClientSecret
+	return &TestTenentST{
+		Application: application,
+		Tenent:      tenent,
+		Messages:    messages,
+	}
+}
+
+func (tenent *TestTenentST) Headers() map[string]string {
+	return map[string]string{"Tenent-Id": tenent.Tenent.ClientId.String()}
+}
+
+// Token requests a token with the tenent's client id
+func (tenent *TestTenentST) Token(t *testing.T, request model.TokenRequestST) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	var token model.TokenST
+	response := ApiRequest(t, http.MethodPost, "/token", tenent.Headers(), request, &token)
+	return token, response
+}
+
+type TestUserST struct {
+	User     repository.UserRowST
+	Password string
+}
+
+func CreateTestUser(t *testing.T, applicationId int32) *TestUserST {
+	t.Helper()
+	password := "password-" + uuid.NewString()
+	result, err := repository.CreateUserWithPassword(applicationId, "user-"+uuid.NewString(), password)
+	if err != nil {
+		t.Fatalf("could not create user: %s\n", err)
+	}
+	return &TestUserST{
+		User:     result.User,
+		Password: password,
+	}
+}
+
+// PasswordToken signs the user in with their password
+func (tenent *TestTenentST) PasswordToken(t *testing.T, user *TestUserST) (model.TokenST, ApiResponseST) {
+	t.Helper()
+	return tenent.Token(t, model.TokenRequestST{
+		GrantType: model.PasswordGrantType,
+		Username:  user.User.Username,
+		Password:  user.Password,
+	})
+}
+
+// ConfirmedEmail gives the user a confirmed primary email
+func (user *TestUserST) ConfirmedEmail(t *testing.T) repository.EmailRowST {
+	t.Helper()
+	email, err := repository.CreateEmail(user.User.ApplicationId, user.User.Id, user.User.Username+"@example.com")
+	if err != nil {
+		t.Fatalf("could not create email: %s\n", err)
+	}
+	if _, err := repository.Execute(`UPDATE emails SET confirmed=true WHERE id=$1;`, email.Id); err != nil {
+		t.Fatalf("could not confirm email: %s\n", err)
+	}
+	if _, err := repository.SetPrimaryEmail(user.User.Id, email.Id); err != nil {
+		t.Fatalf("could not set primary email: %s\n", err)
+	}
+	email.Confirmed = true
+	return email
+}
+
+// ConfirmedPhoneNumber gives the user a confirmed primary phone number
+func (user *TestUserST) ConfirmedPhoneNumber(t *testing.T, phoneNumber string) repository.PhoneNumberRowST {
+	t.Helper()
+	row, err := repository.CreatePhoneNumber(user.User.ApplicationId, user.User.Id, phoneNumber)
+	if err != nil {
+		t.Fatalf("could not create phone number: %s\n", err)
+	}
+	if _, err := repository.Execute(`UPDATE phone_numbers SET confirmed=true WHERE id=$1;`, row.Id); err != nil {
+		t.Fatalf("could not confirm phone number: %s\n", err)
+	}
+	if _, err := repository.SetPrimaryPhoneNumber(user.User.Id, row.Id); err != nil {
+		t.Fatalf("could not set primary phone number: %s\n", err)
+	}
+	row.Confirmed = true
+	return row
+}
+
+// BearerToken signs the user in with their password and expects an access token
+func (tenent *TestTenentST) BearerToken(t *testing.T, user *TestUserST) model.TokenST {
+	t.Helper()
+	token, response := tenent.PasswordToken(t, user)
+	if response.Status != http.StatusOK || token.TokenType != jwt.BearerTokenType {
+		t.Fatalf("could not sign in: %s\n", response)
+	}
+	return token
+}
+
+// Claims reads the token's claims without validating it, the api already did
+func Claims(t *testing.T, token model.TokenST) *jwt.Claims {
+	t.Helper()
+	claims, err := jwt.ParseClaimsFromTokenNoValidation(token.AccessToken)
+	if err != nil {
+		t.Fatalf("could not parse token: %s\n", err)
+	}
+	return claims
+}
+
+// AdminClientId is the client id of the admin ui's tenent
+var AdminClientId = uuid.MustParse("cbf7bbef-5132-4b2c-8622-06e28359c291")
+
+// AdminToken creates a user with the admin role in the admin application and signs them in with the admin ui's tenent,
+// the user is deleted when the test finishes
+func AdminToken(t *testing.T) model.TokenST {
+	t.Helper()
+	tenent, err := repository.GetTenentByClientId(AdminClientId)
+	if err != nil || tenent == nil {
+		t.Fatalf("could not get admin tenent: %s\n", err)
+	}
+	application, err := repository.GetApplicationById(tenent.ApplicationId)
+	if err != nil || application == nil {
+		t.Fatalf("could not get admin application: %s\n", err)
+	}
+	admin := CreateTestUser(t, application.Id)
+	t.Cleanup(func() {
+		if _, err := repository.DeleteUserById(application.Id, admin.User.Id); err != nil {
+			slog.Error("could not delete admin", "error", err)
+		}
+	})
+	if _, err := repository.Execute(`INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE application_id=$2 AND uri='admin';`, admin.User.Id, application.Id); err != nil {
+		t.Fatalf("could not give admin role: %s\n", err)
+	}
+	adminTenent := &TestTenentST{Application: *application, Tenent: *tenent}
+	return adminTenent.BearerToken(t, admin)
+}
+
+// SetConfig changes a config and waits until the change is seen, the api is notified of it along with this test. The
+// previous value is restored when the test finishes
+func SetConfig(t *testing.T, key string, value interface{}) {
+	t.Helper()
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("could not encode config %s: %s\n", key, err)
+	}
+	previous, err := repository.Get[string](`SELECT value::text FROM configs WHERE key=$1;`, key)
+	if err != nil {
+		t.Fatalf("could not get config %s: %s\n", key, err)
+	}
+	if previous == string(valueJSON) {
+		return
+	}
+	setConfig(t, key, string(valueJSON))
+	t.Cleanup(func() {
+		setConfig(t, key, previous)
+	})
+}
+
+func setConfig(t *testing.T, key, valueJSON string) {
+	t.Helper()
+	before, _ := json.Marshal(config.Get())
+	if _, err := repository.Execute(`UPDATE configs SET value=$2::jsonb WHERE key=$1;`, key, valueJSON); err != nil {
+		t.Fatalf("could not set config %s: %s\n", key, err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if after, _ := json.Marshal(config.Get()); !bytes.Equal(before, after) {
+			return
+		}
+	}
+}
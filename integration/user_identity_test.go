@@ -0,0 +1,113 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/config"
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// linkIdentity starts linking the provider to the user signed in with bearer and returns the query the api redirected
+// back with
+func linkIdentity(t *testing.T, bearer string, provider repository.IdentityProviderRowST) url.Values {
+	t.Helper()
+	var authorization model.IdentityProviderAuthorizationST
+	if response := ApiRequest(t, http.MethodPost, "/user/identities", Bearer(bearer), model.LinkUserIdentityST{IdentityProviderId: provider.Id}, &authorization); response.Status != http.StatusOK {
+		t.Fatalf("could not start linking identity: %s\n", response)
+	}
+	return FollowIdentityProvider(t, authorization.AuthorizationURL)
+}
+
+func getUserIdentities(t *testing.T, bearer string) []model.UserIdentityST {
+	t.Helper()
+	var identities []model.UserIdentityST
+	if response := ApiRequest(t, http.MethodGet, "/user/identities", Bearer(bearer), nil, &identities); response.Status != http.StatusOK {
+		t.Fatalf("could not get identities: %s\n", response)
+	}
+	return identities
+}
+
+func TestLinkIdentity(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user)
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString()})
+
+	if result := linkIdentity(t, bearer.AccessToken, provider); result.Get("identity_provider_linked") != strconv.Itoa(int(provider.Id)) {
+		t.Fatalf("expected identity to be linked, got %v\n", result)
+	}
+	if identities := getUserIdentities(t, bearer.AccessToken); len(identities) != 1 || identities[0].IdentityProviderId != provider.Id {
+		t.Fatalf("expected the linked identity, got %v\n", identities)
+	}
+	token, response := identityProviderToken(t, tenent, identityProviderSignIn(t, tenent, provider).Get("identity_provider_code"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected linked identity to sign in, got %s\n", response)
+	}
+	if subject := Claims(t, token).Subject; subject != user.User.Id {
+		t.Fatalf("expected user %d to sign in with the linked identity, got %d\n", user.User.Id, subject)
+	}
+}
+
+func TestLinkIdentityRequiresFreshAuthentication(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	claims := Claims(t, tenent.BearerToken(t, user))
+	claims.AuthTimeSeconds -= config.Get().User.ReauthenticationMaxAgeSeconds + 60
+	stale, err := jwt.CreateToken(claims, &tenent.Tenent)
+	if err != nil {
+		t.Fatalf("could not create token: %s\n", err)
+	}
+
+	if response := ApiRequest(t, http.MethodPost, "/user/identities", Bearer(stale), model.LinkUserIdentityST{IdentityProviderId: provider.Id}, nil); response.Status != http.StatusUnauthorized || !response.HasError("authorization", "reauthentication_required") {
+		t.Fatalf("expected linking to require a fresh sign in, got %s\n", response)
+	}
+}
+
+func TestLinkIdentityAlreadyLinked(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{})
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString()})
+	if _, response := identityProviderToken(t, tenent, identityProviderSignIn(t, tenent, provider).Get("identity_provider_code")); response.Status != http.StatusOK {
+		t.Fatalf("expected identity provider code to sign in, got %s\n", response)
+	}
+
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user)
+	if result := linkIdentity(t, bearer.AccessToken, provider); result.Get("identity_provider_error") != "identity_already_linked" {
+		t.Fatalf("expected another user's identity to be rejected, got %v\n", result)
+	}
+	if identities := getUserIdentities(t, bearer.AccessToken); len(identities) != 0 {
+		t.Fatalf("expected no linked identities, got %v\n", identities)
+	}
+}
+
+func TestUnlinkLastIdentity(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	oidc := NewOIDCProvider(t)
+	provider := oidc.Create(t, tenent, repository.CreateIdentityProviderST{})
+	oidc.SignInAs(OIDCUserST{Subject: uuid.NewString()})
+	bearer, response := identityProviderToken(t, tenent, identityProviderSignIn(t, tenent, provider).Get("identity_provider_code"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("expected identity provider code to sign in, got %s\n", response)
+	}
+
+	identities := getUserIdentities(t, bearer.AccessToken)
+	if len(identities) != 1 {
+		t.Fatalf("expected the identity the user was created with, got %v\n", identities)
+	}
+	if response := ApiRequest(t, http.MethodDelete, fmt.Sprintf("/user/identities/%d", identities[0].Id), Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusBadRequest || !response.HasError("credentials", "last") {
+		t.Fatalf("expected the last credential to be kept, got %s\n", response)
+	}
+}
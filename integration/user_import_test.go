@@ -0,0 +1,272 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/util"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usersRequest sends body as is to the application's users import or export, the response body is returned when
+// it succeeded
+func usersRequest(t *testing.T, method string, applicationId int32, path, contentType, body string) (ApiResponseST, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/applications/%d/users/%s", ApiUrl, applicationId, path), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("could not create request: %s\n", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+AdminToken(t).AccessToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("could not send request: %s\n", err)
+	}
+	defer res.Body.Close()
+	responseBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("could not read response: %s\n", err)
+	}
+	response := ApiResponseST{Status: res.StatusCode}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		response.Errors = model.NewError(res.StatusCode)
+		if err := json.Unmarshal(responseBody, response.Errors); err != nil {
+			response.Errors = nil
+		}
+		return response, nil
+	}
+	return response, responseBody
+}
+
+func importUsers(t *testing.T, applicationId int32, contentType, body string) (model.ImportUsersResultST, ApiResponseST) {
+	t.Helper()
+	return importUsersWithQuery(t, applicationId, "", contentType, body)
+}
+
+func importUsersWithQuery(t *testing.T, applicationId int32, query, contentType, body string) (model.ImportUsersResultST, ApiResponseST) {
+	t.Helper()
+	var result model.ImportUsersResultST
+	response, responseBody := usersRequest(t, http.MethodPost, applicationId, "import?"+query, contentType, body)
+	if responseBody != nil {
+		if err := json.Unmarshal(responseBody, &result); err != nil {
+			t.Fatalf("could not decode import result: %s\n", err)
+		}
+	}
+	return result, response
+}
+
+func importedLineErrors(result model.ImportUsersResultST, line int) map[string][]*model.ErrorMessageST {
+	for _, lineErrors := range result.Errors {
+		if lineErrors.Line == line {
+			return lineErrors.Errors
+		}
+	}
+	return nil
+}
+
+func hasLineError(result model.ImportUsersResultST, line int, name, message string) bool {
+	for _, errorMessage := range importedLineErrors(result, line)[name] {
+		if errorMessage.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func getImportedUser(t *testing.T, applicationId int32, username string) *repository.UserRowST {
+	t.Helper()
+	user, err := repository.GetUserByUsername(applicationId, username)
+	if err != nil {
+		t.Fatalf("could not get user: %s\n", err)
+	}
+	return user
+}
+
+func TestUserImportCSV(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	password := "password-" + uuid.NewString()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("could not hash password: %s\n", err)
+	}
+	plain, hashed := "plain-"+uuid.NewString(), "hashed-"+uuid.NewString()
+	body := strings.Join([]string{
+		"username,password,encrypted_password,emails,confirmed_emails,given_name",
+		fmt.Sprintf("%s,%s,,%s@example.com;other-%s@example.com,%s@example.com,Plain", plain, password, plain, plain, plain),
+		fmt.Sprintf("%s,,%s,,,", hashed, hash),
+		fmt.Sprintf(",%s,,,,", password),
+		fmt.Sprintf("%s,%s,,,,", plain, password),
+		fmt.Sprintf("bounds-%s,,$pbkdf2-sha256$100000000$c2FsdHNhbHRzYWx0c2FsdA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,,,", uuid.NewString()),
+		"too,many,columns,in,this,row,here",
+	}, "\n") + "\n"
+
+	result, response := importUsers(t, tenent.Application.Id, "text/csv", body)
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not import users: %s\n", response)
+	}
+	if result.Imported != 2 || result.Failed != 4 {
+		t.Fatalf("expected 2 users imported and 4 failed, got %+v\n", result)
+	}
+	if !hasLineError(result, 4, "username", "required") {
+		t.Fatalf("expected the line without a username to fail, got %+v\n", result.Errors)
+	}
+	if !hasLineError(result, 5, "username", "duplicate") {
+		t.Fatalf("expected the line repeating a username to fail, got %+v\n", result.Errors)
+	}
+	if !hasLineError(result, 6, "encrypted_password", "invalid") {
+		t.Fatalf("expected the line with an out of range hash to fail, got %+v\n", result.Errors)
+	}
+	if !hasLineError(result, 7, "line", "invalid") {
+		t.Fatalf("expected the line with too many columns to fail, got %+v\n", result.Errors)
+	}
+
+	plainUser := getImportedUser(t, tenent.Application.Id, plain)
+	if plainUser == nil || plainUser.EmailId == nil {
+		t.Fatalf("expected %s to be imported with a primary email\n", plain)
+	}
+	emails, err := repository.GetEmailsByUserId(plainUser.Id)
+	if err != nil {
+		t.Fatalf("could not get emails: %s\n", err)
+	}
+	if len(emails) != 2 {
+		t.Fatalf("expected 2 emails, got %d\n", len(emails))
+	}
+	for _, email := range emails {
+		if email.Confirmed != (email.Email == plain+"@example.com") {
+			t.Fatalf("expected only %s@example.com to be confirmed, got %+v\n", plain, email)
+		}
+	}
+	tenent.BearerToken(t, &TestUserST{User: *plainUser, Password: password})
+
+	hashedUser := getImportedUser(t, tenent.Application.Id, hashed)
+	if hashedUser == nil || hashedUser.EncryptedPassword != string(hash) {
+		t.Fatalf("expected %s to be imported with its password hash\n", hashed)
+	}
+	tenent.BearerToken(t, &TestUserST{User: *hashedUser, Password: password})
+}
+
+func TestUserImportJSONL(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	password := "password-" + uuid.NewString()
+	withPassword, withoutPassword := "jsonl-"+uuid.NewString(), "jsonl-"+uuid.NewString()
+	lines := []string{
+		fmt.Sprintf(`{"username":%q,"password":%q,"phone_numbers":[{"phone_number":"+12015550123","confirmed":true}]}`, withPassword, password),
+		"",
+		fmt.Sprintf(`{"username":%q}`, withoutPassword),
+		`{"username":`,
+		fmt.Sprintf(`{"username":"jsonl-%s","encrypted_password":"$unknown$hash"}`, uuid.NewString()),
+	}
+
+	result, response := importUsers(t, tenent.Application.Id, "application/x-ndjson", strings.Join(lines, "\n"))
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not import users: %s\n", response)
+	}
+	if result.Imported != 2 || result.Failed != 2 {
+		t.Fatalf("expected 2 users imported and 2 failed, got %+v\n", result)
+	}
+	if !hasLineError(result, 4, "line", "invalid") {
+		t.Fatalf("expected the line that isn't json to fail, got %+v\n", result.Errors)
+	}
+	if !hasLineError(result, 5, "encrypted_password", "invalid") {
+		t.Fatalf("expected the line with an unknown hash to fail, got %+v\n", result.Errors)
+	}
+
+	user := getImportedUser(t, tenent.Application.Id, withPassword)
+	if user == nil || user.PhoneNumberId == nil {
+		t.Fatalf("expected %s to be imported with a primary phone number\n", withPassword)
+	}
+	tenent.BearerToken(t, &TestUserST{User: *user, Password: password})
+
+	noPassword := getImportedUser(t, tenent.Application.Id, withoutPassword)
+	if noPassword == nil || noPassword.EncryptedPassword != util.NoPassword {
+		t.Fatalf("expected %s to be imported without a password\n", withoutPassword)
+	}
+	if _, response := tenent.PasswordToken(t, &TestUserST{User: *noPassword, Password: password}); response.Status != http.StatusUnauthorized {
+		t.Fatalf("expected a user imported without a password not to sign in with one, got %s\n", response)
+	}
+}
+
+func TestUserImportMaxRows(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	SetConfig(t, "user.import_max_rows", 2)
+	usernames := []string{"max-" + uuid.NewString(), "max-" + uuid.NewString(), "max-" + uuid.NewString()}
+
+	body := "username\n" + strings.Join(usernames, "\n") + "\n"
+	if _, response := importUsers(t, tenent.Application.Id, "text/csv", body); response.Status != http.StatusBadRequest || !response.HasError("users", "tooMany") {
+		t.Fatalf("expected an import over the row limit to be rejected, got %s\n", response)
+	}
+	for _, username := range usernames {
+		if getImportedUser(t, tenent.Application.Id, username) != nil {
+			t.Fatalf("expected a rejected import not to import %s\n", username)
+		}
+	}
+	body = fmt.Sprintf("{\"username\":%q}\n{\"username\":%q}\n", usernames[0], usernames[1])
+	if result, response := importUsers(t, tenent.Application.Id, "application/x-ndjson", body); response.Status != http.StatusOK || result.Imported != 2 {
+		t.Fatalf("expected an import at the row limit to be imported, got %s %+v\n", response, result)
+	}
+}
+
+func TestUserExport(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	email := user.ConfirmedEmail(t)
+
+	response, body := usersRequest(t, http.MethodGet, tenent.Application.Id, "export?include_password_hashes=true", "application/json", "")
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not export users: %s\n", response)
+	}
+	var exported []model.ExportUserST
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var line model.ExportUserST
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("could not decode exported user: %s\n", err)
+		}
+		exported = append(exported, line)
+	}
+	if len(exported) != 1 || exported[0].Id != user.User.Id || exported[0].Username != user.User.Username {
+		t.Fatalf("expected the export to have the application's user, got %+v\n", exported)
+	}
+	if exported[0].EncryptedPassword == nil || *exported[0].EncryptedPassword != encryptedPassword(t, user) {
+		t.Fatalf("expected the export to include the password hash\n")
+	}
+	if len(exported[0].Emails) != 1 || exported[0].Emails[0].Email != email.Email || !exported[0].Emails[0].Confirmed {
+		t.Fatalf("expected the export to include the confirmed email, got %+v\n", exported[0].Emails)
+	}
+
+	response, body = usersRequest(t, http.MethodGet, tenent.Application.Id, "export?format=csv", "application/json", "")
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not export users: %s\n", response)
+	}
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("could not read exported csv: %s\n", err)
+	}
+	if len(records) != 2 || records[0][0] != "id" || records[1][1] != user.User.Username {
+		t.Fatalf("expected a header and the application's user, got %v\n", records)
+	}
+	for i, column := range records[0] {
+		if column == "encrypted_password" && records[1][i] != "" {
+			t.Fatalf("expected the export to leave out password hashes unless asked\n")
+		}
+	}
+
+	// the export imports as is into another application
+	other := CreateTestTenent(t, repository.CreateTenentST{})
+	if result, response := importUsers(t, other.Application.Id, "text/csv", string(body)); response.Status != http.StatusOK || result.Imported != 1 {
+		t.Fatalf("expected the export to import, got %s %+v\n", response, result)
+	}
+	if getImportedUser(t, other.Application.Id, user.User.Username) == nil {
+		t.Fatalf("expected the exported user to be imported\n")
+	}
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/google/uuid"
+)
+
+// searchUsers lists the application's users as an admin with query
+func searchUsers(t *testing.T, applicationId int32, query url.Values) (model.PaginationST[model.UserST], ApiResponseST) {
+	t.Helper()
+	var users model.PaginationST[model.UserST]
+	response := ApiRequest(t, http.MethodGet, fmt.Sprintf("/applications/%d/users?%s", applicationId, query.Encode()), Bearer(AdminToken(t).AccessToken), nil, &users)
+	return users, response
+}
+
+// expectUsers lists the application's users with query and expects exactly users in order
+func expectUsers(t *testing.T, applicationId int32, query url.Values, users ...*TestUserST) model.PaginationST[model.UserST] {
+	t.Helper()
+	result, response := searchUsers(t, applicationId, query)
+	if response.Status != http.StatusOK {
+		t.Fatalf("could not list users with %s: %s\n", query.Encode(), response)
+	}
+	expected := make([]int32, 0, len(users))
+	for _, user := range users {
+		expected = append(expected, user.User.Id)
+	}
+	got := make([]int32, 0, len(result.Items))
+	for _, user := range result.Items {
+		got = append(got, user.Id)
+	}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected users %v with %s, got %v\n", expected, query.Encode(), got)
+	}
+	return result
+}
+
+func createSearchUser(t *testing.T, applicationId int32, username string) *TestUserST {
+	t.Helper()
+	user := CreateTestUser(t, applicationId)
+	if _, err := repository.Execute(`UPDATE users SET username=$2 WHERE id=$1;`, user.User.Id, username); err != nil {
+		t.Fatalf("could not rename user: %s\n", err)
+	}
+	user.User.Username = username
+	return user
+}
+
+func TestUserSearchFilters(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	suffix := uuid.NewString()
+	alpha := createSearchUser(t, tenent.Application.Id, "alpha-"+suffix)
+	beta := createSearchUser(t, tenent.Application.Id, "beta-"+suffix)
+	gamma := createSearchUser(t, tenent.Application.Id, "Gamma-"+suffix)
+
+	alpha.ConfirmedEmail(t)
+	if _, err := repository.Execute(`UPDATE user_infos SET given_name='Josephine', family_name='Bloggs' WHERE user_id=$1;`, alpha.User.Id); err != nil {
+		t.Fatalf("could not set user info: %s\n", err)
+	}
+	if _, err := repository.Execute(`WITH new_role AS (
+			INSERT INTO roles (application_id, description, uri) VALUES ($1, 'Editor', 'editor') RETURNING id
+		)
+		INSERT INTO user_roles (user_id, role_id) SELECT $2, id FROM new_role;`, tenent.Application.Id, alpha.User.Id); err != nil {
+		t.Fatalf("could not give role: %s\n", err)
+	}
+	beta.ConfirmedPhoneNumber(t, "+15550001111")
+	enableTestTOTP(t, tenent, beta)
+	if _, err := repository.Execute(`UPDATE users SET created_at='2020-01-01T00:00:00Z' WHERE id=$1;`, gamma.User.Id); err != nil {
+		t.Fatalf("could not change created_at: %s\n", err)
+	}
+
+	byUsername := func(values url.Values) url.Values {
+		values.Set("sort", "username")
+		values.Set("order", "asc")
+		return values
+	}
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"username": {"GAMMA"}}), gamma)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"username": {"%"}}))
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"email": {"alpha-"}}), alpha)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"email_confirmed": {"true"}}), alpha)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"email_confirmed": {"false"}}), beta, gamma)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"phone_number": {"+1555000"}, "phone_number_confirmed": {"true"}}), beta)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"role": {"editor"}}), alpha)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"mfa_enabled": {"true"}}), beta)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"mfa_enabled": {"false"}, "email_confirmed": {"false"}}), gamma)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"q": {"joseph"}}), alpha)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"q": {"bloggs"}, "role": {"editor"}, "email_confirmed": {"true"}}), alpha)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"q": {"joseph"}, "mfa_enabled": {"true"}}))
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"created_before": {"2021-01-01T00:00:00Z"}}), gamma)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"created_after": {"2021-01-01T00:00:00Z"}}), alpha, beta)
+	expectUsers(t, tenent.Application.Id, byUsername(url.Values{"created_after": {"2021-01-01T00:00:00Z"}, "phone_number_confirmed": {"false"}}), alpha)
+}
+
+func TestUserSearchSort(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	suffix := uuid.NewString()
+	alpha := createSearchUser(t, tenent.Application.Id, "alpha-"+suffix)
+	beta := createSearchUser(t, tenent.Application.Id, "Beta-"+suffix)
+	gamma := createSearchUser(t, tenent.Application.Id, "gamma-"+suffix)
+	// updated_at is set by a trigger, updating the users in turn orders them beta, alpha then gamma
+	for i, user := range []*TestUserST{beta, alpha, gamma} {
+		if _, err := repository.Execute(`UPDATE users SET created_at=$2 WHERE id=$1;`, user.User.Id, fmt.Sprintf("2020-01-0%dT00:00:00Z", 3-i)); err != nil {
+			t.Fatalf("could not change created_at: %s\n", err)
+		}
+	}
+
+	expectUsers(t, tenent.Application.Id, url.Values{}, gamma, alpha, beta)
+	expectUsers(t, tenent.Application.Id, url.Values{"sort": {"username"}}, gamma, beta, alpha)
+	expectUsers(t, tenent.Application.Id, url.Values{"sort": {"username"}, "order": {"asc"}}, alpha, beta, gamma)
+	expectUsers(t, tenent.Application.Id, url.Values{"sort": {"created_at"}, "order": {"asc"}}, gamma, alpha, beta)
+	expectUsers(t, tenent.Application.Id, url.Values{"sort": {"updated_at"}, "order": {"asc"}}, beta, alpha, gamma)
+	expectUsers(t, tenent.Application.Id, url.Values{"sort": {"username"}, "order": {"asc"}, "username": {"b"}}, beta)
+
+	result, response := searchUsers(t, tenent.Application.Id, url.Values{"sort": {"username"}, "order": {"asc"}, "limit": {"2"}})
+	if response.Status != http.StatusOK || len(result.Items) != 2 || !result.HasMore || result.Items[0].Id != alpha.User.Id {
+		t.Fatalf("expected the first page of users by username, got %s %+v\n", response, result)
+	}
+	result, response = searchUsers(t, tenent.Application.Id, url.Values{"sort": {"username"}, "order": {"asc"}, "limit": {"2"}, "offset": {"2"}})
+	if response.Status != http.StatusOK || len(result.Items) != 1 || result.HasMore || result.Items[0].Id != gamma.User.Id {
+		t.Fatalf("expected the last page of users by username, got %s %+v\n", response, result)
+	}
+}
+
+func TestUserSearchRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+
+	for _, rejected := range []struct {
+		query url.Values
+		name  string
+	}{
+		{url.Values{"sort": {"password"}}, "sort"},
+		{url.Values{"sort": {"email"}, "order": {"asc"}}, "sort"},
+		{url.Values{"order": {"sideways"}}, "order"},
+		{url.Values{"created_after": {"yesterday"}}, "created_after"},
+		{url.Values{"updated_before": {"2020-01-01"}}, "updated_before"},
+	} {
+		if _, response := searchUsers(t, tenent.Application.Id, rejected.query); response.Status != http.StatusBadRequest || !response.HasError(rejected.name, "invalid") {
+			t.Fatalf("expected %s to be rejected, got %s\n", rejected.query.Encode(), response)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+)
+
+func setUserStatus(t *testing.T, admin string, user *TestUserST, update model.UpdateUserStatusST) (model.UserST, ApiResponseST) {
+	t.Helper()
+	var result model.UserST
+	response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/applications/%d/users/%d/status", user.User.ApplicationId, user.User.Id), Bearer(admin), update, &result)
+	return result, response
+}
+
+func TestUserStatus(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	bearer := tenent.BearerToken(t, user)
+	admin := AdminToken(t).AccessToken
+
+	reason := "Too many complaints"
+	result, response := setUserStatus(t, admin, user, model.UpdateUserStatusST{Status: repository.UserStatusDisabled, Reason: &reason})
+	if response.Status != http.StatusOK || result.Status != repository.UserStatusDisabled || result.StatusReason == nil || *result.StatusReason != reason {
+		t.Fatalf("could not disable user: %s %+v\n", response, result)
+	}
+	if response := ApiRequest(t, http.MethodGet, "/user", Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusUnauthorized || !response.HasError("authorization", "invalid") {
+		t.Fatalf("expected the disabled user's tokens to be revoked, got %s\n", response)
+	}
+	if _, response := tenent.PasswordToken(t, user); response.Status != http.StatusUnauthorized || !response.HasError("user", repository.UserStatusDisabled) {
+		t.Fatalf("expected the disabled user to not sign in, got %s\n", response)
+	}
+
+	if result, response := setUserStatus(t, admin, user, model.UpdateUserStatusST{Status: repository.UserStatusActive}); response.Status != http.StatusOK || result.Status != repository.UserStatusActive || result.StatusReason != nil {
+		t.Fatalf("could not activate user: %s %+v\n", response, result)
+	}
+	if _, response := tenent.PasswordToken(t, user); response.Status != http.StatusOK {
+		t.Fatalf("expected the active user to sign in, got %s\n", response)
+	}
+}
+
+func TestUserStatusExpires(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	admin := AdminToken(t).AccessToken
+
+	expiresAt := time.Now().Add(time.Hour)
+	if _, response := setUserStatus(t, admin, user, model.UpdateUserStatusST{Status: repository.UserStatusLocked, ExpiresAt: &expiresAt}); response.Status != http.StatusOK {
+		t.Fatalf("could not lock user: %s\n", response)
+	}
+	if _, response := tenent.PasswordToken(t, user); response.Status != http.StatusUnauthorized || !response.HasError("user", repository.UserStatusLocked) {
+		t.Fatalf("expected the locked user to not sign in, got %s\n", response)
+	}
+	if _, err := repository.Execute(`UPDATE users SET status_expires_at = NOW() WHERE id=$1;`, user.User.Id); err != nil {
+		t.Fatalf("could not expire user status: %s\n", err)
+	}
+	if _, response := tenent.PasswordToken(t, user); response.Status != http.StatusOK {
+		t.Fatalf("expected the user to sign in once the lock expired, got %s\n", response)
+	}
+}
+
+func TestUserStatusRejected(t *testing.T) {
+	tenent := CreateTestTenent(t, repository.CreateTenentST{})
+	user := CreateTestUser(t, tenent.Application.Id)
+	admin := AdminToken(t).AccessToken
+
+	if _, response := setUserStatus(t, admin, user, model.UpdateUserStatusST{Status: "deleted"}); response.Status != http.StatusBadRequest || !response.HasError("status", "invalid") {
+		t.Fatalf("expected unknown status to be rejected, got %s\n", response)
+	}
+	expiresAt := time.Now().Add(-time.Hour)
+	if _, response := setUserStatus(t, admin, user, model.UpdateUserStatusST{Status: repository.UserStatusLocked, ExpiresAt: &expiresAt}); response.Status != http.StatusBadRequest || !response.HasError("expires_at", "invalid") {
+		t.Fatalf("expected expiry in the past to be rejected, got %s\n", response)
+	}
+	other := tenent.BearerToken(t, CreateTestUser(t, tenent.Application.Id))
+	if _, response := setUserStatus(t, other.AccessToken, user, model.UpdateUserStatusST{Status: repository.UserStatusDisabled}); response.Status != http.StatusForbidden {
+		t.Fatalf("expected users outside the admin application to not change statuses, got %s\n", response)
+	}
+	if _, response := tenent.PasswordToken(t, user); response.Status != http.StatusOK {
+		t.Fatalf("expected the user to still sign in, got %s\n", response)
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/aicacia/auth/api/app/jwt"
+	"github.com/aicacia/auth/api/app/model"
+	"github.com/aicacia/auth/api/app/repository"
+	"github.com/aicacia/auth/api/app/service"
+)
+
+func createVerificationTenent(t *testing.T) *TestTenentST {
+	t.Helper()
+	required := true
+	return CreateTestTenent(t, repository.CreateTenentST{RequireConfirmedEmail: &required})
+}
+
+// verificationToken signs the user in and expects a verification token asking them to confirm an email
+func verificationToken(t *testing.T, tenent *TestTenentST, user *TestUserST) model.TokenST {
+	t.Helper()
+	token, response := tenent.PasswordToken(t, user)
+	if response.Status != http.StatusOK || token.TokenType != jwt.VerificationTokenType || token.RefreshToken != nil || !slices.Equal(token.VerificationRequired, []string{"email"}) {
+		t.Fatalf("expected a verification token requiring an email, got %s %+v\n", response, token)
+	}
+	return token
+}
+
+func TestVerification(t *testing.T) {
+	tenent := createVerificationTenent(t)
+	user := CreateTestUser(t, tenent.Application.Id)
+	token := verificationToken(t, tenent, user)
+
+	var email model.EmailST
+	if response := ApiRequest(t, http.MethodPost, "/verification/emails", Bearer(token.AccessToken), model.CreateEmailST{Email: user.User.Username + "@example.com"}, &email); response.Status != http.StatusCreated {
+		t.Fatalf("could not add email: %s\n", response)
+	}
+	message := tenent.Messages.Next(t, service.MessageKindConfirmation, email.Email)
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/verification/emails/%d/confirm", email.Id), Bearer(token.AccessToken), model.ConfirmEmailST{Token: Data(t, message, "code")}, &email); response.Status != http.StatusOK || !email.Confirmed {
+		t.Fatalf("could not confirm email: %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/verification/emails/%d/set-primary", email.Id), Bearer(token.AccessToken), nil, nil); response.Status != http.StatusNoContent {
+		t.Fatalf("could not set primary email: %s\n", response)
+	}
+	var bearer model.TokenST
+	if response := ApiRequest(t, http.MethodPost, "/verification", Bearer(token.AccessToken), nil, &bearer); response.Status != http.StatusOK || bearer.TokenType != jwt.BearerTokenType || bearer.RefreshToken == nil {
+		t.Fatalf("expected verification to issue tokens, got %s\n", response)
+	}
+	tenent.BearerToken(t, user)
+}
+
+func TestVerificationRejected(t *testing.T) {
+	tenent := createVerificationTenent(t)
+	user := CreateTestUser(t, tenent.Application.Id)
+	token := verificationToken(t, tenent, user)
+
+	if response := ApiRequest(t, http.MethodGet, "/user", Bearer(token.AccessToken), nil, nil); response.Status != http.StatusUnauthorized || !response.HasError("authorization", "invalid") {
+		t.Fatalf("expected verification token to not authorize the api, got %s\n", response)
+	}
+	if response := ApiRequest(t, http.MethodPost, "/verification", Bearer(token.AccessToken), nil, nil); response.Status != http.StatusBadRequest || !response.HasError("email", "unconfirmed") {
+		t.Fatalf("expected verification to require a confirmed email, got %s\n", response)
+	}
+	var email model.EmailST
+	if response := ApiRequest(t, http.MethodPost, "/verification/emails", Bearer(token.AccessToken), model.CreateEmailST{Email: user.User.Username + "@example.com"}, &email); response.Status != http.StatusCreated {
+		t.Fatalf("could not add email: %s\n", response)
+	}
+	code := Data(t, tenent.Messages.Next(t, service.MessageKindConfirmation, email.Email), "code")
+	if response := ApiRequest(t, http.MethodPatch, fmt.Sprintf("/verification/emails/%d/confirm", email.Id), Bearer(token.AccessToken), model.ConfirmEmailST{Token: wrongCode(code)}, nil); response.Status != http.StatusBadRequest || !response.HasError("token", "invalid") {
+		t.Fatalf("expected wrong confirmation code to be rejected, got %s\n", response)
+	}
+
+	other := CreateTestUser(t, tenent.Application.Id)
+	other.ConfirmedEmail(t)
+	bearer := tenent.BearerToken(t, other)
+	if response := ApiRequest(t, http.MethodPost, "/verification", Bearer(bearer.AccessToken), nil, nil); response.Status != http.StatusUnauthorized || !response.HasError("authorization", "invalid") {
+		t.Fatalf("expected a bearer token to not be a verification token, got %s\n", response)
+	}
+}
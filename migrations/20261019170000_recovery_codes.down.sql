@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "recovery_codes" cascade;
+DROP TABLE IF EXISTS "audit_logs" cascade;
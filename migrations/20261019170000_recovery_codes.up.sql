@@ -0,0 +1,26 @@
+CREATE TABLE "audit_logs"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"user_id" INT4,
+	"action" VARCHAR(255) NOT NULL,
+	"data" JSONB NOT NULL DEFAULT '{}',
+	"ip" VARCHAR(255),
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "audit_logs_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "audit_logs_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+CREATE INDEX "audit_logs_application_id_created_at_idx" ON "audit_logs" ("application_id", "created_at");
+CREATE INDEX "audit_logs_user_id_idx" ON "audit_logs" ("user_id");
+
+
+CREATE TABLE "recovery_codes"(
+	"id" SERIAL PRIMARY KEY,
+	"user_id" INT4 NOT NULL,
+	"encrypted_code" VARCHAR(255) NOT NULL,
+	"used_at" TIMESTAMPTZ,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "recovery_codes_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "recovery_codes_user_id_encrypted_code_unique_idx" ON "recovery_codes" ("user_id", "encrypted_code");
+CREATE TRIGGER "recovery_codes_updated_at_tgr" BEFORE UPDATE ON "recovery_codes" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
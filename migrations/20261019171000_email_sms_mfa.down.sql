@@ -0,0 +1,9 @@
+DELETE FROM "configs" WHERE "key" IN ('mfa.code_expires_in_seconds', 'mfa.code_max_attempts', 'mfa.code_resend_seconds', 'mfa.code_max_sends_per_hour');
+
+DROP TABLE IF EXISTS "mfa_codes" cascade;
+
+DELETE FROM "user_mfas" WHERE "type" IN ('email', 'sms');
+ALTER TYPE MFA_TYPE RENAME TO MFA_TYPE_OLD;
+CREATE TYPE MFA_TYPE AS ENUM ('totp');
+ALTER TABLE "user_mfas" ALTER COLUMN "type" TYPE MFA_TYPE USING "type"::text::MFA_TYPE;
+DROP TYPE MFA_TYPE_OLD;
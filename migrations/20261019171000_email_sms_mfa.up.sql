@@ -0,0 +1,25 @@
+ALTER TYPE MFA_TYPE ADD VALUE IF NOT EXISTS 'email';
+ALTER TYPE MFA_TYPE ADD VALUE IF NOT EXISTS 'sms';
+
+
+CREATE TABLE "mfa_codes"(
+	"id" SERIAL PRIMARY KEY,
+	"user_id" INT4 NOT NULL,
+	"type" MFA_TYPE NOT NULL,
+	"encrypted_code" VARCHAR(255) NOT NULL,
+	"attempts" INT4 NOT NULL DEFAULT 0,
+	"used_at" TIMESTAMPTZ,
+	"expires_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "mfa_codes_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX "mfa_codes_user_id_type_created_at_idx" ON "mfa_codes" ("user_id", "type", "created_at");
+CREATE TRIGGER "mfa_codes_updated_at_tgr" BEFORE UPDATE ON "mfa_codes" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('mfa.code_expires_in_seconds', '300'),
+	('mfa.code_max_attempts', '5'),
+	('mfa.code_resend_seconds', '30'),
+	('mfa.code_max_sends_per_hour', '5');
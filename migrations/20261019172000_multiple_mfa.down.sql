@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS "user_mfas_user_id_preferred_unique_idx";
+DELETE FROM "user_mfas" WHERE NOT "preferred" OR "type" = 'passkey';
+ALTER TABLE "user_mfas" DROP CONSTRAINT "user_mfas_pkey";
+ALTER TABLE "user_mfas" DROP COLUMN "preferred";
+ALTER TABLE "user_mfas" ADD PRIMARY KEY ("user_id");
+
+ALTER TYPE MFA_TYPE RENAME TO MFA_TYPE_OLD;
+CREATE TYPE MFA_TYPE AS ENUM ('totp', 'email', 'sms');
+ALTER TABLE "user_mfas" ALTER COLUMN "type" TYPE MFA_TYPE USING "type"::text::MFA_TYPE;
+ALTER TABLE "mfa_codes" ALTER COLUMN "type" TYPE MFA_TYPE USING "type"::text::MFA_TYPE;
+DROP TYPE MFA_TYPE_OLD;
@@ -0,0 +1,8 @@
+ALTER TYPE MFA_TYPE ADD VALUE IF NOT EXISTS 'passkey';
+
+
+ALTER TABLE "user_mfas" DROP CONSTRAINT "user_mfas_pkey";
+ALTER TABLE "user_mfas" ADD COLUMN "preferred" BOOL NOT NULL DEFAULT false;
+UPDATE "user_mfas" SET "preferred" = true;
+ALTER TABLE "user_mfas" ADD PRIMARY KEY ("user_id", "type", "id");
+CREATE UNIQUE INDEX "user_mfas_user_id_preferred_unique_idx" ON "user_mfas" ("user_id") WHERE "preferred";
@@ -0,0 +1,6 @@
+DELETE FROM "configs" WHERE "key" IN ('passwordless.code_expires_in_seconds', 'passwordless.code_max_attempts', 'passwordless.code_resend_seconds', 'passwordless.code_max_sends_per_hour');
+
+DROP TABLE IF EXISTS "passwordless_codes" cascade;
+
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "passwordless_registration_enabled";
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "passwordless_enabled";
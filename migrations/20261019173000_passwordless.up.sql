@@ -0,0 +1,26 @@
+ALTER TABLE "tenents" ADD COLUMN "passwordless_enabled" BOOL NOT NULL DEFAULT false;
+ALTER TABLE "tenents" ADD COLUMN "passwordless_registration_enabled" BOOL NOT NULL DEFAULT false;
+
+
+CREATE TABLE "passwordless_codes"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"email" VARCHAR(255) NOT NULL,
+	"encrypted_code" VARCHAR(255) NOT NULL,
+	"encrypted_token" VARCHAR(255) NOT NULL,
+	"attempts" INT4 NOT NULL DEFAULT 0,
+	"used_at" TIMESTAMPTZ,
+	"expires_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "passwordless_codes_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE
+);
+CREATE INDEX "passwordless_codes_application_id_email_created_at_idx" ON "passwordless_codes" ("application_id", "email", "created_at");
+CREATE TRIGGER "passwordless_codes_updated_at_tgr" BEFORE UPDATE ON "passwordless_codes" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('passwordless.code_expires_in_seconds', '600'),
+	('passwordless.code_max_attempts', '5'),
+	('passwordless.code_resend_seconds', '30'),
+	('passwordless.code_max_sends_per_hour', '5');
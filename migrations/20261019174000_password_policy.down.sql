@@ -0,0 +1,4 @@
+DELETE FROM "configs" WHERE "key" IN ('password.breached_path');
+
+DROP TABLE IF EXISTS "password_histories" cascade;
+DROP TABLE IF EXISTS "password_policies" cascade;
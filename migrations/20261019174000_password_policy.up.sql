@@ -0,0 +1,35 @@
+CREATE TABLE "password_policies"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"tenent_id" INT4,
+	"min_length" INT4 NOT NULL DEFAULT 0,
+	"max_length" INT4 NOT NULL DEFAULT 0,
+	"require_lowercase" BOOL NOT NULL DEFAULT false,
+	"require_uppercase" BOOL NOT NULL DEFAULT false,
+	"require_digit" BOOL NOT NULL DEFAULT false,
+	"require_symbol" BOOL NOT NULL DEFAULT false,
+	"disallow_username" BOOL NOT NULL DEFAULT false,
+	"history_count" INT4 NOT NULL DEFAULT 0,
+	"check_breached" BOOL NOT NULL DEFAULT false,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "password_policies_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "password_policies_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "password_policies_application_id_unique_idx" ON "password_policies" ("application_id") WHERE "tenent_id" IS NULL;
+CREATE UNIQUE INDEX "password_policies_application_id_tenent_id_unique_idx" ON "password_policies" ("application_id", "tenent_id") WHERE "tenent_id" IS NOT NULL;
+CREATE TRIGGER "password_policies_updated_at_tgr" BEFORE UPDATE ON "password_policies" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+CREATE TABLE "password_histories"(
+	"id" SERIAL PRIMARY KEY,
+	"user_id" INT4 NOT NULL,
+	"encrypted_password" VARCHAR(255) NOT NULL,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "password_histories_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX "password_histories_user_id_created_at_idx" ON "password_histories" ("user_id", "created_at");
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('password.breached_path', '""');
@@ -0,0 +1 @@
+DELETE FROM "configs" WHERE "key" IN ('argon2.memory', 'argon2.iterations', 'argon2.parallelism', 'argon2.salt_length', 'argon2.key_length');
@@ -0,0 +1,6 @@
+INSERT INTO "configs" ("key", "value") VALUES
+	('argon2.memory', '65536'),
+	('argon2.iterations', '3'),
+	('argon2.parallelism', '2'),
+	('argon2.salt_length', '16'),
+	('argon2.key_length', '32');
@@ -0,0 +1 @@
+DELETE FROM "configs" WHERE "key" IN ('user.import_max_rows');
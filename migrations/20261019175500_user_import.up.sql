@@ -0,0 +1,2 @@
+INSERT INTO "configs" ("key", "value") VALUES
+	('user.import_max_rows', '10000');
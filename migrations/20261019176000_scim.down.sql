@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "roles_application_id_external_id_unique_idx";
+ALTER TABLE "roles" DROP COLUMN IF EXISTS "external_id";
+
+DROP INDEX IF EXISTS "users_application_id_external_id_unique_idx";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "active";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "external_id";
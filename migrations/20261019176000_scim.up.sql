@@ -0,0 +1,6 @@
+ALTER TABLE "users" ADD COLUMN "external_id" VARCHAR(255);
+ALTER TABLE "users" ADD COLUMN "active" BOOL NOT NULL DEFAULT true;
+CREATE UNIQUE INDEX "users_application_id_external_id_unique_idx" ON "users" ("application_id", "external_id") WHERE "external_id" IS NOT NULL;
+
+ALTER TABLE "roles" ADD COLUMN "external_id" VARCHAR(255);
+CREATE UNIQUE INDEX "roles_application_id_external_id_unique_idx" ON "roles" ("application_id", "external_id") WHERE "external_id" IS NOT NULL;
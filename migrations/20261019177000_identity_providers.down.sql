@@ -0,0 +1,5 @@
+DELETE FROM "configs" WHERE "key" IN ('identity_provider.login_expires_in_seconds');
+
+DROP TABLE IF EXISTS "identity_provider_logins" cascade;
+DROP TABLE IF EXISTS "user_identities" cascade;
+DROP TABLE IF EXISTS "identity_providers" cascade;
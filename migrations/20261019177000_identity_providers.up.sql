@@ -0,0 +1,67 @@
+CREATE TABLE "identity_providers"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"tenent_id" INT4 NOT NULL,
+	"name" VARCHAR(255) NOT NULL,
+	"kind" VARCHAR(255) NOT NULL DEFAULT 'oidc',
+	"issuer" VARCHAR(255),
+	"authorization_endpoint" VARCHAR(255),
+	"token_endpoint" VARCHAR(255),
+	"userinfo_endpoint" VARCHAR(255),
+	"jwks_uri" VARCHAR(255),
+	"client_id" VARCHAR(255) NOT NULL,
+	"client_secret" VARCHAR(255) NOT NULL,
+	"scopes" VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR[],
+	"enabled" BOOL NOT NULL DEFAULT true,
+	"registration_enabled" BOOL NOT NULL DEFAULT true,
+	"link_by_email" BOOL NOT NULL DEFAULT false,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "identity_providers_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "identity_providers_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "identity_providers_tenent_id_name_unique_idx" ON "identity_providers" ("tenent_id", "name");
+CREATE TRIGGER "identity_providers_updated_at_tgr" BEFORE UPDATE ON "identity_providers" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+CREATE TABLE "user_identities"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"user_id" INT4 NOT NULL,
+	"identity_provider_id" INT4 NOT NULL,
+	"subject" VARCHAR(255) NOT NULL,
+	"email" VARCHAR(255),
+	"last_login_at" TIMESTAMPTZ,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "user_identities_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "user_identities_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+	CONSTRAINT "user_identities_identity_provider_id_fk" FOREIGN KEY("identity_provider_id") REFERENCES "identity_providers"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "user_identities_identity_provider_id_subject_unique_idx" ON "user_identities" ("identity_provider_id", "subject");
+CREATE INDEX "user_identities_user_id_idx" ON "user_identities" ("user_id");
+CREATE TRIGGER "user_identities_updated_at_tgr" BEFORE UPDATE ON "user_identities" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+CREATE TABLE "identity_provider_logins"(
+	"id" SERIAL PRIMARY KEY,
+	"identity_provider_id" INT4 NOT NULL,
+	"encrypted_state" VARCHAR(255) NOT NULL,
+	"nonce" VARCHAR(255) NOT NULL,
+	"code_verifier" VARCHAR(255) NOT NULL,
+	"user_id" INT4,
+	"encrypted_code" VARCHAR(255),
+	"used_at" TIMESTAMPTZ,
+	"expires_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "identity_provider_logins_identity_provider_id_fk" FOREIGN KEY("identity_provider_id") REFERENCES "identity_providers"("id") ON DELETE CASCADE,
+	CONSTRAINT "identity_provider_logins_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "identity_provider_logins_encrypted_state_unique_idx" ON "identity_provider_logins" ("encrypted_state");
+CREATE UNIQUE INDEX "identity_provider_logins_encrypted_code_unique_idx" ON "identity_provider_logins" ("encrypted_code");
+CREATE TRIGGER "identity_provider_logins_updated_at_tgr" BEFORE UPDATE ON "identity_provider_logins" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('identity_provider.login_expires_in_seconds', '600');
@@ -0,0 +1,6 @@
+DELETE FROM "configs" WHERE "key" IN ('saml.request_expires_in_seconds');
+
+DROP TABLE IF EXISTS "saml_requests" cascade;
+DROP TABLE IF EXISTS "saml_service_providers" cascade;
+
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "saml_certificate";
@@ -0,0 +1,41 @@
+ALTER TABLE "tenents" ADD COLUMN "saml_certificate" TEXT;
+
+
+CREATE TABLE "saml_service_providers"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"tenent_id" INT4 NOT NULL,
+	"name" VARCHAR(255) NOT NULL,
+	"entity_id" VARCHAR(255) NOT NULL,
+	"metadata" TEXT,
+	"acs_url" VARCHAR(255),
+	"name_id_format" VARCHAR(255) NOT NULL DEFAULT 'persistent',
+	"attribute_mapping" JSONB NOT NULL DEFAULT '{}',
+	"enabled" BOOL NOT NULL DEFAULT true,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "saml_service_providers_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "saml_service_providers_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "saml_service_providers_tenent_id_entity_id_unique_idx" ON "saml_service_providers" ("tenent_id", "entity_id");
+CREATE TRIGGER "saml_service_providers_updated_at_tgr" BEFORE UPDATE ON "saml_service_providers" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+CREATE TABLE "saml_requests"(
+	"id" SERIAL PRIMARY KEY,
+	"saml_service_provider_id" INT4 NOT NULL,
+	"encrypted_token" VARCHAR(255) NOT NULL,
+	"request" TEXT NOT NULL,
+	"relay_state" TEXT NOT NULL DEFAULT '',
+	"used_at" TIMESTAMPTZ,
+	"expires_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "saml_requests_saml_service_provider_id_fk" FOREIGN KEY("saml_service_provider_id") REFERENCES "saml_service_providers"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "saml_requests_encrypted_token_unique_idx" ON "saml_requests" ("encrypted_token");
+CREATE TRIGGER "saml_requests_updated_at_tgr" BEFORE UPDATE ON "saml_requests" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('saml.request_expires_in_seconds', '600');
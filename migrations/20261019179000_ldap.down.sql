@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "users_application_id_ldap_dn_unique_idx";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "ldap_dn";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "ldap_backed";
+
+DROP TABLE IF EXISTS "ldap_connectors" cascade;
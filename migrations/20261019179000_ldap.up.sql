@@ -0,0 +1,33 @@
+CREATE TABLE "ldap_connectors"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"url" VARCHAR(255) NOT NULL,
+	"start_tls" BOOL NOT NULL DEFAULT false,
+	"tls_skip_verify" BOOL NOT NULL DEFAULT false,
+	"tls_root_ca" TEXT,
+	"bind_dn" VARCHAR(1024) NOT NULL DEFAULT '',
+	"bind_password" VARCHAR(255) NOT NULL DEFAULT '',
+	"user_search_base" VARCHAR(1024) NOT NULL,
+	"user_filter" VARCHAR(1024) NOT NULL DEFAULT '(&(objectClass=person)(|(uid={username})(mail={username})))',
+	"username_attribute" VARCHAR(255) NOT NULL DEFAULT 'uid',
+	"email_attribute" VARCHAR(255) NOT NULL DEFAULT 'mail',
+	"name_attribute" VARCHAR(255) NOT NULL DEFAULT 'cn',
+	"given_name_attribute" VARCHAR(255) NOT NULL DEFAULT 'givenName',
+	"family_name_attribute" VARCHAR(255) NOT NULL DEFAULT 'sn',
+	"group_search_base" VARCHAR(1024),
+	"group_filter" VARCHAR(1024) NOT NULL DEFAULT '(|(member={dn})(uniqueMember={dn}))',
+	"group_name_attribute" VARCHAR(255) NOT NULL DEFAULT 'cn',
+	"group_role_mapping" JSONB NOT NULL DEFAULT '{}',
+	"registration_enabled" BOOL NOT NULL DEFAULT true,
+	"enabled" BOOL NOT NULL DEFAULT true,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "ldap_connectors_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "ldap_connectors_application_id_unique_idx" ON "ldap_connectors" ("application_id");
+CREATE TRIGGER "ldap_connectors_updated_at_tgr" BEFORE UPDATE ON "ldap_connectors" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+ALTER TABLE "users" ADD COLUMN "ldap_backed" BOOL NOT NULL DEFAULT false;
+ALTER TABLE "users" ADD COLUMN "ldap_dn" VARCHAR(1024);
+CREATE UNIQUE INDEX "users_application_id_ldap_dn_unique_idx" ON "users" ("application_id", "ldap_dn");
@@ -0,0 +1,6 @@
+DELETE FROM "configs" WHERE "key" IN ('user.reauthentication_max_age_seconds');
+
+ALTER TABLE "identity_provider_logins" DROP CONSTRAINT IF EXISTS "identity_provider_logins_link_user_id_fk";
+ALTER TABLE "identity_provider_logins" DROP COLUMN IF EXISTS "link_user_id";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "password_set";
@@ -0,0 +1,8 @@
+ALTER TABLE "users" ADD "password_set" BOOL NOT NULL DEFAULT true;
+
+ALTER TABLE "identity_provider_logins" ADD "link_user_id" INT4;
+ALTER TABLE "identity_provider_logins" ADD CONSTRAINT "identity_provider_logins_link_user_id_fk" FOREIGN KEY("link_user_id") REFERENCES "users"("id") ON DELETE CASCADE;
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('user.reauthentication_max_age_seconds', '300');
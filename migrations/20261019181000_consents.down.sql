@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "user_consents" cascade;
+
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "third_party";
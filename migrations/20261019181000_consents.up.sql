@@ -0,0 +1,17 @@
+ALTER TABLE "tenents" ADD "third_party" BOOL NOT NULL DEFAULT false;
+
+
+CREATE TABLE "user_consents"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"user_id" INT4 NOT NULL,
+	"tenent_id" INT4 NOT NULL,
+	"scopes" VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR[],
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "user_consents_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "user_consents_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+	CONSTRAINT "user_consents_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "user_consents_user_id_tenent_id_unique_idx" ON "user_consents" ("user_id", "tenent_id");
+CREATE TRIGGER "user_consents_updated_at_tgr" BEFORE UPDATE ON "user_consents" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
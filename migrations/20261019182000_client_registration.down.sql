@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "initial_access_tokens" cascade;
+
+DROP INDEX IF EXISTS "tenents_encrypted_registration_access_token_unique_idx";
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "encrypted_registration_access_token";
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "token_endpoint_auth_method";
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "grant_types";
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "redirect_uris";
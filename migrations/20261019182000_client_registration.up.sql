@@ -0,0 +1,19 @@
+ALTER TABLE "tenents" ADD "redirect_uris" VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR[];
+ALTER TABLE "tenents" ADD "grant_types" VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR[];
+ALTER TABLE "tenents" ADD "token_endpoint_auth_method" VARCHAR(255) NOT NULL DEFAULT 'none';
+ALTER TABLE "tenents" ADD "encrypted_registration_access_token" VARCHAR(255);
+CREATE UNIQUE INDEX "tenents_encrypted_registration_access_token_unique_idx" ON "tenents" ("encrypted_registration_access_token");
+
+
+CREATE TABLE "initial_access_tokens"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"description" VARCHAR(255) NOT NULL,
+	"encrypted_token" VARCHAR(255) NOT NULL,
+	"expires_at" TIMESTAMPTZ,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "initial_access_tokens_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "initial_access_tokens_encrypted_token_unique_idx" ON "initial_access_tokens" ("encrypted_token");
+CREATE TRIGGER "initial_access_tokens_updated_at_tgr" BEFORE UPDATE ON "initial_access_tokens" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
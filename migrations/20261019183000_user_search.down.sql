@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS "user_infos_names_trgm_idx";
+DROP INDEX IF EXISTS "user_roles_role_id_idx";
+DROP INDEX IF EXISTS "phone_numbers_user_id_idx";
+DROP INDEX IF EXISTS "phone_numbers_application_id_phone_number_idx";
+DROP INDEX IF EXISTS "emails_user_id_idx";
+DROP INDEX IF EXISTS "emails_application_id_lower_email_idx";
+DROP INDEX IF EXISTS "users_application_id_updated_at_idx";
+DROP INDEX IF EXISTS "users_application_id_created_at_idx";
+DROP INDEX IF EXISTS "users_application_id_lower_username_idx";
+
+DROP EXTENSION IF EXISTS "pg_trgm";
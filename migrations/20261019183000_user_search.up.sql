@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+
+CREATE INDEX "users_application_id_lower_username_idx" ON "users" ("application_id", LOWER("username") text_pattern_ops);
+CREATE INDEX "users_application_id_created_at_idx" ON "users" ("application_id", "created_at");
+CREATE INDEX "users_application_id_updated_at_idx" ON "users" ("application_id", "updated_at");
+
+CREATE INDEX "emails_application_id_lower_email_idx" ON "emails" ("application_id", LOWER("email") text_pattern_ops);
+CREATE INDEX "emails_user_id_idx" ON "emails" ("user_id");
+
+CREATE INDEX "phone_numbers_application_id_phone_number_idx" ON "phone_numbers" ("application_id", "phone_number" text_pattern_ops);
+CREATE INDEX "phone_numbers_user_id_idx" ON "phone_numbers" ("user_id");
+
+CREATE INDEX "user_roles_role_id_idx" ON "user_roles" ("role_id");
+
+CREATE INDEX "user_infos_names_trgm_idx" ON "user_infos" USING GIN ((LOWER(COALESCE("name", '') || ' ' || COALESCE("given_name", '') || ' ' || COALESCE("middle_name", '') || ' ' || COALESCE("family_name", '') || ' ' || COALESCE("nickname", ''))) gin_trgm_ops);
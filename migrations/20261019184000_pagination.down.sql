@@ -0,0 +1,8 @@
+DELETE FROM "configs" WHERE "key" IN ('pagination.default_limit', 'pagination.max_limit');
+
+DROP INDEX IF EXISTS "applications_updated_at_id_idx";
+DROP INDEX IF EXISTS "tenents_application_id_updated_at_id_idx";
+DROP INDEX IF EXISTS "users_application_id_updated_at_id_idx";
+DROP INDEX IF EXISTS "users_application_id_created_at_id_idx";
+CREATE INDEX "users_application_id_created_at_idx" ON "users" ("application_id", "created_at");
+CREATE INDEX "users_application_id_updated_at_idx" ON "users" ("application_id", "updated_at");
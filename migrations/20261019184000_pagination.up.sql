@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS "users_application_id_created_at_idx";
+DROP INDEX IF EXISTS "users_application_id_updated_at_idx";
+CREATE INDEX "users_application_id_created_at_id_idx" ON "users" ("application_id", "created_at", "id");
+CREATE INDEX "users_application_id_updated_at_id_idx" ON "users" ("application_id", "updated_at", "id");
+
+CREATE INDEX "tenents_application_id_updated_at_id_idx" ON "tenents" ("application_id", "updated_at", "id");
+
+CREATE INDEX "applications_updated_at_id_idx" ON "applications" ("updated_at", "id");
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('pagination.default_limit', '20'),
+	('pagination.max_limit', '100');
@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN "active" BOOL NOT NULL DEFAULT true;
+UPDATE "users" SET "active" = false WHERE "status" <> 'active' AND ("status_expires_at" IS NULL OR "status_expires_at" > CURRENT_TIMESTAMP);
+
+DROP INDEX IF EXISTS "users_application_id_status_idx";
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_status_changed_by_service_account_id_fk";
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_status_changed_by_user_id_fk";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "tokens_revoked_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status_changed_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status_changed_by_service_account_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status_changed_by_user_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status_expires_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status_reason";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "status";
+DROP TYPE IF EXISTS USER_STATUS;
@@ -0,0 +1,14 @@
+CREATE TYPE USER_STATUS AS ENUM ('active', 'disabled', 'locked', 'pending');
+
+ALTER TABLE "users" ADD COLUMN "status" USER_STATUS NOT NULL DEFAULT 'active';
+ALTER TABLE "users" ADD COLUMN "status_reason" VARCHAR(255);
+ALTER TABLE "users" ADD COLUMN "status_expires_at" TIMESTAMPTZ;
+ALTER TABLE "users" ADD COLUMN "status_changed_by_user_id" INT4;
+ALTER TABLE "users" ADD COLUMN "status_changed_by_service_account_id" INT4;
+ALTER TABLE "users" ADD COLUMN "status_changed_at" TIMESTAMPTZ;
+ALTER TABLE "users" ADD COLUMN "tokens_revoked_at" TIMESTAMPTZ;
+ALTER TABLE "users" ADD CONSTRAINT "users_status_changed_by_user_id_fk" FOREIGN KEY("status_changed_by_user_id") REFERENCES "users"("id") ON DELETE SET NULL;
+ALTER TABLE "users" ADD CONSTRAINT "users_status_changed_by_service_account_id_fk" FOREIGN KEY("status_changed_by_service_account_id") REFERENCES "service_accounts"("id") ON DELETE SET NULL;
+UPDATE "users" SET "status" = 'disabled', "status_changed_at" = CURRENT_TIMESTAMP WHERE NOT "active";
+ALTER TABLE "users" DROP COLUMN "active";
+CREATE INDEX "users_application_id_status_idx" ON "users" ("application_id", "status");
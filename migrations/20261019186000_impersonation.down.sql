@@ -0,0 +1,4 @@
+DELETE FROM "configs" WHERE "key" IN ('impersonation.expires_in_seconds');
+
+UPDATE "role_resource_permissions" SET "actions" = array_remove("actions", 'impersonate');
+UPDATE "resources" SET "actions" = array_remove("actions", 'impersonate') WHERE "uri" = 'users';
@@ -0,0 +1,9 @@
+UPDATE "resources" SET "actions" = array_append("actions", 'impersonate')
+	WHERE "uri" = 'users' AND "application_id" = (SELECT id FROM "applications" WHERE uri='admin' LIMIT 1) AND NOT ('impersonate' = ANY("actions"));
+
+UPDATE "role_resource_permissions" SET "actions" = array_append("actions", 'impersonate')
+	WHERE "role_id" = (SELECT id FROM "roles" WHERE uri='admin' LIMIT 1) AND "resource_id" = (SELECT id FROM "resources" WHERE uri='users' LIMIT 1) AND NOT ('impersonate' = ANY("actions"));
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('impersonation.expires_in_seconds', '900');
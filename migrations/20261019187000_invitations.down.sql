@@ -0,0 +1,3 @@
+DELETE FROM "configs" WHERE "key" IN ('invitation.expires_in_seconds');
+
+DROP TABLE IF EXISTS "invitations";
@@ -0,0 +1,28 @@
+CREATE TABLE "invitations"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"tenent_id" INT4 NOT NULL,
+	"user_id" INT4,
+	"email" VARCHAR(255) NOT NULL,
+	"encrypted_token" VARCHAR(255),
+	"invited_by_user_id" INT4,
+	"invited_by_service_account_id" INT4,
+	"expires_at" TIMESTAMPTZ NOT NULL,
+	"accepted_at" TIMESTAMPTZ,
+	"revoked_at" TIMESTAMPTZ,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "invitations_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "invitations_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE,
+	CONSTRAINT "invitations_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE SET NULL,
+	CONSTRAINT "invitations_invited_by_user_id_fk" FOREIGN KEY("invited_by_user_id") REFERENCES "users"("id") ON DELETE SET NULL,
+	CONSTRAINT "invitations_invited_by_service_account_id_fk" FOREIGN KEY("invited_by_service_account_id") REFERENCES "service_accounts"("id") ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX "invitations_encrypted_token_unique_idx" ON "invitations" ("encrypted_token");
+CREATE INDEX "invitations_application_id_created_at_id_idx" ON "invitations" ("application_id", "created_at", "id") WHERE "accepted_at" IS NULL AND "revoked_at" IS NULL;
+CREATE INDEX "invitations_user_id_idx" ON "invitations" ("user_id");
+CREATE TRIGGER "invitations_updated_at_tgr" BEFORE UPDATE ON "invitations" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('invitation.expires_in_seconds', '604800');
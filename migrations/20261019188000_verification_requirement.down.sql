@@ -0,0 +1,2 @@
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "require_confirmed_phone_number";
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "require_confirmed_email";
@@ -0,0 +1,2 @@
+ALTER TABLE "tenents" ADD COLUMN "require_confirmed_email" BOOL NOT NULL DEFAULT false;
+ALTER TABLE "tenents" ADD COLUMN "require_confirmed_phone_number" BOOL NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "registration_invite_codes";
+DROP TABLE IF EXISTS "registration_schemas";
+
+DROP TYPE IF EXISTS REGISTRATION_MODE;
@@ -0,0 +1,38 @@
+CREATE TYPE REGISTRATION_MODE AS ENUM ('open', 'invite_code', 'closed');
+
+
+CREATE TABLE "registration_schemas"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"tenent_id" INT4 NOT NULL,
+	"mode" REGISTRATION_MODE NOT NULL DEFAULT 'open',
+	"require_email" BOOL NOT NULL DEFAULT false,
+	"require_phone_number" BOOL NOT NULL DEFAULT false,
+	"required_info" TEXT[] NOT NULL DEFAULT '{}',
+	"allowed_email_domains" TEXT[] NOT NULL DEFAULT '{}',
+	"blocked_email_domains" TEXT[] NOT NULL DEFAULT '{}',
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "registration_schemas_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "registration_schemas_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "registration_schemas_tenent_id_unique_idx" ON "registration_schemas" ("tenent_id");
+CREATE TRIGGER "registration_schemas_updated_at_tgr" BEFORE UPDATE ON "registration_schemas" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+
+CREATE TABLE "registration_invite_codes"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"tenent_id" INT4 NOT NULL,
+	"description" VARCHAR(255) NOT NULL,
+	"encrypted_code" VARCHAR(255) NOT NULL,
+	"max_uses" INT4,
+	"uses" INT4 NOT NULL DEFAULT 0,
+	"expires_at" TIMESTAMPTZ,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "registration_invite_codes_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "registration_invite_codes_tenent_id_fk" FOREIGN KEY("tenent_id") REFERENCES "tenents"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX "registration_invite_codes_tenent_id_encrypted_code_unique_idx" ON "registration_invite_codes" ("tenent_id", "encrypted_code");
+CREATE TRIGGER "registration_invite_codes_updated_at_tgr" BEFORE UPDATE ON "registration_invite_codes" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
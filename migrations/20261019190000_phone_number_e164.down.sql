@@ -0,0 +1,2 @@
+-- phone numbers converted to E.164 are left as they are, their previous formatting isn't kept
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "default_phone_region";
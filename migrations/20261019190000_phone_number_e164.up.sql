@@ -0,0 +1,49 @@
+ALTER TABLE "tenents" ADD COLUMN "default_phone_region" VARCHAR(2);
+
+-- phone numbers were stored with their formatting stripped, only the ones written internationally with + or 00 are
+-- rewritten to E.164, anything else may be a national number of an unknown region and is left as it is. Numbers of an
+-- application that convert to the same E.164 number as one it already has, or as each other, keep all but the oldest
+-- as they are so the unique index holds
+WITH "converted" AS (
+	SELECT p."id", p."application_id", '+' || regexp_replace(regexp_replace(p."phone_number", '\D', '', 'g'), '^00', '') AS "phone_number"
+	FROM "phone_numbers" p
+	WHERE p."phone_number" !~ '^\+[1-9][0-9]{7,14}$'
+		AND p."phone_number" ~ '^\s*(\+|00)'
+), "ranked" AS (
+	SELECT c."id", c."phone_number", ROW_NUMBER() OVER (PARTITION BY c."application_id", c."phone_number" ORDER BY c."id") AS "rank"
+	FROM "converted" c
+	WHERE c."phone_number" ~ '^\+[1-9][0-9]{7,14}$'
+		AND NOT EXISTS (
+			SELECT 1 FROM "phone_numbers" o
+			WHERE o."application_id" = c."application_id"
+				AND o."phone_number" = c."phone_number"
+		)
+)
+UPDATE "phone_numbers" p SET "phone_number" = r."phone_number"
+	FROM "ranked" r
+	WHERE p."id" = r."id"
+		AND r."rank" = 1;
+
+-- numbers left as they are because another one took their E.164 form are reported with the user that has it so they
+-- can be merged or removed by hand
+DO $$
+DECLARE
+	"collision" RECORD;
+	"remaining" INT8;
+BEGIN
+	FOR "collision" IN
+		SELECT o."application_id", o."phone_number", o."user_id", array_agg(p."user_id" ORDER BY p."id") AS "user_ids"
+		FROM "phone_numbers" p
+		JOIN "phone_numbers" o ON o."application_id" = p."application_id"
+			AND o."phone_number" = '+' || regexp_replace(regexp_replace(p."phone_number", '\D', '', 'g'), '^00', '')
+		WHERE p."phone_number" !~ '^\+[1-9][0-9]{7,14}$'
+			AND p."phone_number" ~ '^\s*(\+|00)'
+		GROUP BY o."application_id", o."phone_number", o."user_id"
+	LOOP
+		RAISE NOTICE 'application % phone number % of user % is also written differently by users %, theirs were left as they are', "collision"."application_id", "collision"."phone_number", "collision"."user_id", "collision"."user_ids";
+	END LOOP;
+	SELECT COUNT(*) INTO "remaining" FROM "phone_numbers" WHERE "phone_number" !~ '^\+[1-9][0-9]{7,14}$';
+	IF "remaining" > 0 THEN
+		RAISE NOTICE '% phone numbers were left as they are, find them with SELECT * FROM phone_numbers WHERE phone_number !~ ''^\+'' and update them with the E.164 form for their tenent''s default_phone_region', "remaining";
+	END IF;
+END $$;
@@ -0,0 +1,12 @@
+ALTER TABLE "tenents" DROP COLUMN IF EXISTS "fold_email_aliases";
+
+DROP INDEX IF EXISTS "emails_application_id_folded_email_idx";
+DROP INDEX IF EXISTS "emails_email_unique_idx";
+CREATE UNIQUE INDEX "emails_email_unique_idx" ON "emails" ("application_id", "email");
+
+DROP INDEX IF EXISTS "users_username_unique_idx";
+CREATE UNIQUE INDEX "users_username_unique_idx" ON "users" ("application_id", "username");
+
+DROP FUNCTION IF EXISTS "folded_email"(TEXT);
+DROP FUNCTION IF EXISTS "canonical_email"(TEXT);
+DROP FUNCTION IF EXISTS "canonical_username"(TEXT);
@@ -0,0 +1,55 @@
+CREATE OR REPLACE FUNCTION "canonical_username"("username" TEXT) RETURNS TEXT
+	LANGUAGE SQL IMMUTABLE STRICT PARALLEL SAFE
+	AS $$ SELECT LOWER(NORMALIZE(BTRIM("username"), NFKC)) $$;
+
+CREATE OR REPLACE FUNCTION "canonical_email"("email" TEXT) RETURNS TEXT
+	LANGUAGE SQL IMMUTABLE STRICT PARALLEL SAFE
+	AS $$ SELECT LOWER(BTRIM("email")) $$;
+
+-- gmail ignores dots and anything after a + in the local part, googlemail.com is the same mailbox
+CREATE OR REPLACE FUNCTION "folded_email"("email" TEXT) RETURNS TEXT
+	LANGUAGE SQL IMMUTABLE STRICT PARALLEL SAFE
+	AS $$ SELECT CASE
+		WHEN SPLIT_PART(LOWER(BTRIM("email")), '@', 2) IN ('gmail.com', 'googlemail.com')
+			THEN REPLACE(SPLIT_PART(SPLIT_PART(LOWER(BTRIM("email")), '@', 1), '+', 1), '.', '') || '@gmail.com'
+		ELSE LOWER(BTRIM("email"))
+	END $$;
+
+-- users that would share a username or email once they are compared canonically have to be merged or renamed by
+-- hand, every collision is reported before failing so they can all be fixed at once
+DO $$
+DECLARE
+	"collision" RECORD;
+	"collisions" INT4 := 0;
+BEGIN
+	FOR "collision" IN
+		SELECT "application_id", canonical_username("username") AS "value", array_agg("id" ORDER BY "id") AS "user_ids"
+		FROM "users"
+		GROUP BY "application_id", canonical_username("username")
+		HAVING COUNT(*) > 1
+	LOOP
+		RAISE NOTICE 'application % username % is shared by users %', "collision"."application_id", "collision"."value", "collision"."user_ids";
+		"collisions" := "collisions" + 1;
+	END LOOP;
+	FOR "collision" IN
+		SELECT "application_id", canonical_email("email") AS "value", array_agg("user_id" ORDER BY "user_id") AS "user_ids"
+		FROM "emails"
+		GROUP BY "application_id", canonical_email("email")
+		HAVING COUNT(*) > 1
+	LOOP
+		RAISE NOTICE 'application % email % is shared by users %', "collision"."application_id", "collision"."value", "collision"."user_ids";
+		"collisions" := "collisions" + 1;
+	END LOOP;
+	IF "collisions" > 0 THEN
+		RAISE EXCEPTION '% usernames or emails collide when compared case insensitively, resolve them and run the migration again', "collisions";
+	END IF;
+END $$;
+
+DROP INDEX IF EXISTS "users_username_unique_idx";
+CREATE UNIQUE INDEX "users_username_unique_idx" ON "users" ("application_id", canonical_username("username"));
+
+DROP INDEX IF EXISTS "emails_email_unique_idx";
+CREATE UNIQUE INDEX "emails_email_unique_idx" ON "emails" ("application_id", canonical_email("email"));
+CREATE INDEX "emails_application_id_folded_email_idx" ON "emails" ("application_id", folded_email("email"));
+
+ALTER TABLE "tenents" ADD COLUMN "fold_email_aliases" BOOL NOT NULL DEFAULT false;
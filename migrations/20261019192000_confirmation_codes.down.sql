@@ -0,0 +1,6 @@
+DELETE FROM "configs" WHERE "key" IN ('confirmation.code_expires_in_seconds', 'confirmation.code_max_attempts', 'confirmation.code_resend_seconds', 'confirmation.code_max_sends_per_hour');
+
+ALTER TABLE "phone_numbers" ADD COLUMN "confirmation_token" VARCHAR(255);
+ALTER TABLE "emails" ADD COLUMN "confirmation_token" VARCHAR(255);
+
+DROP TABLE IF EXISTS "confirmation_codes" cascade;
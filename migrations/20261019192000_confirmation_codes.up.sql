@@ -0,0 +1,34 @@
+CREATE TABLE "confirmation_codes"(
+	"id" SERIAL PRIMARY KEY,
+	"application_id" INT4 NOT NULL,
+	"user_id" INT4 NOT NULL,
+	"email_id" INT4,
+	"phone_number_id" INT4,
+	"encrypted_code" VARCHAR(255) NOT NULL,
+	"encrypted_token" VARCHAR(255) NOT NULL,
+	"attempts" INT4 NOT NULL DEFAULT 0,
+	"used_at" TIMESTAMPTZ,
+	"expires_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT "confirmation_codes_target_check" CHECK (("email_id" IS NULL) <> ("phone_number_id" IS NULL)),
+	CONSTRAINT "confirmation_codes_application_id_fk" FOREIGN KEY("application_id") REFERENCES "applications"("id") ON DELETE CASCADE,
+	CONSTRAINT "confirmation_codes_user_id_fk" FOREIGN KEY("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+	CONSTRAINT "confirmation_codes_email_id_fk" FOREIGN KEY("email_id") REFERENCES "emails"("id") ON DELETE CASCADE,
+	CONSTRAINT "confirmation_codes_phone_number_id_fk" FOREIGN KEY("phone_number_id") REFERENCES "phone_numbers"("id") ON DELETE CASCADE
+);
+CREATE INDEX "confirmation_codes_email_id_created_at_idx" ON "confirmation_codes" ("email_id", "created_at");
+CREATE INDEX "confirmation_codes_phone_number_id_created_at_idx" ON "confirmation_codes" ("phone_number_id", "created_at");
+CREATE UNIQUE INDEX "confirmation_codes_encrypted_token_unique_idx" ON "confirmation_codes" ("encrypted_token");
+CREATE TRIGGER "confirmation_codes_updated_at_tgr" BEFORE UPDATE ON "confirmation_codes" FOR EACH ROW EXECUTE PROCEDURE "trigger_updated_at"();
+
+-- outstanding plain text tokens can't be carried over, unconfirmed emails and phone numbers need a new code sent
+ALTER TABLE "emails" DROP COLUMN IF EXISTS "confirmation_token";
+ALTER TABLE "phone_numbers" DROP COLUMN IF EXISTS "confirmation_token";
+
+
+INSERT INTO "configs" ("key", "value") VALUES
+	('confirmation.code_expires_in_seconds', '86400'),
+	('confirmation.code_max_attempts', '5'),
+	('confirmation.code_resend_seconds', '60'),
+	('confirmation.code_max_sends_per_hour', '5');